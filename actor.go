@@ -3,6 +3,8 @@ package grid
 import (
 	"context"
 	"fmt"
+
+	"github.com/lytics/grid/codec"
 )
 
 // MakeActor using the given data to parameterize
@@ -14,6 +16,36 @@ type Actor interface {
 	Act(c context.Context)
 }
 
+// Preloader is an actor that loads its state before it acts. The
+// server runs Preload before Act, with the actor's context, bounded
+// by that of the start, and acks the start only once it returns, so
+// an actor that creates its mailboxes in Act is not sent requests
+// until its state is loaded. If Preload fails, the actor is
+// unregistered without acting, and the start fails with its error.
+//
+// Example usage:
+//
+//     func (a *counter) Preload(ctx context.Context) error {
+//         return a.state.Load(ctx)
+//     }
+//
+//     func (a *counter) Act(ctx context.Context) {
+//         mailbox, err := grid.NewMailbox(server, "counter", 100)
+//         ...
+//     }
+//
+type Preloader interface {
+	Preload(c context.Context) error
+}
+
+// preload the actor, if it is a Preloader.
+func preload(c context.Context, actor Actor) error {
+	if p, ok := actor.(Preloader); ok {
+		return p.Preload(c)
+	}
+	return nil
+}
+
 // NewActorStart message with the name of the actor
 // to start, its type will be equal to its name
 // unless its changed:
@@ -37,7 +69,90 @@ func NewActorStart(name string, v ...interface{}) *ActorStart {
 	}
 }
 
+// SetData of the start to the value, encoded with protobuf, so
+// the value must be a registered protobuf message, whatever the
+// codec of the client, since the start does not record a codec.
+// The type is recorded, so that decoding the data into another
+// type fails:
+//
+//     start := grid.NewActorStart("worker-%d", i)
+//     start.Type = "worker"
+//     err := start.SetData(&WorkerArgs{Partition: i})
+//
+func (m *ActorStart) SetData(v interface{}) error {
+	typeName, data, err := codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	m.Data = data
+	m.DataType = typeName
+	return nil
+}
+
+// DecodeData of the start into the value, which must be a pointer
+// to a registered protobuf message, see SetData. Data set without
+// SetData is decoded as is.
+// It is not named GetData since that is the generated getter of
+// the raw data.
+func (m *ActorStart) DecodeData(v interface{}) error {
+	if m.DataType != "" && m.DataType != codec.TypeName(v) {
+		return ErrUnexpectedDataType
+	}
+	return codec.UnmarshalInto(m.Data, v)
+}
+
+// MakeActorWithArgs adapts a function taking the decoded data of
+// the start to a MakeActor. When the start has no data the args
+// are the zero value of their type.
+//
+// Example usage:
+//
+//     server.RegisterDef("worker", grid.MakeActorWithArgs(func(args *WorkerArgs) (grid.Actor, error) {
+//         return &WorkerActor{partition: args.Partition}, nil
+//     }))
+//
+func MakeActorWithArgs[T any](f func(args *T) (Actor, error)) MakeActor {
+	return func(data []byte) (Actor, error) {
+		args := new(T)
+		if len(data) > 0 {
+			err := codec.UnmarshalInto(data, args)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return f(args)
+	}
+}
+
+// Validate the type and name of the start, returning an error
+// describing what is invalid, before the start is sent.
+func (m *ActorStart) Validate() error {
+	if err := ValidateName(m.Type); err != nil {
+		return fmt.Errorf("%w: type: %v", ErrInvalidActorType, err)
+	}
+	if err := ValidateName(m.Name); err != nil {
+		return fmt.Errorf("%w: name: %v", ErrInvalidActorName, err)
+	}
+	return nil
+}
+
 func init() {
 	Register(Ack{})
 	Register(ActorStart{})
+	Register(Control{})
+	Register(Heartbeat{})
+	Register(MailboxPeek{})
+	Register(MailboxPeekResult{})
+	Register(MailboxRequeue{})
+	Register(MailboxTransfer{})
+	Register(MailboxTransferResult{})
+	Register(ActorDiagnose{})
+	Register(ActorDiagnosis{})
+	Register(PendingStartsQuery{})
+	Register(PendingStarts{})
+	Register(PeerStatsQuery{})
+	Register(PeerStats{})
+	Register(EtcdEndpointsQuery{})
+	Register(EtcdEndpoints{})
+	Register(ActorLogs{})
 }
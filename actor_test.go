@@ -0,0 +1,165 @@
+package grid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type argsActor struct {
+	args *EchoMsg
+}
+
+func (a *argsActor) Act(c context.Context) {}
+
+func TestActorStartData(t *testing.T) {
+	Register(EchoMsg{})
+
+	start := NewActorStart("worker")
+	if err := start.SetData(&EchoMsg{Msg: "partition-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	args := &EchoMsg{}
+	if err := start.DecodeData(args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Msg != "partition-1" {
+		t.Fatalf("expected data to round trip, got: %v", args.Msg)
+	}
+	if err := start.DecodeData(&Ack{}); err != ErrUnexpectedDataType {
+		t.Fatalf("expected unexpected data type, got: %v", err)
+	}
+
+	makeActor := MakeActorWithArgs(func(args *EchoMsg) (Actor, error) {
+		return &argsActor{args: args}, nil
+	})
+	actor, err := makeActor(start.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actor.(*argsActor).args.Msg != "partition-1" {
+		t.Fatal("expected actor made with decoded args")
+	}
+
+	// Without data the args are the zero value.
+	actor, err = makeActor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actor.(*argsActor).args == nil {
+		t.Fatal("expected zero value args")
+	}
+}
+
+type preloadingActor struct {
+	err   error
+	steps []string
+}
+
+func (a *preloadingActor) Preload(c context.Context) error {
+	a.steps = append(a.steps, "preload")
+	return a.err
+}
+
+func (a *preloadingActor) Act(c context.Context) {
+	a.steps = append(a.steps, "act")
+}
+
+func TestPreload(t *testing.T) {
+	a := &preloadingActor{}
+	if err := preload(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.steps) != 1 || a.steps[0] != "preload" {
+		t.Fatalf("expected preload only, got: %v", a.steps)
+	}
+
+	a = &preloadingActor{err: errors.New("state unavailable")}
+	if err := preload(context.Background(), a); err != a.err {
+		t.Fatalf("expected preload error, got: %v", err)
+	}
+}
+
+// blockedPreloadActor preloads once released, and tells
+// when it acts.
+type blockedPreloadActor struct {
+	err      error
+	preloads chan struct{}
+	release  chan struct{}
+	acts     chan struct{}
+}
+
+func (a *blockedPreloadActor) Preload(c context.Context) error {
+	a.preloads <- struct{}{}
+	<-a.release
+	return a.err
+}
+
+func (a *blockedPreloadActor) Act(c context.Context) {
+	a.acts <- struct{}{}
+	<-c.Done()
+}
+
+func TestStartActorPreload(t *testing.T) {
+	etcd, server, client := bootstrapClientTest(t)
+	defer etcd.Close()
+	defer server.Stop()
+	defer client.Close()
+
+	a := &blockedPreloadActor{
+		preloads: make(chan struct{}, 1),
+		release:  make(chan struct{}),
+		acts:     make(chan struct{}, 1),
+	}
+	server.RegisterDef("preloading", func([]byte) (Actor, error) { return a, nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The start is acked only once the actor is preloaded,
+	// and the actor acts only after that.
+	started := make(chan error, 1)
+	go func() {
+		started <- server.startActorC(ctx, NewActorStart("preloading"))
+	}()
+	<-a.preloads
+	select {
+	case err := <-started:
+		t.Fatalf("expected start to wait for preload, got: %v", err)
+	case <-a.acts:
+		t.Fatal("expected actor not to act before preload")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(a.release)
+	if err := <-started; err != nil {
+		t.Fatal(err)
+	}
+	<-a.acts
+
+	// A failed preload fails the start, and the actor is
+	// unregistered, so that it can be started again.
+	failing := &blockedPreloadActor{
+		err:      errors.New("state unavailable"),
+		preloads: make(chan struct{}, 1),
+		release:  make(chan struct{}),
+		acts:     make(chan struct{}, 1),
+	}
+	close(failing.release)
+	server.RegisterDef("failing", func([]byte) (Actor, error) { return failing, nil })
+	err := server.startActorC(ctx, NewActorStart("failing"))
+	if !errors.Is(err, failing.err) {
+		t.Fatalf("expected preload error, got: %v", err)
+	}
+	<-failing.preloads
+	select {
+	case <-failing.acts:
+		t.Fatal("expected actor not to act")
+	default:
+	}
+	failing.err = nil
+	if err := server.startActorC(ctx, NewActorStart("failing")); err != nil {
+		t.Fatalf("expected actor unregistered after failed preload, got: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package grid
+
+import (
+	"sync"
+	"time"
+)
+
+// AdmissionPolicy of the mailboxes of a server, deciding in which
+// order queued requests are delivered to actors, and which requests
+// are rejected, when actors cannot keep up. It gives operators levers
+// to preserve latency for important traffic during overload.
+//
+// Example usage:
+//
+//     server, err := grid.NewServer(etcd, grid.ServerCfg{
+//         Namespace: "search",
+//         Admission: &grid.AdmissionPolicy{
+//             LIFO:        true,
+//             MaxQueueAge: 500 * time.Millisecond,
+//             Priority: func(msg interface{}) int {
+//                 if _, ok := msg.(*Query); ok {
+//                     return 1
+//                 }
+//                 return 0
+//             },
+//         },
+//     })
+//
+type AdmissionPolicy struct {
+	// LIFO delivers the newest requests first while a mailbox is
+	// overloaded, since the senders of the oldest requests are the
+	// most likely to have given up on them already.
+	LIFO bool
+	// Overload is the number of queued requests at which a mailbox
+	// is overloaded. Default is half of the mailbox's size.
+	Overload int
+	// Priority of a request's message, requests of higher priority
+	// are delivered first. Default is the same priority for all.
+	Priority func(msg interface{}) int
+	// MaxQueueAge of the oldest queued request of a mailbox, beyond
+	// which new requests are rejected with ErrOverloaded, until the
+	// actor catches up. The default of zero is no limit.
+	MaxQueueAge time.Duration
+}
+
+// admissionQueue of a mailbox with an admission policy. Requests are
+// queued here, and a pump delivers them one at a time into the
+// mailbox's unbuffered channel, choosing the next request by the
+// policy only once the actor is ready to receive it.
+type admissionQueue struct {
+	mu       sync.Mutex
+	policy   *AdmissionPolicy
+	size     int
+	overload int
+	items    []*admitted
+	ready    chan struct{}
+	out      chan Request
+	done     chan struct{}
+	pumped   chan struct{}
+	stop     sync.Once
+	// held by the pump, until the mailbox's
+	// receiver takes it.
+	held Request
+}
+
+// admitted request, with the time it was queued.
+type admitted struct {
+	req      Request
+	at       time.Time
+	priority int
+}
+
+func newAdmissionQueue(policy *AdmissionPolicy, size int, out chan Request) *admissionQueue {
+	if size < 1 {
+		size = 1
+	}
+	overload := policy.Overload
+	if overload <= 0 {
+		overload = size / 2
+	}
+	q := &admissionQueue{
+		policy:   policy,
+		size:     size,
+		overload: overload,
+		ready:    make(chan struct{}, 1),
+		out:      out,
+		done:     make(chan struct{}),
+		pumped:   make(chan struct{}),
+	}
+	go q.pump()
+	return q
+}
+
+// put the request into the queue, unless it is full, or the
+// oldest request in it has waited longer than allowed.
+func (q *admissionQueue) put(req Request) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) >= q.size {
+		return ErrReceiverBusy
+	}
+	now := time.Now()
+	if q.policy.MaxQueueAge > 0 && len(q.items) > 0 && now.Sub(q.items[0].at) > q.policy.MaxQueueAge {
+		return ErrOverloaded
+	}
+	priority := 0
+	if q.policy.Priority != nil {
+		priority = q.policy.Priority(req.Msg())
+	}
+	q.items = append(q.items, &admitted{req: req, at: now, priority: priority})
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// credit of the queue, ie: how many more requests it has room for.
+func (q *admissionQueue) credit() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size - len(q.items)
+}
+
+// depth of the queue, ie: how many requests are queued in it.
+func (q *admissionQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// next request to deliver, or nil if the queue is empty. The
+// items are kept in order of arrival, so the oldest is first.
+func (q *admissionQueue) next() Request {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return nil
+	}
+	lifo := q.policy.LIFO && len(q.items) >= q.overload
+	best := 0
+	for i, item := range q.items {
+		switch {
+		case item.priority > q.items[best].priority:
+			best = i
+		case item.priority == q.items[best].priority && lifo:
+			best = i
+		}
+	}
+	req := q.items[best].req
+	q.items = append(q.items[:best], q.items[best+1:]...)
+	return req
+}
+
+// pump requests into the mailbox's channel until closed.
+func (q *admissionQueue) pump() {
+	defer close(q.pumped)
+	for {
+		req := q.next()
+		if req == nil {
+			select {
+			case <-q.ready:
+				continue
+			case <-q.done:
+				return
+			}
+		}
+		q.mu.Lock()
+		q.held = req
+		q.mu.Unlock()
+		select {
+		case q.out <- req:
+			q.mu.Lock()
+			q.held = nil
+			q.mu.Unlock()
+		case <-q.done:
+			return
+		}
+	}
+}
+
+// undelivered requests, including the one held by the pump.
+func (q *admissionQueue) undelivered() []Request {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var reqs []Request
+	if q.held != nil {
+		reqs = append(reqs, q.held)
+	}
+	for _, item := range q.items {
+		reqs = append(reqs, item.req)
+	}
+	return reqs
+}
+
+// close the queue, waiting for the pump to stop, after which
+// the mailbox's channel may be closed.
+func (q *admissionQueue) close() {
+	q.stop.Do(func() {
+		close(q.done)
+	})
+	<-q.pumped
+}
@@ -0,0 +1,102 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAdmissionQueue(t *testing.T) {
+	msgs := func(q *admissionQueue) []int {
+		var order []int
+		for req := q.next(); req != nil; req = q.next() {
+			order = append(order, req.Msg().(int))
+		}
+		return order
+	}
+	put := func(q *admissionQueue, ms ...int) {
+		for _, m := range ms {
+			if err := q.put(newRequest(context.Background(), m, &Provenance{})); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The queue is not started, so that
+	// its order can be checked directly.
+	policy := &AdmissionPolicy{
+		LIFO:     true,
+		Overload: 3,
+		Priority: func(msg interface{}) int {
+			if msg.(int) >= 10 {
+				return 1
+			}
+			return 0
+		},
+	}
+	q := &admissionQueue{policy: policy, size: 4, overload: 3, ready: make(chan struct{}, 1)}
+
+	// Below the overload in order of arrival.
+	put(q, 1, 2)
+	if order := msgs(q); len(order) != 2 || order[0] != 1 || order[1] != 2 {
+		t.Fatalf("expected fifo order, got: %v", order)
+	}
+
+	// Overloaded the newest first, and higher priority
+	// before all others, back to order of arrival once
+	// no longer overloaded.
+	put(q, 1, 10, 2, 3)
+	if err := q.put(newRequest(context.Background(), 4, &Provenance{})); err != ErrReceiverBusy {
+		t.Fatalf("expected receiver busy, got: %v", err)
+	}
+	if order := msgs(q); len(order) != 4 || order[0] != 10 || order[1] != 3 || order[2] != 1 || order[3] != 2 {
+		t.Fatalf("expected priority then lifo order, got: %v", order)
+	}
+
+	// Rejected once the oldest request is too old.
+	policy.MaxQueueAge = 10 * time.Millisecond
+	put(q, 1)
+	time.Sleep(20 * time.Millisecond)
+	if err := q.put(newRequest(context.Background(), 2, &Provenance{})); err != ErrOverloaded {
+		t.Fatalf("expected overloaded, got: %v", err)
+	}
+}
+
+func TestMailboxAdmission(t *testing.T) {
+	boxC := make(chan Request)
+	box := &Mailbox{
+		C:       boxC,
+		c:       boxC,
+		queue:   newAdmissionQueue(&AdmissionPolicy{}, 2, boxC),
+		cleanup: func() error { return nil },
+	}
+
+	err := box.put(newRequest(context.Background(), 1, &Provenance{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := box.Recv(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Msg().(int) != 1 {
+		t.Fatalf("expected message: 1, got: %v", req.Msg())
+	}
+	if credit := box.credit(); credit != 2 {
+		t.Fatalf("expected credit: 2, got: %v", credit)
+	}
+
+	// Closing stops the queue's pump before
+	// the mailbox's channel is closed.
+	err = box.put(newRequest(context.Background(), 2, &Provenance{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := box.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := box.Recv(context.Background()); err != ErrMailboxClosed {
+		t.Fatalf("expected mailbox closed, got: %v", err)
+	}
+}
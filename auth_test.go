@@ -0,0 +1,34 @@
+package grid
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAuthenticate(t *testing.T) {
+	s := &Server{cfg: ServerCfg{
+		Namespace: "testing",
+		Auth:      StaticTokens("secret"),
+	}}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tokenMetadataKey, "secret"))
+	if err := s.authenticate(ctx); err != nil {
+		t.Fatalf("expected token to be accepted, got: %v", err)
+	}
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(tokenMetadataKey, "guess"))
+	if err := s.authenticate(ctx); err != ErrUnauthorized {
+		t.Fatalf("expected unauthorized, got: %v", err)
+	}
+
+	if err := s.authenticate(context.Background()); err != ErrUnauthorized {
+		t.Fatalf("expected unauthorized without token, got: %v", err)
+	}
+
+	s.cfg.Auth = nil
+	if err := s.authenticate(context.Background()); err != nil {
+		t.Fatalf("expected no auth without hook, got: %v", err)
+	}
+}
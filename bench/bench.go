@@ -0,0 +1,361 @@
+// Package bench is a load generator for grid. It starts peers in the
+// current process, creates receiving mailboxes spread across them,
+// and sends messages of a configurable size in a configurable shape.
+// The same seed produces the same payloads and the same choice of
+// receivers, so runs before and after a change are comparable.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+	"github.com/lytics/grid"
+)
+
+// Shape of the traffic between senders and receivers.
+type Shape string
+
+const (
+	// Pairs has each sender send to its own receiver,
+	// chosen by the seed.
+	Pairs Shape = "pairs"
+	// FanIn has every sender send to the same receiver.
+	FanIn Shape = "fanin"
+	// FanOut has every sender broadcast each message
+	// to every receiver.
+	FanOut Shape = "fanout"
+)
+
+var (
+	// ErrUnknownShape when the config's shape is not one
+	// of the defined shapes.
+	ErrUnknownShape = errors.New("bench: unknown shape")
+)
+
+// Config of a run, fields with their zero value will
+// receive defaults.
+type Config struct {
+	// Namespace of the grid the run uses.
+	Namespace string `json:"namespace"`
+	// Peers to start in this process.
+	Peers int `json:"peers"`
+	// Receivers are mailboxes, spread across the peers.
+	Receivers int `json:"receivers"`
+	// Senders each send Messages messages, one at a time.
+	Senders int `json:"senders"`
+	// Messages sent by each sender.
+	Messages int `json:"messages"`
+	// MessageSize of each message's payload in bytes.
+	MessageSize int `json:"message_size"`
+	// Shape of the traffic, default is Pairs.
+	Shape Shape `json:"shape"`
+	// Seed of the payloads and receiver choices.
+	Seed int64 `json:"seed"`
+	// Timeout of each request.
+	Timeout time.Duration `json:"timeout"`
+}
+
+// setConfigDefaults for those fields that have their zero value.
+func setConfigDefaults(cfg *Config) {
+	if cfg.Namespace == "" {
+		cfg.Namespace = "bench"
+	}
+	if cfg.Peers == 0 {
+		cfg.Peers = 1
+	}
+	if cfg.Receivers == 0 {
+		cfg.Receivers = 1
+	}
+	if cfg.Senders == 0 {
+		cfg.Senders = 1
+	}
+	if cfg.Messages == 0 {
+		cfg.Messages = 1000
+	}
+	if cfg.MessageSize == 0 {
+		cfg.MessageSize = 100
+	}
+	if cfg.Shape == "" {
+		cfg.Shape = Pairs
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+}
+
+// Result of a run, it encodes to JSON so that results can
+// be published and compared.
+type Result struct {
+	Config     Config        `json:"config"`
+	Requests   int           `json:"requests"`
+	Errors     int           `json:"errors"`
+	Duration   time.Duration `json:"duration"`
+	Throughput float64       `json:"throughput"`
+	P50        time.Duration `json:"p50"`
+	P90        time.Duration `json:"p90"`
+	P99        time.Duration `json:"p99"`
+	Max        time.Duration `json:"max"`
+}
+
+// JSON of the result.
+func (r *Result) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "    ")
+}
+
+// String of the result, as one line for logs and tables.
+func (r *Result) String() string {
+	return fmt.Sprintf("shape: %v, peers: %v, receivers: %v, senders: %v, size: %v, requests: %v, errors: %v, throughput: %.0f/s, p50: %v, p90: %v, p99: %v, max: %v",
+		r.Config.Shape, r.Config.Peers, r.Config.Receivers, r.Config.Senders, r.Config.MessageSize,
+		r.Requests, r.Errors, r.Throughput, r.P50, r.P90, r.P99, r.Max)
+}
+
+// Run the load described by the config against a grid in
+// the given etcd, and return its result.
+//
+// Example usage:
+//
+//     res, err := bench.Run(ctx, etcd, bench.Config{
+//         Peers:       3,
+//         Receivers:   30,
+//         Senders:     10,
+//         MessageSize: 1024,
+//         Shape:       bench.FanIn,
+//     })
+//     ...
+//     fmt.Println(res)
+//
+func Run(ctx context.Context, etcd *etcdv3.Client, cfg Config) (*Result, error) {
+	setConfigDefaults(&cfg)
+	switch cfg.Shape {
+	case Pairs, FanIn, FanOut:
+	default:
+		return nil, ErrUnknownShape
+	}
+
+	err := grid.Register(grid.EchoMsg{})
+	if err != nil {
+		return nil, err
+	}
+
+	servers, serveErrs, err := startPeers(ctx, etcd, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer stopPeers(servers)
+
+	receivers, err := startReceivers(ctx, servers, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer stopReceivers(receivers)
+
+	client, err := grid.NewClient(etcd, grid.ClientCfg{Namespace: cfg.Namespace})
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	names := make([]string, len(receivers))
+	for i, mailbox := range receivers {
+		names[i] = mailbox.Name()
+	}
+
+	// All randomness comes from the seed, and is drawn
+	// before any sender starts, so that it does not
+	// depend on the scheduling of senders.
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	payload := make([]byte, cfg.MessageSize)
+	for i := range payload {
+		payload[i] = byte('a' + rng.Intn(26))
+	}
+	msg := &grid.EchoMsg{Msg: string(payload)}
+	targets := make([]string, cfg.Senders)
+	for i := range targets {
+		switch cfg.Shape {
+		case Pairs:
+			targets[i] = names[rng.Intn(len(names))]
+		case FanIn:
+			targets[i] = names[0]
+		}
+	}
+
+	latencies := make([][]time.Duration, cfg.Senders)
+	errs := make([]int, cfg.Senders)
+
+	var wg sync.WaitGroup
+	t0 := time.Now()
+	for i := 0; i < cfg.Senders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			group := grid.NewListGroup(names...)
+			for j := 0; j < cfg.Messages; j++ {
+				timeout, cancel := context.WithTimeout(ctx, cfg.Timeout)
+				t1 := time.Now()
+				var err error
+				if cfg.Shape == FanOut {
+					_, err = client.BroadcastC(timeout, group, msg)
+				} else {
+					_, err = client.RequestC(timeout, targets[i], msg)
+				}
+				cancel()
+				if err != nil {
+					errs[i]++
+					continue
+				}
+				latencies[i] = append(latencies[i], time.Since(t1))
+			}
+		}(i)
+	}
+	wg.Wait()
+	duration := time.Since(t0)
+
+	// A peer that failed during the run makes its
+	// results meaningless.
+	select {
+	case err := <-serveErrs:
+		return nil, err
+	default:
+	}
+
+	var all []time.Duration
+	res := &Result{
+		Config:   cfg,
+		Duration: duration,
+	}
+	for i := range latencies {
+		all = append(all, latencies[i]...)
+		res.Errors += errs[i]
+	}
+	res.Requests = len(all) + res.Errors
+	res.Throughput = float64(len(all)) / duration.Seconds()
+	res.P50, res.P90, res.P99, res.Max = percentiles(all)
+	return res, nil
+}
+
+// startPeers and wait for all of them to register. Errors of
+// the peers serving are sent on the returned channel.
+func startPeers(ctx context.Context, etcd *etcdv3.Client, cfg Config) ([]*grid.Server, <-chan error, error) {
+	var servers []*grid.Server
+	serveErrs := make(chan error, cfg.Peers)
+	for i := 0; i < cfg.Peers; i++ {
+		server, err := grid.NewServer(etcd, grid.ServerCfg{
+			Namespace:         cfg.Namespace,
+			DisalowLeadership: true,
+		})
+		if err != nil {
+			stopPeers(servers)
+			return nil, nil, err
+		}
+		lis, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			stopPeers(servers)
+			return nil, nil, err
+		}
+		go func() {
+			err := server.Serve(lis)
+			if err != nil {
+				serveErrs <- err
+			}
+		}()
+		servers = append(servers, server)
+	}
+
+	// Stopping a peer that has not started serving
+	// races with its start, so wait until each has
+	// registered, or failed.
+	client, err := grid.NewClient(etcd, grid.ClientCfg{Namespace: cfg.Namespace})
+	if err != nil {
+		stopPeers(servers)
+		return nil, nil, err
+	}
+	defer client.Close()
+	for {
+		peers, err := client.QueryC(ctx, grid.Peers)
+		if err == nil && len(peers) == cfg.Peers {
+			return servers, serveErrs, nil
+		}
+		select {
+		case err := <-serveErrs:
+			stopPeers(servers)
+			return nil, nil, err
+		case <-ctx.Done():
+			stopPeers(servers)
+			return nil, nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func stopPeers(servers []*grid.Server) {
+	for _, server := range servers {
+		server.Stop()
+	}
+}
+
+// startReceivers round-robin across the peers, each receiver
+// acks every message it receives.
+func startReceivers(ctx context.Context, servers []*grid.Server, cfg Config) ([]*grid.Mailbox, error) {
+	var receivers []*grid.Mailbox
+	for i := 0; i < cfg.Receivers; i++ {
+		server := servers[i%len(servers)]
+		name := fmt.Sprintf("receiver-%d", i)
+
+		// The peer may still be starting, in which
+		// case it reports that it is not running.
+		var mailbox *grid.Mailbox
+		var err error
+		for {
+			mailbox, err = grid.NewMailbox(server, name, 1000)
+			if err != grid.ErrServerNotRunning {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				stopReceivers(receivers)
+				return nil, ctx.Err()
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		if err != nil {
+			stopReceivers(receivers)
+			return nil, err
+		}
+		receivers = append(receivers, mailbox)
+
+		go func() {
+			for req := range mailbox.C {
+				req.Ack()
+			}
+		}()
+	}
+	return receivers, nil
+}
+
+func stopReceivers(receivers []*grid.Mailbox) {
+	for _, mailbox := range receivers {
+		mailbox.Close()
+	}
+}
+
+// percentiles 50, 90, and 99, and the max of the latencies.
+func percentiles(latencies []time.Duration) (p50, p90, p99, max time.Duration) {
+	if len(latencies) == 0 {
+		return
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	at := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+	return at(0.50), at(0.90), at(0.99), latencies[len(latencies)-1]
+}
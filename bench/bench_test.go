@@ -0,0 +1,55 @@
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lytics/grid/testetcd"
+)
+
+func TestPercentiles(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	p50, p90, p99, max := percentiles(latencies)
+	if p50 != 50*time.Millisecond {
+		t.Fatalf("expected p50 of 50ms, got: %v", p50)
+	}
+	if p90 != 90*time.Millisecond {
+		t.Fatalf("expected p90 of 90ms, got: %v", p90)
+	}
+	if p99 != 99*time.Millisecond {
+		t.Fatalf("expected p99 of 99ms, got: %v", p99)
+	}
+	if max != 100*time.Millisecond {
+		t.Fatalf("expected max of 100ms, got: %v", max)
+	}
+}
+
+func TestRun(t *testing.T) {
+	etcd := testetcd.StartAndConnect(t)
+	defer etcd.Close()
+
+	for _, shape := range []Shape{Pairs, FanIn, FanOut} {
+		res, err := Run(context.Background(), etcd, Config{
+			Namespace: "testing-bench-" + string(shape),
+			Peers:     2,
+			Receivers: 4,
+			Senders:   2,
+			Messages:  10,
+			Shape:     shape,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Errors != 0 {
+			t.Fatalf("expected no errors, got: %v", res.Errors)
+		}
+		if res.Requests != 20 {
+			t.Fatalf("expected 20 requests, got: %v", res.Requests)
+		}
+	}
+}
@@ -1,8 +1,16 @@
 package grid
 
 import (
+	"context"
+	"crypto/tls"
 	"runtime"
 	"time"
+
+	"github.com/lytics/grid/codec"
+	"github.com/lytics/grid/registry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 )
 
 // Logger hides the logging function Printf behind a simple
@@ -20,11 +28,96 @@ type ClientCfg struct {
 	Timeout time.Duration
 	// PeersRefreshInterval for polling list of peers in etcd.
 	PeersRefreshInterval time.Duration
+	// DefaultRequestTimeout of requests and broadcasts made with
+	// a timeout of zero. Default is 10 seconds.
+	DefaultRequestTimeout time.Duration
+	// DefaultQueryTimeout of queries made with a timeout of zero.
+	// Default is 10 seconds.
+	DefaultQueryTimeout time.Duration
 	// ConnectionsPerPeer sets the number gRPC connections to
 	// establish to each remote. Default is max(1, numCPUs/2).
 	// More connections allow for more messages per second,
 	// but increases the number of file-handles used.
 	ConnectionsPerPeer int
+	// RetryBudget is the largest fraction of requests the client
+	// may retry, such as 0.1, beyond a reserve of a few retries.
+	// The default of zero is no limit.
+	RetryBudget float64
+	// CoalesceDelay is how long a sink may hold a small message
+	// to send it in one frame with others to the same peer. The
+	// default of zero disables coalescing. Messages to peers of
+	// older versions, which do not understand batched frames,
+	// are never coalesced.
+	CoalesceDelay time.Duration
+	// CoalesceSize in bytes at which a batch of coalesced messages
+	// is sent without waiting for the delay, messages this size
+	// or larger are never held. Default is 16KiB.
+	CoalesceSize int
+	// Mirroring of requests to canaries, see SetMirror, which
+	// has the client read the namespace's mirrors from etcd
+	// once per PeersRefreshInterval. Default is no mirroring.
+	Mirroring bool
+	// WarmConnections, when above zero, has the client dial every
+	// registered peer ahead of its first request, and redial them
+	// as peers come and go, with this many peers dialed at once.
+	// The default of zero dials peers on their first request.
+	WarmConnections int
+	// Tenant on whose behalf requests are sent, when their
+	// context has none, see WithTenant.
+	Tenant string
+	// SaturatedDepth of a mailbox, the number of requests queued
+	// in it, at which RequestGroup avoids it while other members
+	// of the group are below it. The default of zero disables it.
+	SaturatedDepth int
+	// MaxRecvMsgSize in bytes the client can receive, the default
+	// of zero keeps gRPC's default of 4MB.
+	MaxRecvMsgSize int
+	// MaxSendMsgSize in bytes the client can send, the default
+	// of zero keeps gRPC's default.
+	MaxSendMsgSize int
+	// KeepaliveTime after which the client pings a peer if it has
+	// seen no activity, the default of zero disables pings. Peers
+	// must permit pings this often, see ServerCfg.KeepaliveMinTime.
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout after a ping, after which the connection
+	// is closed, the default of zero keeps gRPC's default.
+	KeepaliveTimeout time.Duration
+	// Signer optionally used to sign requests, required when
+	// the receiving servers are configured with a Signer.
+	Signer Signer
+	// KMS optionally used to encrypt actor state and durable
+	// actor definitions before they are written to etcd.
+	KMS KMS
+	// ValueCodec of durable actor definitions written to etcd,
+	// either registry.JSON or registry.Protobuf. Definitions
+	// written by any known codec can be read. Default is JSON.
+	ValueCodec registry.Codec
+	// Codec of requests, such as codec.JSON for messages that are
+	// plain structs, or that actors in other languages receive.
+	// Responses are encoded with the codec of their request, and
+	// messages by any registered codec can be received. Default
+	// is codec.Protobuf.
+	Codec codec.Codec
+	// Labels of the client's location, such as its LabelHost and
+	// LabelZone, compared with the labels of peers, see Locality.
+	Labels map[string]string
+	// Locality is the labels, in order of preference, whose values
+	// RequestGroup prefers members on peers to share with the
+	// client, for example LabelHost then LabelZone, to cut the
+	// traffic between zones. Default is no preference.
+	Locality []string
+	// Token optionally sent with every request, as credentials
+	// for the namespace, see ServerCfg.Auth.
+	Token string
+	// TLS optionally used to connect to peers, default is to
+	// connect without TLS. Use a CertReloader to rotate the
+	// client certificate without a restart.
+	TLS *tls.Config
+	// Server optionally in the same process as the client, to
+	// whose mailboxes requests are delivered in process, without
+	// going through gRPC. The server's own client, which actors
+	// get through ContextClient, always delivers in process.
+	Server *Server
 	// Logger optionally used for logging, default is to not log.
 	Logger Logger
 }
@@ -40,6 +133,38 @@ func setClientCfgDefaults(cfg *ClientCfg) {
 	if cfg.ConnectionsPerPeer == 0 {
 		cfg.ConnectionsPerPeer = maxInt(1, runtime.NumCPU()/2)
 	}
+	if cfg.CoalesceSize == 0 {
+		cfg.CoalesceSize = 16 * 1024
+	}
+	if cfg.DefaultRequestTimeout == 0 {
+		cfg.DefaultRequestTimeout = 10 * time.Second
+	}
+	if cfg.DefaultQueryTimeout == 0 {
+		cfg.DefaultQueryTimeout = 10 * time.Second
+	}
+	if cfg.ValueCodec == nil {
+		cfg.ValueCodec = registry.JSON
+	}
+	if cfg.Codec == nil {
+		cfg.Codec = codec.Protobuf
+	}
+}
+
+// orDefault returns the timeout, or the default if it is zero.
+func orDefault(timeout, def time.Duration) time.Duration {
+	if timeout == 0 {
+		return def
+	}
+	return timeout
+}
+
+// orDefaultDeadline returns the context, bounded by the default
+// timeout if it has no deadline of its own.
+func orDefaultDeadline(ctx context.Context, def time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || def <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, def)
 }
 
 // ServerCfg where the only required argument is Namespace,
@@ -49,12 +174,184 @@ type ServerCfg struct {
 	Namespace string
 	// DisalowLeadership to prevent leader from running on a node.
 	DisalowLeadership bool
+	// PartialStart to keep serving when the leader fails to
+	// start, retrying it later, rather than stopping the server
+	// with a StartupError. A leader that is not registered, or
+	// whose definition makes a nil actor, is always skipped.
+	PartialStart bool
+	// LeaderHeartbeat interval at which a peer running the leader
+	// checks that no other peer is running it too. Default is 10s.
+	LeaderHeartbeat time.Duration
+	// LeaderConflictPolicy deciding how a peer reacts to another
+	// peer running the leader too, default is LeaderStepDown.
+	LeaderConflictPolicy LeaderConflictPolicy
+	// OnLeaderConflict optionally handles the conflicts found
+	// between leaders, default is to log them.
+	OnLeaderConflict func(lc *LeaderConflict)
+	// OnVersionSkew optionally handles the peers found speaking
+	// other versions of the wire protocol, default is to log them.
+	OnVersionSkew func(vs *VersionSkew)
+	// GossipInterval at which the peer sends heartbeats directly
+	// to other peers, the default of zero disables gossip.
+	GossipInterval time.Duration
+	// GossipFanout is the number of peers each heartbeat is sent
+	// to. Default is 3 when gossip is enabled.
+	GossipFanout int
+	// SuspectAfter a peer has not been heard of by gossip for this
+	// long it is suspect. Default is 3 gossip intervals.
+	SuspectAfter time.Duration
+	// MaxClockSkew between this peer's clock and the clocks of
+	// peers heard of by gossip, beyond which a warning is logged,
+	// see Server.ClockSkews. Default is 1 second.
+	MaxClockSkew time.Duration
+	// FailureDetector optionally decides which peers heard of by
+	// gossip are suspect, instead of the SuspectAfter timeout,
+	// for example a PhiAccrualDetector.
+	FailureDetector FailureDetector
 	// Timeout for communication with etcd, and internal gossip.
 	Timeout time.Duration
 	// LeaseDuration for data in etcd.
 	LeaseDuration time.Duration
+	// ReconcileInterval for checking that durable actors
+	// are running somewhere in the namespace.
+	ReconcileInterval time.Duration
+	// DrainTimeout actors are given to finish when the server
+	// stops, after being notified through ContextDrain, before
+	// their contexts are cancelled. The default of zero cancels
+	// them without notice.
+	DrainTimeout time.Duration
+	// LameDuckDelay the server keeps serving when it stops, after
+	// announcing it is a lame duck, see LameDuck, before draining,
+	// so that clients notice and stop routing new requests to it.
+	// It is best set to the clients' PeersRefreshInterval. The
+	// default of zero announces without waiting.
+	LameDuckDelay time.Duration
+	// CPUWorkers is the size of the worker pool shared by actors
+	// defined with OpExecCPU. Default is max(1, numCPUs-1), which
+	// leaves a CPU for serving messages.
+	CPUWorkers int
+	// MaxRecvMsgSize in bytes the server can receive, the default
+	// of zero keeps gRPC's default of 4MB.
+	MaxRecvMsgSize int
+	// MaxSendMsgSize in bytes the server can send, the default
+	// of zero keeps gRPC's default.
+	MaxSendMsgSize int
+	// KeepaliveTime after which the server pings a client if it
+	// has seen no activity, the default of zero keeps gRPC's
+	// default.
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout after a ping, after which the connection
+	// is closed, the default of zero keeps gRPC's default.
+	KeepaliveTimeout time.Duration
+	// KeepaliveMinTime clients must wait between pings, clients
+	// pinging more often are disconnected. The default of zero
+	// keeps gRPC's default of 5 minutes.
+	KeepaliveMinTime time.Duration
+	// MaxConnectionAge after which a connection is gracefully
+	// closed, and the client reconnects. Useful for spreading
+	// load after peers join. The default of zero is no limit.
+	MaxConnectionAge time.Duration
+	// MaxConnectionAgeGrace for requests in flight on a connection
+	// closed for its age, the default of zero is no limit.
+	MaxConnectionAgeGrace time.Duration
+	// Signer optionally used to verify requests, when set
+	// requests that are unsigned or wrongly signed are
+	// rejected. The server's own client signs with it.
+	Signer Signer
+	// KMS optionally used to encrypt actor state and durable
+	// actor definitions before they are written to etcd. The
+	// server's own client encrypts with it.
+	KMS KMS
+	// ValueCodec of registrations and durable actor definitions
+	// written to etcd, either registry.JSON for debuggability, or
+	// registry.Protobuf for size. Values written by any known codec
+	// can be read, but peers of versions without codecs only read
+	// JSON. The server's own client writes with it. Default is JSON.
+	ValueCodec registry.Codec
+	// Codec of the requests of the server's own client, see
+	// ClientCfg.Codec. Default is codec.Protobuf.
+	Codec codec.Codec
+	// Labels of the peer, such as its LabelHost and LabelZone,
+	// written into its registrations, so that clients can route
+	// by them, see ClientCfg.Locality. They are also the labels
+	// of the server's own client.
+	Labels map[string]string
+	// Locality of the server's own client, see ClientCfg.Locality.
+	Locality []string
+	// Build of the application, such as its version or commit,
+	// written into the peer's registrations, see LabelBuild.
+	// Default is the version of the main module, or else its
+	// VCS revision, from the binary's build info.
+	Build string
+	// Checkpoints optionally stores the checkpoints of actors,
+	// see SaveState, instead of etcd, for snapshots larger than
+	// etcd allows values to be.
+	Checkpoints BlobStore
+	// Secrets optionally resolves the secret references of
+	// actor starts, when the actor is made. Actors started
+	// with secrets fail to start if it is not set.
+	Secrets SecretProvider
+	// MessageStore of the messages of durable mailboxes, see
+	// NewDurableMailbox. Default keeps them in etcd.
+	MessageStore MessageStore
+	// Auth optionally verifies the token of each request, when
+	// set requests without a valid token are rejected.
+	Auth AuthFunc
+	// Token sent by the server's own client, for example when
+	// starting actors on other peers.
+	Token string
+	// Policy optionally decides which callers may manage actors,
+	// and which may only send messages. Callers are identified
+	// by their TLS certificate, or by Identify.
+	Policy Policy
+	// AllowFaults injected into the server, see Client.InjectFault,
+	// for game days, never set it on peers that must not fail.
+	AllowFaults bool
+	// Identify optionally maps a caller's token to an identity,
+	// for callers without a TLS certificate.
+	Identify IdentityFunc
+	// Auditor optionally records audit events, such as requests
+	// denied by the Policy, default is to log them.
+	Auditor Auditor
+	// Metrics optionally receives the metrics reported by actors,
+	// see ContextMetrics, default is to discard them.
+	Metrics MetricsBackend
+	// RateLimit of requests per second from each caller, callers
+	// are identified as for the Policy, or else by their host.
+	// The default of zero is no limit.
+	RateLimit float64
+	// RateBurst of requests a caller may send at once above
+	// the RateLimit. Default is 1.
+	RateBurst int
+	// TenantQuota of each tenant, see WithTenant, and TenantQuotas
+	// of particular tenants, which replace it. Requests sent on
+	// behalf of no tenant share the quota of the empty tenant.
+	TenantQuota  TenantQuota
+	TenantQuotas map[string]TenantQuota
+	// DeliverySlots limits the number of requests being delivered
+	// into mailboxes at once. While all are taken, the mailboxes
+	// waiting take turns, so that a flooded mailbox cannot crowd
+	// out the others. The default of zero is no limit.
+	DeliverySlots int
+	// Admission policy of mailboxes, deciding the order in which
+	// queued requests are delivered, and which are rejected under
+	// overload. The default of nil delivers in order of arrival.
+	Admission *AdmissionPolicy
+	// TLS optionally used to serve peers and clients, default
+	// is to serve without TLS. Use a CertReloader to rotate the
+	// certificate without a restart. The server's own client
+	// uses the same config, so it should hold a certificate
+	// usable as a client certificate, and the root CAs of
+	// other peers.
+	TLS *tls.Config
 	// Logger optionally used for logging, default is to not log.
+	// The logs of actors, see ContextLogger, are also written
+	// to it, tagged with the actor's name and type.
 	Logger Logger
+	// LogCollector optionally names a mailbox to which the logs
+	// of actors are forwarded in batches of ActorLogs, so that
+	// the logs of all peers can be gathered in one place.
+	LogCollector string
 }
 
 // setServerCfgDefaults for those fields that have their zero value.
@@ -65,6 +362,96 @@ func setServerCfgDefaults(cfg *ServerCfg) {
 	if cfg.LeaseDuration == 0 {
 		cfg.LeaseDuration = 60 * time.Second
 	}
+	if cfg.ReconcileInterval == 0 {
+		cfg.ReconcileInterval = 10 * time.Second
+	}
+	if cfg.LeaderHeartbeat == 0 {
+		cfg.LeaderHeartbeat = 10 * time.Second
+	}
+	if cfg.GossipInterval > 0 {
+		if cfg.GossipFanout == 0 {
+			cfg.GossipFanout = 3
+		}
+		if cfg.SuspectAfter == 0 {
+			cfg.SuspectAfter = 3 * cfg.GossipInterval
+		}
+	}
+	if cfg.MaxClockSkew == 0 {
+		cfg.MaxClockSkew = time.Second
+	}
+	if cfg.CPUWorkers == 0 {
+		cfg.CPUWorkers = runtime.NumCPU() - 1
+		if cfg.CPUWorkers < 1 {
+			cfg.CPUWorkers = 1
+		}
+	}
+	if cfg.ValueCodec == nil {
+		cfg.ValueCodec = registry.JSON
+	}
+	if cfg.Codec == nil {
+		cfg.Codec = codec.Protobuf
+	}
+}
+
+// serverOptions of gRPC from the config, only fields that
+// are set change gRPC's defaults.
+func serverOptions(cfg ServerCfg) []grpc.ServerOption {
+	var opts []grpc.ServerOption
+	if cfg.TLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg.TLS)))
+	}
+	if cfg.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize))
+	}
+	if cfg.MaxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(cfg.MaxSendMsgSize))
+	}
+	opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+		Time:                  cfg.KeepaliveTime,
+		Timeout:               cfg.KeepaliveTimeout,
+		MaxConnectionAge:      cfg.MaxConnectionAge,
+		MaxConnectionAgeGrace: cfg.MaxConnectionAgeGrace,
+	}))
+	if cfg.KeepaliveMinTime > 0 {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.KeepaliveMinTime,
+			PermitWithoutStream: true,
+		}))
+	}
+	return opts
+}
+
+// dialOptions of gRPC from the config, only fields that
+// are set change gRPC's defaults.
+func dialOptions(cfg ClientCfg) []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithBackoffMaxDelay(20 * time.Second),
+	}
+	if cfg.TLS != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(cfg.TLS)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	var callOpts []grpc.CallOption
+	if cfg.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(cfg.MaxRecvMsgSize))
+	}
+	if cfg.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(cfg.MaxSendMsgSize))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+	if cfg.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials{token: cfg.Token}))
+	}
+	if cfg.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    cfg.KeepaliveTime,
+			Timeout: cfg.KeepaliveTimeout,
+		}))
+	}
+	return opts
 }
 
 func maxInt(a, b int) int {
@@ -1,8 +1,11 @@
 package grid
 
+import "context"
 import "testing"
 import "time"
 
+import "github.com/lytics/grid/registry"
+
 func TestSetClientCfgDefaults(t *testing.T) {
 	cfg := ClientCfg{Namespace: "testing"}
 
@@ -12,6 +15,9 @@ func TestSetClientCfgDefaults(t *testing.T) {
 	if cfg.PeersRefreshInterval != 0 {
 		t.Fatalf("initial PeersRefreshInterval should be zero value")
 	}
+	if cfg.CoalesceSize != 0 {
+		t.Fatalf("initial CoalesceSize should be zero value")
+	}
 
 	setClientCfgDefaults(&cfg)
 
@@ -21,6 +27,24 @@ func TestSetClientCfgDefaults(t *testing.T) {
 	if cfg.PeersRefreshInterval != 2*time.Second {
 		t.Fatalf("initial PeersRefreshInterval should be 2s")
 	}
+	if cfg.CoalesceDelay != 0 {
+		t.Fatalf("initial CoalesceDelay should be zero")
+	}
+	if cfg.CoalesceSize != 16*1024 {
+		t.Fatalf("initial CoalesceSize should be 16KiB")
+	}
+	if cfg.DefaultRequestTimeout != 10*time.Second {
+		t.Fatalf("initial DefaultRequestTimeout should be 10s")
+	}
+	if cfg.DefaultQueryTimeout != 10*time.Second {
+		t.Fatalf("initial DefaultQueryTimeout should be 10s")
+	}
+	if orDefault(0, cfg.DefaultRequestTimeout) != 10*time.Second {
+		t.Fatalf("zero timeout should be the default")
+	}
+	if orDefault(time.Second, cfg.DefaultRequestTimeout) != time.Second {
+		t.Fatalf("non-zero timeout should override the default")
+	}
 }
 
 func TestSetServerCfgDefaults(t *testing.T) {
@@ -32,6 +56,15 @@ func TestSetServerCfgDefaults(t *testing.T) {
 	if cfg.LeaseDuration != 0 {
 		t.Fatalf("initial LeaseDuration should be zero value")
 	}
+	if cfg.ReconcileInterval != 0 {
+		t.Fatalf("initial ReconcileInterval should be zero value")
+	}
+	if cfg.LeaderHeartbeat != 0 {
+		t.Fatalf("initial LeaderHeartbeat should be zero value")
+	}
+	if cfg.CPUWorkers != 0 {
+		t.Fatalf("initial CPUWorkers should be zero value")
+	}
 
 	setServerCfgDefaults(&cfg)
 
@@ -41,4 +74,78 @@ func TestSetServerCfgDefaults(t *testing.T) {
 	if cfg.LeaseDuration != 60*time.Second {
 		t.Fatalf("initial LeaseDuration should be 60s")
 	}
+	if cfg.ReconcileInterval != 10*time.Second {
+		t.Fatalf("initial ReconcileInterval should be 10s")
+	}
+	if cfg.LeaderHeartbeat != 10*time.Second {
+		t.Fatalf("initial LeaderHeartbeat should be 10s")
+	}
+	if cfg.CPUWorkers < 1 {
+		t.Fatalf("initial CPUWorkers should be at least 1")
+	}
+	if cfg.GossipFanout != 0 || cfg.SuspectAfter != 0 {
+		t.Fatalf("gossip should stay disabled")
+	}
+	if cfg.MaxClockSkew != time.Second {
+		t.Fatalf("initial MaxClockSkew should be 1s")
+	}
+	if cfg.ValueCodec != registry.JSON {
+		t.Fatalf("initial ValueCodec should be JSON")
+	}
+
+	cfg = ServerCfg{Namespace: "testing", GossipInterval: time.Second}
+	setServerCfgDefaults(&cfg)
+	if cfg.GossipFanout != 3 {
+		t.Fatalf("initial GossipFanout should be 3")
+	}
+	if cfg.SuspectAfter != 3*time.Second {
+		t.Fatalf("initial SuspectAfter should be 3 gossip intervals")
+	}
+}
+
+func TestDialOptions(t *testing.T) {
+	cfg := ClientCfg{Namespace: "testing"}
+	setClientCfgDefaults(&cfg)
+	if n := len(dialOptions(cfg)); n != 2 {
+		t.Fatalf("expected 2 default dial options, got: %v", n)
+	}
+
+	cfg.MaxRecvMsgSize = 64 * 1024 * 1024
+	cfg.KeepaliveTime = 30 * time.Second
+	if n := len(dialOptions(cfg)); n != 4 {
+		t.Fatalf("expected 4 dial options, got: %v", n)
+	}
+}
+
+func TestServerOptions(t *testing.T) {
+	cfg := ServerCfg{Namespace: "testing"}
+	setServerCfgDefaults(&cfg)
+	if n := len(serverOptions(cfg)); n != 1 {
+		t.Fatalf("expected 1 default server option, got: %v", n)
+	}
+
+	cfg.MaxRecvMsgSize = 64 * 1024 * 1024
+	cfg.MaxSendMsgSize = 64 * 1024 * 1024
+	cfg.KeepaliveMinTime = 10 * time.Second
+	if n := len(serverOptions(cfg)); n != 4 {
+		t.Fatalf("expected 4 server options, got: %v", n)
+	}
+}
+
+func TestOrDefaultDeadline(t *testing.T) {
+	ctx, cancel := orDefaultDeadline(context.Background(), time.Minute)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Fatalf("expected default deadline, got: %v", deadline)
+	}
+
+	// A deadline of the caller's is kept.
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Hour)
+	defer cancelParent()
+	ctx, cancel = orDefaultDeadline(parent, time.Minute)
+	defer cancel()
+	if ctx != parent {
+		t.Fatal("expected context with deadline to be kept")
+	}
 }
@@ -0,0 +1,185 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+)
+
+// checkpointKey of the actor state holding the actor's
+// checkpoint, when checkpoints are kept in etcd.
+const checkpointKey = "grid-checkpoint"
+
+// BlobStore of the checkpoints of actors, see ServerCfg.Checkpoints,
+// for snapshots larger than etcd allows values to be.
+type BlobStore interface {
+	// Get the value of the key, nil if it has none.
+	Get(c context.Context, key string) ([]byte, error)
+	// Put the value of the key.
+	Put(c context.Context, key string, value []byte) error
+}
+
+// Checkpointer is an actor whose state is saved when it exits, and
+// handed back to it when it is started again, on any peer, so that
+// actors rescheduled after a drain, move, or restart carry on where
+// they left off. The server runs Restore before Preload and Act,
+// if the actor has a checkpoint, and Checkpoint once Act returns,
+// unless the actor panicked, or its peer lost its lease. Actors
+// that must survive the failure of their peer also save their
+// state as they go, see SaveState.
+//
+// Example usage:
+//
+//     func (a *counter) Checkpoint(ctx context.Context) ([]byte, error) {
+//         return json.Marshal(a.counts)
+//     }
+//
+//     func (a *counter) Restore(ctx context.Context, snapshot []byte) error {
+//         return json.Unmarshal(snapshot, &a.counts)
+//     }
+//
+type Checkpointer interface {
+	Checkpoint(c context.Context) ([]byte, error)
+	Restore(c context.Context, snapshot []byte) error
+}
+
+// SaveState snapshot of the actor, keyed by its name, replacing
+// its previous snapshot. It is kept in etcd, among the actor's
+// state, see ActorState, or in the server's Checkpoints store.
+//
+// Example usage:
+//
+//     case <-ticker.C:
+//         snapshot, err := json.Marshal(a.counts)
+//         ...
+//         err = grid.SaveState(ctx, snapshot)
+//
+func SaveState(c context.Context, snapshot []byte) error {
+	server, err := ContextServer(c)
+	if err != nil {
+		return err
+	}
+	name, err := ContextActorName(c)
+	if err != nil {
+		return err
+	}
+	return server.saveCheckpoint(c, name, snapshot)
+}
+
+// LoadState snapshot of the actor, saved by SaveState, or as its
+// checkpoint, nil if it has none.
+func LoadState(c context.Context) ([]byte, error) {
+	server, err := ContextServer(c)
+	if err != nil {
+		return nil, err
+	}
+	name, err := ContextActorName(c)
+	if err != nil {
+		return nil, err
+	}
+	return server.loadCheckpoint(c, name)
+}
+
+// saveCheckpoint of the actor.
+func (s *Server) saveCheckpoint(c context.Context, actor string, snapshot []byte) error {
+	sealed, err := seal(c, s.cfg.KMS, snapshot)
+	if err != nil {
+		return err
+	}
+	if s.cfg.Checkpoints != nil {
+		key, err := namespaceName(states, s.cfg.Namespace, actor)
+		if err != nil {
+			return err
+		}
+		return s.cfg.Checkpoints.Put(c, key, sealed)
+	}
+	key, err := stateKey(s.cfg.Namespace, actor, checkpointKey)
+	if err != nil {
+		return err
+	}
+	_, err = s.etcd.Put(c, key, string(sealed))
+	return err
+}
+
+// loadCheckpoint of the actor, nil if it has none.
+func (s *Server) loadCheckpoint(c context.Context, actor string) ([]byte, error) {
+	var sealed []byte
+	if s.cfg.Checkpoints != nil {
+		key, err := namespaceName(states, s.cfg.Namespace, actor)
+		if err != nil {
+			return nil, err
+		}
+		sealed, err = s.cfg.Checkpoints.Get(c, key)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		key, err := stateKey(s.cfg.Namespace, actor, checkpointKey)
+		if err != nil {
+			return nil, err
+		}
+		res, err := s.etcd.Get(c, key)
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Kvs) > 0 {
+			sealed = res.Kvs[0].Value
+		}
+	}
+	if sealed == nil {
+		return nil, nil
+	}
+	return open(c, s.cfg.KMS, sealed)
+}
+
+// prepareActor named to act, restoring its checkpoint, if it
+// is a Checkpointer, and then preloading it, if it is a Preloader.
+func (s *Server) prepareActor(c context.Context, name string, actor Actor) error {
+	if cp, ok := actor.(Checkpointer); ok {
+		snapshot, err := s.loadCheckpoint(c, name)
+		if err != nil {
+			return fmt.Errorf("failed loading checkpoint: %w", err)
+		}
+		if snapshot != nil {
+			err := cp.Restore(c, snapshot)
+			if err != nil {
+				return fmt.Errorf("failed restoring checkpoint: %w", err)
+			}
+		}
+	}
+	err := preload(c, actor)
+	if err != nil {
+		return fmt.Errorf("failed preloading: %w", err)
+	}
+	return nil
+}
+
+// runActor named, once prepared, saving its checkpoint once
+// it exits, if it is a Checkpointer.
+func (s *Server) runActor(c context.Context, name string, actor Actor) error {
+	actor.Act(c)
+	cp, ok := actor.(Checkpointer)
+	if !ok {
+		return nil
+	}
+
+	// Once the peer has lost its lease, or the leader has
+	// stepped down, the actor may already run elsewhere,
+	// and its checkpoint must not be overwritten.
+	reason, _ := ContextDoneReason(c)
+	if reason == DoneFailed || reason == DoneSteppedDown {
+		return nil
+	}
+	// The actor's context is likely done, so the
+	// checkpoint is saved with one of its own.
+	timeout, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+	snapshot, err := cp.Checkpoint(timeout)
+	if err != nil {
+		return fmt.Errorf("failed checkpointing: %w", err)
+	}
+	err = s.saveCheckpoint(timeout, name, snapshot)
+	if err != nil {
+		return fmt.Errorf("failed saving checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package grid
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lytics/grid/testetcd"
+)
+
+// memBlobStore keeps blobs in memory.
+type memBlobStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func (m *memBlobStore) Get(c context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.blobs[key], nil
+}
+
+func (m *memBlobStore) Put(c context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = value
+	return nil
+}
+
+// checkpointingActor counts the times it acts.
+type checkpointingActor struct {
+	acts     int
+	restored bool
+	err      error
+}
+
+func (a *checkpointingActor) Act(c context.Context) { a.acts++ }
+
+func (a *checkpointingActor) Checkpoint(c context.Context) ([]byte, error) {
+	return []byte{byte(a.acts)}, a.err
+}
+
+func (a *checkpointingActor) Restore(c context.Context, snapshot []byte) error {
+	a.acts = int(snapshot[0])
+	a.restored = true
+	return nil
+}
+
+func TestCheckpoint(t *testing.T) {
+	store := &memBlobStore{blobs: map[string][]byte{}}
+	s := &Server{cfg: ServerCfg{Namespace: "testing", Checkpoints: store}}
+	ctx := context.WithValue(context.Background(), contextKey, &contextVal{server: s, actorName: "counter"})
+
+	snapshot, err := LoadState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot != nil {
+		t.Fatalf("expected no snapshot, got: %v", snapshot)
+	}
+
+	run := func(a Actor) error {
+		if err := s.prepareActor(ctx, "counter", a); err != nil {
+			return err
+		}
+		return s.runActor(ctx, "counter", a)
+	}
+
+	// The actor is checkpointed when it exits, and
+	// restored from it when it starts again.
+	a := &checkpointingActor{}
+	if err := run(a); err != nil {
+		t.Fatal(err)
+	}
+	if a.restored {
+		t.Fatal("expected no restore without a checkpoint")
+	}
+	a = &checkpointingActor{}
+	if err := run(a); err != nil {
+		t.Fatal(err)
+	}
+	if !a.restored || a.acts != 2 {
+		t.Fatalf("expected restored actor to act a second time, got: %v", a.acts)
+	}
+	if got := store.blobs["testing.state.counter"]; len(got) != 1 || got[0] != 2 {
+		t.Fatalf("expected checkpoint in store, got: %v", got)
+	}
+
+	err = SaveState(ctx, []byte{7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err = LoadState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot) != 1 || snapshot[0] != 7 {
+		t.Fatalf("expected saved snapshot, got: %v", snapshot)
+	}
+
+	a = &checkpointingActor{err: errors.New("unavailable")}
+	if err := run(a); !errors.Is(err, a.err) {
+		t.Fatalf("expected checkpoint error, got: %v", err)
+	}
+
+	if _, err := LoadState(context.Background()); err != ErrInvalidContext {
+		t.Fatalf("expected invalid context, got: %v", err)
+	}
+}
+
+func TestCheckpointEtcd(t *testing.T) {
+	const timeout = 2 * time.Second
+
+	etcd := testetcd.StartAndConnect(t)
+	defer etcd.Close()
+
+	// Without a blob store checkpoints are kept in etcd.
+	s, err := NewServer(etcd, ServerCfg{Namespace: newNamespace()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	snapshot, err := s.loadCheckpoint(ctx, "counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot != nil {
+		t.Fatalf("expected no checkpoint, got: %v", snapshot)
+	}
+
+	err = s.saveCheckpoint(ctx, "counter", []byte{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err = s.loadCheckpoint(ctx, "counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot) != 2 || snapshot[0] != 1 || snapshot[1] != 2 {
+		t.Fatalf("expected saved checkpoint, got: %v", snapshot)
+	}
+}
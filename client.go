@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -13,13 +14,14 @@ import (
 	etcdv3 "github.com/coreos/etcd/clientv3"
 	"github.com/lytics/grid/codec"
 	"github.com/lytics/grid/registry"
-	"github.com/lytics/retry"
 	"google.golang.org/grpc"
 )
 
 // Register a message so it may be sent and received.
 // Value v should not be a pointer to a type, but
-// the type itself.
+// the type itself. Messages that are plain structs,
+// rather than protobuf messages, are sent with a
+// codec such as codec.JSON, see WithCodec.
 //
 // For example:
 //     Register(MyMsg{})    // Correct
@@ -77,8 +79,65 @@ func (ccp *clientAndConnPool) close() error {
 // clientAndConn of the generated gRPC client
 // plus the actual gRPC client connection.
 type clientAndConn struct {
+	mu     sync.Mutex
 	conn   *grpc.ClientConn
 	client WireClient
+	stream *muxStream
+	unary  bool
+	// Coalescing of the stream, see ClientCfg.
+	coalesceDelay time.Duration
+	coalesceSize  int
+	// loads of mailboxes, shared by the client's
+	// connections, see RequestGroup.
+	loads *loadTable
+}
+
+// request a response over the connection's multiplexed stream.
+// Peers that do not serve streams are sent unary requests.
+func (cc *clientAndConn) request(ctx context.Context, d *Delivery) (*Delivery, error) {
+	ms, err := cc.openStream()
+	if err != nil {
+		return nil, err
+	}
+	if ms == nil {
+		return cc.client.Process(ctx, d)
+	}
+	res, err := ms.request(ctx, d)
+	if err != nil && ms.isUnimplemented() {
+		return cc.client.Process(ctx, d)
+	}
+	return res, err
+}
+
+// openStream returns the connection's stream, replacing it
+// if it has broken, or nil if the peer does not serve streams.
+func (cc *clientAndConn) openStream() (*muxStream, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.unary {
+		return nil, nil
+	}
+	if cc.stream != nil && cc.stream.isUnimplemented() {
+		cc.unary = true
+		cc.stream.close()
+		cc.stream = nil
+		return nil, nil
+	}
+	if cc.stream == nil || cc.stream.broken() {
+		if cc.stream != nil {
+			cc.stream.close()
+		}
+		ms, err := newMuxStream(cc.client, cc.loads)
+		if err != nil {
+			return nil, err
+		}
+		if cc.coalesceDelay > 0 {
+			ms.co = newCoalescer(ms, cc.coalesceDelay, cc.coalesceSize)
+		}
+		cc.stream = ms
+	}
+	return cc.stream, nil
 }
 
 // close the gRPC connection.
@@ -88,6 +147,11 @@ func (cc *clientAndConn) close() error {
 	if cc == nil {
 		return fmt.Errorf("client and conn is nil")
 	}
+	cc.mu.Lock()
+	if cc.stream != nil {
+		cc.stream.close()
+	}
+	cc.mu.Unlock()
 	return cc.conn.Close()
 }
 
@@ -96,15 +160,39 @@ func (cc *clientAndConn) close() error {
 type Client struct {
 	mu              sync.Mutex
 	cfg             ClientCfg
+	etcd            *etcdv3.Client
 	registry        *registry.Registry
 	addresses       map[string]string
 	clientsAndConns map[string]*clientAndConnPool
+	// peer of the server owning the client, if
+	// any, stamped on the client's requests.
+	peer string
+	// weights of the namespace's mailboxes, see RequestGroup.
+	weights *weightCache
+	// mirrors of the namespace's mailboxes, see SetMirror.
+	mirrors *mirrorCache
+	// lameDucks of the namespace, see Server.LameDuck.
+	lameDucks *lameDuckCache
+	// quarantines of the namespace, see QuarantinePeer.
+	quarantines *quarantineCache
+	// peerLabels of the namespace, see Locality.
+	peerLabels *peerLabelCache
+	// budget of retries, nil for no limit.
+	budget *retryBudget
+	// unwarm stops keeping connections warm,
+	// nil unless WarmConnections is set.
+	unwarm func()
+	// loads of mailboxes, as advertised by their
+	// peers, see RequestGroup.
+	loads *loadTable
 	// Test hook.
 	cs *clientStats
 }
 
-// NewClient using the given etcd client and configuration.
-func NewClient(etcd *etcdv3.Client, cfg ClientCfg) (*Client, error) {
+// NewClient using the given etcd client and configuration, given
+// either as a ClientCfg, or as options, see NewServer.
+func NewClient(etcd *etcdv3.Client, options ...ClientOption) (*Client, error) {
+	cfg := newClientCfg(options)
 	setClientCfgDefaults(&cfg)
 
 	r, err := registry.New(etcd)
@@ -118,18 +206,30 @@ func NewClient(etcd *etcdv3.Client, cfg ClientCfg) (*Client, error) {
 		r.Logger = cfg.Logger
 	}
 
-	return &Client{
+	c := &Client{
 		cfg:             cfg,
+		etcd:            etcd,
 		registry:        r,
 		addresses:       make(map[string]string),
 		clientsAndConns: make(map[string]*clientAndConnPool),
-	}, nil
+		budget:          newRetryBudget(cfg.RetryBudget),
+		loads:           newLoadTable(),
+	}
+	if cfg.WarmConnections > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		c.unwarm = cancel
+		go c.keepWarm(ctx)
+	}
+	return c, nil
 }
 
 // Close all outbound connections of this client immediately.
 func (c *Client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.unwarm != nil {
+		c.unwarm()
+	}
 	var err error
 	for _, ccpool := range c.clientsAndConns {
 		closeErr := ccpool.close()
@@ -140,59 +240,124 @@ func (c *Client) Close() error {
 	return err
 }
 
-// Request a response for the given message.
+// Request a response for the given message. A timeout of
+// zero means the client's DefaultRequestTimeout.
 func (c *Client) Request(timeout time.Duration, receiver string, msg interface{}) (interface{}, error) {
-	timeoutC, cancel := context.WithTimeout(context.Background(), timeout)
+	timeoutC, cancel := context.WithTimeout(context.Background(), orDefault(timeout, c.cfg.DefaultRequestTimeout))
 	defer cancel()
 	return c.RequestC(timeoutC, receiver, msg)
 }
 
 // RequestC (request) a response for the given message. The context can be
-// used to control cancelation or timeouts.
+// used to control cancelation or timeouts. A context without a deadline
+// is bounded by the client's DefaultRequestTimeout.
 func (c *Client) RequestC(ctx context.Context, receiver string, msg interface{}) (interface{}, error) {
+	ctx, cancel := orDefaultDeadline(ctx, c.cfg.DefaultRequestTimeout)
+	defer cancel()
+	c.mirror(ctx, receiver, msg)
+	return c.requestC(ctx, receiver, msg)
+}
+
+// requestC a response for the given message, without mirroring it.
+func (c *Client) requestC(ctx context.Context, receiver string, msg interface{}) (interface{}, error) {
+	res, err := c.requestDelivery(ctx, receiver, msg)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := decode(res.Codec, res.TypeName, res.Data)
+	putDelivery(res)
+	return reply, err
+}
+
+// requestDelivery of the response for the given message, still
+// encoded. The caller puts it back into the pool once done with it.
+func (c *Client) requestDelivery(ctx context.Context, receiver string, msg interface{}) (*Delivery, error) {
 	// Namespaced receiver name.
 	nsReceiver, err := namespaceName(Mailboxes, c.cfg.Namespace, receiver)
 	if err != nil {
 		return nil, err
 	}
 
-	typeName, data, err := codec.Marshal(msg)
+	// Mailboxes of a server in the same process
+	// are delivered to without going through gRPC.
+	if res, ok, err := c.requestLocal(ctx, nsReceiver, msg); ok {
+		return res, err
+	}
+
+	raw, err := encode(c.cfg.Codec, msg)
 	if err != nil {
 		return nil, err
 	}
 
 	req := &Delivery{
-		Ver:      Delivery_V1,
-		Data:     data,
-		TypeName: typeName,
+		Ver:      protocolVersion,
+		Data:     raw.Data,
+		TypeName: raw.TypeName,
+		Codec:    raw.Codec,
 		Receiver: nsReceiver,
 	}
+	c.stamp(ctx, req)
+	err = sign(c.cfg.Signer, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests of a handle go to its address, rather
+	// than the one cached by the client.
+	h := contextHandle(ctx, nsReceiver)
 
 	var res *Delivery
-	retry.X(3, 1*time.Second, func() bool {
-		var client WireClient
+	c.retry(ctx, func() bool {
+		var client *clientAndConn
 		var clientID int64
-		client, clientID, err = c.getWireClient(ctx, nsReceiver)
+		client, clientID, err = c.wireClientFor(ctx, nsReceiver, h)
 		if err != nil && strings.Contains(err.Error(), ErrUnregisteredMailbox.Error()) {
 			// Test hook.
 			c.cs.Inc(numErrUnregisteredMailbox)
 			// Receiver is currently unregistered, so
 			// clear them out of the cache and don't
 			// try finding them again.
-			c.deleteAddress(nsReceiver)
+			c.forgetAddress(nsReceiver, h)
 			return false
 		}
 		if err != nil {
 			return false
 		}
-		res, err = client.Process(ctx, req)
+		res, err = client.request(ctx, req)
+		if err != nil && strings.Contains(err.Error(), ErrUnauthorized.Error()) {
+			// Trying again will not change the
+			// token, so don't.
+			return false
+		}
+		if err != nil && strings.Contains(err.Error(), errStreamClosed.Error()) {
+			// Test hook.
+			c.cs.Inc(numErrStreamClosed)
+			// The stream to the receiver's host broke,
+			// likely the host died or restarted. Replace
+			// the client, which opens a new stream.
+			c.replaceClientAndConn(nsReceiver, h, clientID)
+			select {
+			case <-ctx.Done():
+				return false
+			default:
+				return true
+			}
+		}
+		if err != nil && strings.Contains(err.Error(), ErrStreamBroken.Error()) {
+			// The stream broke after the request was sent,
+			// so the receiver may have handled it, and it
+			// is not sent again. Later requests are sent
+			// on a new stream.
+			c.replaceClientAndConn(nsReceiver, h, clientID)
+			return false
+		}
 		if err != nil && strings.Contains(err.Error(), "the client connection is closing") {
 			// Test hook.
 			c.cs.Inc(numErrClientConnectionClosing)
 			// The request is via a client that is
 			// closing and gRPC is reporting that
 			// a request is not a valid operation.
-			c.deleteClientAndConn(nsReceiver, clientID)
+			c.replaceClientAndConn(nsReceiver, h, clientID)
 			select {
 			case <-ctx.Done():
 				return false
@@ -207,7 +372,7 @@ func (c *Client) RequestC(ctx context.Context, receiver string, msg interface{})
 			// The error "connection is unavailable"
 			// comes from gRPC itself. In such a case
 			// it's best to try and replace the client.
-			c.deleteClientAndConn(nsReceiver, clientID)
+			c.replaceClientAndConn(nsReceiver, h, clientID)
 			select {
 			case <-ctx.Done():
 				return false
@@ -222,7 +387,7 @@ func (c *Client) RequestC(ctx context.Context, receiver string, msg interface{})
 			// The error "connection refused" comes from
 			// gRPC itself. In such a case it's best to
 			// try and replace the client.
-			c.deleteClientAndConn(nsReceiver, clientID)
+			c.replaceClientAndConn(nsReceiver, h, clientID)
 			select {
 			case <-ctx.Done():
 				return false
@@ -237,7 +402,34 @@ func (c *Client) RequestC(ctx context.Context, receiver string, msg interface{})
 			// host for one reason or another. Get
 			// rid of old address and try discovering
 			// new host, and send again.
-			c.deleteAddress(nsReceiver)
+			c.forgetAddress(nsReceiver, h)
+			select {
+			case <-ctx.Done():
+				return false
+			default:
+				return true
+			}
+		}
+		if err != nil && strings.Contains(err.Error(), ErrThrottled.Error()) {
+			// Test hook.
+			c.cs.Inc(numErrThrottled)
+			// The receiver's server is limiting the
+			// rate of this client's requests, and did
+			// NOT deliver the request, so it is safe
+			// to try again after the retry's backoff.
+			select {
+			case <-ctx.Done():
+				return false
+			default:
+				return true
+			}
+		}
+		if err != nil && strings.Contains(err.Error(), ErrDeliveryPaused.Error()) {
+			// Test hook.
+			c.cs.Inc(numErrDeliveryPaused)
+			// The namespace is in maintenance, and the
+			// receiver did NOT get the message, so it
+			// is safe to try again after the backoff.
 			select {
 			case <-ctx.Done():
 				return false
@@ -264,17 +456,40 @@ func (c *Client) RequestC(ctx context.Context, receiver string, msg interface{})
 	if err != nil {
 		return nil, err
 	}
+	return res, nil
+}
 
-	reply, err := codec.Unmarshal(res.Data, res.TypeName)
+// RequestT (request) a response of type T for the given message,
+// the response is an error if it is of any other type. The types
+// of responses are pointers to their messages.
+//
+// Example usage:
+//
+//     res, err := grid.RequestT[*Result](ctx, client, "worker-1", &Work{})
+//     if err != nil {
+//         ...
+//     }
+//
+func RequestT[T any](ctx context.Context, c *Client, receiver string, msg interface{}) (T, error) {
+	res, err := c.RequestC(ctx, receiver, msg)
+	return responseAs[T](res, err)
+}
+
+// responseAs the type T, or an error.
+func responseAs[T any](res interface{}, err error) (T, error) {
+	var zero T
 	if err != nil {
-		return nil, err
+		return zero, err
 	}
-
-	return reply, nil
+	v, ok := res.(T)
+	if !ok {
+		return zero, ErrUnexpectedResponse
+	}
+	return v, nil
 }
 
 // getWireClient for the address of the receiver.
-func (c *Client) getWireClient(ctx context.Context, nsReceiver string) (WireClient, int64, error) {
+func (c *Client) getWireClient(ctx context.Context, nsReceiver string) (*clientAndConn, int64, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -296,32 +511,54 @@ func (c *Client) getWireClient(ctx context.Context, nsReceiver string) (WireClie
 		c.addresses[nsReceiver] = address
 	}
 
-	ccpool, ok := c.clientsAndConns[address]
-	if !ok {
-		ccpool = &clientAndConnPool{id: rand.Int63(), clientConns: make([]*clientAndConn, c.cfg.ConnectionsPerPeer)}
-		for i := 0; i < c.cfg.ConnectionsPerPeer; i++ {
-			// Test hook.
-			c.cs.Inc(numGRPCDial)
+	return c.nextClientAndConn(address)
+}
 
-			// Dial the destination.
-			conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithBackoffMaxDelay(20*time.Second))
-			if err != nil {
-				return nil, noID, err
-			}
-			client := NewWireClient(conn)
-			cc := &clientAndConn{
-				conn:   conn,
-				client: client,
-			}
-			ccpool.clientConns[i] = cc
-		}
-		c.clientsAndConns[address] = ccpool
+// nextClientAndConn of the pool of the address. The caller must
+// hold the client's lock.
+func (c *Client) nextClientAndConn(address string) (*clientAndConn, int64, error) {
+	const noID = -1
+
+	ccpool, err := c.dialPool(address)
+	if err != nil {
+		return nil, noID, err
 	}
 	cc, err := ccpool.next()
 	if err != nil {
 		return nil, noID, err
 	}
-	return cc.client, ccpool.id, nil
+	return cc, ccpool.id, nil
+}
+
+// dialPool of connections to the address, unless there is one
+// already. The caller must hold the client's lock.
+func (c *Client) dialPool(address string) (*clientAndConnPool, error) {
+	ccpool, ok := c.clientsAndConns[address]
+	if ok {
+		return ccpool, nil
+	}
+	ccpool = &clientAndConnPool{id: rand.Int63(), clientConns: make([]*clientAndConn, c.cfg.ConnectionsPerPeer)}
+	for i := 0; i < c.cfg.ConnectionsPerPeer; i++ {
+		// Test hook.
+		c.cs.Inc(numGRPCDial)
+
+		// Dial the destination.
+		conn, err := grpc.Dial(address, dialOptions(c.cfg)...)
+		if err != nil {
+			return nil, err
+		}
+		client := NewWireClient(conn)
+		cc := &clientAndConn{
+			conn:          conn,
+			client:        client,
+			coalesceDelay: c.cfg.CoalesceDelay,
+			coalesceSize:  c.cfg.CoalesceSize,
+			loads:         c.loads,
+		}
+		ccpool.clientConns[i] = cc
+	}
+	c.clientsAndConns[address] = ccpool
+	return ccpool, nil
 }
 
 func (c *Client) deleteAddress(nsReceiver string) {
@@ -346,7 +583,13 @@ func (c *Client) deleteClientAndConn(nsReceiver string, clientID int64) {
 		return
 	}
 	delete(c.addresses, nsReceiver)
+	c.closeClientAndConn(address, clientID)
+}
 
+// closeClientAndConn of the address, unless it has been replaced
+// since the client of the ID was gotten. The caller must hold the
+// client's lock.
+func (c *Client) closeClientAndConn(address string, clientID int64) {
 	ccpool, ok := c.clientsAndConns[address]
 	if !ok {
 		return
@@ -371,9 +614,10 @@ func (c *Client) logf(format string, v ...interface{}) {
 	}
 }
 
-// Broadcast a message to all members in a Group
+// Broadcast a message to all members in a Group. A timeout of
+// zero means the client's DefaultRequestTimeout.
 func (c *Client) Broadcast(timeout time.Duration, g *Group, msg interface{}) (BroadcastResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), orDefault(timeout, c.cfg.DefaultRequestTimeout))
 	defer cancel()
 	return c.broadcast(ctx, cancel, g, msg)
 }
@@ -386,10 +630,40 @@ func (c *Client) BroadcastC(ctx context.Context, g *Group, msg interface{}) (Bro
 	return c.broadcast(cont, cancel, g, msg)
 }
 
+// BroadcastPrefix a message to all registered mailboxes whose names
+// begin with the prefix, resolved from the registry at the time of
+// the call. A timeout of zero means the client's DefaultRequestTimeout,
+// which bounds both the lookup and the requests.
+//
+// Example usage:
+//
+//     res, err := client.BroadcastPrefix(timeout, "worker-", msg)
+//     for name, r := range res {
+//         ...
+//     }
+//
+func (c *Client) BroadcastPrefix(timeout time.Duration, prefix string, msg interface{}) (BroadcastResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), orDefault(timeout, c.cfg.DefaultRequestTimeout))
+	defer cancel()
+	g, err := c.MailboxGroup(ctx, NamePrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	return c.broadcast(ctx, cancel, g, msg)
+}
+
 func (c *Client) broadcast(ctx context.Context, cancel context.CancelFunc, g *Group, msg interface{}) (BroadcastResult, error) {
 	res := make(BroadcastResult)
 	receivers := g.Members()
 
+	// Encode the message once, rather than once
+	// per receiver.
+	raw, err := encode(c.cfg.Codec, msg)
+	if err != nil {
+		return nil, err
+	}
+	msg = &raw
+
 	var broadcastErr error
 	successes := 0
 	mu := new(sync.Mutex)
@@ -442,6 +716,25 @@ func NewListGroup(members ...string) *Group {
 	}
 }
 
+// MailboxGroup of the registered mailboxes selected by all of the
+// selectors, ordered by name. It returns ErrNoGroupMember if no
+// mailbox is selected.
+func (c *Client) MailboxGroup(ctx context.Context, selectors ...Selector) (*Group, error) {
+	events, err := c.QueryC(ctx, Mailboxes, selectors...)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrNoGroupMember
+	}
+	members := make([]string, 0, len(events))
+	for _, e := range events {
+		members = append(members, e.Name())
+	}
+	sort.Strings(members)
+	return NewListGroup(members...), nil
+}
+
 // Members returns the members (actors) of the Group
 func (g *Group) Members() []string {
 	return g.members
@@ -500,6 +793,11 @@ const (
 	numErrUnregisteredMailbox     statName = "numErrUnregisteredMailbox"
 	numErrUnknownMailbox          statName = "numErrUnknownMailbox"
 	numErrReceiverBusy            statName = "numErrReceiverBusy"
+	numErrStreamClosed            statName = "numErrStreamClosed"
+	numErrThrottled               statName = "numErrThrottled"
+	numErrDeliveryPaused          statName = "numErrDeliveryPaused"
+	numRetryBudgetSpent           statName = "numRetryBudgetSpent"
+	numRetryDeadline              statName = "numRetryDeadline"
 	numDeleteAddress              statName = "numDeleteAddress"
 	numDeleteClientAndConn        statName = "numDeleteClientAndConn"
 	numGetWireClient              statName = "numGetWireClient"
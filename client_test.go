@@ -558,3 +558,26 @@ func bootstrapClientTest(t *testing.T) (*clientv3.Client, *Server, *Client) {
 
 	return etcd, server, client
 }
+
+func TestResponseAs(t *testing.T) {
+	res, err := responseAs[*EchoMsg](&EchoMsg{Msg: "hi"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Msg != "hi" {
+		t.Fatalf("expected response, got: %v", res)
+	}
+
+	res, err = responseAs[*EchoMsg](&Ack{}, nil)
+	if err != ErrUnexpectedResponse {
+		t.Fatalf("expected unexpected response, got: %v", err)
+	}
+	if res != nil {
+		t.Fatal("expected zero value")
+	}
+
+	_, err = responseAs[*EchoMsg](nil, ErrReceiverBusy)
+	if err != ErrReceiverBusy {
+		t.Fatalf("expected request error, got: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+// Command grid helps start new grid applications.
+//
+// Usage:
+//
+//     grid init [-module path] [-namespace name] dir
+//     grid stub -actor type [-package name] [-output file] Request:Response...
+//
+// The init command writes a runnable project skeleton into dir,
+// with a leader, a worker, message types, configuration flags,
+// and graceful shutdown.
+//
+// The stub command writes a typed client, and a typed handler, of
+// the protocol of an actor type, given as pairs of request and
+// response messages. It is meant for go:generate:
+//
+//     //go:generate grid stub -actor worker Event:EventResponse
+//
+// which writes worker_stub.go with a WorkerClient, whose method
+// DoEvent sends an Event and returns the EventResponse, and the
+// WorkerHandler interface, whose requests ServeWorker dispatches
+// from a mailbox, without type switches in caller or actor.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const usage = `usage: grid init [-module path] [-namespace name] dir
+       grid stub -actor type [-package name] [-output file] Request:Response...
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "init":
+		initMain()
+	case "stub":
+		stubMain()
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func initMain() {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	module := flags.String("module", "", "module path of the project, default is the dir's name")
+	namespace := flags.String("namespace", "", "grid namespace of the project, default is the dir's name")
+	flags.Parse(os.Args[2:])
+	if flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "usage: grid init [-module path] [-namespace name] dir\n")
+		os.Exit(2)
+	}
+
+	dir := flags.Arg(0)
+	p := params{
+		Name:      filepath.Base(dir),
+		Module:    *module,
+		Namespace: *namespace,
+	}
+	if p.Module == "" {
+		p.Module = p.Name
+	}
+	if p.Namespace == "" {
+		p.Namespace = p.Name
+	}
+
+	err := scaffold(dir, p)
+	successOrDie(err)
+	fmt.Printf("created %v, run it with: cd %v && go run . -address localhost:7777\n", dir, dir)
+}
+
+func stubMain() {
+	flags := flag.NewFlagSet("stub", flag.ExitOnError)
+	actor := flags.String("actor", "", "actor type of the protocol")
+	pkg := flags.String("package", os.Getenv("GOPACKAGE"), "package of the stub, default is the package of go:generate")
+	output := flags.String("output", "", "file of the stub, default is <actor>_stub.go")
+	flags.Parse(os.Args[2:])
+	if *actor == "" || *pkg == "" || flags.NArg() == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = strings.ReplaceAll(*actor, "-", "_") + "_stub.go"
+	}
+
+	pairs, err := parsePairs(flags.Args())
+	successOrDie(err)
+	err = writeStub(*output, stubParams{
+		Package: *pkg,
+		Actor:   *actor,
+		Type:    exportedName(*actor),
+		Pairs:   pairs,
+	})
+	successOrDie(err)
+}
+
+func successOrDie(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/lytics/grid"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// errExists when a file of the skeleton already exists,
+// since the generator never overwrites files.
+var errExists = errors.New("grid: file exists")
+
+// params of the project skeleton.
+type params struct {
+	Name      string
+	Module    string
+	Namespace string
+}
+
+// scaffold the project skeleton into the dir, which is created
+// if it does not exist. Existing files are never overwritten.
+func scaffold(dir string, p params) error {
+	if err := grid.ValidateName(p.Namespace); err != nil {
+		return fmt.Errorf("namespace: %w", err)
+	}
+	tmpls, err := template.ParseFS(templates, "templates/*.tmpl")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for _, t := range tmpls.Templates() {
+		path := filepath.Join(dir, strings.TrimSuffix(t.Name(), ".tmpl"))
+		err := write(path, t, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write the executed template to the path.
+func write(path string, t *template.Template, p params) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return fmt.Errorf("%w: %v", errExists, path)
+	}
+	if err != nil {
+		return err
+	}
+	err = t.Execute(f, p)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
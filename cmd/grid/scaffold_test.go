@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScaffold(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "myapp")
+	p := params{Name: "myapp", Module: "example.com/myapp", Namespace: "myapp"}
+
+	err := scaffold(dir, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"main.go", "leader.go", "worker.go", "msgs.go", "msgs.proto", "README.md"} {
+		buf, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		// Generated code is formatted, which also
+		// checks that it parses.
+		formatted, err := format.Source(buf)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if !bytes.Equal(buf, formatted) {
+			t.Fatalf("%v: expected formatted source", name)
+		}
+	}
+
+	// Existing files are never overwritten.
+	err = scaffold(dir, p)
+	if !errors.Is(err, errExists) {
+		t.Fatalf("expected file exists, got: %v", err)
+	}
+}
+
+func TestScaffoldInvalidNamespace(t *testing.T) {
+	err := scaffold(t.TempDir(), params{Name: "my.app", Module: "my.app", Namespace: "my.app"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"strings"
+	"text/template"
+)
+
+//go:embed stubs/stub.go.tmpl
+var stubTemplate string
+
+// errInvalidPair when a message pair is not of the
+// form Request:Response, with Go identifiers.
+var errInvalidPair = errors.New("grid: invalid message pair")
+
+// stubParams of the stub of an actor's protocol.
+type stubParams struct {
+	Package string
+	Actor   string
+	Type    string
+	Pairs   []messagePair
+}
+
+// messagePair of a request and its response.
+type messagePair struct {
+	Request  string
+	Response string
+}
+
+// parsePairs of the form Request:Response.
+func parsePairs(args []string) ([]messagePair, error) {
+	var pairs []messagePair
+	for _, arg := range args {
+		parts := strings.Split(arg, ":")
+		if len(parts) != 2 || !token.IsIdentifier(parts[0]) || !token.IsIdentifier(parts[1]) {
+			return nil, fmt.Errorf("%w: %v", errInvalidPair, arg)
+		}
+		pairs = append(pairs, messagePair{Request: parts[0], Response: parts[1]})
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("%w: none given", errInvalidPair)
+	}
+	return pairs, nil
+}
+
+// exportedName of the actor type, ie: "word-count" is "WordCount".
+func exportedName(actor string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(actor, func(r rune) bool { return r == '-' || r == '_' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// stub of the actor's protocol, formatted.
+func stub(p stubParams) ([]byte, error) {
+	t, err := template.New("stub").Parse(stubTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, p)
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// writeStub of the actor's protocol to the path.
+func writeStub(path string, p stubParams) error {
+	if !token.IsIdentifier(p.Type) {
+		return fmt.Errorf("grid: invalid actor type: %v", p.Actor)
+	}
+	src, err := stub(p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, src, 0644)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStub(t *testing.T) {
+	pairs, err := parsePairs([]string{"Event:EventResponse", "Flush:FlushResponse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := stub(stubParams{
+		Package: "myapp",
+		Actor:   "word-count",
+		Type:    exportedName("word-count"),
+		Pairs:   pairs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"type WordCountClient struct",
+		"func (c *WordCountClient) DoEvent(ctx context.Context, msg *Event) (*EventResponse, error)",
+		"HandleFlush(ctx context.Context, msg *Flush) (*FlushResponse, error)",
+		"func ServeWordCount(ctx context.Context, mailbox *grid.Mailbox, h WordCountHandler) error",
+	} {
+		if !strings.Contains(string(src), expected) {
+			t.Fatalf("expected stub to contain: %v, got:\n%s", expected, src)
+		}
+	}
+}
+
+func TestParsePairsInvalid(t *testing.T) {
+	for _, args := range [][]string{nil, {"Event"}, {"Event:"}, {"my-event:Response"}} {
+		_, err := parsePairs(args)
+		if !errors.Is(err, errInvalidPair) {
+			t.Fatalf("args: %v, expected invalid pair, got: %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package grid
+
+import (
+	"sync"
+	"time"
+)
+
+// coalescer packs small deliveries, sent on a stream within a
+// short delay of each other, into one frame. It trades a little
+// latency for far fewer frames when many small messages are sent,
+// as is typical of telemetry, in the style of Nagle's algorithm.
+type coalescer struct {
+	mu    sync.Mutex
+	ms    *muxStream
+	delay time.Duration
+	size  int
+	batch []*Delivery
+	bytes int
+	timer *time.Timer
+}
+
+func newCoalescer(ms *muxStream, delay time.Duration, size int) *coalescer {
+	return &coalescer{
+		ms:    ms,
+		delay: delay,
+		size:  size,
+	}
+}
+
+// add the delivery to the batch, which is sent once it is full,
+// or once the delay since the first delivery of the batch has
+// passed. Deliveries that are not small are sent immediately,
+// after the current batch, to keep the order of sending.
+func (co *coalescer) add(d *Delivery) error {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+
+	size := deliverySize(d)
+	if size >= co.size {
+		err := co.flushLocked()
+		if err != nil {
+			return err
+		}
+		err = co.ms.write(d)
+		if err != nil {
+			co.ms.fail(err)
+			return co.ms.failure()
+		}
+		return nil
+	}
+
+	co.batch = append(co.batch, d)
+	co.bytes += size
+	if co.bytes >= co.size {
+		return co.flushLocked()
+	}
+	if co.timer == nil {
+		co.timer = time.AfterFunc(co.delay, co.flush)
+	}
+	return nil
+}
+
+// flush the batch when its delay has passed.
+func (co *coalescer) flush() {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+	co.flushLocked()
+}
+
+func (co *coalescer) flushLocked() error {
+	if co.timer != nil {
+		co.timer.Stop()
+		co.timer = nil
+	}
+	if len(co.batch) == 0 {
+		return nil
+	}
+
+	var err error
+	if len(co.batch) == 1 {
+		err = co.ms.write(co.batch[0])
+	} else {
+		err = co.ms.write(&Delivery{
+			Ver:   protocolVersion,
+			Batch: co.batch,
+		})
+	}
+	// The sent batch may still be read by gRPC, so
+	// the next batch does not reuse its slice.
+	co.batch = nil
+	co.bytes = 0
+
+	// The waiters of the batch have no other way
+	// to learn that it was not sent.
+	if err != nil {
+		co.ms.fail(err)
+		return co.ms.failure()
+	}
+	return nil
+}
+
+// deliverySize is the approximate encoded size of the delivery.
+func deliverySize(d *Delivery) int {
+	return len(d.Data) + len(d.TypeName) + len(d.Receiver) + 24
+}
@@ -0,0 +1,104 @@
+package codec
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Codec of messages. The name of the codec that encoded a message
+// is sent with it, so that the receiver decodes it alike, whichever
+// codec the receiver itself sends with. Codecs other than Protobuf
+// and JSON must be registered, with RegisterCodec, by every process
+// receiving messages they encode.
+type Codec interface {
+	// Name of the codec, unique among registered codecs.
+	Name() string
+	// Marshal the value.
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal the data into the value, a pointer.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// Protobuf codec, the default. It encodes protobuf
+	// messages only.
+	Protobuf Codec = protobufCodec{}
+	// JSON codec, for actors written in other languages,
+	// and for messages that are plain structs.
+	JSON Codec = jsonCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		Protobuf.Name(): Protobuf,
+		JSON.Name():     JSON,
+	}
+)
+
+// RegisterCodec so that messages it encodes can be received.
+func RegisterCodec(c Codec) error {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, ok := codecs[c.Name()]; ok || c.Name() == "" {
+		return ErrCodecRegistered
+	}
+	codecs[c.Name()] = c
+	return nil
+}
+
+// FindCodec by name. The empty name is the Protobuf codec,
+// which is how senders without codecs encode.
+func FindCodec(name string) (Codec, error) {
+	if name == "" {
+		return Protobuf, nil
+	}
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[name]
+	if !ok {
+		return nil, ErrUnknownCodec
+	}
+	return c, nil
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return "protobuf" }
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	pb, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrUnsupportedMessage
+	}
+	// Size the buffer exactly, so that marshalling
+	// allocates once instead of growing the buffer.
+	buf := proto.NewBuffer(make([]byte, 0, proto.Size(pb)))
+	err := buf.Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	pb, ok := v.(proto.Message)
+	if !ok {
+		return ErrUnsupportedMessage
+	}
+	return proto.Unmarshal(data, pb)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
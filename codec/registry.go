@@ -15,15 +15,26 @@ var (
 	// ErrUnregisteredMessageType when a unregistered type is called
 	// for marshalling or unmarshalling.
 	ErrUnregisteredMessageType = errors.New("codec: unregistered message type")
+	// ErrUnknownCodec when a message is received that was encoded
+	// by a codec that is not registered.
+	ErrUnknownCodec = errors.New("codec: unknown codec")
+	// ErrCodecRegistered when a codec of the same name is
+	// already registered.
+	ErrCodecRegistered = errors.New("codec: codec already registered")
 )
 
 var (
 	mu       = &sync.RWMutex{}
 	registry = map[string]interface{}{}
+	// names caches the type name of each reflected
+	// type, since building the name allocates.
+	names = &sync.Map{}
 )
 
-// Register a type for marshalling and unmarshalling.
-// The type must currently implement proto.Message.
+// Register a type for marshalling and unmarshalling. The
+// type must implement proto.Message, or be a struct, whose
+// messages are then only encoded by codecs other than
+// Protobuf, such as JSON.
 func Register(v interface{}) error {
 	mu.Lock()
 	defer mu.Unlock()
@@ -32,10 +43,11 @@ func Register(v interface{}) error {
 	// as a pointer type, but to check if
 	// it is a proto message, the pointer
 	// type must be checked.
-	pv := reflect.New(reflect.TypeOf(v)).Interface()
+	rt := reflect.TypeOf(v)
+	pv := reflect.New(rt).Interface()
 
 	_, ok := pv.(proto.Message)
-	if !ok {
+	if !ok && rt.Kind() != reflect.Struct {
 		return ErrUnsupportedMessage
 	}
 
@@ -47,6 +59,11 @@ func Register(v interface{}) error {
 // Marshal the value into bytes. The function returns
 // the type name, the bytes, or an error.
 func Marshal(v interface{}) (string, []byte, error) {
+	return MarshalWith(Protobuf, v)
+}
+
+// MarshalWith the codec, see Marshal.
+func MarshalWith(c Codec, v interface{}) (string, []byte, error) {
 	mu.RLock()
 	defer mu.RUnlock()
 
@@ -55,7 +72,7 @@ func Marshal(v interface{}) (string, []byte, error) {
 	if !ok {
 		return "", nil, ErrUnregisteredMessageType
 	}
-	buf, err := protoMarshal(v)
+	buf, err := c.Marshal(v)
 	if err != nil {
 		return "", nil, err
 	}
@@ -65,25 +82,52 @@ func Marshal(v interface{}) (string, []byte, error) {
 // Unmarshal the bytes into a value whos type is given,
 // or return an error.
 func Unmarshal(buf []byte, name string) (interface{}, error) {
+	return UnmarshalWith(Protobuf, buf, name)
+}
+
+// UnmarshalWith the codec, see Unmarshal.
+func UnmarshalWith(c Codec, buf []byte, name string) (interface{}, error) {
 	mu.RLock()
 	defer mu.RUnlock()
 
-	c, ok := registry[name]
+	t, ok := registry[name]
 	if !ok {
 		return nil, ErrUnregisteredMessageType
 	}
-	v := reflect.New(reflect.TypeOf(c)).Interface()
-	err := protoUnmarshal(buf, v)
+	v := reflect.New(reflect.TypeOf(t)).Interface()
+	err := c.Unmarshal(buf, v)
 	if err != nil {
 		return nil, err
 	}
 	return v, nil
 }
 
+// UnmarshalInto the value, which must be a pointer to a
+// registered type, or return an error.
+func UnmarshalInto(buf []byte, v interface{}) error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	_, ok := registry[TypeName(v)]
+	if !ok {
+		return ErrUnregisteredMessageType
+	}
+	return Protobuf.Unmarshal(buf, v)
+}
+
 // TypeName of a value. This name is used in the registry
 // to distinguish types.
 func TypeName(v interface{}) string {
 	rt := reflect.TypeOf(v)
+	if name, ok := names.Load(rt); ok {
+		return name.(string)
+	}
+	name := typeName(rt)
+	names.Store(rt, name)
+	return name
+}
+
+func typeName(rt reflect.Type) string {
 	pkg := rt.PkgPath()
 	name := rt.Name()
 	if name == "" {
@@ -93,13 +137,3 @@ func TypeName(v interface{}) string {
 	}
 	return pkg + "/" + name
 }
-
-func protoMarshal(v interface{}) ([]byte, error) {
-	pb := v.(proto.Message)
-	return proto.Marshal(pb)
-}
-
-func protoUnmarshal(buf []byte, v interface{}) error {
-	pb := v.(proto.Message)
-	return proto.Unmarshal(buf, pb)
-}
@@ -63,6 +63,57 @@ func TestNonProtobuf(t *testing.T) {
 	}
 }
 
+func TestMarshalWithJSON(t *testing.T) {
+	type plain struct {
+		Name string
+	}
+	err := Register(plain{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Plain structs are only encoded by codecs other
+	// than protobuf.
+	_, _, err = Marshal(&plain{Name: "James Tester"})
+	if err != ErrUnsupportedMessage {
+		t.Fatalf("expected unsupported message, got: %v", err)
+	}
+
+	typeName, data, err := MarshalWith(JSON, &plain{Name: "James Tester"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"Name":"James Tester"}` {
+		t.Fatalf("unexpected encoding: %s", data)
+	}
+	res, err := UnmarshalWith(JSON, data, typeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.(*plain).Name != "James Tester" {
+		t.Fatal("expected same name")
+	}
+}
+
+func TestFindCodec(t *testing.T) {
+	c, err := FindCodec("")
+	if err != nil || c != Protobuf {
+		t.Fatalf("expected protobuf for empty name, got: %v, %v", c, err)
+	}
+	c, err = FindCodec("json")
+	if err != nil || c != JSON {
+		t.Fatalf("expected json, got: %v, %v", c, err)
+	}
+	_, err = FindCodec("msgpack")
+	if err != ErrUnknownCodec {
+		t.Fatalf("expected unknown codec, got: %v", err)
+	}
+	err = RegisterCodec(JSON)
+	if err != ErrCodecRegistered {
+		t.Fatalf("expected codec registered, got: %v", err)
+	}
+}
+
 // BenchmarkMarshal checks how fast it is to look up
 // a type in the registry and marshal.
 //
@@ -79,6 +130,7 @@ func BenchmarkMarshal(b *testing.B) {
 		Name: "James Tester",
 	}
 
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		_, data, err := Marshal(msg)
 		if err != nil {
@@ -110,6 +162,7 @@ func BenchmarkUnmarshal(b *testing.B) {
 		b.Fatal(err)
 	}
 
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		res, err := Unmarshal(data, typeName)
 		if err != nil {
@@ -0,0 +1,176 @@
+package grid
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+const (
+	// configs is the key space of namespace configuration.
+	configs EntityType = "config"
+	// configName of the namespace's configuration document.
+	configName = "document"
+)
+
+// Config of a namespace, a JSON document kept in etcd that every
+// peer watches, so that applications need not build their own
+// watcher to distribute configuration to their actors.
+type Config struct {
+	mu      sync.Mutex
+	doc     []byte
+	rev     int64
+	changed chan struct{}
+}
+
+func newConfig() *Config {
+	return &Config{changed: make(chan struct{})}
+}
+
+// Bytes of the document, nil if none has been put.
+func (cfg *Config) Bytes() []byte {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.doc
+}
+
+// Decode the document into v. If no document has been
+// put then v is left unchanged.
+func (cfg *Config) Decode(v interface{}) error {
+	doc := cfg.Bytes()
+	if doc == nil {
+		return nil
+	}
+	return json.Unmarshal(doc, v)
+}
+
+// Changed returns a channel that is closed the next time the
+// document changes, after which Changed must be called again
+// to be notified of the change after that.
+func (cfg *Config) Changed() <-chan struct{} {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return cfg.changed
+}
+
+// set the document as of the etcd revision, notifying of the
+// change, unless the config is already of a later revision.
+func (cfg *Config) set(doc []byte, rev int64) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if rev <= cfg.rev {
+		return
+	}
+	cfg.doc = doc
+	cfg.rev = rev
+	close(cfg.changed)
+	cfg.changed = make(chan struct{})
+}
+
+// ContextConfig of the actor's namespace.
+//
+// Example usage:
+//
+//     config, err := grid.ContextConfig(ctx)
+//     ...
+//     var settings Settings
+//     err = config.Decode(&settings)
+//     ...
+//     for {
+//         select {
+//         case <-config.Changed():
+//             err := config.Decode(&settings)
+//             ...
+//         case req := <-mailbox.C:
+//             ...
+//         }
+//     }
+//
+func ContextConfig(c context.Context) (*Config, error) {
+	server, err := ContextServer(c)
+	if err != nil {
+		return nil, err
+	}
+	return server.config, nil
+}
+
+// PutConfig document of the namespace, v is encoded as JSON.
+// Peers see the new document shortly after it is put.
+func (c *Client) PutConfig(ctx context.Context, v interface{}) error {
+	key, err := namespaceName(configs, c.cfg.Namespace, configName)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Put(ctx, key, string(buf))
+	return err
+}
+
+// watchConfig of the namespace.
+func (s *Server) watchConfig() {
+	s.watchDocument(configs, configName, s.config.set)
+}
+
+// watchDocument of the namespace, passing each version of it
+// to set, with its etcd revision, and nil once it is deleted.
+// The document is watched again after any failure, until the
+// server stops.
+func (s *Server) watchDocument(entity EntityType, name string, set func(doc []byte, rev int64)) {
+	key, err := namespaceName(entity, s.cfg.Namespace, name)
+	if err != nil {
+		s.logf("%v: invalid %v key: %v", s.cfg.Namespace, entity, err)
+		return
+	}
+	go func() {
+		for {
+			err := s.followDocument(key, set)
+			select {
+			case <-s.ctx.Done():
+				return
+			default:
+			}
+			s.logf("%v: failed watching %v: %v", s.cfg.Namespace, entity, err)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(1 * time.Second):
+			}
+		}
+	}()
+}
+
+// followDocument by reading it and then watching its
+// changes from the revision read.
+func (s *Server) followDocument(key string, set func(doc []byte, rev int64)) error {
+	timeout, cancel := context.WithTimeout(s.ctx, s.cfg.Timeout)
+	res, err := s.etcd.Get(timeout, key, etcdv3.WithLimit(1))
+	cancel()
+	if err != nil {
+		return err
+	}
+	if res.Count > 0 {
+		set(res.Kvs[0].Value, res.Kvs[0].ModRevision)
+	}
+
+	deltas := s.etcd.Watch(s.ctx, key, etcdv3.WithRev(res.Header.Revision+1))
+	for delta := range deltas {
+		if err := delta.Err(); err != nil {
+			return err
+		}
+		for _, ev := range delta.Events {
+			if ev.Type == mvccpb.DELETE {
+				set(nil, ev.Kv.ModRevision)
+			} else {
+				set(ev.Kv.Value, ev.Kv.ModRevision)
+			}
+		}
+	}
+	return ErrConfigWatchClosed
+}
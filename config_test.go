@@ -0,0 +1,64 @@
+package grid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	cfg := newConfig()
+
+	settings := struct {
+		Level string `json:"level"`
+	}{Level: "default"}
+	err := cfg.Decode(&settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Level != "default" {
+		t.Fatalf("expected settings to be unchanged, got: %v", settings.Level)
+	}
+
+	changed := cfg.Changed()
+	cfg.set([]byte(`{"level":"debug"}`), 2)
+	select {
+	case <-changed:
+	default:
+		t.Fatal("expected change notification")
+	}
+	err = cfg.Decode(&settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.Level != "debug" {
+		t.Fatalf("expected level: debug, got: %v", settings.Level)
+	}
+
+	// Older revisions are ignored.
+	changed = cfg.Changed()
+	cfg.set([]byte(`{"level":"info"}`), 1)
+	select {
+	case <-changed:
+		t.Fatal("expected no change notification")
+	default:
+	}
+	if string(cfg.Bytes()) != `{"level":"debug"}` {
+		t.Fatalf("unexpected document: %s", cfg.Bytes())
+	}
+}
+
+func TestContextConfig(t *testing.T) {
+	s := &Server{config: newConfig()}
+	ctx := context.WithValue(context.Background(), contextKey, &contextVal{server: s})
+	cfg, err := ContextConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg != s.config {
+		t.Fatal("expected the server's config")
+	}
+	_, err = ContextConfig(context.Background())
+	if err != ErrInvalidContext {
+		t.Fatalf("expected error: %v, got: %v", ErrInvalidContext, err)
+	}
+}
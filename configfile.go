@@ -0,0 +1,217 @@
+package grid
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig of a deployment, loaded from a YAML or JSON file by
+// LoadConfig, so that servers and clients are configured alike,
+// without flags for each setting in every main.go. Durations are
+// written as strings, such as "10s". Fields that are left out keep
+// their defaults, and unknown fields are an error.
+//
+// Example file:
+//
+//     namespace: billing
+//     etcd:
+//       endpoints: ["etcd-0:2379", "etcd-1:2379"]
+//     tls:
+//       cert: /etc/grid/tls.crt
+//       key: /etc/grid/tls.key
+//       ca: /etc/grid/ca.crt
+//     server:
+//       rateLimit: 100
+//       drainTimeout: 30s
+//     client:
+//       requestTimeout: 5s
+//
+type FileConfig struct {
+	// Namespace of grid, required.
+	Namespace string `yaml:"namespace"`
+	// Etcd endpoints, and timeout of dialing them.
+	Etcd struct {
+		Endpoints   []string      `yaml:"endpoints"`
+		DialTimeout time.Duration `yaml:"dialTimeout"`
+	} `yaml:"etcd"`
+	// TLS certificate and key files, reloaded when they change,
+	// see CertReloader, and the file of the CAs of other peers
+	// and clients, whose certificates are then required.
+	TLS struct {
+		Cert string `yaml:"cert"`
+		Key  string `yaml:"key"`
+		CA   string `yaml:"ca"`
+	} `yaml:"tls"`
+	// Timeout and message sizes of both servers and clients,
+	// see ServerCfg and ClientCfg.
+	Timeout        time.Duration `yaml:"timeout"`
+	MaxRecvMsgSize int           `yaml:"maxRecvMsgSize"`
+	MaxSendMsgSize int           `yaml:"maxSendMsgSize"`
+	// Server settings, see ServerCfg.
+	Server struct {
+		DisallowLeadership    bool          `yaml:"disallowLeadership"`
+		MaxClockSkew          time.Duration `yaml:"maxClockSkew"`
+		LeaseDuration         time.Duration `yaml:"leaseDuration"`
+		ReconcileInterval     time.Duration `yaml:"reconcileInterval"`
+		DrainTimeout          time.Duration `yaml:"drainTimeout"`
+		LameDuckDelay         time.Duration `yaml:"lameDuckDelay"`
+		CPUWorkers            int           `yaml:"cpuWorkers"`
+		RateLimit             float64       `yaml:"rateLimit"`
+		RateBurst             int           `yaml:"rateBurst"`
+		DeliverySlots         int           `yaml:"deliverySlots"`
+		KeepaliveMinTime      time.Duration `yaml:"keepaliveMinTime"`
+		MaxConnectionAge      time.Duration `yaml:"maxConnectionAge"`
+		MaxConnectionAgeGrace time.Duration `yaml:"maxConnectionAgeGrace"`
+		LogCollector          string        `yaml:"logCollector"`
+	} `yaml:"server"`
+	// Client settings, see ClientCfg.
+	Client struct {
+		PeersRefreshInterval time.Duration `yaml:"peersRefreshInterval"`
+		RequestTimeout       time.Duration `yaml:"requestTimeout"`
+		QueryTimeout         time.Duration `yaml:"queryTimeout"`
+		ConnectionsPerPeer   int           `yaml:"connectionsPerPeer"`
+		RetryBudget          float64       `yaml:"retryBudget"`
+		SaturatedDepth       int           `yaml:"saturatedDepth"`
+		WarmConnections      int           `yaml:"warmConnections"`
+		Tenant               string        `yaml:"tenant"`
+	} `yaml:"client"`
+
+	tls *tls.Config
+}
+
+// LoadConfig of a deployment from the YAML or JSON file, whose TLS
+// files, if any, are loaded immediately, so that errors are found
+// early. Settings that are code, such as a Logger, Policy, or
+// MetricsBackend, are given as options after the file's config.
+//
+// Example usage:
+//
+//     fc, err := grid.LoadConfig("/etc/grid/grid.yaml")
+//     ...
+//     etcd, err := etcdv3.New(fc.EtcdCfg())
+//     ...
+//     server, err := grid.NewServer(etcd, fc.ServerCfg(), grid.WithLogger(logger))
+//
+func LoadConfig(path string) (*FileConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fc := &FileConfig{}
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	err = dec.Decode(fc)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%w: %v: %v", ErrInvalidConfigFile, path, err)
+	}
+	if !isNameValid(fc.Namespace) {
+		return nil, ErrInvalidNamespace
+	}
+	fc.tls, err = fc.loadTLS()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v: %v", ErrInvalidConfigFile, path, err)
+	}
+	return fc, nil
+}
+
+// loadTLS config of the TLS files, nil if there are none. The
+// same config is used to serve and to dial, as the server's own
+// client uses the server's config.
+func (fc *FileConfig) loadTLS() (*tls.Config, error) {
+	files := fc.TLS
+	if files.Cert == "" && files.Key == "" && files.CA == "" {
+		return nil, nil
+	}
+	if files.Cert == "" || files.Key == "" {
+		return nil, fmt.Errorf("tls needs both a cert and a key")
+	}
+	reloader, err := NewCertReloader(files.Cert, files.Key)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		GetCertificate:       reloader.GetCertificate,
+		GetClientCertificate: reloader.GetClientCertificate,
+	}
+	if files.CA != "" {
+		pem, err := os.ReadFile(files.CA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in ca: %v", files.CA)
+		}
+		config.RootCAs = pool
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// EtcdCfg of the file, for etcdv3.New.
+func (fc *FileConfig) EtcdCfg() etcdv3.Config {
+	return etcdv3.Config{
+		Endpoints:   fc.Etcd.Endpoints,
+		DialTimeout: fc.Etcd.DialTimeout,
+	}
+}
+
+// ServerCfg of the file. It sets all fields of the config when
+// given as an option to NewServer, so it must come before other
+// options.
+func (fc *FileConfig) ServerCfg() ServerCfg {
+	s := fc.Server
+	return ServerCfg{
+		Namespace:             fc.Namespace,
+		DisalowLeadership:     s.DisallowLeadership,
+		MaxClockSkew:          s.MaxClockSkew,
+		Timeout:               fc.Timeout,
+		LeaseDuration:         s.LeaseDuration,
+		ReconcileInterval:     s.ReconcileInterval,
+		DrainTimeout:          s.DrainTimeout,
+		LameDuckDelay:         s.LameDuckDelay,
+		CPUWorkers:            s.CPUWorkers,
+		MaxRecvMsgSize:        fc.MaxRecvMsgSize,
+		MaxSendMsgSize:        fc.MaxSendMsgSize,
+		KeepaliveMinTime:      s.KeepaliveMinTime,
+		MaxConnectionAge:      s.MaxConnectionAge,
+		MaxConnectionAgeGrace: s.MaxConnectionAgeGrace,
+		RateLimit:             s.RateLimit,
+		RateBurst:             s.RateBurst,
+		DeliverySlots:         s.DeliverySlots,
+		TLS:                   fc.tls,
+		LogCollector:          s.LogCollector,
+	}
+}
+
+// ClientCfg of the file. It sets all fields of the config when
+// given as an option to NewClient, so it must come before other
+// options.
+func (fc *FileConfig) ClientCfg() ClientCfg {
+	c := fc.Client
+	return ClientCfg{
+		Namespace:             fc.Namespace,
+		Timeout:               fc.Timeout,
+		PeersRefreshInterval:  c.PeersRefreshInterval,
+		DefaultRequestTimeout: c.RequestTimeout,
+		DefaultQueryTimeout:   c.QueryTimeout,
+		ConnectionsPerPeer:    c.ConnectionsPerPeer,
+		RetryBudget:           c.RetryBudget,
+		WarmConnections:       c.WarmConnections,
+		Tenant:                c.Tenant,
+		SaturatedDepth:        c.SaturatedDepth,
+		MaxRecvMsgSize:        fc.MaxRecvMsgSize,
+		MaxSendMsgSize:        fc.MaxSendMsgSize,
+		TLS:                   fc.tls,
+	}
+}
@@ -0,0 +1,104 @@
+package grid
+
+import (
+	"crypto/tls"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	yamlFile := writeConfigFile(t, "grid.yaml", `
+namespace: billing
+etcd:
+  endpoints: ["etcd-0:2379", "etcd-1:2379"]
+  dialTimeout: 5s
+timeout: 3s
+server:
+  rateLimit: 100
+  drainTimeout: 30s
+client:
+  requestTimeout: 2s
+  tenant: acme
+`)
+	jsonFile := writeConfigFile(t, "grid.json", `{
+    "namespace": "billing",
+    "etcd": {"endpoints": ["etcd-0:2379", "etcd-1:2379"], "dialTimeout": "5s"},
+    "timeout": "3s",
+    "server": {"rateLimit": 100, "drainTimeout": "30s"},
+    "client": {"requestTimeout": "2s", "tenant": "acme"}
+}`)
+
+	for _, path := range []string{yamlFile, jsonFile} {
+		fc, err := LoadConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		etcd := fc.EtcdCfg()
+		if len(etcd.Endpoints) != 2 || etcd.DialTimeout != 5*time.Second {
+			t.Fatalf("%v: unexpected etcd config: %+v", path, etcd)
+		}
+		scfg := newServerCfg([]ServerOption{fc.ServerCfg(), WithLeaseDuration(time.Minute)})
+		if scfg.Namespace != "billing" || scfg.Timeout != 3*time.Second ||
+			scfg.RateLimit != 100 || scfg.DrainTimeout != 30*time.Second ||
+			scfg.LeaseDuration != time.Minute || scfg.TLS != nil {
+			t.Fatalf("%v: unexpected server config: %+v", path, scfg)
+		}
+		ccfg := fc.ClientCfg()
+		if ccfg.Namespace != "billing" || ccfg.DefaultRequestTimeout != 2*time.Second || ccfg.Tenant != "acme" {
+			t.Fatalf("%v: unexpected client config: %+v", path, ccfg)
+		}
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	_, err := LoadConfig(writeConfigFile(t, "grid.yaml", "namespace: billing\nnamespce: typo\n"))
+	if !errors.Is(err, ErrInvalidConfigFile) {
+		t.Fatalf("expected invalid config file for unknown field, got: %v", err)
+	}
+	_, err = LoadConfig(writeConfigFile(t, "grid.yaml", ""))
+	if err != ErrInvalidNamespace {
+		t.Fatalf("expected invalid namespace, got: %v", err)
+	}
+	_, err = LoadConfig(writeConfigFile(t, "grid.yaml", "namespace: billing\ntls:\n  cert: /missing.crt\n"))
+	if !errors.Is(err, ErrInvalidConfigFile) {
+		t.Fatalf("expected invalid config file for cert without key, got: %v", err)
+	}
+}
+
+func TestLoadConfigTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, "peer")
+	fc, err := LoadConfig(writeConfigFile(t, "grid.yaml", `
+namespace: billing
+tls:
+  cert: `+certFile+`
+  key: `+keyFile+`
+  ca: `+certFile+`
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scfg, ccfg := fc.ServerCfg(), fc.ClientCfg()
+	if scfg.TLS == nil || scfg.TLS != ccfg.TLS {
+		t.Fatal("expected the same tls config for server and client")
+	}
+	if scfg.TLS.ClientAuth != tls.RequireAndVerifyClientCert || scfg.TLS.RootCAs == nil {
+		t.Fatalf("expected verified peers, got: %+v", scfg.TLS)
+	}
+	cert, err := scfg.TLS.GetCertificate(nil)
+	if err != nil || cert == nil {
+		t.Fatalf("expected certificate, got: %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package grid
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lytics/grid/codec"
+)
+
+// connStream of a connection, either side of the gRPC stream.
+type connStream interface {
+	Send(*Delivery) error
+	Recv() (*Delivery, error)
+}
+
+// Conn is a dedicated bidirectional stream between an actor and
+// a receiving mailbox, for sustained high-volume exchange. Unlike
+// requests, messages sent on it are not acked nor responded to,
+// each side simply sends and receives. Gaining a connection is
+// discovered and authorized like any request to the mailbox.
+//
+// The receiver gets the connection as the message of a request,
+// and accepts it by acking the request, or refuses it by
+// responding with an error:
+//
+//     case req := <-mailbox.C:
+//         switch m := req.Msg().(type) {
+//         case *grid.Conn:
+//             req.Ack()
+//             go consume(m)
+//         }
+//
+// Either side may close the connection, the other side then
+// receives ErrConnClosed.
+type Conn struct {
+	stream  connStream
+	codec   codec.Codec
+	sendMu  sync.Mutex
+	sent    bool
+	recvd   chan *Delivery
+	closing chan struct{}
+	pumped  chan struct{}
+	close   sync.Once
+	end     func() error
+	cancel  func()
+	err     error
+}
+
+// newConn on the stream, end finishes this side of the
+// stream, and cancel releases it.
+func newConn(stream connStream, end func() error, cancel func()) *Conn {
+	c := &Conn{
+		stream:  stream,
+		recvd:   make(chan *Delivery),
+		closing: make(chan struct{}),
+		pumped:  make(chan struct{}),
+		end:     end,
+		cancel:  cancel,
+	}
+	go c.pump()
+	return c
+}
+
+// pump received deliveries until the stream ends, those
+// received once the connection is closing are dropped.
+func (c *Conn) pump() {
+	defer close(c.pumped)
+	defer close(c.recvd)
+	for {
+		d, err := c.stream.Recv()
+		if err != nil {
+			c.err = err
+			return
+		}
+		select {
+		case c.recvd <- d:
+		case <-c.closing:
+		}
+	}
+}
+
+// Send the message to the other side.
+func (c *Conn) Send(msg interface{}) error {
+	select {
+	case <-c.closing:
+		return ErrConnClosed
+	default:
+	}
+
+	raw, err := encode(c.codec, msg)
+	if err != nil {
+		return err
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.sent {
+		return ErrConnClosed
+	}
+	err = c.stream.Send(&Delivery{
+		Ver:      protocolVersion,
+		Data:     raw.Data,
+		TypeName: raw.TypeName,
+		Codec:    raw.Codec,
+	})
+	if err == io.EOF {
+		// The stream has ended, its reason
+		// is only known to the receiving side.
+		return ErrConnClosed
+	}
+	return err
+}
+
+// Recv the next message from the other side, blocking until one
+// is available, the context finishes, or the connection closes.
+func (c *Conn) Recv(ctx context.Context) (interface{}, error) {
+	select {
+	case d, ok := <-c.recvd:
+		if !ok {
+			return nil, c.failure()
+		}
+		return decode(d.Codec, d.TypeName, d.Data)
+	case <-ctx.Done():
+		return nil, ErrContextFinished
+	}
+}
+
+// failure of the ended stream, ErrConnClosed if it ended
+// because either side closed it.
+func (c *Conn) failure() error {
+	if c.err == io.EOF || strings.Contains(c.err.Error(), "context canceled") {
+		return ErrConnClosed
+	}
+	return c.err
+}
+
+// Close the connection, waiting for the other side to close
+// too, or for the context to finish. Messages received but
+// not yet read are dropped.
+func (c *Conn) Close(ctx context.Context) error {
+	var err error
+	c.close.Do(func() {
+		close(c.closing)
+		err = c.closeSend()
+	})
+	select {
+	case <-c.pumped:
+	case <-ctx.Done():
+		if err == nil {
+			err = ErrContextFinished
+		}
+	}
+	c.cancel()
+	return err
+}
+
+// closeSend ends this side of the stream, once, the other
+// side then receives ErrConnClosed.
+func (c *Conn) closeSend() error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.sent {
+		return nil
+	}
+	c.sent = true
+	return c.end()
+}
+
+// Connect to the receiver's mailbox, from the actor's context.
+// The connection lives as long as the context, or until either
+// side closes it. See Client.Connect.
+func Connect(ctx context.Context, receiver string) (*Conn, error) {
+	client, err := ContextClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.Connect(ctx, receiver)
+}
+
+// Connect to the receiver's mailbox, waiting at most the client's
+// DefaultRequestTimeout for the receiver to accept. The connection
+// lives as long as the context, or until either side closes it.
+//
+// Example usage:
+//
+//     conn, err := client.Connect(ctx, "consumer")
+//     ...
+//     defer conn.Close(ctx)
+//
+//     for _, msg := range msgs {
+//         err := conn.Send(msg)
+//         ...
+//     }
+//
+func (c *Client) Connect(ctx context.Context, receiver string) (*Conn, error) {
+	nsReceiver, err := namespaceName(Mailboxes, c.cfg.Namespace, receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &Delivery{
+		Ver:      protocolVersion,
+		Receiver: nsReceiver,
+		Deadline: time.Now().Add(c.cfg.DefaultRequestTimeout).UnixNano(),
+		Timeout:  int64(c.cfg.DefaultRequestTimeout),
+	}
+	c.stamp(ctx, req)
+	err = sign(c.cfg.Signer, req)
+	if err != nil {
+		return nil, err
+	}
+
+	client, clientID, err := c.getWireClient(ctx, nsReceiver)
+	if err != nil {
+		if strings.Contains(err.Error(), ErrUnregisteredMailbox.Error()) {
+			c.deleteAddress(nsReceiver)
+		}
+		return nil, err
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := client.client.Connect(streamCtx)
+	if err != nil {
+		cancel()
+		c.deleteClientAndConn(nsReceiver, clientID)
+		return nil, err
+	}
+	err = stream.Send(req)
+	if err == nil {
+		// The receiver accepts with an empty delivery,
+		// or fails the stream with the reason it could
+		// not accept.
+		_, err = stream.Recv()
+	}
+	if err != nil {
+		cancel()
+		if strings.Contains(err.Error(), ErrUnknownMailbox.Error()) {
+			c.deleteAddress(nsReceiver)
+		}
+		return nil, err
+	}
+	return newConn(stream, stream.CloseSend, cancel), nil
+}
+
+// Connect a dedicated stream between a sender and a receiving
+// mailbox, see Client.Connect, or carry a request with progress
+// updates, see Client.RequestWithProgress. Implements the interface
+// for gRPC definition of the wire service. Consider this a private
+// method.
+func (s *Server) Connect(stream Wire_ConnectServer) error {
+	err := s.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	d, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	caller, mailbox, err := s.admit(stream.Context(), d)
+	if err != nil {
+		return err
+	}
+
+	// The receiver must accept within the
+	// deadline the sender requested.
+	c, cancel := requestContext(stream.Context(), d)
+	defer cancel()
+
+	// Delay or drop the request as faults injected
+	// into the peer say.
+	drop, err := s.injectDelivery(c, mailbox)
+	if err != nil {
+		return err
+	}
+	if drop {
+		<-c.Done()
+		return ErrContextFinished
+	}
+
+	// A request for progress updates, rather
+	// than for a connection, carries a message.
+	if d.TypeName != "" {
+		return s.streamRequest(c, stream, caller, mailbox, d)
+	}
+
+	done := make(chan struct{})
+	conn := newConn(stream, func() error {
+		close(done)
+		return nil
+	}, func() {})
+
+	req, err := s.enqueue(c, caller, mailbox, d, conn, nil)
+	if err != nil {
+		return err
+	}
+	res, err := s.await(c, req)
+	if err != nil {
+		return err
+	}
+	putDelivery(res)
+
+	err = stream.Send(&Delivery{Ver: protocolVersion})
+	if err != nil {
+		return err
+	}
+
+	// The stream ends when this side closes the
+	// connection, the other side goes away, or
+	// the server stops.
+	select {
+	case <-done:
+	case <-stream.Context().Done():
+	case <-s.ctx.Done():
+	}
+	return nil
+}
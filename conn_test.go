@@ -0,0 +1,92 @@
+package grid
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pipeStream is one end of an in-memory stream.
+type pipeStream struct {
+	once sync.Once
+	in   chan *Delivery
+	out  chan *Delivery
+}
+
+func newPipe() (*pipeStream, *pipeStream) {
+	ab := make(chan *Delivery, 10)
+	ba := make(chan *Delivery, 10)
+	return &pipeStream{in: ba, out: ab}, &pipeStream{in: ab, out: ba}
+}
+
+func (p *pipeStream) Send(d *Delivery) error {
+	p.out <- d
+	return nil
+}
+
+func (p *pipeStream) Recv() (*Delivery, error) {
+	d, ok := <-p.in
+	if !ok {
+		return nil, io.EOF
+	}
+	return d, nil
+}
+
+func (p *pipeStream) CloseSend() error {
+	p.once.Do(func() { close(p.out) })
+	return nil
+}
+
+func TestConn(t *testing.T) {
+	Register(EchoMsg{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	a, b := newPipe()
+	sender := newConn(a, a.CloseSend, func() {})
+	receiver := newConn(b, b.CloseSend, func() {})
+
+	for _, msg := range []string{"a", "b", "c"} {
+		err := sender.Send(&EchoMsg{Msg: msg})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, expected := range []string{"a", "b", "c"} {
+		msg, err := receiver.Recv(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		echo, ok := msg.(*EchoMsg)
+		if !ok || echo.Msg != expected {
+			t.Fatalf("expected message: %v, got: %v", expected, msg)
+		}
+	}
+
+	// The receiver sees the sender close, and closes its
+	// side, which the sender is waiting for.
+	closed := make(chan error, 1)
+	go func() {
+		closed <- sender.Close(ctx)
+	}()
+	_, err := receiver.Recv(ctx)
+	if err != ErrConnClosed {
+		t.Fatalf("expected error: %v, got: %v", ErrConnClosed, err)
+	}
+	err = receiver.Close(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = <-closed
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sender.Send(&EchoMsg{Msg: "d"})
+	if err != ErrConnClosed {
+		t.Fatalf("expected error: %v, got: %v", ErrConnClosed, err)
+	}
+}
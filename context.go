@@ -42,3 +42,31 @@ func ContextActorNamespace(c context.Context) (string, error) {
 	}
 	return cv.server.cfg.Namespace, nil
 }
+
+// ContextServer returns the server running the actor
+// associated with this context.
+func ContextServer(c context.Context) (*Server, error) {
+	v := c.Value(contextKey)
+	if v == nil {
+		return nil, ErrInvalidContext
+	}
+	cv, ok := v.(*contextVal)
+	if !ok {
+		return nil, ErrInvalidContext
+	}
+	return cv.server, nil
+}
+
+// ContextClient returns the client of the server running the
+// actor associated with this context, so that actors can send
+// requests without being made with a client of their own.
+func ContextClient(c context.Context) (*Client, error) {
+	server, err := ContextServer(c)
+	if err != nil {
+		return nil, err
+	}
+	if server.client == nil {
+		return nil, ErrServerNotRunning
+	}
+	return server.client, nil
+}
@@ -49,6 +49,22 @@ func TestContextError(t *testing.T) {
 	if namespace != "" {
 		t.Fatal("expected zero value")
 	}
+
+	server, err := ContextServer(c)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if server != nil {
+		t.Fatal("expected zero value")
+	}
+
+	client, err := ContextClient(c)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if client != nil {
+		t.Fatal("expected zero value")
+	}
 }
 
 func TestValidContext(t *testing.T) {
@@ -112,6 +128,22 @@ func TestValidContext(t *testing.T) {
 				t.Fatal("expected non-zero value")
 			}
 
+			s, err := ContextServer(a.ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != server {
+				t.Fatal("expected server running the actor")
+			}
+
+			client, err := ContextClient(a.ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if client == nil {
+				t.Fatal("expected non-zero value")
+			}
+
 			return
 		}
 	}
@@ -0,0 +1,82 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+)
+
+// ControlFunc handles a control command, with the data sent
+// along with it. An error returned is the peer's failure to
+// carry out the command, reported back to the sender.
+type ControlFunc func(ctx context.Context, data []byte) error
+
+// HandleControl command on this server. Control commands are sent
+// to every peer of the namespace at once, see Client.Control, for
+// operations that concern whole processes rather than actors, such
+// as flushing caches, rotating logs, or entering a degraded mode.
+//
+// Example usage:
+//
+//     server.HandleControl("flush-caches", func(ctx context.Context, data []byte) error {
+//         cache.Flush()
+//         return nil
+//     })
+//
+func (s *Server) HandleControl(command string, f ControlFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.controls[command] = f
+}
+
+// control command of the request, acking it once handled.
+func (s *Server) control(req Request, msg *Control) {
+	s.mu.Lock()
+	f := s.controls[msg.Command]
+	s.mu.Unlock()
+
+	var err error
+	if f == nil {
+		err = fmt.Errorf("%v: %v", ErrUnknownControl, msg.Command)
+	} else {
+		err = f(req.Context(), msg.Data)
+	}
+	if err != nil {
+		err2 := req.Respond(err)
+		if err2 != nil {
+			s.logf("%v: failed sending response for failed control command: %v, original error: %v", s.cfg.Namespace, err2, err)
+		}
+		return
+	}
+	err = req.Ack()
+	if err != nil {
+		s.logf("%v: failed sending ack: %v", s.cfg.Namespace, err)
+	}
+}
+
+// Control sends the command to every peer of the namespace, and
+// collects their acks. The result holds the outcome of each peer
+// by its name, and the error is ErrIncompleteBroadcast if any
+// peer failed, including peers with no handler for the command.
+//
+// Example usage:
+//
+//     res, err := client.Control(ctx, "flush-caches", nil)
+//     if err != nil {
+//         for peer, r := range res {
+//             if r.Err != nil {
+//                 ...
+//             }
+//         }
+//     }
+//
+func (c *Client) Control(ctx context.Context, command string, data []byte) (BroadcastResult, error) {
+	peers, err := c.QueryC(ctx, Peers)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		names = append(names, peer.Name())
+	}
+	return c.BroadcastC(ctx, NewListGroup(names...), &Control{Command: command, Data: data})
+}
@@ -0,0 +1,48 @@
+package grid
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestControl(t *testing.T) {
+	s := &Server{controls: map[string]ControlFunc{}}
+
+	var flushed []byte
+	s.HandleControl("flush", func(ctx context.Context, data []byte) error {
+		flushed = data
+		return nil
+	})
+	s.HandleControl("fail", func(ctx context.Context, data []byte) error {
+		return errors.New("failed")
+	})
+
+	req := newRequest(context.Background(), &Control{Command: "flush", Data: []byte("x")}, nil)
+	s.control(req, req.Msg().(*Control))
+	select {
+	case <-req.response:
+	default:
+		t.Fatal("expected ack")
+	}
+	if string(flushed) != "x" {
+		t.Fatalf("expected data: x, got: %s", flushed)
+	}
+
+	for command, expected := range map[string]string{
+		"fail":    "failed",
+		"unknown": ErrUnknownControl.Error(),
+	} {
+		req := newRequest(context.Background(), &Control{Command: command}, nil)
+		s.control(req, req.Msg().(*Control))
+		select {
+		case err := <-req.failure:
+			if !strings.Contains(err.Error(), expected) {
+				t.Fatalf("expected error: %v, got: %v", expected, err)
+			}
+		default:
+			t.Fatalf("expected failure of command: %v", command)
+		}
+	}
+}
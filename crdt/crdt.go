@@ -0,0 +1,168 @@
+package crdt
+
+import (
+	"errors"
+	"sort"
+)
+
+var (
+	// ErrMismatchedType when a value is merged with a
+	// value of a different type.
+	ErrMismatchedType = errors.New("crdt: mismatched type")
+)
+
+// Value that is conflict-free and replicated. Each replica
+// mutates only its own copy of the value, and copies are
+// combined with Merge. Merge must be commutative, associative,
+// and idempotent, so that the order and number of merges
+// does not change the result.
+type Value interface {
+	Merge(other Value) error
+}
+
+// GCounter is a grow-only counter. Each replica increments
+// only its own slot, and the value of the counter is the sum
+// of all slots.
+type GCounter struct {
+	Counts map[string]uint64 `json:"counts"`
+}
+
+// NewGCounter with no counts.
+func NewGCounter() *GCounter {
+	return &GCounter{Counts: map[string]uint64{}}
+}
+
+// Inc the slot of the replica by n.
+func (c *GCounter) Inc(replica string, n uint64) {
+	if c.Counts == nil {
+		c.Counts = map[string]uint64{}
+	}
+	c.Counts[replica] += n
+}
+
+// Value of the counter, ie: the sum of all replica slots.
+func (c *GCounter) Value() uint64 {
+	var sum uint64
+	for _, n := range c.Counts {
+		sum += n
+	}
+	return sum
+}
+
+// Merge other into the counter by taking the max of
+// each replica's slot.
+func (c *GCounter) Merge(other Value) error {
+	o, ok := other.(*GCounter)
+	if !ok {
+		return ErrMismatchedType
+	}
+	if c.Counts == nil {
+		c.Counts = map[string]uint64{}
+	}
+	for replica, n := range o.Counts {
+		if n > c.Counts[replica] {
+			c.Counts[replica] = n
+		}
+	}
+	return nil
+}
+
+// PNCounter is a counter that can be incremented and
+// decremented, built from two grow-only counters.
+type PNCounter struct {
+	P *GCounter `json:"p"`
+	N *GCounter `json:"n"`
+}
+
+// NewPNCounter with no counts.
+func NewPNCounter() *PNCounter {
+	return &PNCounter{P: NewGCounter(), N: NewGCounter()}
+}
+
+// Inc the slot of the replica by n.
+func (c *PNCounter) Inc(replica string, n uint64) {
+	c.init()
+	c.P.Inc(replica, n)
+}
+
+// Dec the slot of the replica by n.
+func (c *PNCounter) Dec(replica string, n uint64) {
+	c.init()
+	c.N.Inc(replica, n)
+}
+
+// Value of the counter, ie: increments minus decrements.
+func (c *PNCounter) Value() int64 {
+	c.init()
+	return int64(c.P.Value()) - int64(c.N.Value())
+}
+
+// Merge other into the counter.
+func (c *PNCounter) Merge(other Value) error {
+	o, ok := other.(*PNCounter)
+	if !ok {
+		return ErrMismatchedType
+	}
+	c.init()
+	if o.P != nil {
+		c.P.Merge(o.P)
+	}
+	if o.N != nil {
+		c.N.Merge(o.N)
+	}
+	return nil
+}
+
+func (c *PNCounter) init() {
+	if c.P == nil {
+		c.P = NewGCounter()
+	}
+	if c.N == nil {
+		c.N = NewGCounter()
+	}
+}
+
+// GSet is a grow-only set of strings.
+type GSet struct {
+	Elements map[string]bool `json:"elements"`
+}
+
+// NewGSet with no elements.
+func NewGSet() *GSet {
+	return &GSet{Elements: map[string]bool{}}
+}
+
+// Add the element to the set.
+func (s *GSet) Add(e string) {
+	if s.Elements == nil {
+		s.Elements = map[string]bool{}
+	}
+	s.Elements[e] = true
+}
+
+// Contains returns true if the element is in the set.
+func (s *GSet) Contains(e string) bool {
+	return s.Elements[e]
+}
+
+// Members of the set, sorted.
+func (s *GSet) Members() []string {
+	members := make([]string, 0, len(s.Elements))
+	for e := range s.Elements {
+		members = append(members, e)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// Merge other into the set by union.
+func (s *GSet) Merge(other Value) error {
+	o, ok := other.(*GSet)
+	if !ok {
+		return ErrMismatchedType
+	}
+	for e := range o.Elements {
+		s.Add(e)
+	}
+	return nil
+}
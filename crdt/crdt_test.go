@@ -0,0 +1,92 @@
+package crdt
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGCounterMerge(t *testing.T) {
+	a := NewGCounter()
+	b := NewGCounter()
+
+	a.Inc("a", 3)
+	b.Inc("b", 4)
+	b.Inc("a", 1)
+
+	err := a.Merge(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Value() != 7 {
+		t.Fatalf("expected 7, got: %v", a.Value())
+	}
+
+	// Merging again must not change the value.
+	err = a.Merge(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Value() != 7 {
+		t.Fatalf("expected merge to be idempotent, got: %v", a.Value())
+	}
+}
+
+func TestPNCounterMerge(t *testing.T) {
+	a := NewPNCounter()
+	b := NewPNCounter()
+
+	a.Inc("a", 10)
+	b.Dec("b", 4)
+
+	err := b.Merge(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Value() != 6 {
+		t.Fatalf("expected 6, got: %v", b.Value())
+	}
+}
+
+func TestGSetMerge(t *testing.T) {
+	a := NewGSet()
+	b := NewGSet()
+
+	a.Add("x")
+	b.Add("y")
+	b.Add("x")
+
+	err := a.Merge(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := a.Members()
+	if len(members) != 2 || members[0] != "x" || members[1] != "y" {
+		t.Fatalf("expected [x y], got: %v", members)
+	}
+}
+
+func TestMergeMismatchedType(t *testing.T) {
+	err := NewGCounter().Merge(NewGSet())
+	if err != ErrMismatchedType {
+		t.Fatal("expected mismatched type error")
+	}
+}
+
+func TestPNCounterJSON(t *testing.T) {
+	a := NewPNCounter()
+	a.Inc("a", 5)
+	a.Dec("a", 2)
+
+	buf, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &PNCounter{}
+	err = json.Unmarshal(buf, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Value() != 3 {
+		t.Fatalf("expected 3, got: %v", b.Value())
+	}
+}
@@ -0,0 +1,113 @@
+package grid
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+	"github.com/lytics/grid/crdt"
+)
+
+// values is the key space of replicated conflict-free values.
+const values EntityType = "crdt"
+
+// PublishValue writes the replica's copy of the named value. Each
+// replica, typically each actor of a type, should publish under
+// its own replica name, so no two writers ever touch the same key
+// and no coordination is needed.
+//
+// Example usage:
+//
+//     processed := crdt.NewGCounter()
+//     ...
+//     processed.Inc(actorName, 1)
+//     err := client.PublishValue(ctx, "processed", actorName, processed)
+//
+func (c *Client) PublishValue(ctx context.Context, name, replica string, v crdt.Value) error {
+	key, err := valueKey(c.cfg.Namespace, name, replica)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Put(ctx, key, string(buf))
+	return err
+}
+
+// LoadReplica reads the replica's last published copy of the named
+// value into v. Replicas that restart should load their copy before
+// mutating it, otherwise previous counts can be lost. If the replica
+// has never published then v is left unchanged.
+func (c *Client) LoadReplica(ctx context.Context, name, replica string, v crdt.Value) error {
+	key, err := valueKey(c.cfg.Namespace, name, replica)
+	if err != nil {
+		return err
+	}
+	res, err := c.etcd.Get(ctx, key, etcdv3.WithLimit(1))
+	if err != nil {
+		return err
+	}
+	if res.Count == 0 {
+		return nil
+	}
+	return json.Unmarshal(res.Kvs[0].Value, v)
+}
+
+// FetchValue merges every replica's copy of the named value into v,
+// giving the cluster wide value, for example the total count of
+// messages processed by all actors of a type.
+func (c *Client) FetchValue(ctx context.Context, name string, v crdt.Value) error {
+	prefix, err := valuePrefix(c.cfg.Namespace, name)
+	if err != nil {
+		return err
+	}
+	res, err := c.etcd.Get(ctx, prefix, etcdv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+	rt := reflect.TypeOf(v).Elem()
+	for _, kv := range res.Kvs {
+		replica := reflect.New(rt).Interface().(crdt.Value)
+		err := json.Unmarshal(kv.Value, replica)
+		if err != nil {
+			return err
+		}
+		err = v.Merge(replica)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteValue removes all replicas of the named value.
+func (c *Client) DeleteValue(ctx context.Context, name string) error {
+	prefix, err := valuePrefix(c.cfg.Namespace, name)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Delete(ctx, prefix, etcdv3.WithPrefix())
+	return err
+}
+
+func valueKey(namespace, name, replica string) (string, error) {
+	prefix, err := valuePrefix(namespace, name)
+	if err != nil {
+		return "", err
+	}
+	if !isNameValid(replica) {
+		return "", ErrInvalidName
+	}
+	return prefix + replica, nil
+}
+
+func valuePrefix(namespace, name string) (string, error) {
+	nsName, err := namespaceName(values, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	return nsName + ".", nil
+}
@@ -0,0 +1,106 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lytics/grid/crdt"
+	"github.com/lytics/grid/testetcd"
+)
+
+func TestPublishFetchValue(t *testing.T) {
+	const timeout = 2 * time.Second
+
+	etcd := testetcd.StartAndConnect(t)
+	defer etcd.Close()
+
+	client, err := NewClient(etcd, ClientCfg{Namespace: newNamespace()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for i, replica := range []string{"worker-1", "worker-2"} {
+		processed := crdt.NewGCounter()
+		processed.Inc(replica, uint64(i+1))
+		err = client.PublishValue(ctx, "processed", replica, processed)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total := crdt.NewGCounter()
+	err = client.FetchValue(ctx, "processed", total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.Value() != 3 {
+		t.Fatalf("expected merged value: 3, got: %v", total.Value())
+	}
+
+	err = client.DeleteValue(ctx, "processed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	total = crdt.NewGCounter()
+	err = client.FetchValue(ctx, "processed", total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.Value() != 0 {
+		t.Fatalf("expected no value after delete, got: %v", total.Value())
+	}
+}
+
+func TestLoadReplica(t *testing.T) {
+	const timeout = 2 * time.Second
+
+	etcd := testetcd.StartAndConnect(t)
+	defer etcd.Close()
+
+	client, err := NewClient(etcd, ClientCfg{Namespace: newNamespace()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// A replica that never published is left unchanged.
+	loaded := crdt.NewPNCounter()
+	loaded.Inc("worker-1", 7)
+	err = client.LoadReplica(ctx, "balance", "worker-1", loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Value() != 7 {
+		t.Fatalf("expected unchanged value: 7, got: %v", loaded.Value())
+	}
+
+	balance := crdt.NewPNCounter()
+	balance.Inc("worker-1", 5)
+	balance.Dec("worker-1", 2)
+	err = client.PublishValue(ctx, "balance", "worker-1", balance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded = crdt.NewPNCounter()
+	err = client.LoadReplica(ctx, "balance", "worker-1", loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Value() != 3 {
+		t.Fatalf("expected loaded value: 3, got: %v", loaded.Value())
+	}
+
+	err = client.PublishValue(ctx, "balance", "invalid/name", balance)
+	if err != ErrInvalidName {
+		t.Fatalf("expected invalid name, got: %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package grid
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+)
+
+// What the goroutines of an actor are blocked on, see ActorGoroutines.
+const (
+	// BlockedOnReceive is waiting in Mailbox.Recv or RecvBatch.
+	BlockedOnReceive = "receive"
+	// BlockedOnRequest is waiting on an outbound call of the
+	// client, such as a Request or Query.
+	BlockedOnRequest = "request"
+	// BlockedOnUser is anywhere else, running or blocked in the
+	// actor's own code.
+	BlockedOnUser = "user"
+)
+
+// gridPkg is the import path of this package, as it appears
+// in the function names of stacks.
+var gridPkg = reflect.TypeOf(Mailbox{}).PkgPath()
+
+// DiagnoseActor reports what the goroutines of the running actor are
+// blocked on, so that why an actor is stuck can be answered without
+// a core dump. Goroutines are found by the profiler labels the actor
+// is started with, which the goroutines it starts inherit, and those
+// with the same stack are reported once, with their count. Servers
+// with a Policy only allow it to callers allowed ActionInspect on
+// the actor.
+//
+// Example usage:
+//
+//     diag, err := client.DiagnoseActor(ctx, "worker-1")
+//     ...
+//     for _, g := range diag.Goroutines {
+//         fmt.Println(g.Count, g.BlockedOn)
+//         fmt.Println(g.Stack)
+//     }
+//
+// Actors receiving from Mailbox.C directly, rather than with Recv
+// or RecvBatch, are reported as BlockedOnUser while they wait, the
+// stack tells on what.
+func (c *Client) DiagnoseActor(ctx context.Context, name string) (*ActorDiagnosis, error) {
+	peer, err := c.actorPeer(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return RequestT[*ActorDiagnosis](ctx, c, peer, &ActorDiagnose{Actor: name})
+}
+
+// actorPeer the actor is registered on.
+func (c *Client) actorPeer(ctx context.Context, name string) (string, error) {
+	nsName, err := namespaceName(Actors, c.cfg.Namespace, name)
+	if err != nil {
+		return "", err
+	}
+	reg, err := c.registry.FindRegistration(ctx, nsName)
+	if err != nil {
+		return "", ErrUnknownActor
+	}
+	return reg.Registry, nil
+}
+
+// diagnoseActor of the request, responding with its goroutines.
+func (s *Server) diagnoseActor(req Request, msg *ActorDiagnose) {
+	s.mu.Lock()
+	_, ok := s.contexts[msg.Actor]
+	s.mu.Unlock()
+	if !ok {
+		s.respondInspect(req, ErrUnknownActor)
+		return
+	}
+	var buf bytes.Buffer
+	err := pprof.Lookup("goroutine").WriteTo(&buf, 1)
+	if err != nil {
+		s.respondInspect(req, err)
+		return
+	}
+	s.respondInspect(req, &ActorDiagnosis{
+		Actor:      msg.Actor,
+		Peer:       s.registry.Registry(),
+		Goroutines: actorGoroutines(buf.Bytes(), msg.Actor),
+	})
+}
+
+// actorGoroutines of the actor in the goroutine profile, written
+// with debug level 1, in which goroutines with the same stack and
+// labels are grouped, and each group is followed by a blank line.
+func actorGoroutines(profile []byte, actor string) []*ActorGoroutines {
+	label := strconv.Quote("grid.actor") + ":" + strconv.Quote(actor)
+
+	var result []*ActorGoroutines
+	var count int
+	var labeled bool
+	var frames []string
+	var stack strings.Builder
+	flush := func() {
+		if labeled && count > 0 {
+			result = append(result, &ActorGoroutines{
+				Count:     int32(count),
+				BlockedOn: blockedOn(frames),
+				Stack:     stack.String(),
+			})
+		}
+		count, labeled, frames = 0, false, nil
+		stack.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(profile))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "# labels: "):
+			labeled = containsLabel(line[len("# labels: "):], label)
+		case strings.HasPrefix(line, "#\t"):
+			// Frames are: #, pc, function+offset, file:line.
+			fields := strings.Split(line, "\t")
+			if len(fields) < 4 {
+				continue
+			}
+			fn := fields[2]
+			if i := strings.LastIndex(fn, "+"); i > 0 {
+				fn = fn[:i]
+			}
+			frames = append(frames, fn)
+			stack.WriteString(fn + "\n\t" + fields[3] + "\n")
+		case strings.Contains(line, " @ "):
+			count, _ = strconv.Atoi(line[:strings.Index(line, " @ ")])
+		}
+	}
+	flush()
+	return result
+}
+
+// containsLabel if the labels, formatted as {"key":"value", ...},
+// contain the label, formatted as "key":"value".
+func containsLabel(labels, label string) bool {
+	labels = strings.TrimSuffix(strings.TrimPrefix(labels, "{"), "}")
+	for _, l := range strings.Split(labels, ", ") {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedOn of the goroutine with the stack of functions, the
+// innermost first.
+func blockedOn(frames []string) string {
+	for _, fn := range frames {
+		switch {
+		case strings.HasPrefix(fn, gridPkg+".(*Mailbox).Recv"):
+			return BlockedOnReceive
+		case strings.HasPrefix(fn, gridPkg+".(*Client)."),
+			strings.HasPrefix(fn, gridPkg+".RequestT["):
+			return BlockedOnRequest
+		}
+	}
+	return BlockedOnUser
+}
@@ -0,0 +1,66 @@
+package grid
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActorGoroutines(t *testing.T) {
+	profile := []byte(`goroutine profile: total 4
+2 @ 0x1 0x2
+# labels: {"grid.actor":"worker-1", "grid.type":"worker"}
+#	0x1	runtime.gopark+0x10	/go/src/runtime/proc.go:1
+#	0x2	` + gridPkg + `.(*Mailbox).Recv+0x20	/grid/mailbox.go:2
+#	0x3	main.(*Worker).Act+0x30	/app/worker.go:3
+
+1 @ 0x1 0x4
+# labels: {"grid.actor":"worker-1", "grid.type":"worker"}
+#	0x1	runtime.gopark+0x10	/go/src/runtime/proc.go:1
+#	0x4	` + gridPkg + `.RequestT[...]+0x40	/grid/client.go:4
+
+1 @ 0x1 0x5
+# labels: {"grid.actor":"worker-10", "grid.type":"worker"}
+#	0x1	runtime.gopark+0x10	/go/src/runtime/proc.go:1
+
+`)
+	got := actorGoroutines(profile, "worker-1")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 groups of goroutines, got: %v", got)
+	}
+	if got[0].Count != 2 || got[0].BlockedOn != BlockedOnReceive {
+		t.Fatalf("expected 2 goroutines blocked on receive, got: %v", got[0])
+	}
+	if !strings.Contains(got[0].Stack, "main.(*Worker).Act\n\t/app/worker.go:3") {
+		t.Fatalf("expected stack of the actor, got: %v", got[0].Stack)
+	}
+	if got[1].Count != 1 || got[1].BlockedOn != BlockedOnRequest {
+		t.Fatalf("expected 1 goroutine blocked on request, got: %v", got[1])
+	}
+}
+
+func TestActorGoroutinesLive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	waiting := make(chan bool)
+	labels := pprof.Labels("grid.actor", "diagnosed", "grid.type", "test")
+	go pprof.Do(ctx, labels, func(ctx context.Context) {
+		close(waiting)
+		<-ctx.Done()
+	})
+	<-waiting
+	time.Sleep(10 * time.Millisecond)
+
+	var buf bytes.Buffer
+	err := pprof.Lookup("goroutine").WriteTo(&buf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := actorGoroutines(buf.Bytes(), "diagnosed")
+	if len(got) != 1 || got[0].Count != 1 || got[0].BlockedOn != BlockedOnUser {
+		t.Fatalf("expected 1 goroutine in user code, got: %v", got)
+	}
+}
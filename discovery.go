@@ -0,0 +1,131 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// seedName of a peer's mailbox, for processes that
+// reach the peer by its address, without its name.
+const seedName = "seed"
+
+// seedReceiver of the namespace. It is named as a peer, which
+// no mailbox can be, and no peer is, since peers are named by
+// their address.
+func seedReceiver(namespace string) (string, error) {
+	return namespaceName(Peers, namespace, seedName)
+}
+
+// DiscoverEtcdSRV endpoints of etcd from the DNS SRV records of the
+// domain, following the convention of etcd's own discovery. Records
+// of _etcd-client-ssl._tcp are used if there are any, as https URLs,
+// otherwise records of _etcd-client._tcp, as http URLs.
+//
+// Example usage:
+//
+//     endpoints, err := grid.DiscoverEtcdSRV(ctx, "example.com")
+//     ...
+//     etcd, err := etcdv3.New(etcdv3.Config{Endpoints: endpoints})
+//
+func DiscoverEtcdSRV(ctx context.Context, domain string) ([]string, error) {
+	var lastErr error
+	for _, service := range []struct{ name, scheme string }{
+		{"etcd-client-ssl", "https"},
+		{"etcd-client", "http"},
+	} {
+		_, srvs, err := net.DefaultResolver.LookupSRV(ctx, service.name, "tcp", domain)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if endpoints := srvEndpoints(service.scheme, srvs); len(endpoints) > 0 {
+			return endpoints, nil
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrNoEtcdEndpoints
+}
+
+// srvEndpoints as URLs of the scheme, in the order of the records,
+// which is by priority, and randomized by weight.
+func srvEndpoints(scheme string, srvs []*net.SRV) []string {
+	endpoints := make([]string, 0, len(srvs))
+	for _, srv := range srvs {
+		host := strings.TrimSuffix(srv.Target, ".")
+		endpoints = append(endpoints, fmt.Sprintf("%v://%v", scheme, net.JoinHostPort(host, fmt.Sprint(srv.Port))))
+	}
+	return endpoints
+}
+
+// DiscoverEtcdSeed endpoints of etcd from a seed peer, which answers
+// with the endpoints of the etcd its grid uses, so that processes need
+// only the address of one running peer. The options must include the
+// namespace, and any TLS, token, or signer that the peer requires.
+//
+// Example usage:
+//
+//     endpoints, err := grid.DiscoverEtcdSeed(ctx, "10.0.0.7:7777",
+//         grid.WithNamespace("search"))
+//     ...
+//     etcd, err := etcdv3.New(etcdv3.Config{Endpoints: endpoints})
+//
+func DiscoverEtcdSeed(ctx context.Context, seed string, options ...ClientOption) ([]string, error) {
+	cfg := newClientCfg(options)
+	setClientCfgDefaults(&cfg)
+
+	receiver, err := seedReceiver(cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	typeName, data, err := marshal(&EtcdEndpointsQuery{})
+	if err != nil {
+		return nil, err
+	}
+	req := &Delivery{
+		Ver:      protocolVersion,
+		Data:     data,
+		TypeName: typeName,
+		Receiver: receiver,
+	}
+	err = sign(cfg.Signer, req)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.DialContext(ctx, seed, dialOptions(cfg)...)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	res, err := NewWireClient(conn).Process(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := decode(res.Codec, res.TypeName, res.Data)
+	if err != nil {
+		return nil, err
+	}
+	ee, err := responseAs[*EtcdEndpoints](reply, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(ee.Endpoints) == 0 {
+		return nil, ErrNoEtcdEndpoints
+	}
+	return ee.Endpoints, nil
+}
+
+// etcdEndpoints of the server, answering a process
+// that discovers etcd through this peer as a seed.
+func (s *Server) etcdEndpoints(req Request) {
+	err := req.Respond(&EtcdEndpoints{Endpoints: s.etcd.Endpoints()})
+	if err != nil {
+		s.logf("%v: failed sending response for etcd endpoints: %v", s.cfg.Namespace, err)
+	}
+}
@@ -0,0 +1,39 @@
+package grid
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestSRVEndpoints(t *testing.T) {
+	endpoints := srvEndpoints("https", []*net.SRV{
+		{Target: "etcd-1.example.com.", Port: 2379},
+		{Target: "fd00::1", Port: 2379},
+	})
+	expected := []string{
+		"https://etcd-1.example.com:2379",
+		"https://[fd00::1]:2379",
+	}
+	if !reflect.DeepEqual(endpoints, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, endpoints)
+	}
+}
+
+func TestSeedReceiver(t *testing.T) {
+	receiver, err := seedReceiver("testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No mailbox can have the seed's name.
+	mailbox, err := namespaceName(Mailboxes, "testing", seedName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receiver == mailbox {
+		t.Fatalf("seed receiver is a mailbox name: %v", receiver)
+	}
+	if _, err := seedReceiver("invalid.namespace"); err != ErrInvalidNamespace {
+		t.Fatalf("expected invalid namespace, got: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package grid
+
+import (
+	"context"
+	"hash/fnv"
+	"runtime/debug"
+	"sync"
+)
+
+// Dispatch requests from the mailbox to the handler, handling up to
+// concurrency of them at once, until the context finishes or the
+// mailbox is closed, when it waits for the requests being handled
+// and returns ErrContextFinished or ErrMailboxClosed. The handler
+// must respond to or ack each request. A handler that panics fails
+// its request with ErrHandlerPanicked, and its worker handles the
+// next request. If key is not nil, requests
+// with the same key are handled one at a time, in the order they
+// were received, while requests with other keys are handled
+// concurrently. It spares simple actors a pool of workers.
+//
+// Example Usage:
+//
+//     err := mailbox.Dispatch(ctx, 8, func(req grid.Request) {
+//         ...
+//         req.Respond(res)
+//     }, func(req grid.Request) string {
+//         return req.Msg().(*Update).Account
+//     })
+//
+func (box *Mailbox) Dispatch(ctx context.Context, concurrency int, handler func(req Request), key func(req Request) string) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Each worker has its own queue, so that the
+	// requests of a key, which all go to the same
+	// worker, are handled in order. Without keys
+	// any idle worker takes the next request from
+	// a queue shared by all of them.
+	queues := make([]chan Request, concurrency)
+	shared := make(chan Request)
+	for i := range queues {
+		queues[i] = shared
+		if key != nil {
+			queues[i] = make(chan Request)
+		}
+	}
+	var wg sync.WaitGroup
+	for _, queue := range queues {
+		wg.Add(1)
+		go func(queue chan Request) {
+			defer wg.Done()
+			for req := range queue {
+				box.dispatchOne(req, handler)
+			}
+		}(queue)
+	}
+	defer func() {
+		if key == nil {
+			close(shared)
+		} else {
+			for _, queue := range queues {
+				close(queue)
+			}
+		}
+		wg.Wait()
+	}()
+
+	for {
+		req, err := box.Recv(ctx)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			shared <- req
+			continue
+		}
+		queues[dispatchIndex(key(req), concurrency)] <- req
+	}
+}
+
+// dispatchOne request to the handler, recovering from its panic
+// so that only the request fails, rather than the whole process.
+func (box *Mailbox) dispatchOne(req Request, handler func(req Request)) {
+	defer func() {
+		if err := recover(); err != nil {
+			if box.server != nil {
+				stack := niceStack(debug.Stack())
+				box.server.logf("panic in namespace: %v, mailbox: %v, recovered from: %v, stack trace: %v",
+					box.server.cfg.Namespace, box.name, err, stack)
+			}
+			// The handler may have responded before
+			// it panicked, in which case this does
+			// nothing.
+			req.Respond(ErrHandlerPanicked)
+		}
+	}()
+	handler(req)
+}
+
+// dispatchIndex of the worker handling requests of the key.
+func dispatchIndex(key string, concurrency int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(concurrency))
+}
@@ -0,0 +1,108 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestMailbox(size int) (*Mailbox, chan Request) {
+	c := make(chan Request, size)
+	return &Mailbox{C: c, c: c}, c
+}
+
+func TestDispatchConcurrency(t *testing.T) {
+	box, c := newTestMailbox(10)
+	for i := 0; i < 10; i++ {
+		c <- newRequest(context.Background(), &EchoMsg{}, &Provenance{})
+	}
+	close(c)
+
+	var running, most int32
+	err := box.Dispatch(context.Background(), 4, func(req Request) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&most)
+			if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		req.Ack()
+	}, nil)
+	if err != ErrMailboxClosed {
+		t.Fatalf("expected mailbox closed, got: %v", err)
+	}
+	if most < 2 || most > 4 {
+		t.Fatalf("expected between 2 and 4 concurrent requests, got: %v", most)
+	}
+}
+
+func TestDispatchOrderedByKey(t *testing.T) {
+	box, c := newTestMailbox(100)
+	for i := 0; i < 100; i++ {
+		msg := &EchoMsg{Msg: fmt.Sprintf("%v:%02d", i%3, i)}
+		c <- newRequest(context.Background(), msg, &Provenance{})
+	}
+	close(c)
+
+	var mu sync.Mutex
+	seen := map[string][]string{}
+	err := box.Dispatch(context.Background(), 4, func(req Request) {
+		msg := req.Msg().(*EchoMsg).Msg
+		mu.Lock()
+		seen[msg[:1]] = append(seen[msg[:1]], msg)
+		mu.Unlock()
+		req.Ack()
+	}, func(req Request) string {
+		return req.Msg().(*EchoMsg).Msg[:1]
+	})
+	if err != ErrMailboxClosed {
+		t.Fatalf("expected mailbox closed, got: %v", err)
+	}
+	for key, msgs := range seen {
+		for i := 1; i < len(msgs); i++ {
+			if msgs[i-1] > msgs[i] {
+				t.Fatalf("expected key: %v in order, got: %v", key, msgs)
+			}
+		}
+	}
+}
+
+func TestDispatchPanic(t *testing.T) {
+	box, c := newTestMailbox(2)
+	bad := newRequest(context.Background(), &EchoMsg{Msg: "bad"}, &Provenance{})
+	good := newRequest(context.Background(), &EchoMsg{Msg: "good"}, &Provenance{})
+	c <- bad
+	c <- good
+	close(c)
+
+	// The request whose handler panics fails, and
+	// the worker goes on to handle the next one.
+	err := box.Dispatch(context.Background(), 1, func(req Request) {
+		if req.Msg().(*EchoMsg).Msg == "bad" {
+			panic("bad request")
+		}
+		req.Ack()
+	}, nil)
+	if err != ErrMailboxClosed {
+		t.Fatalf("expected mailbox closed, got: %v", err)
+	}
+	select {
+	case err := <-bad.failure:
+		if err != ErrHandlerPanicked {
+			t.Fatalf("expected handler panicked, got: %v", err)
+		}
+	default:
+		t.Fatal("expected failure of the request")
+	}
+	select {
+	case <-good.response:
+	default:
+		t.Fatal("expected response to the next request")
+	}
+}
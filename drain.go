@@ -0,0 +1,195 @@
+package grid
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// drainSignal to the actors of a server that it is stopping.
+type drainSignal struct {
+	once     sync.Once
+	c        chan struct{}
+	deadline time.Time
+}
+
+func newDrainSignal() *drainSignal {
+	return &drainSignal{c: make(chan struct{})}
+}
+
+// begin the drain, the deadline is set before the
+// channel is closed, so readers of it see it set.
+func (ds *drainSignal) begin(deadline time.Time) {
+	ds.once.Do(func() {
+		ds.deadline = deadline
+		close(ds.c)
+	})
+}
+
+// ContextDrain returns a channel that is closed when the server
+// running the actor begins draining, ie: stopping. The actor then
+// has until the deadline, see ContextDrainDeadline, to checkpoint
+// and finish, after which its context is cancelled.
+//
+// Example usage:
+//
+//     drain, err := grid.ContextDrain(ctx)
+//     ...
+//     for {
+//         select {
+//         case <-drain:
+//             checkpoint()
+//             return
+//         case <-ctx.Done():
+//             return
+//         case req := <-mailbox.C:
+//             ...
+//         }
+//     }
+//
+func ContextDrain(c context.Context) (<-chan struct{}, error) {
+	server, err := ContextServer(c)
+	if err != nil {
+		return nil, err
+	}
+	return server.drain.c, nil
+}
+
+// ContextDrainDeadline returns the time at which the context of the
+// actor is cancelled, once the server running it is draining. The
+// deadline is zero if the server is not draining.
+func ContextDrainDeadline(c context.Context) (time.Time, error) {
+	server, err := ContextServer(c)
+	if err != nil {
+		return time.Time{}, err
+	}
+	select {
+	case <-server.drain.c:
+		return server.drain.deadline, nil
+	default:
+		return time.Time{}, nil
+	}
+}
+
+// drainActors notifies the actors that the server is draining, and
+// waits for them to finish, or for the timeout.
+func (s *Server) drainActors(timeout time.Duration) {
+	s.drain.begin(time.Now().Add(timeout))
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		s.logf("%v: drain timeout reached, cancelling remaining actors", s.cfg.Namespace)
+	}
+}
+
+// Drain the server and then stop it, for deploys without downtime.
+// The server announces it is a lame duck, so that clients and
+// placements avoid it, waits out the LameDuckDelay, and waits for
+// the requests it is serving to be answered. Its actors are then
+// notified through ContextDrain, and given until the context
+// finishes to checkpoint and finish. If relocate is true, its
+// durable actors still running are then moved, see DoneMoved, and
+// the drain waits, until the context finishes, for them to be
+// running on other peers, see PutDurableActor. The server is then
+// stopped as with Stop. Actors are only cancelled once the context
+// finishes, so it should have a deadline.
+//
+// Example usage:
+//
+//     <-sigterm
+//     ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//     defer cancel()
+//     err := server.Drain(ctx, true)
+//
+func (s *Server) Drain(ctx context.Context, relocate bool) error {
+	if s.registry == nil || s.cancel == nil {
+		return ErrServerNotRunning
+	}
+	peer := s.registry.Registry()
+
+	err := s.LameDuck(ctx)
+	if err != nil {
+		s.logf("%v: failed announcing lame duck: %v", s.cfg.Namespace, err)
+	}
+	wait(ctx, time.After(s.cfg.LameDuckDelay))
+	s.awaitInflight(ctx)
+
+	var durable []string
+	if relocate {
+		durable, err = s.client.durableActorsOn(ctx, peer)
+		if err != nil {
+			s.logf("%v: failed finding durable actors to relocate: %v", s.cfg.Namespace, err)
+		}
+	}
+
+	deadline, _ := ctx.Deadline()
+	s.drain.begin(deadline)
+	finished := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(finished)
+	}()
+	wait(ctx, finished)
+
+	if len(durable) > 0 {
+		s.moveActors(durable)
+		err := s.awaitRelocated(ctx, peer, durable)
+		if err != nil {
+			s.logf("%v: failed relocating durable actors: %v", s.cfg.Namespace, err)
+		}
+	}
+
+	s.stop.Do(func() { s.shutdown(true) })
+	return nil
+}
+
+// awaitInflight requests to be answered, or the context to finish.
+func (s *Server) awaitInflight(ctx context.Context) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&s.inflight) > 0 {
+		if !wait(ctx, ticker.C) {
+			s.logf("%v: drain context finished with requests in flight", s.cfg.Namespace)
+			return
+		}
+	}
+}
+
+// awaitRelocated actors of the peer to be running on other
+// peers, or the context to finish.
+func (s *Server) awaitRelocated(ctx context.Context, peer string, actors []string) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		running, err := s.client.QueryC(ctx, Actors)
+		if err != nil {
+			return err
+		}
+		if movedOff(actors, peer, running) {
+			return nil
+		}
+		if !wait(ctx, ticker.C) {
+			return ErrContextFinished
+		}
+	}
+}
+
+// wait for the channel to receive or close, it
+// returns false if the context finishes first.
+func wait[T any](ctx context.Context, c <-chan T) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-c:
+		return true
+	}
+}
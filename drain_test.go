@@ -0,0 +1,86 @@
+package grid
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDrainActors(t *testing.T) {
+	s := &Server{drain: newDrainSignal()}
+	ctx := context.WithValue(context.Background(), contextKey, &contextVal{server: s})
+
+	deadline, err := ContextDrainDeadline(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deadline.IsZero() {
+		t.Fatal("expected no deadline before draining")
+	}
+
+	drain, err := ContextDrain(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An actor that checkpoints when notified.
+	checkpointed := make(chan time.Time, 1)
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		<-drain
+		deadline, _ := ContextDrainDeadline(ctx)
+		checkpointed <- deadline
+	}()
+
+	t0 := time.Now()
+	s.drainActors(time.Minute)
+	if time.Since(t0) > 10*time.Second {
+		t.Fatal("expected drain to end once actors finished")
+	}
+	if deadline := <-checkpointed; deadline.Before(t0.Add(time.Minute)) {
+		t.Fatalf("expected drain deadline, got: %v", deadline)
+	}
+
+	// Actors that do not finish are waited for
+	// only until the timeout.
+	s = &Server{drain: newDrainSignal()}
+	s.running.Add(1)
+	defer s.running.Done()
+	t0 = time.Now()
+	s.drainActors(20 * time.Millisecond)
+	if time.Since(t0) < 20*time.Millisecond {
+		t.Fatal("expected drain to wait for the timeout")
+	}
+}
+
+func TestAwaitInflight(t *testing.T) {
+	s := &Server{}
+	if err := s.Drain(context.Background(), false); err != ErrServerNotRunning {
+		t.Fatalf("expected server not running, got: %v", err)
+	}
+
+	atomic.AddInt64(&s.inflight, 1)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		atomic.AddInt64(&s.inflight, -1)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	t0 := time.Now()
+	s.awaitInflight(ctx)
+	if d := time.Since(t0); d < 200*time.Millisecond || d > 5*time.Second {
+		t.Fatalf("expected wait for the request in flight, waited: %v", d)
+	}
+
+	// Requests that are never answered are waited
+	// for only until the context finishes.
+	atomic.AddInt64(&s.inflight, 1)
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s.awaitInflight(ctx)
+	if ctx.Err() == nil {
+		t.Fatal("expected wait until the context finished")
+	}
+}
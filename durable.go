@@ -0,0 +1,236 @@
+package grid
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+	"github.com/lytics/grid/registry"
+)
+
+const (
+	// durables is the key space of durable actor definitions.
+	durables EntityType = "durable"
+	// reconcilers is the key space used to elect the single
+	// peer that keeps durable actors running.
+	reconcilers EntityType = "reconciler"
+)
+
+// desiredStateVersion of the document produced by Export.
+const desiredStateVersion = 1
+
+// DesiredState of a namespace, ie: the workload that the grid
+// keeps running regardless of which peers come and go. It is
+// a single document so that a cluster's workload can be moved
+// to another environment, or restored after a wipe.
+type DesiredState struct {
+	Version   int           `json:"version"`
+	Namespace string        `json:"namespace"`
+	Actors    []*ActorStart `json:"actors"`
+}
+
+// PutDurableActor defines an actor that the grid keeps running.
+// If the actor is not running on any peer, one of the peers will
+// start it on some peer in the namespace. The definition is not
+// bound to the lifetime of any process, it stays until deleted.
+func (c *Client) PutDurableActor(ctx context.Context, start *ActorStart) error {
+	if !isNameValid(start.Type) {
+		return ErrInvalidActorType
+	}
+	if !isNameValid(start.Name) {
+		return ErrInvalidActorName
+	}
+	key, err := namespaceName(durables, c.cfg.Namespace, start.Name)
+	if err != nil {
+		return err
+	}
+	buf, err := registry.Encode(c.cfg.ValueCodec, start)
+	if err != nil {
+		return err
+	}
+	buf, err = seal(ctx, c.cfg.KMS, buf)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Put(ctx, key, string(buf))
+	return err
+}
+
+// DeleteDurableActor definition. A running actor is not stopped,
+// but it will not be started again once it exits.
+func (c *Client) DeleteDurableActor(ctx context.Context, name string) error {
+	key, err := namespaceName(durables, c.cfg.Namespace, name)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Delete(ctx, key)
+	return err
+}
+
+// DurableActors defined in this client's namespace.
+func (c *Client) DurableActors(ctx context.Context) ([]*ActorStart, error) {
+	return findDurableActors(ctx, c.etcd, c.cfg.KMS, c.cfg.Namespace)
+}
+
+// Export the desired state of this client's namespace.
+func (c *Client) Export(ctx context.Context) (*DesiredState, error) {
+	actors, err := c.DurableActors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &DesiredState{
+		Version:   desiredStateVersion,
+		Namespace: c.cfg.Namespace,
+		Actors:    actors,
+	}, nil
+}
+
+// Import the desired state into this client's namespace, which
+// need not be the namespace it was exported from. Definitions
+// with the same name are overwritten, other existing definitions
+// are left in place.
+func (c *Client) Import(ctx context.Context, state *DesiredState) error {
+	if state.Version > desiredStateVersion {
+		return ErrUnsupportedDesiredState
+	}
+	for _, start := range state.Actors {
+		err := c.PutDurableActor(ctx, start)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findDurableActors(ctx context.Context, etcd *etcdv3.Client, kms KMS, namespace string) ([]*ActorStart, error) {
+	prefix, err := namespacePrefix(durables, namespace)
+	if err != nil {
+		return nil, err
+	}
+	res, err := etcd.Get(ctx, prefix, etcdv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	starts := make([]*ActorStart, 0, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		buf, err := open(ctx, kms, kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		start := &ActorStart{}
+		err = registry.Decode(buf, start)
+		if err != nil {
+			return nil, err
+		}
+		starts = append(starts, start)
+	}
+	return starts, nil
+}
+
+// reconcileDurableActors starts any durable actor that is not
+// currently registered, and whose dependencies are running, on
+// a randomly chosen peer that is not a lame duck. Actors that
+// fail to start are queued, and retried once their backoff has
+// passed, see PendingDurableActors. Only the peer holding the
+// reconciler registration does any work.
+func (s *Server) reconcileDurableActors() {
+	key, err := namespaceName(reconcilers, s.cfg.Namespace, "durable")
+	if err != nil {
+		s.logf("%v: invalid reconciler key: %v", s.cfg.Namespace, err)
+		return
+	}
+
+	timeout, cancel := context.WithTimeout(s.ctx, s.cfg.Timeout)
+	defer cancel()
+
+	err = s.registry.Register(timeout, key, registry.OpAllowReentrantRegistration)
+	if err == registry.ErrAlreadyRegistered {
+		s.starts.clear()
+		return
+	}
+	if err != nil {
+		s.logf("%v: failed claiming reconciler: %v", s.cfg.Namespace, err)
+		return
+	}
+
+	starts, err := findDurableActors(timeout, s.etcd, s.cfg.KMS, s.cfg.Namespace)
+	if err != nil {
+		s.logf("%v: failed finding durable actors: %v", s.cfg.Namespace, err)
+		return
+	}
+	if len(starts) == 0 {
+		s.starts.clear()
+		return
+	}
+	actors, err := s.client.QueryC(timeout, Actors)
+	if err != nil {
+		s.logf("%v: failed querying actors: %v", s.cfg.Namespace, err)
+		return
+	}
+	found, err := s.client.QueryC(timeout, Peers)
+	if err != nil {
+		s.logf("%v: failed querying peers: %v", s.cfg.Namespace, err)
+		return
+	}
+	// Peers shutting down would only stop the
+	// actors again, and those in quarantine take
+	// no new work.
+	peers := make([]*QueryEvent, 0, len(found))
+	for _, peer := range found {
+		if !peer.LameDuck() && !peer.Quarantined() {
+			peers = append(peers, peer)
+		}
+	}
+
+	running := make(map[string]bool, len(actors))
+	for _, a := range actors {
+		running[a.Name()] = true
+	}
+	stopped := make(map[string]bool, len(starts))
+	for _, start := range starts {
+		if !running[start.Name] {
+			stopped[start.Name] = true
+		}
+	}
+	s.starts.prune(stopped)
+
+	now := time.Now()
+	if len(peers) == 0 {
+		for name := range stopped {
+			if s.starts.due(name, now) {
+				s.starts.failed(name, "", ErrNoPeers, now, s.cfg.ReconcileInterval)
+			}
+		}
+		return
+	}
+	// Actors declared to start after others wait
+	// for a later round until those are running.
+	var types map[string]int
+	for _, start := range starts {
+		if running[start.Name] || !s.starts.due(start.Name, now) {
+			continue
+		}
+		if deps := s.dependencies(start.Type); len(deps) > 0 {
+			if types == nil {
+				st, err := s.client.Stats(timeout)
+				if err != nil {
+					s.logf("%v: failed finding running actor types: %v", s.cfg.Namespace, err)
+					return
+				}
+				types = st.ActorTypes
+			}
+			if unmetDependency(deps, types) != "" {
+				continue
+			}
+		}
+		peer := peers[rand.Intn(len(peers))]
+		_, err := s.client.RequestC(timeout, peer.Name(), start)
+		if err != nil {
+			s.starts.failed(start.Name, peer.Name(), err, now, s.cfg.ReconcileInterval)
+			s.logf("%v: failed starting durable actor: %v, on peer: %v, error: %v", s.cfg.Namespace, start.Name, peer.Name(), err)
+			continue
+		}
+		s.starts.started(start.Name)
+	}
+}
@@ -0,0 +1,70 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lytics/grid/testetcd"
+)
+
+func TestExportImport(t *testing.T) {
+	const timeout = 2 * time.Second
+
+	etcd := testetcd.StartAndConnect(t)
+	defer etcd.Close()
+
+	src, err := NewClient(etcd, ClientCfg{Namespace: newNamespace()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	dst, err := NewClient(etcd, ClientCfg{Namespace: newNamespace()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := NewActorStart("worker-%d", 1)
+	start.Type = "worker"
+	start.Data = []byte("config")
+	err = src.PutDurableActor(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := src.Export(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Actors) != 1 {
+		t.Fatalf("expected 1 actor, got: %v", len(state.Actors))
+	}
+
+	err = dst.Import(ctx, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actors, err := dst.DurableActors(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actors) != 1 {
+		t.Fatalf("expected 1 actor, got: %v", len(actors))
+	}
+	if actors[0].Name != "worker-1" || actors[0].Type != "worker" || string(actors[0].Data) != "config" {
+		t.Fatalf("unexpected actor: %v", actors[0])
+	}
+}
+
+func TestImportUnsupportedVersion(t *testing.T) {
+	client := &Client{cfg: ClientCfg{Namespace: newNamespace()}}
+	err := client.Import(context.Background(), &DesiredState{Version: desiredStateVersion + 1})
+	if err != ErrUnsupportedDesiredState {
+		t.Fatal("expected unsupported desired state error")
+	}
+}
@@ -0,0 +1,142 @@
+package grid
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+)
+
+// KMS wraps and unwraps data keys. Payloads that grid persists,
+// such as actor state and durable actor definitions, are encrypted
+// with a fresh data key, and only the data key wrapped by the KMS
+// is stored next to them, ie: envelope encryption. Implementations
+// typically call out to a cloud KMS or a vault.
+type KMS interface {
+	// Wrap the data key.
+	Wrap(ctx context.Context, key []byte) ([]byte, error)
+	// Unwrap a data key wrapped by Wrap.
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// NewLocalKMS wraps data keys with AES-GCM under the given master
+// key, which must be 16, 24, or 32 bytes. Useful for development,
+// or where the master key is provided by the environment.
+func NewLocalKMS(master []byte) (KMS, error) {
+	block, err := aes.NewCipher(master)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &localKMS{aead: aead}, nil
+}
+
+type localKMS struct {
+	aead cipher.AEAD
+}
+
+func (lk *localKMS) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	return aeadSeal(lk.aead, key)
+}
+
+func (lk *localKMS) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return aeadOpen(lk.aead, wrapped)
+}
+
+// sealedPrefix marks encrypted payloads, so that payloads written
+// before encryption was enabled can still be read.
+var sealedPrefix = []byte("\x00grid-sealed-1\x00")
+
+// seal the payload, if there is a KMS.
+func seal(ctx context.Context, kms KMS, payload []byte) ([]byte, error) {
+	if kms == nil {
+		return payload, nil
+	}
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := kms.Wrap(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := aeadSeal(aead, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, len(sealedPrefix)+binary.MaxVarintLen64+len(wrapped)+len(ciphertext))
+	buf = append(buf, sealedPrefix...)
+	buf = binary.AppendUvarint(buf, uint64(len(wrapped)))
+	buf = append(buf, wrapped...)
+	buf = append(buf, ciphertext...)
+	return buf, nil
+}
+
+// open the payload, if it was sealed.
+func open(ctx context.Context, kms KMS, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, sealedPrefix) {
+		return data, nil
+	}
+	if kms == nil {
+		return nil, ErrSealedPayload
+	}
+	data = data[len(sealedPrefix):]
+	n, size := binary.Uvarint(data)
+	if size <= 0 || uint64(len(data)-size) < n {
+		return nil, ErrSealedPayload
+	}
+	wrapped := data[size : size+int(n)]
+	ciphertext := data[size+int(n):]
+
+	key, err := kms.Unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return aeadOpen(aead, ciphertext)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// aeadSeal the plaintext, prefixed by a random nonce.
+func aeadSeal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// aeadOpen the ciphertext sealed by aeadSeal.
+func aeadOpen(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrSealedPayload
+	}
+	nonce := ciphertext[:aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, ciphertext[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrSealedPayload
+	}
+	return plaintext, nil
+}
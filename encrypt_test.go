@@ -0,0 +1,54 @@
+package grid
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	ctx := context.Background()
+	kms, err := NewLocalKMS(bytes.Repeat([]byte("k"), 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte("secret offset")
+	sealed, err := seal(ctx, kms, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, payload) {
+		t.Fatal("expected payload to not be stored in cleartext")
+	}
+
+	opened, err := open(ctx, kms, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, payload) {
+		t.Fatalf("expected: %q, got: %q", payload, opened)
+	}
+
+	// Payloads written before encryption was
+	// enabled are read as they are.
+	opened, err = open(ctx, kms, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, payload) {
+		t.Fatalf("expected: %q, got: %q", payload, opened)
+	}
+
+	if _, err := open(ctx, nil, sealed); err != ErrSealedPayload {
+		t.Fatalf("expected sealed payload error, got: %v", err)
+	}
+
+	other, err := NewLocalKMS(bytes.Repeat([]byte("o"), 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := open(ctx, other, sealed); err != ErrSealedPayload {
+		t.Fatalf("expected sealed payload error, got: %v", err)
+	}
+}
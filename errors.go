@@ -17,12 +17,21 @@ var (
 	// ErrInvalidMailboxName when a mailbox name contains invalid
 	// character codes.
 	ErrInvalidMailboxName = errors.New("grid: invalid mailbox name")
+	// ErrInvalidMailboxOptions when a mailbox is created with more
+	// than one takeover policy, or with an unknown option.
+	ErrInvalidMailboxOptions = errors.New("grid: invalid mailbox options")
+	// ErrInvalidStateKey when an actor state key contains invalid
+	// character codes.
+	ErrInvalidStateKey = errors.New("grid: invalid state key")
 )
 
 var (
 	// ErrReceiverBusy when the message buffer of a mailbox is
 	// full, conisder a larger size when creating the mailbox.
 	ErrReceiverBusy = errors.New("grid: receiver busy")
+	// ErrOverloaded when the oldest request queued in a mailbox
+	// has waited longer than the server's admission policy allows.
+	ErrOverloaded = errors.New("grid: receiver overloaded")
 	// ErrUnknownMailbox when a message is received by a peer for
 	// a mailbox the peer does not serve, likely the mailbox has
 	// moved between the time of discovery and the message receive.
@@ -33,9 +42,42 @@ var (
 	// ErrContextFinished when the context signals done before the
 	// request could receive a response from the receiver.
 	ErrContextFinished = errors.New("grid: context finished")
+	// ErrStreamBroken when the stream to the receiver's peer broke
+	// after the request was sent, so the receiver may or may not
+	// have handled it. The request is not sent again, since that
+	// could deliver it twice, but the requester may.
+	ErrStreamBroken = errors.New("grid: stream broken")
 	// ErrIncompleteBroadcast when the Broadcast cannot successfully request
 	// an actor in the Group
 	ErrIncompleteBroadcast = errors.New("grid: incomplete broadcast")
+	// ErrNoGroupMember when a request to a Group finds no
+	// member with weight that could receive it.
+	ErrNoGroupMember = errors.New("grid: no group member")
+	// ErrThrottled when the caller has sent more requests than
+	// the receiving server's rate limit allows, the request was
+	// not delivered and may be sent again.
+	ErrThrottled = errors.New("grid: throttled")
+	// ErrUnauthorized when a request's token does not grant
+	// access to the namespace of the receiving server.
+	ErrUnauthorized = errors.New("grid: unauthorized")
+	// ErrInvalidSignature when a request is unsigned, or its
+	// signature is not valid, on a server with a Signer.
+	ErrInvalidSignature = errors.New("grid: invalid signature")
+	// ErrUnexpectedResponse when the response to a request
+	// is not of the type the requester expects.
+	ErrUnexpectedResponse = errors.New("grid: unexpected response")
+	// ErrUnsupportedVersion when a request is in a version of
+	// the wire protocol newer than the receiving peer's.
+	ErrUnsupportedVersion = errors.New("grid: unsupported protocol version")
+	// ErrMailboxClosed when receiving from a mailbox
+	// that has been closed.
+	ErrMailboxClosed = errors.New("grid: mailbox closed")
+	// ErrSinkClosed when a message is sent to a sink
+	// that has been closed.
+	ErrSinkClosed = errors.New("grid: sink closed")
+	// ErrConnClosed when sending or receiving on a connection
+	// that either side has closed.
+	ErrConnClosed = errors.New("grid: connection closed")
 )
 
 var (
@@ -50,6 +92,35 @@ var (
 	// ErrDefNotRegistered when a actor type which has never
 	// been registered is requested for start.
 	ErrDefNotRegistered = errors.New("grid: def not registered")
+	// ErrUnknownControl when a control command is sent to a
+	// server that has no handler for it.
+	ErrUnknownControl = errors.New("grid: unknown control command")
+	// ErrMaintenance when an actor is started while the
+	// namespace is in maintenance.
+	ErrMaintenance = errors.New("grid: namespace in maintenance")
+	// ErrDependencyNotRunning when an actor is started before
+	// any actor of a type it was declared to start after, see
+	// StartAfter.
+	ErrDependencyNotRunning = errors.New("grid: dependency not running")
+	// ErrDeliveryPaused when a request is sent to a mailbox
+	// that is not critical while the namespace is in maintenance
+	// with delivery paused, the request was not delivered and
+	// may be sent again.
+	ErrDeliveryPaused = errors.New("grid: delivery paused")
+	// ErrMessageDiscarded when a request is discarded from the
+	// mailbox it was queued in, before the receiver got it.
+	ErrMessageDiscarded = errors.New("grid: message discarded")
+	// ErrUnknownQueuedMessage when a message to requeue or
+	// discard is no longer queued in the mailbox.
+	ErrUnknownQueuedMessage = errors.New("grid: unknown queued message")
+	// ErrPoisonMessage when a message is rejected because the
+	// receiver of the mailbox stopped while handling identical
+	// messages too many times, see Mailbox.QuarantinePoison.
+	ErrPoisonMessage = errors.New("grid: poison message")
+	// ErrConfigWatchClosed when the watch of the namespace's
+	// configuration, or of its maintenance mode, closes while
+	// the server is running.
+	ErrConfigWatchClosed = errors.New("grid: config watch closed")
 	// ErrServerNotRunning when an operation which requires the
 	// server be running, but is not, is requested.
 	ErrServerNotRunning = errors.New("grid: server not running")
@@ -59,4 +130,82 @@ var (
 	// ErrWatchClosedUnexpectedly when a query watch closes before
 	// it was requested to close, likely do to some etcd issue.
 	ErrWatchClosedUnexpectedly = errors.New("grid: watch closed unexpectedly")
+	// ErrLeaseHeld when a lease is acquired on a resource
+	// that someone else already holds.
+	ErrLeaseHeld = errors.New("grid: lease held")
+	// ErrInvalidLeaseTTL when a lease is acquired with a
+	// time-to-live shorter than one second.
+	ErrInvalidLeaseTTL = errors.New("grid: invalid lease ttl")
+	// ErrSealedPayload when an encrypted payload cannot be
+	// decrypted, because it is corrupt, the key is wrong, or
+	// no KMS is configured.
+	ErrSealedPayload = errors.New("grid: sealed payload")
+	// ErrNilSecretProvider when an actor is started with
+	// secrets by a server without a SecretProvider.
+	ErrNilSecretProvider = errors.New("grid: nil secret provider")
+	// ErrUnresolvedSecret when a secret reference does not
+	// name any secret known to the provider.
+	ErrUnresolvedSecret = errors.New("grid: unresolved secret")
+	// ErrUnexpectedDataType when the data of an actor start is
+	// decoded into a type other than the one it was set from.
+	ErrUnexpectedDataType = errors.New("grid: unexpected data type")
+	// ErrInvalidSchema when the schema registered for the
+	// start data of an actor type cannot be parsed, or uses
+	// keywords that are not supported.
+	ErrInvalidSchema = errors.New("grid: invalid schema")
+	// ErrInvalidStartData when the data of an actor start does
+	// not validate against the schema of the actor's type.
+	ErrInvalidStartData = errors.New("grid: invalid start data")
+	// ErrInvalidQueryToken when a query page is requested with
+	// a token that was not returned by a previous page.
+	ErrInvalidQueryToken = errors.New("grid: invalid query token")
+	// ErrInvalidQueryLimit when a query page is requested with
+	// a limit of less than one.
+	ErrInvalidQueryLimit = errors.New("grid: invalid query limit")
+	// ErrInvalidWeight when a mailbox's weight is set
+	// to a negative number.
+	ErrInvalidWeight = errors.New("grid: invalid weight")
+	// ErrInvalidMirror when a mailbox's mirror has a fraction
+	// not from 0 to 1, or an invalid canary.
+	ErrInvalidMirror = errors.New("grid: invalid mirror")
+	// ErrUnsupportedDesiredState when importing a desired state
+	// document written by a newer version of grid.
+	ErrUnsupportedDesiredState = errors.New("grid: unsupported desired state")
+	// ErrNoEtcdEndpoints when discovery finds no endpoints
+	// of etcd.
+	ErrNoEtcdEndpoints = errors.New("grid: no etcd endpoints")
+	// ErrProgressUnsupported when progress is sent for a
+	// request whose sender did not ask for updates.
+	ErrProgressUnsupported = errors.New("grid: progress unsupported")
+	// ErrFaultsDisabled when faults are injected into a peer
+	// whose server does not allow fault injection.
+	ErrFaultsDisabled = errors.New("grid: fault injection disabled")
+	// ErrInvalidFault when a fault's drop fraction is not from
+	// 0 to 1, or its delay or duration is negative.
+	ErrInvalidFault = errors.New("grid: invalid fault")
+	// ErrFaultInjected when an operation fails because of a
+	// fault injected into the peer, such as a loss of etcd.
+	ErrFaultInjected = errors.New("grid: injected fault")
+	// ErrReplicationIncomplete when a replicated request was
+	// received by the primary, but not by its standby.
+	ErrReplicationIncomplete = errors.New("grid: replication incomplete")
+	// ErrUnknownActor when an actor is stopped or diagnosed
+	// that is not registered, or not running on the peer, or
+	// a standby is started for a primary not registered.
+	ErrUnknownActor = errors.New("grid: unknown actor")
+	// ErrStandbyOnPrimaryPeer when a standby runs on the peer of
+	// its primary, and so would die with it.
+	ErrStandbyOnPrimaryPeer = errors.New("grid: standby on the primary's peer")
+	// ErrNoPeers when an actor is started on any peer, but
+	// there is no peer to start it on.
+	ErrNoPeers = errors.New("grid: no peers")
+	// ErrTooManyRestarts when the children of a supervisor
+	// restart more often than its MaxRestarts allow.
+	ErrTooManyRestarts = errors.New("grid: too many restarts")
+	// ErrInvalidConfigFile when a config file cannot be decoded,
+	// or its TLS files cannot be loaded.
+	ErrInvalidConfigFile = errors.New("grid: invalid config file")
+	// ErrHandlerPanicked when the handler of a dispatched
+	// request panicked before responding to it.
+	ErrHandlerPanicked = errors.New("grid: handler panicked")
 )
@@ -177,51 +177,37 @@ func successOrDie(err error) {
 }
 
 type apiServer struct {
-	c                *grid.Client
-	ctx              context.Context
-	peers            map[string]bool
-	workerCt         int
-	mu               sync.Mutex
-	workerLoadTicker *time.Ticker
+	c        *grid.Client
+	ctx      context.Context
+	cancel   func()
+	peers    map[string]bool
+	workerCt int
+	mu       sync.Mutex
 }
 
 func NewApi(c *grid.Client) *apiServer {
 	a := &apiServer{c: c}
-	a.ctx = context.Background()
-	a.workerLoadTicker = time.NewTicker(2 * time.Second)
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+	a.peers = make(map[string]bool)
 	return a
 }
 
 // Keep watching for changes to workers
 func (m *apiServer) loadWorkers() {
+	events, err := m.c.Watch(m.ctx, []grid.EntityType{grid.Peers})
+	successOrDie(err)
 
-	defer m.workerLoadTicker.Stop()
-
-	// TODO:  use querywatch instead
-	// m.c.QueryWatch(ctx, filter)
-
-	for {
-		select {
-		case <-m.ctx.Done():
-			return
-		case _, ok := <-m.workerLoadTicker.C:
-			if !ok {
-				fmt.Println("Got exit in ticket")
-				return
-			}
-			// Ask for current peers.
-			peers, err := m.c.Query(timeout, grid.Peers)
-			successOrDie(err)
-			existing := make(map[string]bool)
-			m.mu.Lock()
-			for _, peer := range peers {
-				existing[peer.Name()] = true
-			}
-			m.peers = existing
-			m.workerCt = len(existing)
-			fmt.Println("found peers ", m.peers)
-			m.mu.Unlock()
+	for e := range events {
+		successOrDie(e.Err)
+		m.mu.Lock()
+		if e.Type == grid.EntityLeft {
+			delete(m.peers, e.Name)
+		} else {
+			m.peers[e.Name] = true
 		}
+		m.workerCt = len(m.peers)
+		fmt.Println("found peers ", m.peers)
+		m.mu.Unlock()
 	}
 }
 
@@ -273,6 +259,6 @@ func (m *apiServer) Run() {
 		// In event this server/node already has other
 		// workers, we won't run api on this one as we
 		// only need one per server.
-		m.workerLoadTicker.Stop()
+		m.cancel()
 	}
 }
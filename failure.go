@@ -0,0 +1,139 @@
+package grid
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// FailureDetector decides if a registered peer has failed, from
+// the heartbeats that gossip hears of it. Without a detector, a
+// server with gossip enabled suspects peers not heard of for its
+// SuspectAfter. The registry itself only forgets a peer once its
+// lease expires, whatever the detector decides.
+type FailureDetector interface {
+	// Heartbeat of the peer heard of at the time. Peers found
+	// registered but not yet heard of get a first heartbeat at
+	// the time they were found.
+	Heartbeat(peer string, now time.Time)
+	// Forget the peer, which is no longer registered.
+	Forget(peer string)
+	// Suspect if the peer is thought to have failed.
+	Suspect(peer string, now time.Time) bool
+}
+
+// LeaseDetector leaves the failure of peers to their leases in
+// etcd, it never suspects a registered peer, even with gossip.
+type LeaseDetector struct{}
+
+// Heartbeat is ignored.
+func (LeaseDetector) Heartbeat(peer string, now time.Time) {}
+
+// Forget is ignored.
+func (LeaseDetector) Forget(peer string) {}
+
+// Suspect is always false.
+func (LeaseDetector) Suspect(peer string, now time.Time) bool { return false }
+
+// phiWindow of the intervals between heartbeats remembered.
+const phiWindow = 100
+
+// PhiAccrualDetector suspects peers by how unlikely the time since
+// their last heartbeat is, given the intervals between their past
+// heartbeats, rather than by a fixed timeout. It adapts to the
+// jitter of each peer's network, and its threshold tunes how
+// sensitive it is. A threshold of 8 means a peer is suspected
+// when the chance it is alive is about 1 in 100,000,000.
+type PhiAccrualDetector struct {
+	mu        sync.Mutex
+	threshold float64
+	interval  time.Duration
+	peers     map[string]*arrivals
+}
+
+// arrivals of a peer's heartbeats.
+type arrivals struct {
+	last      time.Time
+	intervals []float64
+}
+
+// NewPhiAccrualDetector with the threshold of phi at which peers are
+// suspected, and the interval at which heartbeats are expected, which
+// is assumed until enough heartbeats have been heard of.
+func NewPhiAccrualDetector(threshold float64, interval time.Duration) *PhiAccrualDetector {
+	return &PhiAccrualDetector{
+		threshold: threshold,
+		interval:  interval,
+		peers:     map[string]*arrivals{},
+	}
+}
+
+// Heartbeat of the peer heard of at the time.
+func (d *PhiAccrualDetector) Heartbeat(peer string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	a, ok := d.peers[peer]
+	if !ok {
+		// Seed the history with the expected
+		// interval, until the peer is heard of.
+		d.peers[peer] = &arrivals{
+			last:      now,
+			intervals: []float64{float64(d.interval)},
+		}
+		return
+	}
+	a.intervals = append(a.intervals, float64(now.Sub(a.last)))
+	if len(a.intervals) > phiWindow {
+		a.intervals = a.intervals[1:]
+	}
+	a.last = now
+}
+
+// Forget the peer.
+func (d *PhiAccrualDetector) Forget(peer string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.peers, peer)
+}
+
+// Suspect if the peer's phi is above the threshold. Peers
+// never heard of are not suspect.
+func (d *PhiAccrualDetector) Suspect(peer string, now time.Time) bool {
+	return d.Phi(peer, now) >= d.threshold
+}
+
+// Phi of the peer at the time, the suspicion that it failed on
+// a logarithmic scale, zero for peers never heard of.
+func (d *PhiAccrualDetector) Phi(peer string, now time.Time) float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	a, ok := d.peers[peer]
+	if !ok {
+		return 0
+	}
+	var mean, variance float64
+	for _, v := range a.intervals {
+		mean += v
+	}
+	mean /= float64(len(a.intervals))
+	for _, v := range a.intervals {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(a.intervals))
+	// A floor on the deviation keeps very regular
+	// heartbeats from making phi jump on any delay.
+	stdDev := math.Max(math.Sqrt(variance), float64(d.interval)/4)
+	return phi(float64(now.Sub(a.last)), mean, stdDev)
+}
+
+// phi of the elapsed time, given the mean and deviation of the
+// intervals, using the logistic approximation of the normal
+// distribution's cumulative distribution function.
+func phi(elapsed, mean, stdDev float64) float64 {
+	y := (elapsed - mean) / stdDev
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if elapsed > mean {
+		return -math.Log10(e / (1 + e))
+	}
+	return -math.Log10(1 - 1/(1+e))
+}
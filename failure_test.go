@@ -0,0 +1,57 @@
+package grid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPhiAccrualDetector(t *testing.T) {
+	t0 := time.Now()
+	d := NewPhiAccrualDetector(8, time.Second)
+	if d.Suspect("peer-a", t0) {
+		t.Fatalf("expected unknown peer not to be suspect")
+	}
+
+	// Heartbeats a second apart, give or take.
+	now := t0
+	for i := 0; i < 20; i++ {
+		d.Heartbeat("peer-a", now)
+		now = now.Add(time.Second + time.Duration(i%3)*100*time.Millisecond)
+	}
+	if d.Suspect("peer-a", now) {
+		t.Fatalf("expected peer-a not to be suspect on time, phi: %v", d.Phi("peer-a", now))
+	}
+	late := now.Add(10 * time.Second)
+	if !d.Suspect("peer-a", late) {
+		t.Fatalf("expected peer-a to be suspect when late, phi: %v", d.Phi("peer-a", late))
+	}
+	if d.Phi("peer-a", now) >= d.Phi("peer-a", late) {
+		t.Fatalf("expected phi to grow with time")
+	}
+
+	d.Forget("peer-a")
+	if d.Suspect("peer-a", late) {
+		t.Fatalf("expected forgotten peer not to be suspect")
+	}
+}
+
+func TestGossipTableDetector(t *testing.T) {
+	t0 := time.Now()
+	g := newGossipTable(LeaseDetector{})
+	g.track([]string{"peer-a"}, t0)
+	if g.suspect("peer-a", time.Second, t0.Add(time.Hour)) {
+		t.Fatalf("expected lease detector never to suspect registered peers")
+	}
+
+	d := NewPhiAccrualDetector(8, time.Second)
+	g = newGossipTable(d)
+	g.track([]string{"peer-a"}, t0)
+	g.merge(map[string]int64{"peer-a": 1}, t0.Add(time.Second))
+	if g.suspect("peer-a", time.Hour, t0.Add(time.Minute)) != d.Suspect("peer-a", t0.Add(time.Minute)) {
+		t.Fatalf("expected the detector to decide")
+	}
+	g.track(nil, t0.Add(time.Minute))
+	if d.Phi("peer-a", t0.Add(time.Minute)) != 0 {
+		t.Fatalf("expected the detector to forget unregistered peers")
+	}
+}
@@ -0,0 +1,215 @@
+package grid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Control commands of fault injection, sent to one peer.
+const (
+	controlInjectFault = "grid-inject-fault"
+	controlClearFaults = "grid-clear-faults"
+)
+
+// errDropped when a delivery is dropped by an injected fault,
+// it is never answered, so its sender times out.
+var errDropped = errors.New("grid: delivery dropped")
+
+// Fault injected into a live peer, for game days against real
+// deployments. Faults only apply to peers whose servers allow
+// them, see WithFaultInjection.
+type Fault struct {
+	// Mailbox the fault applies to, empty for all mailboxes
+	// other than the peer's own, which is never faulted so
+	// that faults can always be cleared.
+	Mailbox string `json:"mailbox,omitempty"`
+	// Delay of each delivery to the mailbox.
+	Delay time.Duration `json:"delay,omitempty"`
+	// Drop the fraction of deliveries to the mailbox, from
+	// 0 to 1. Dropped requests are never answered, so their
+	// senders time out.
+	Drop float64 `json:"drop,omitempty"`
+	// EtcdLoss simulates the loss of etcd, actors and mailboxes
+	// fail to start with ErrFaultInjected.
+	EtcdLoss bool `json:"etcdLoss,omitempty"`
+	// Duration of the fault, zero for until cleared.
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// valid if the fault's fraction and durations are in range.
+func (f *Fault) valid() bool {
+	return f.Drop >= 0 && f.Drop <= 1 && f.Delay >= 0 && f.Duration >= 0
+}
+
+// InjectFault into the peer, which must allow fault injection,
+// and the caller must be permitted ActionInjectFault when the
+// peer has a Policy.
+//
+// Example usage:
+//
+//     err := client.InjectFault(ctx, peer, &grid.Fault{
+//         Mailbox:  "orders",
+//         Drop:     0.1,
+//         Duration: 10 * time.Minute,
+//     })
+//
+func (c *Client) InjectFault(ctx context.Context, peer string, f *Fault) error {
+	if !f.valid() {
+		return ErrInvalidFault
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	_, err = c.RequestC(ctx, peer, &Control{Command: controlInjectFault, Data: data})
+	return err
+}
+
+// ClearFaults injected into the peer.
+func (c *Client) ClearFaults(ctx context.Context, peer string) error {
+	_, err := c.RequestC(ctx, peer, &Control{Command: controlClearFaults})
+	return err
+}
+
+// handleFaultControls of fault injection.
+func (s *Server) handleFaultControls() {
+	s.HandleControl(controlInjectFault, func(ctx context.Context, data []byte) error {
+		if !s.cfg.AllowFaults {
+			return ErrFaultsDisabled
+		}
+		var f Fault
+		err := json.Unmarshal(data, &f)
+		if err != nil {
+			return err
+		}
+		if !f.valid() {
+			return ErrInvalidFault
+		}
+		s.logf("%v: injecting fault: %+v", s.cfg.Namespace, f)
+		s.faults.add(f, time.Now())
+		return nil
+	})
+	s.HandleControl(controlClearFaults, func(ctx context.Context, _ []byte) error {
+		if !s.cfg.AllowFaults {
+			return ErrFaultsDisabled
+		}
+		s.logf("%v: clearing faults", s.cfg.Namespace)
+		s.faults.clear()
+		return nil
+	})
+}
+
+// isFaultControl if the message is a command of fault injection.
+func isFaultControl(msg interface{}) bool {
+	c, ok := msg.(*Control)
+	return ok && (c.Command == controlInjectFault || c.Command == controlClearFaults)
+}
+
+// injectedFault with the time it expires, zero for never.
+type injectedFault struct {
+	Fault
+	expires time.Time
+}
+
+// faultTable of the faults injected into a server.
+type faultTable struct {
+	mu     sync.Mutex
+	faults []injectedFault
+}
+
+func newFaultTable() *faultTable {
+	return &faultTable{}
+}
+
+// add the fault, injected at now.
+func (ft *faultTable) add(f Fault, now time.Time) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	inj := injectedFault{Fault: f}
+	if f.Duration > 0 {
+		inj.expires = now.Add(f.Duration)
+	}
+	ft.faults = append(ft.faults, inj)
+}
+
+// clear all faults.
+func (ft *faultTable) clear() {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	ft.faults = nil
+}
+
+// active faults at now, dropping those expired.
+// The caller must hold the lock.
+func (ft *faultTable) active(now time.Time) []injectedFault {
+	live := ft.faults[:0]
+	for _, f := range ft.faults {
+		if f.expires.IsZero() || now.Before(f.expires) {
+			live = append(live, f)
+		}
+	}
+	ft.faults = live
+	return live
+}
+
+// delivery faults to the mailbox at now, the delay of the
+// delivery, and if it is dropped.
+func (ft *faultTable) delivery(mailbox string, now time.Time) (time.Duration, bool) {
+	if ft == nil {
+		return 0, false
+	}
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	var delay time.Duration
+	for _, f := range ft.active(now) {
+		if f.Mailbox != "" && f.Mailbox != mailbox {
+			continue
+		}
+		if f.Drop > 0 && rand.Float64() < f.Drop {
+			return 0, true
+		}
+		delay += f.Delay
+	}
+	return delay, false
+}
+
+// etcdLost if a fault simulates the loss of etcd at now.
+func (ft *faultTable) etcdLost(now time.Time) bool {
+	if ft == nil {
+		return false
+	}
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	for _, f := range ft.active(now) {
+		if f.EtcdLoss {
+			return true
+		}
+	}
+	return false
+}
+
+// injectDelivery faults into the request to the mailbox, waiting
+// out the delay, it returns true if the request is dropped.
+func (s *Server) injectDelivery(c context.Context, mailbox *Mailbox) (bool, error) {
+	if mailbox.nsName == s.peerMailbox {
+		return false, nil
+	}
+	delay, drop := s.faults.delivery(mailbox.Name(), time.Now())
+	if drop {
+		return true, nil
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-c.Done():
+			return false, ErrContextFinished
+		case <-timer.C:
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,171 @@
+package grid
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestFaultTableDelivery(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	ft := newFaultTable()
+	ft.add(Fault{Mailbox: "orders", Delay: time.Second}, now)
+	ft.add(Fault{Delay: time.Second, Duration: time.Minute}, now)
+
+	delay, drop := ft.delivery("orders", now)
+	if drop || delay != 2*time.Second {
+		t.Fatalf("expected delay of 2s, got: %v, drop: %v", delay, drop)
+	}
+	delay, drop = ft.delivery("invoices", now)
+	if drop || delay != time.Second {
+		t.Fatalf("expected delay of 1s, got: %v, drop: %v", delay, drop)
+	}
+	delay, drop = ft.delivery("invoices", now.Add(time.Hour))
+	if drop || delay != 0 {
+		t.Fatalf("expected expired fault, got delay: %v, drop: %v", delay, drop)
+	}
+
+	ft.add(Fault{Mailbox: "orders", Drop: 1}, now)
+	if _, drop := ft.delivery("orders", now); !drop {
+		t.Fatal("expected drop")
+	}
+	if _, drop := ft.delivery("invoices", now); drop {
+		t.Fatal("expected no drop of other mailbox")
+	}
+
+	ft.clear()
+	delay, drop = ft.delivery("orders", now)
+	if drop || delay != 0 {
+		t.Fatalf("expected cleared faults, got delay: %v, drop: %v", delay, drop)
+	}
+}
+
+func TestFaultTableEtcdLost(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	ft := newFaultTable()
+	if ft.etcdLost(now) {
+		t.Fatal("expected etcd")
+	}
+	ft.add(Fault{EtcdLoss: true, Duration: time.Minute}, now)
+	if !ft.etcdLost(now) {
+		t.Fatal("expected etcd loss")
+	}
+	if ft.etcdLost(now.Add(time.Minute)) {
+		t.Fatal("expected etcd loss to expire")
+	}
+
+	var nilTable *faultTable
+	if nilTable.etcdLost(now) {
+		t.Fatal("expected nil table to have no faults")
+	}
+}
+
+func TestFaultValid(t *testing.T) {
+	t.Parallel()
+
+	for _, f := range []Fault{{Drop: -0.1}, {Drop: 1.5}, {Delay: -1}, {Duration: -1}} {
+		if f.valid() {
+			t.Fatalf("expected invalid fault: %+v", f)
+		}
+	}
+	f := Fault{Drop: 0.5, Delay: time.Second}
+	if !f.valid() {
+		t.Fatalf("expected valid fault: %+v", f)
+	}
+}
+
+func TestFaultControlAuthorized(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{
+		cfg:         ServerCfg{Namespace: "ns", Policy: &RolePolicy{}, Auditor: &recordingAuditor{}},
+		peerMailbox: "ns.mailbox.peer",
+	}
+	d := &Delivery{Receiver: "ns.mailbox.peer"}
+	err := s.authorize(context.Background(), "someone", d, &Control{Command: controlInjectFault})
+	if err != ErrUnauthorized {
+		t.Fatalf("expected unauthorized, got: %v", err)
+	}
+}
+
+// recvStreamServer receives the deliveries sent on recv,
+// until it is closed, and sends responses on sent.
+type recvStreamServer struct {
+	grpc.ServerStream
+	ctx  context.Context
+	recv chan *Delivery
+	sent chan *Delivery
+}
+
+func (x *recvStreamServer) Context() context.Context        { return x.ctx }
+func (x *recvStreamServer) SendHeader(md metadata.MD) error { return nil }
+
+func (x *recvStreamServer) Send(d *Delivery) error {
+	x.sent <- d
+	return nil
+}
+
+func (x *recvStreamServer) Recv() (*Delivery, error) {
+	d := &Delivery{}
+	return d, x.RecvMsg(d)
+}
+
+func (x *recvStreamServer) RecvMsg(m interface{}) error {
+	d, ok := <-x.recv
+	if !ok {
+		return io.EOF
+	}
+	*m.(*Delivery) = *d
+	return nil
+}
+
+func TestFaultDropStream(t *testing.T) {
+	s := &Server{cfg: ServerCfg{Namespace: "testing"}, mailboxes: newMailboxMap(), faults: newFaultTable()}
+	boxC := make(chan Request, 1)
+	box := &Mailbox{name: "worker", nsName: "testing.mailbox.worker", C: boxC, c: boxC}
+	s.mailboxes.reserve(box.nsName)
+	s.mailboxes.set(box.nsName, box)
+	s.faults.add(Fault{Mailbox: "worker", Drop: 1}, time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &recvStreamServer{ctx: ctx, recv: make(chan *Delivery), sent: make(chan *Delivery, 1)}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Stream(stream)
+	}()
+
+	// Dropped requests leave nothing waiting for
+	// them, even while the stream lives on. They
+	// are followed by a request of an unsupported
+	// version, to the same receiver, which is
+	// answered once they have been handled.
+	before := runtime.NumGoroutine()
+	for i := 1; i <= 50; i++ {
+		stream.recv <- &Delivery{Ver: protocolVersion, Id: uint64(i), Receiver: box.nsName}
+	}
+	stream.recv <- &Delivery{Ver: protocolVersion + 1, Id: 51, Receiver: box.nsName}
+	if res := <-stream.sent; res.Id != 51 {
+		t.Fatalf("expected only the last request answered, got: %v", res.Id)
+	}
+	if n := runtime.NumGoroutine(); n > before+5 {
+		t.Fatalf("expected no goroutines left for dropped requests, got: %v, before: %v", n, before)
+	}
+	if len(boxC) != 0 {
+		t.Fatal("expected dropped requests not to be delivered")
+	}
+
+	close(stream.recv)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,143 @@
+package grid
+
+import (
+	"context"
+	"sync"
+)
+
+// initialCredit of a mailbox before its peer has advertised
+// any, enough to learn the mailbox's actual headroom.
+const initialCredit = 1
+
+// flowControl of the requests a stream sends to each mailbox.
+// Receiving peers advertise, with each response, how many more
+// requests the mailbox has room for, and senders wait for credit
+// rather than sending requests that would fail as busy. Credit
+// is kept per mailbox, so a full mailbox does not hold up the
+// requests to other mailboxes on the same stream, and waiters
+// for the same mailbox are granted credit in order of arrival.
+type flowControl struct {
+	mu        sync.Mutex
+	mailboxes map[string]*mailboxCredit
+}
+
+type mailboxCredit struct {
+	credit   int
+	inflight int
+	waiters  []chan struct{}
+	// unlimited when the peer does not
+	// advertise credit, ie: an older peer.
+	unlimited bool
+}
+
+func newFlowControl() *flowControl {
+	return &flowControl{mailboxes: make(map[string]*mailboxCredit)}
+}
+
+// acquire credit to send one request to the receiver, waiting
+// for it if needed, or until the context finishes.
+func (fc *flowControl) acquire(ctx context.Context, receiver string) error {
+	fc.mu.Lock()
+	mc, ok := fc.mailboxes[receiver]
+	if !ok {
+		mc = &mailboxCredit{credit: initialCredit}
+		fc.mailboxes[receiver] = mc
+	}
+	if len(mc.waiters) == 0 && mc.available() {
+		mc.take()
+		fc.mu.Unlock()
+		return nil
+	}
+	granted := make(chan struct{})
+	mc.waiters = append(mc.waiters, granted)
+	fc.mu.Unlock()
+
+	select {
+	case <-granted:
+		return nil
+	case <-ctx.Done():
+		fc.mu.Lock()
+		defer fc.mu.Unlock()
+		for i, w := range mc.waiters {
+			if w == granted {
+				mc.waiters = append(mc.waiters[:i], mc.waiters[i+1:]...)
+				return ErrContextFinished
+			}
+		}
+		// Credit was granted just as the context finished,
+		// so give it to the next waiter.
+		mc.credit++
+		mc.inflight--
+		mc.grant()
+		return ErrContextFinished
+	}
+}
+
+// release the credit of a request that will never receive
+// a response with newly advertised credit.
+func (fc *flowControl) release(receiver string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	mc, ok := fc.mailboxes[receiver]
+	if !ok {
+		return
+	}
+	mc.credit++
+	mc.inflight--
+	mc.grant()
+}
+
+// update the credit of the receiver from a response to one
+// of the requests sent to it.
+func (fc *flowControl) update(receiver string, res *Delivery) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	mc, ok := fc.mailboxes[receiver]
+	if !ok {
+		return
+	}
+	mc.inflight--
+	if res.Flow {
+		// The advertised headroom does not account
+		// for requests sent after the response.
+		mc.credit = int(res.Credit) - mc.inflight
+	} else {
+		mc.unlimited = true
+	}
+	mc.grant()
+}
+
+// reset lets all waiters go, used when the stream has failed,
+// since no more credit will be advertised on it.
+func (fc *flowControl) reset() {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	for receiver, mc := range fc.mailboxes {
+		for _, w := range mc.waiters {
+			close(w)
+		}
+		delete(fc.mailboxes, receiver)
+	}
+}
+
+// available is true if a request may be sent. When nothing is
+// in flight one request is always allowed, as a probe, since no
+// response would otherwise ever advertise new credit.
+func (mc *mailboxCredit) available() bool {
+	return mc.unlimited || mc.credit > 0 || mc.inflight == 0
+}
+
+func (mc *mailboxCredit) take() {
+	mc.credit--
+	mc.inflight++
+}
+
+// grant credit to waiters, in order of arrival.
+func (mc *mailboxCredit) grant() {
+	for len(mc.waiters) > 0 && mc.available() {
+		w := mc.waiters[0]
+		mc.waiters = mc.waiters[1:]
+		mc.take()
+		close(w)
+	}
+}
@@ -0,0 +1,215 @@
+package grid
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// gossipTable of the heartbeats of peers. Each peer counts its own
+// heartbeats, and peers pass along the highest count they know of
+// every peer. A peer whose count has not gone up for a while is
+// suspect. Counts are compared rather than times, so that the
+// clocks of peers need not agree.
+type gossipTable struct {
+	mu    sync.Mutex
+	peers map[string]*gossipEntry
+	// detector of failed peers, fed the heartbeats,
+	// nil to suspect peers by their SuspectAfter.
+	detector FailureDetector
+}
+
+// gossipEntry of a peer, with the local time its count last went up.
+type gossipEntry struct {
+	count   int64
+	updated time.Time
+}
+
+func newGossipTable(detector FailureDetector) *gossipTable {
+	return &gossipTable{
+		peers:    map[string]*gossipEntry{},
+		detector: detector,
+	}
+}
+
+// heard of the peer at the time, for the detector.
+func (g *gossipTable) heard(peer string, now time.Time) {
+	if g.detector != nil {
+		g.detector.Heartbeat(peer, now)
+	}
+}
+
+// beat of the peer itself.
+func (g *gossipTable) beat(peer string, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e, ok := g.peers[peer]
+	if !ok {
+		e = &gossipEntry{}
+		g.peers[peer] = e
+	}
+	e.count++
+	e.updated = now
+	g.heard(peer, now)
+}
+
+// merge the counts seen by another peer, keeping the highest.
+func (g *gossipTable) merge(seen map[string]int64, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for peer, count := range seen {
+		e, ok := g.peers[peer]
+		if !ok {
+			g.peers[peer] = &gossipEntry{count: count, updated: now}
+			g.heard(peer, now)
+			continue
+		}
+		if count > e.count {
+			e.count = count
+			e.updated = now
+			g.heard(peer, now)
+		}
+	}
+}
+
+// track the registered peers, forgetting any others. Registered
+// peers not yet heard of are tracked from now, so that they too
+// become suspect if they are never heard of.
+func (g *gossipTable) track(registered []string, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	keep := make(map[string]bool, len(registered))
+	for _, peer := range registered {
+		keep[peer] = true
+		if _, ok := g.peers[peer]; !ok {
+			g.peers[peer] = &gossipEntry{updated: now}
+			g.heard(peer, now)
+		}
+	}
+	for peer := range g.peers {
+		if !keep[peer] {
+			delete(g.peers, peer)
+			if g.detector != nil {
+				g.detector.Forget(peer)
+			}
+		}
+	}
+}
+
+// view of the counts of all known peers.
+func (g *gossipTable) view() map[string]int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	seen := make(map[string]int64, len(g.peers))
+	for peer, e := range g.peers {
+		seen[peer] = e.count
+	}
+	return seen
+}
+
+// suspect if the peer's count has not gone up for longer than
+// after, or as the detector decides, if there is one. Peers
+// that are not known are not suspect.
+func (g *gossipTable) suspect(peer string, after time.Duration, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e, ok := g.peers[peer]
+	if !ok {
+		return false
+	}
+	if g.detector != nil {
+		return g.detector.Suspect(peer, now)
+	}
+	return now.Sub(e.updated) > after
+}
+
+// monitorGossip by sending heartbeats to other peers
+// at the gossip interval, if gossip is enabled.
+func (s *Server) monitorGossip() {
+	if s.cfg.GossipInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.cfg.GossipInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.gossipHeartbeat()
+			}
+		}
+	}()
+}
+
+// gossipHeartbeat to a random few of the registered peers,
+// carrying all the counts this peer knows of.
+func (s *Server) gossipHeartbeat() {
+	name := s.registry.Registry()
+	now := time.Now()
+	s.gossip.beat(name, now)
+
+	timeout, cancel := context.WithTimeout(s.ctx, s.cfg.GossipInterval)
+	defer cancel()
+	peers, err := s.client.QueryC(timeout, Peers)
+	if err != nil {
+		s.logf("%v: failed querying peers for gossip: %v", s.cfg.Namespace, err)
+		return
+	}
+	registered := make([]string, 0, len(peers))
+	others := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		registered = append(registered, peer.Name())
+		if peer.Name() != name {
+			others = append(others, peer.Name())
+		}
+	}
+	s.gossip.track(registered, now)
+	s.skews.track(registered)
+
+	rand.Shuffle(len(others), func(i, j int) {
+		others[i], others[j] = others[j], others[i]
+	})
+	if len(others) > s.cfg.GossipFanout {
+		others = others[:s.cfg.GossipFanout]
+	}
+
+	// Heartbeats that fail are not logged, since
+	// peers failing is what gossip is to detect.
+	hb := &Heartbeat{Peer: name, Seen: s.gossip.view(), Sent: time.Now().UnixNano()}
+	var wg sync.WaitGroup
+	for _, peer := range others {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+			s.client.RequestC(timeout, peer, hb)
+		}(peer)
+	}
+	wg.Wait()
+}
+
+// heartbeat received from another peer.
+func (s *Server) heartbeat(req Request, msg *Heartbeat) {
+	now := time.Now()
+	s.gossip.merge(msg.Seen, now)
+	s.checkSkew(msg, now)
+	err := req.Ack()
+	if err != nil {
+		s.logf("%v: failed sending ack: %v", s.cfg.Namespace, err)
+	}
+}
+
+// Suspect reports if the peer is suspected of having failed, because
+// gossip has not heard of it for the server's SuspectAfter, or as its
+// FailureDetector decides, even if it is still registered. Suspect peers can be avoided before the registry
+// catches up, which only happens once their lease expires. Only clients
+// of a server with gossip enabled suspect peers, see WithServer.
+func (c *Client) Suspect(peer string) bool {
+	s := c.cfg.Server
+	if s == nil || s.cfg.GossipInterval <= 0 {
+		return false
+	}
+	return s.gossip.suspect(peer, s.cfg.SuspectAfter, time.Now())
+}
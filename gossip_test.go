@@ -0,0 +1,60 @@
+package grid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGossipTable(t *testing.T) {
+	const after = 3 * time.Second
+	t0 := time.Now()
+	g := newGossipTable(nil)
+
+	// Registered peers are tracked from the time
+	// they are found, even if not yet heard of.
+	g.beat("peer-a", t0)
+	g.track([]string{"peer-a", "peer-b", "peer-c"}, t0)
+	if g.suspect("peer-b", after, t0.Add(time.Second)) {
+		t.Fatalf("expected peer-b not to be suspect yet")
+	}
+
+	// Counts passed along by other peers keep
+	// peers from becoming suspect, stale counts
+	// do not.
+	g.merge(map[string]int64{"peer-b": 5, "peer-c": 0}, t0.Add(2*time.Second))
+	if g.suspect("peer-b", after, t0.Add(4*time.Second)) {
+		t.Fatalf("expected peer-b not to be suspect")
+	}
+	if !g.suspect("peer-c", after, t0.Add(4*time.Second)) {
+		t.Fatalf("expected peer-c to be suspect")
+	}
+	if seen := g.view(); seen["peer-a"] != 1 || seen["peer-b"] != 5 {
+		t.Fatalf("unexpected view: %v", seen)
+	}
+
+	// Peers no longer registered are forgotten,
+	// and peers not known are not suspect.
+	g.track([]string{"peer-a", "peer-b"}, t0.Add(5*time.Second))
+	if _, ok := g.view()["peer-c"]; ok {
+		t.Fatalf("expected peer-c to be forgotten")
+	}
+	if g.suspect("peer-c", after, t0.Add(10*time.Second)) {
+		t.Fatalf("expected unknown peer not to be suspect")
+	}
+}
+
+func TestClientSuspect(t *testing.T) {
+	s := &Server{
+		cfg:    ServerCfg{GossipInterval: time.Second, SuspectAfter: time.Millisecond},
+		gossip: newGossipTable(nil),
+	}
+	s.gossip.track([]string{"peer-a"}, time.Now().Add(-time.Second))
+
+	c := &Client{cfg: ClientCfg{Server: s}}
+	if !c.Suspect("peer-a") {
+		t.Fatalf("expected peer-a to be suspect")
+	}
+	if (&Client{}).Suspect("peer-a") {
+		t.Fatalf("expected client without server not to suspect peers")
+	}
+}
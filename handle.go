@@ -0,0 +1,201 @@
+package grid
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lytics/grid/registry"
+)
+
+// Handle of a mailbox, resolved to the address of the peer that
+// serves it. Callers that send many requests to the same mailbox
+// can keep the handle and pass it to RequestHandle, so that the
+// mailbox is not looked up in etcd on every request. The handle
+// is resolved again by its first request once it is older than
+// the client's PeersRefreshInterval, so that it follows the
+// mailbox when its registration is taken over, which bumps its
+// epoch, or it moves to another peer. It is resolved again
+// sooner when a request finds that the mailbox moved, or that
+// its peer is gone.
+type Handle struct {
+	mu         sync.Mutex
+	receiver   string
+	nsReceiver string
+	address    string
+	epoch      int64
+	resolved   time.Time
+}
+
+// Receiver name of the mailbox.
+func (h *Handle) Receiver() string {
+	return h.receiver
+}
+
+// Address of the peer serving the mailbox, when last resolved.
+func (h *Handle) Address() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.address
+}
+
+// Epoch of the mailbox's registration, when last resolved.
+func (h *Handle) Epoch() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.epoch
+}
+
+// update the handle to the registration.
+func (h *Handle) update(reg *registry.Registration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.address = reg.Address
+	h.epoch = reg.Epoch
+	h.resolved = time.Now()
+}
+
+// refreshed is true if the handle has an address, resolved
+// less than the interval ago.
+func (h *Handle) refreshed(interval time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.address != "" && time.Since(h.resolved) < interval
+}
+
+// postpone the next refresh of the handle, keeping the
+// address it has.
+func (h *Handle) postpone() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.resolved = time.Now()
+}
+
+// Resolve the receiver to a handle.
+//
+// Example usage:
+//
+//     h, err := client.Resolve(ctx, "worker-1")
+//     ...
+//     for _, work := range works {
+//         res, err := client.RequestHandle(ctx, h, work)
+//         ...
+//     }
+//
+func (c *Client) Resolve(ctx context.Context, receiver string) (*Handle, error) {
+	nsReceiver, err := namespaceName(Mailboxes, c.cfg.Namespace, receiver)
+	if err != nil {
+		return nil, err
+	}
+	h := &Handle{
+		receiver:   receiver,
+		nsReceiver: nsReceiver,
+	}
+	err = c.resolve(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// resolve the handle from its registration.
+func (c *Client) resolve(ctx context.Context, h *Handle) error {
+	reg, err := c.registry.FindRegistration(ctx, h.nsReceiver)
+	if err == registry.ErrUnknownKey {
+		return ErrUnregisteredMailbox
+	}
+	if err != nil {
+		return err
+	}
+	h.update(reg)
+	return nil
+}
+
+// invalidate the handle, so that it is resolved again on its
+// next use, and return the address it had.
+func (h *Handle) invalidate() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	address := h.address
+	h.address = ""
+	return address
+}
+
+// RequestHandle (request) a response for the given message, from
+// the mailbox of the handle, see Resolve. The request goes to the
+// handle's address, without changing the address the client has
+// cached for the mailbox. If the request finds that the mailbox
+// moved, or that its peer is gone, the handle is resolved again,
+// which updates its address and epoch, and the request is retried.
+func (c *Client) RequestHandle(ctx context.Context, h *Handle, msg interface{}) (interface{}, error) {
+	return c.RequestC(context.WithValue(ctx, handleKey, h), h.receiver, msg)
+}
+
+// contextHandle of the receiver, nil if the context is not of a
+// request of a handle of the receiver.
+func contextHandle(ctx context.Context, nsReceiver string) *Handle {
+	h, ok := ctx.Value(handleKey).(*Handle)
+	if !ok || h.nsReceiver != nsReceiver {
+		return nil
+	}
+	return h
+}
+
+// handleWireClient for the address of the handle, resolving it
+// again if it was invalidated, or is due a refresh.
+func (c *Client) handleWireClient(ctx context.Context, h *Handle) (*clientAndConn, int64, error) {
+	const noID = -1
+
+	address := h.Address()
+	if !h.refreshed(c.cfg.PeersRefreshInterval) {
+		err := c.resolve(ctx, h)
+		switch {
+		case err == ErrUnregisteredMailbox || err != nil && address == "":
+			return nil, noID, err
+		case err != nil:
+			// The address last resolved is used
+			// until the next refresh.
+			h.postpone()
+		default:
+			address = h.Address()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nextClientAndConn(address)
+}
+
+// wireClientFor the receiver, at the address of its handle if it
+// has one, otherwise at the address cached by the client.
+func (c *Client) wireClientFor(ctx context.Context, nsReceiver string, h *Handle) (*clientAndConn, int64, error) {
+	if h == nil {
+		return c.getWireClient(ctx, nsReceiver)
+	}
+	return c.handleWireClient(ctx, h)
+}
+
+// forgetAddress of the receiver, or invalidate its handle.
+func (c *Client) forgetAddress(nsReceiver string, h *Handle) {
+	if h == nil {
+		c.deleteAddress(nsReceiver)
+		return
+	}
+	h.invalidate()
+}
+
+// replaceClientAndConn of the receiver, of its handle if it has one,
+// which is also invalidated.
+func (c *Client) replaceClientAndConn(nsReceiver string, h *Handle, clientID int64) {
+	if h == nil {
+		c.deleteClientAndConn(nsReceiver, clientID)
+		return
+	}
+	address := h.invalidate()
+	if address == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeClientAndConn(address, clientID)
+}
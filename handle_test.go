@@ -0,0 +1,111 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lytics/grid/registry"
+)
+
+func TestHandleUpdate(t *testing.T) {
+	h := &Handle{receiver: "worker-1"}
+	h.update(&registry.Registration{Address: "localhost:7777", Epoch: 2})
+	if h.Receiver() != "worker-1" || h.Address() != "localhost:7777" || h.Epoch() != 2 {
+		t.Fatalf("unexpected handle: %v, %v, %v", h.Receiver(), h.Address(), h.Epoch())
+	}
+}
+
+func TestHandleAddress(t *testing.T) {
+	c := &Client{addresses: map[string]string{"ns.mailbox.a": "localhost:8888"}}
+	h := &Handle{receiver: "a", nsReceiver: "ns.mailbox.a", address: "localhost:7777"}
+
+	ctx := context.WithValue(context.Background(), handleKey, h)
+	if contextHandle(ctx, "ns.mailbox.a") != h {
+		t.Fatal("expected handle of the receiver")
+	}
+	if contextHandle(ctx, "ns.mailbox.b") != nil {
+		t.Fatal("expected no handle of another receiver")
+	}
+
+	// Forgetting the address of a handle's request
+	// invalidates the handle, and leaves the address
+	// cached by the client alone.
+	c.forgetAddress("ns.mailbox.a", h)
+	if h.Address() != "" {
+		t.Fatalf("expected invalidated handle, got: %v", h.Address())
+	}
+	if address := c.addresses["ns.mailbox.a"]; address != "localhost:8888" {
+		t.Fatalf("expected cached address, got: %v", address)
+	}
+	c.forgetAddress("ns.mailbox.a", nil)
+	if _, ok := c.addresses["ns.mailbox.a"]; ok {
+		t.Fatal("expected cached address to be deleted")
+	}
+}
+
+func TestHandleRefreshed(t *testing.T) {
+	h := &Handle{receiver: "worker-1"}
+	if h.refreshed(time.Minute) {
+		t.Fatal("expected unresolved handle to be refreshed")
+	}
+	h.update(&registry.Registration{Address: "localhost:7777"})
+	if !h.refreshed(time.Minute) {
+		t.Fatal("expected resolved handle not to be refreshed")
+	}
+	if h.refreshed(0) {
+		t.Fatal("expected old handle to be refreshed")
+	}
+	h.invalidate()
+	if h.refreshed(time.Minute) {
+		t.Fatal("expected invalidated handle to be refreshed")
+	}
+}
+
+func TestHandleTakeover(t *testing.T) {
+	etcd, server, client := bootstrapClientTest(t)
+	defer etcd.Close()
+	defer server.Stop()
+	defer client.Close()
+
+	echo := func(box *Mailbox) {
+		for req := range box.C {
+			req.Respond(req.Msg())
+		}
+	}
+	first, err := NewMailbox(server, "worker", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	go echo(first)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	h, err := client.Resolve(ctx, "worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Epoch() != 0 {
+		t.Fatalf("expected first epoch, got: %v", h.Epoch())
+	}
+
+	// The mailbox is taken over on the same peer, so the
+	// handle's address stays the same, and it learns of
+	// the new epoch once it is refreshed.
+	second, err := NewMailbox(server, "worker", 10, OpTakeoverSteal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	go echo(second)
+
+	time.Sleep(client.cfg.PeersRefreshInterval)
+	if _, err := client.RequestHandle(ctx, h, &EchoMsg{Msg: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if h.Epoch() != 1 {
+		t.Fatalf("expected epoch of takeover, got: %v", h.Epoch())
+	}
+}
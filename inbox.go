@@ -0,0 +1,323 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+	"github.com/golang/protobuf/proto"
+)
+
+// inboxes is the key space of the messages of durable mailboxes.
+const inboxes EntityType = "inbox"
+
+// redeliverInterval between attempts to redeliver a stored message
+// into a durable mailbox that is full.
+const redeliverInterval = 10 * time.Millisecond
+
+// StoredMessage of a durable mailbox, see MessageStore.
+type StoredMessage struct {
+	// ID of the message, unique in its mailbox.
+	ID string
+	// Data of the message, opaque to the store.
+	Data []byte
+}
+
+// MessageStore persists the messages of durable mailboxes until they
+// are handled, see NewDurableMailbox. The default store keeps them in
+// etcd, which suits small messages; a store backed by, for example, a
+// database or an object store suits larger ones.
+type MessageStore interface {
+	// Put the message of the mailbox.
+	Put(ctx context.Context, mailbox string, msg StoredMessage) error
+	// Delete the message of the mailbox.
+	Delete(ctx context.Context, mailbox, id string) error
+	// List the messages of the mailbox, ordered by ID.
+	List(ctx context.Context, mailbox string) ([]StoredMessage, error)
+}
+
+// NewDurableMailbox is NewMailbox, for a mailbox whose messages are
+// persisted in the server's MessageStore, see ServerCfg.MessageStore,
+// until the receiver acks or responds to them. When the mailbox is
+// created again, on this or any other peer, for example after the
+// peer that had it died, the messages that were never handled are
+// redelivered into it, so each message is received at least once.
+// Redelivered messages are put into the mailbox as requests whose
+// responses go nowhere, see IsRedelivery, and receivers must expect
+// to receive a message more than once.
+//
+// Putting a message into a durable mailbox waits for it to be
+// persisted, so the receiver is busy to senders while the store
+// is unavailable.
+//
+// Example usage:
+//
+//     mailbox, err := grid.NewDurableMailbox(server, "billing", 100)
+//     ...
+//     defer mailbox.Close()
+//     for req := range mailbox.C {
+//         err := charge(req.Msg())
+//         if err != nil {
+//             continue // Redelivered when the mailbox is created again.
+//         }
+//         req.Ack()
+//     }
+//
+func NewDurableMailbox(s *Server, name string, size int, options ...MailboxOption) (*Mailbox, error) {
+	store := s.cfg.MessageStore
+	if store == nil {
+		store = &etcdMessageStore{etcd: s.etcd, namespace: s.cfg.Namespace}
+	}
+	in := &inbox{
+		server:  s,
+		store:   store,
+		kms:     s.cfg.KMS,
+		mailbox: name,
+		timeout: s.cfg.Timeout,
+	}
+	box, err := openMailbox(s, name, size, in, 0, options)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	stored, err := store.List(ctx, name)
+	cancel()
+	if err != nil {
+		box.Close()
+		return nil, err
+	}
+	if len(stored) > 0 {
+		go box.redeliver(stored)
+	}
+	return box, nil
+}
+
+// IsRedelivery if the request is a message of a durable mailbox that
+// was not handled by a previous mailbox of the same name, see
+// NewDurableMailbox.
+func IsRedelivery(req Request) bool {
+	r, ok := req.(*request)
+	return ok && r.redelivered
+}
+
+// inbox of a durable mailbox, persisting its messages
+// until they are handled.
+type inbox struct {
+	server  *Server
+	store   MessageStore
+	kms     KMS
+	mailbox string
+	timeout time.Duration
+	seq     uint64
+}
+
+// put the message of the request into the store, and
+// return the ID it is stored by.
+func (in *inbox) put(req *request) (string, error) {
+	raw, err := encode(req.codec, req.msg)
+	if err != nil {
+		return "", err
+	}
+	buf, err := proto.Marshal(&Delivery{
+		Ver:       protocolVersion,
+		Data:      raw.Data,
+		TypeName:  raw.TypeName,
+		Codec:     raw.Codec,
+		FromPeer:  req.from.Peer,
+		FromActor: req.from.Actor,
+		Lineage:   req.from.Lineage,
+		Tenant:    req.from.Tenant,
+	})
+	if err != nil {
+		return "", err
+	}
+	buf, err = seal(req.ctx, in.kms, buf)
+	if err != nil {
+		return "", err
+	}
+	// IDs sort in the order messages are put, within a
+	// mailbox, and closely enough across mailboxes.
+	id := fmt.Sprintf("%020d-%010d", time.Now().UnixNano(), atomic.AddUint64(&in.seq, 1))
+	err = in.store.Put(req.ctx, in.mailbox, StoredMessage{ID: id, Data: buf})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// remove the message from the store, once handled.
+func (in *inbox) remove(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), in.timeout)
+	defer cancel()
+	return in.store.Delete(ctx, in.mailbox, id)
+}
+
+// request of the stored message, for redelivery.
+func (in *inbox) request(stored StoredMessage) (*request, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), in.timeout)
+	buf, err := open(ctx, in.kms, stored.Data)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	d := &Delivery{}
+	err = proto.Unmarshal(buf, d)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := decode(d.Codec, d.TypeName, d.Data)
+	if err != nil {
+		return nil, err
+	}
+	req := newRequest(context.Background(), msg, &Provenance{
+		Peer:    d.FromPeer,
+		Actor:   d.FromActor,
+		Lineage: d.Lineage,
+		Tenant:  d.Tenant,
+	})
+	req.redelivered = true
+	req.inbox = in
+	req.stored = stored.ID
+	return req, nil
+}
+
+// persist the message of the request, if the mailbox is
+// durable and the message is not already stored.
+func (box *Mailbox) persist(req *request) error {
+	if box.inbox == nil || req.inbox != nil {
+		return nil
+	}
+	id, err := box.inbox.put(req)
+	if err != nil {
+		return err
+	}
+	req.inbox = box.inbox
+	req.stored = id
+	return nil
+}
+
+// unpersist the message of a request the mailbox did not
+// accept, since its sender is told the receiver is busy.
+func (box *Mailbox) unpersist(req *request) {
+	if req.inbox == nil || req.redelivered {
+		return
+	}
+	err := req.inbox.remove(req.stored)
+	if err != nil && box.server != nil {
+		box.server.logf("%v: mailbox: %v, failed to remove message: %v: %v", box.server.cfg.Namespace, box.name, req.stored, err)
+	}
+	req.inbox = nil
+	req.stored = ""
+}
+
+// redeliver the stored messages into the mailbox, waiting for room
+// while it is full, until it is closed. Messages that could not be
+// redelivered stay stored, for the next mailbox of the same name.
+func (box *Mailbox) redeliver(stored []StoredMessage) {
+	for _, msg := range stored {
+		req, err := box.inbox.request(msg)
+		if err != nil {
+			box.server.logf("%v: mailbox: %v, dropped redelivery of message: %v: %v", box.server.cfg.Namespace, box.name, msg.ID, err)
+			continue
+		}
+		for {
+			err = box.put(req)
+			if err == nil {
+				break
+			}
+			if box.isClosed() {
+				return
+			}
+			if err != ErrReceiverBusy {
+				box.server.logf("%v: mailbox: %v, dropped redelivery of message: %v: %v", box.server.cfg.Namespace, box.name, msg.ID, err)
+				break
+			}
+			time.Sleep(redeliverInterval)
+		}
+	}
+}
+
+// isClosed if the mailbox is closed.
+func (box *Mailbox) isClosed() bool {
+	box.mu.RLock()
+	defer box.mu.RUnlock()
+	return box.closed
+}
+
+// handledStored removes the message of the request from the
+// store, now that it is handled, if it was stored. It is called
+// without holding the lock of the request, since the store may
+// be slow to answer.
+func (req *request) handledStored() {
+	req.mu.Lock()
+	in, id := req.inbox, req.stored
+	if !req.finished || in == nil {
+		req.mu.Unlock()
+		return
+	}
+	req.inbox = nil
+	req.mu.Unlock()
+
+	err := in.remove(id)
+	if err != nil && in.server != nil {
+		// Left stored, the message is redelivered when
+		// the mailbox is created again.
+		in.server.logf("%v: mailbox: %v, failed to remove handled message: %v: %v", in.server.cfg.Namespace, in.mailbox, id, err)
+	}
+}
+
+// etcdMessageStore keeps the messages of durable mailboxes in etcd.
+type etcdMessageStore struct {
+	etcd      *etcdv3.Client
+	namespace string
+}
+
+// prefix of the keys of the mailbox's messages.
+func (st *etcdMessageStore) prefix(mailbox string) (string, error) {
+	nsName, err := namespaceName(inboxes, st.namespace, mailbox)
+	if err != nil {
+		return "", err
+	}
+	return nsName + ".", nil
+}
+
+func (st *etcdMessageStore) Put(ctx context.Context, mailbox string, msg StoredMessage) error {
+	prefix, err := st.prefix(mailbox)
+	if err != nil {
+		return err
+	}
+	_, err = st.etcd.Put(ctx, prefix+msg.ID, string(msg.Data))
+	return err
+}
+
+func (st *etcdMessageStore) Delete(ctx context.Context, mailbox, id string) error {
+	prefix, err := st.prefix(mailbox)
+	if err != nil {
+		return err
+	}
+	_, err = st.etcd.Delete(ctx, prefix+id)
+	return err
+}
+
+func (st *etcdMessageStore) List(ctx context.Context, mailbox string) ([]StoredMessage, error) {
+	prefix, err := st.prefix(mailbox)
+	if err != nil {
+		return nil, err
+	}
+	res, err := st.etcd.Get(ctx, prefix, etcdv3.WithPrefix(), etcdv3.WithSort(etcdv3.SortByKey, etcdv3.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+	stored := make([]StoredMessage, 0, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		stored = append(stored, StoredMessage{
+			ID:   strings.TrimPrefix(string(kv.Key), prefix),
+			Data: kv.Value,
+		})
+	}
+	return stored, nil
+}
@@ -0,0 +1,188 @@
+package grid
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memMessageStore keeps messages in memory, for testing.
+type memMessageStore struct {
+	mu   sync.Mutex
+	msgs map[string]map[string][]byte
+}
+
+func (st *memMessageStore) Put(ctx context.Context, mailbox string, msg StoredMessage) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.msgs[mailbox] == nil {
+		st.msgs[mailbox] = map[string][]byte{}
+	}
+	st.msgs[mailbox][msg.ID] = msg.Data
+	return nil
+}
+
+func (st *memMessageStore) Delete(ctx context.Context, mailbox, id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.msgs[mailbox], id)
+	return nil
+}
+
+func (st *memMessageStore) List(ctx context.Context, mailbox string) ([]StoredMessage, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var stored []StoredMessage
+	for id, data := range st.msgs[mailbox] {
+		stored = append(stored, StoredMessage{ID: id, Data: data})
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].ID < stored[j].ID })
+	return stored, nil
+}
+
+func TestDurableMailbox(t *testing.T) {
+	store := &memMessageStore{msgs: map[string]map[string][]byte{}}
+	newInbox := func() *inbox {
+		return &inbox{store: store, mailbox: "billing", timeout: time.Second}
+	}
+
+	box, _ := newTestMailbox(10)
+	box.inbox = newInbox()
+	for _, msg := range []string{"a", "b", "c"} {
+		err := box.put(newRequest(context.Background(), &EchoMsg{Msg: msg}, &Provenance{Peer: "peer-1"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := (<-box.C).Ack(); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := store.List(context.Background(), "billing")
+	if len(stored) != 2 {
+		t.Fatalf("expected the unhandled messages stored, got: %v", len(stored))
+	}
+
+	// The mailbox created again gets
+	// the messages never handled.
+	next, _ := newTestMailbox(10)
+	next.inbox = newInbox()
+	next.redeliver(stored)
+	for _, expected := range []string{"b", "c"} {
+		req := <-next.C
+		if !IsRedelivery(req) || req.Msg().(*EchoMsg).Msg != expected {
+			t.Fatalf("expected redelivery of: %v, got: %v", expected, req.Msg())
+		}
+		if req.From().Peer != "peer-1" {
+			t.Fatalf("expected provenance of the sender, got: %v", req.From().Peer)
+		}
+		if err := req.Respond(&EchoMsg{Msg: expected}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stored, _ := store.List(context.Background(), "billing"); len(stored) != 0 {
+		t.Fatalf("expected handled messages removed, got: %v", len(stored))
+	}
+
+	// Messages the mailbox did not accept
+	// are not stored.
+	full, _ := newTestMailbox(0)
+	full.inbox = newInbox()
+	err := full.put(newRequest(context.Background(), &EchoMsg{Msg: "d"}, &Provenance{}))
+	if err != ErrReceiverBusy {
+		t.Fatalf("expected receiver busy, got: %v", err)
+	}
+	if stored, _ := store.List(context.Background(), "billing"); len(stored) != 0 {
+		t.Fatalf("expected rejected message not stored, got: %v", len(stored))
+	}
+}
+
+// countingMessageStore counts the messages put into it.
+type countingMessageStore struct {
+	*memMessageStore
+	puts int
+}
+
+func (st *countingMessageStore) Put(ctx context.Context, mailbox string, msg StoredMessage) error {
+	st.puts++
+	return st.memMessageStore.Put(ctx, mailbox, msg)
+}
+
+func TestDurableMailboxFull(t *testing.T) {
+	store := &countingMessageStore{memMessageStore: &memMessageStore{msgs: map[string]map[string][]byte{}}}
+
+	box, _ := newTestMailbox(1)
+	box.inbox = &inbox{store: store, mailbox: "billing", timeout: time.Second}
+	err := box.put(newRequest(context.Background(), &EchoMsg{Msg: "a"}, &Provenance{Peer: "peer-1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = box.put(newRequest(context.Background(), &EchoMsg{Msg: "b"}, &Provenance{Peer: "peer-1"}))
+	if err != ErrReceiverBusy {
+		t.Fatalf("expected receiver busy, got: %v", err)
+	}
+	if store.puts != 1 {
+		t.Fatalf("expected message of full mailbox not persisted, got puts: %v", store.puts)
+	}
+	stored, _ := store.List(context.Background(), "billing")
+	if len(stored) != 1 {
+		t.Fatalf("expected 1 stored message, got: %v", len(stored))
+	}
+}
+
+// slowMessageStore fails to delete messages, once released.
+type slowMessageStore struct {
+	*memMessageStore
+	deleting chan struct{}
+	release  chan struct{}
+}
+
+func (st *slowMessageStore) Delete(ctx context.Context, mailbox, id string) error {
+	close(st.deleting)
+	<-st.release
+	return errors.New("store unavailable")
+}
+
+func TestDurableMailboxRemoveHandled(t *testing.T) {
+	store := &slowMessageStore{
+		memMessageStore: &memMessageStore{msgs: map[string]map[string][]byte{}},
+		deleting:        make(chan struct{}),
+		release:         make(chan struct{}),
+	}
+	captured := &captureLogger{}
+	s := &Server{cfg: ServerCfg{Namespace: "testing", Logger: captured}}
+
+	box, _ := newTestMailbox(1)
+	box.inbox = &inbox{server: s, store: store, mailbox: "billing", timeout: time.Second}
+	err := box.put(newRequest(context.Background(), &EchoMsg{Msg: "a"}, &Provenance{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := <-box.C
+
+	// The request is not locked while its
+	// message is removed from the store.
+	done := make(chan error, 1)
+	go func() {
+		done <- req.Respond(req.Msg())
+	}()
+	<-store.deleting
+	if err := req.Respond(req.Msg()); err == nil {
+		t.Fatal("expected already responded")
+	}
+	close(store.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages left stored are logged.
+	if len(captured.lines) != 1 || !strings.Contains(captured.lines[0], "failed to remove handled message") {
+		t.Fatalf("expected failed removal logged, got: %v", captured.lines)
+	}
+	if stored, _ := store.List(context.Background(), "billing"); len(stored) != 1 {
+		t.Fatalf("expected message left stored, got: %v", len(stored))
+	}
+}
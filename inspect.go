@@ -0,0 +1,291 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lytics/grid/codec"
+)
+
+// PeekMailbox returns up to limit of the messages queued in the
+// mailbox, the next to be received first, without receiving them.
+// A limit of zero returns all queued messages. The data of each
+// message is only included if payload is true. It is meant for
+// operators responding to incidents, such as a poison message
+// wedging an actor, and servers with a Policy only allow it to
+// callers allowed ActionInspect on the mailbox.
+//
+// Example usage:
+//
+//     msgs, err := client.PeekMailbox(ctx, "worker-1", 10, false)
+//     ...
+//     for _, m := range msgs {
+//         fmt.Println(m.Seq, m.TypeName, m.FromActor)
+//     }
+//
+// Mailboxes of servers with an admission policy are listed in
+// the order the messages arrived, which may differ from the
+// order they are received.
+func (c *Client) PeekMailbox(ctx context.Context, mailbox string, limit int, payload bool) ([]*QueuedMessage, error) {
+	peer, err := c.mailboxPeer(ctx, mailbox)
+	if err != nil {
+		return nil, err
+	}
+	res, err := RequestT[*MailboxPeekResult](ctx, c, peer, &MailboxPeek{
+		Mailbox: mailbox,
+		Limit:   int32(limit),
+		Payload: payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Msgs, nil
+}
+
+// RequeueMessages of the mailbox, by their Seq, moving them to the
+// back of the mailbox's queue, so that the messages behind them are
+// received first. An error is returned if any of the messages is
+// no longer queued, the others are still requeued.
+func (c *Client) RequeueMessages(ctx context.Context, mailbox string, seqs ...uint64) error {
+	return c.rearrangeMailbox(ctx, &MailboxRequeue{Mailbox: mailbox, Requeue: seqs})
+}
+
+// DiscardMessages of the mailbox, by their Seq. The senders of the
+// discarded messages get the error ErrMessageDiscarded. An error is
+// returned if any of the messages is no longer queued, the others
+// are still discarded.
+func (c *Client) DiscardMessages(ctx context.Context, mailbox string, seqs ...uint64) error {
+	return c.rearrangeMailbox(ctx, &MailboxRequeue{Mailbox: mailbox, Discard: seqs})
+}
+
+func (c *Client) rearrangeMailbox(ctx context.Context, msg *MailboxRequeue) error {
+	peer, err := c.mailboxPeer(ctx, msg.Mailbox)
+	if err != nil {
+		return err
+	}
+	_, err = RequestT[*Ack](ctx, c, peer, msg)
+	return err
+}
+
+// mailboxPeer is the name of the peer hosting the mailbox.
+func (c *Client) mailboxPeer(ctx context.Context, mailbox string) (string, error) {
+	nsName, err := namespaceName(Mailboxes, c.cfg.Namespace, mailbox)
+	if err != nil {
+		return "", err
+	}
+	reg, err := c.registry.FindRegistration(ctx, nsName)
+	if err != nil {
+		return "", ErrUnregisteredMailbox
+	}
+	return reg.Registry, nil
+}
+
+// peekMailbox of the request, responding with its queued messages.
+func (s *Server) peekMailbox(req Request, msg *MailboxPeek) {
+	mailbox, err := s.inspected(msg.Mailbox)
+	if err != nil {
+		s.respondInspect(req, err)
+		return
+	}
+	res := &MailboxPeekResult{}
+	for _, r := range mailbox.peek(int(msg.Limit)) {
+		qm, err := queuedMessage(r, msg.Payload)
+		if err != nil {
+			s.respondInspect(req, err)
+			return
+		}
+		res.Msgs = append(res.Msgs, qm)
+	}
+	s.respondInspect(req, res)
+}
+
+// requeueMailbox of the request, moving or discarding its messages.
+func (s *Server) requeueMailbox(req Request, msg *MailboxRequeue) {
+	mailbox, err := s.inspected(msg.Mailbox)
+	if err != nil {
+		s.respondInspect(req, err)
+		return
+	}
+	requeue := seqSet(msg.Requeue)
+	discard := seqSet(msg.Discard)
+	discarded, missing := mailbox.rearrange(requeue, discard)
+	for _, r := range discarded {
+		err := r.Respond(ErrMessageDiscarded)
+		if err != nil {
+			s.logf("%v: failed sending response for discarded message: %v", s.cfg.Namespace, err)
+		}
+	}
+	if len(msg.Requeue) > 0 {
+		s.auditOperation(req.Context(), ActionRequeue, msg.Mailbox)
+	}
+	if len(msg.Discard) > 0 {
+		s.auditOperation(req.Context(), ActionDiscard, msg.Mailbox)
+	}
+	if len(missing) > 0 {
+		s.respondInspect(req, fmt.Errorf("%v: %v", ErrUnknownQueuedMessage, missing))
+		return
+	}
+	s.respondInspect(req, constAck)
+}
+
+// inspected mailbox of this server, by its name.
+func (s *Server) inspected(name string) (*Mailbox, error) {
+	nsName, err := namespaceName(Mailboxes, s.cfg.Namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	mailbox, ok := s.mailboxes.get(nsName)
+	if !ok {
+		return nil, ErrUnknownMailbox
+	}
+	return mailbox, nil
+}
+
+func (s *Server) respondInspect(req Request, res interface{}) {
+	err := req.Respond(res)
+	if err != nil {
+		s.logf("%v: failed sending response for mailbox inspection: %v", s.cfg.Namespace, err)
+	}
+}
+
+// queuedMessage describing the request.
+func queuedMessage(r *request, payload bool) (*QueuedMessage, error) {
+	qm := &QueuedMessage{
+		Seq:       r.seq,
+		TypeName:  codec.TypeName(r.msg),
+		FromPeer:  r.from.Peer,
+		FromActor: r.from.Actor,
+		Identity:  r.from.Identity,
+		Queued:    r.queued.UnixNano(),
+	}
+	if deadline, ok := r.ctx.Deadline(); ok {
+		qm.Deadline = deadline.UnixNano()
+	}
+	if payload {
+		raw, err := encode(r.codec, r.msg)
+		if err != nil {
+			return nil, err
+		}
+		qm.Data = raw.Data
+		qm.Codec = raw.Codec
+	}
+	return qm, nil
+}
+
+func seqSet(seqs []uint64) map[uint64]bool {
+	set := make(map[uint64]bool, len(seqs))
+	for _, seq := range seqs {
+		set[seq] = true
+	}
+	return set
+}
+
+// peek at up to limit of the queued requests, all of them
+// if limit is less than one.
+func (box *Mailbox) peek(limit int) []*request {
+	var queued []*request
+	box.inspect(func(reqs []*request) []*request {
+		queued = reqs
+		return reqs
+	})
+	if limit > 0 && len(queued) > limit {
+		queued = queued[:limit]
+	}
+	return queued
+}
+
+// rearrange the queued requests, moving those to requeue to the
+// back, and removing those to discard, which are returned. The
+// seqs not found are returned as missing.
+func (box *Mailbox) rearrange(requeue, discard map[uint64]bool) (discarded []*request, missing []uint64) {
+	found := map[uint64]bool{}
+	box.inspect(func(reqs []*request) []*request {
+		kept := make([]*request, 0, len(reqs))
+		var moved []*request
+		for _, r := range reqs {
+			switch {
+			case discard[r.seq]:
+				found[r.seq] = true
+				discarded = append(discarded, r)
+			case requeue[r.seq]:
+				found[r.seq] = true
+				moved = append(moved, r)
+			default:
+				kept = append(kept, r)
+			}
+		}
+		return append(kept, moved...)
+	})
+	for _, set := range []map[uint64]bool{requeue, discard} {
+		for seq := range set {
+			if !found[seq] {
+				missing = append(missing, seq)
+			}
+		}
+	}
+	return discarded, missing
+}
+
+// inspect the queued requests, in order, replacing them with
+// those returned by f. Puts wait while the requests are out
+// of the mailbox, but the receiver may still take requests
+// that are not yet out.
+func (box *Mailbox) inspect(f func(reqs []*request) []*request) {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+
+	if box.closed {
+		return
+	}
+	box.inspectLocked(f)
+}
+
+// inspectLocked is inspect with the mailbox already locked.
+func (box *Mailbox) inspectLocked(f func(reqs []*request) []*request) {
+	if box.queue != nil {
+		box.queue.inspect(f)
+		return
+	}
+	var reqs []*request
+drain:
+	for {
+		select {
+		case r := <-box.c:
+			reqs = append(reqs, r.(*request))
+		default:
+			break drain
+		}
+	}
+	// The mailbox has room for all of them,
+	// since puts wait on the lock.
+	for _, r := range f(reqs) {
+		box.c <- r
+	}
+}
+
+// inspect the queued requests, in order of arrival, replacing
+// them with those returned by f. Requeued requests are queued
+// anew, so their age is reset.
+func (q *admissionQueue) inspect(f func(reqs []*request) []*request) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	reqs := make([]*request, 0, len(q.items))
+	items := make(map[*request]*admitted, len(q.items))
+	for _, item := range q.items {
+		r := item.req.(*request)
+		reqs = append(reqs, r)
+		items[r] = item
+	}
+	kept := f(reqs)
+	q.items = q.items[:0]
+	now := time.Now()
+	for i, r := range kept {
+		item := items[r]
+		if i > 0 && item.at.Before(q.items[i-1].at) {
+			item.at = now
+		}
+		q.items = append(q.items, item)
+	}
+}
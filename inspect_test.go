@@ -0,0 +1,57 @@
+package grid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMailboxInspect(t *testing.T) {
+	for _, admission := range []bool{false, true} {
+		box := &Mailbox{}
+		if admission {
+			// The queue is not started, so that its
+			// requests stay queued.
+			box.queue = &admissionQueue{policy: &AdmissionPolicy{}, size: 10, ready: make(chan struct{}, 1)}
+		} else {
+			box.c = make(chan Request, 10)
+		}
+
+		var reqs []*request
+		for i := 0; i < 4; i++ {
+			req := newRequest(context.Background(), &EchoMsg{Msg: "hi"}, &Provenance{Actor: "sender"})
+			if err := box.put(req); err != nil {
+				t.Fatal(err)
+			}
+			reqs = append(reqs, req)
+		}
+
+		peeked := box.peek(2)
+		if len(peeked) != 2 || peeked[0].seq != 1 || peeked[1].seq != 2 {
+			t.Fatalf("unexpected peek: %v", peeked)
+		}
+		qm, err := queuedMessage(peeked[0], true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if qm.TypeName != "github.com/lytics/grid/EchoMsg" || qm.FromActor != "sender" || len(qm.Data) == 0 {
+			t.Fatalf("unexpected queued message: %v", qm)
+		}
+
+		// The first is requeued behind the others,
+		// and the second discarded.
+		discarded, missing := box.rearrange(seqSet([]uint64{1}), seqSet([]uint64{2, 9}))
+		if len(discarded) != 1 || discarded[0] != reqs[1] {
+			t.Fatalf("unexpected discarded: %v", discarded)
+		}
+		if len(missing) != 1 || missing[0] != 9 {
+			t.Fatalf("unexpected missing: %v", missing)
+		}
+		var order []uint64
+		for _, r := range box.peek(0) {
+			order = append(order, r.seq)
+		}
+		if len(order) != 3 || order[0] != 3 || order[1] != 4 || order[2] != 1 {
+			t.Fatalf("admission: %v, unexpected order: %v", admission, order)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package grid
+
+import (
+	"context"
+	"time"
+
+	"github.com/lytics/grid/registry"
+)
+
+// lameDucks is the key space of peers that are shutting down.
+const lameDucks EntityType = "lameduck"
+
+// lameDuckCache of the lame duck peers of a namespace, read
+// from etcd at most once per refresh interval.
+type lameDuckCache struct {
+	fetched   time.Time
+	peers     map[string]bool
+	addresses map[string]bool
+}
+
+// LameDuck announces through the registry that the peer is about to
+// shut down. The peer keeps serving, but clients stop routing new
+// requests to it where they have a choice, such as RequestGroup, and
+// queries mark it, see QueryEvent.LameDuck, so that keyed routing can
+// avoid it too. Stop announces it, before draining, so it is only
+// needed to announce earlier, for example on receiving SIGTERM.
+// The announcement is removed with the peer's registration.
+func (s *Server) LameDuck(ctx context.Context) error {
+	if s.registry == nil {
+		return ErrServerNotRunning
+	}
+	key, err := namespaceName(lameDucks, s.cfg.Namespace, s.registry.Registry())
+	if err != nil {
+		return err
+	}
+	return s.registry.Register(ctx, key, registry.OpAllowReentrantRegistration)
+}
+
+// announceLameDuck when the server stops, and give clients the
+// LameDuckDelay to notice before it stops serving.
+func (s *Server) announceLameDuck() {
+	timeout, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	err := s.LameDuck(timeout)
+	cancel()
+	if err != nil {
+		s.logf("%v: failed announcing lame duck: %v", s.cfg.Namespace, err)
+		return
+	}
+	time.Sleep(s.cfg.LameDuckDelay)
+}
+
+// LameDuck if the query event's entity is on a peer that has
+// announced it is shutting down. New work should not be routed
+// to it. Only queries of current entities, not their changes,
+// mark lame ducks.
+func (e *QueryEvent) LameDuck() bool {
+	return e.lame
+}
+
+// cachedLameDucks of the namespace, refreshed if older than
+// the PeersRefreshInterval.
+func (c *Client) cachedLameDucks(ctx context.Context) (*lameDuckCache, error) {
+	c.mu.Lock()
+	cache := c.lameDucks
+	c.mu.Unlock()
+	if cache != nil && time.Since(cache.fetched) < c.cfg.PeersRefreshInterval {
+		return cache, nil
+	}
+
+	prefix, err := namespacePrefix(lameDucks, c.cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	regs, err := c.registry.FindRegistrations(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	cache = &lameDuckCache{
+		fetched:   time.Now(),
+		peers:     make(map[string]bool, len(regs)),
+		addresses: make(map[string]bool, len(regs)),
+	}
+	for _, reg := range regs {
+		cache.peers[reg.Registry] = true
+		cache.addresses[reg.Address] = true
+	}
+	c.mu.Lock()
+	c.lameDucks = cache
+	c.mu.Unlock()
+	return cache, nil
+}
+
+// avoidLameDucks among the members, which are split into those
+// on live peers and those on lame duck peers. Members whose peer
+// cannot be found are taken to be live.
+func (c *Client) avoidLameDucks(ctx context.Context, members []string) ([]string, []string) {
+	lame, err := c.cachedLameDucks(ctx)
+	if err != nil || len(lame.addresses) == 0 {
+		return members, nil
+	}
+	return partitionMembers(members, func(member string) bool {
+		address, err := c.mailboxAddress(ctx, member)
+		return err == nil && lame.addresses[address]
+	})
+}
+
+// mailboxAddress of the peer of the mailbox.
+func (c *Client) mailboxAddress(ctx context.Context, mailbox string) (string, error) {
+	nsName, err := namespaceName(Mailboxes, c.cfg.Namespace, mailbox)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	address, ok := c.addresses[nsName]
+	c.mu.Unlock()
+	if ok {
+		return address, nil
+	}
+	reg, err := c.registry.FindRegistration(ctx, nsName)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.addresses[nsName] = reg.Address
+	c.mu.Unlock()
+	return reg.Address, nil
+}
+
+// partitionMembers into those that are live and those that are lame.
+func partitionMembers(members []string, isLame func(member string) bool) ([]string, []string) {
+	var live, lame []string
+	for _, m := range members {
+		if isLame(m) {
+			lame = append(lame, m)
+		} else {
+			live = append(live, m)
+		}
+	}
+	return live, lame
+}
@@ -0,0 +1,27 @@
+package grid
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPartitionMembers(t *testing.T) {
+	lame := map[string]bool{"worker-1": true, "worker-3": true}
+	live, rest := partitionMembers([]string{"worker-0", "worker-1", "worker-2", "worker-3"}, func(member string) bool {
+		return lame[member]
+	})
+	if !reflect.DeepEqual(live, []string{"worker-0", "worker-2"}) {
+		t.Fatalf("unexpected live members: %v", live)
+	}
+	if !reflect.DeepEqual(rest, []string{"worker-1", "worker-3"}) {
+		t.Fatalf("unexpected lame members: %v", rest)
+	}
+}
+
+func TestLameDuckNotServing(t *testing.T) {
+	s := &Server{cfg: ServerCfg{Namespace: "testing"}}
+	if err := s.LameDuck(context.Background()); err != ErrServerNotRunning {
+		t.Fatalf("expected server not running, got: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package grid
+
+import "sync"
+
+// lanes run functions in the order they are added per key, each
+// key in its own goroutine, so that work for one key that blocks
+// does not hold up the work for other keys, nor whoever adds it.
+// A key's goroutine exits once the key has no more work.
+type lanes struct {
+	mu      sync.Mutex
+	pending map[string][]func()
+}
+
+func newLanes() *lanes {
+	return &lanes{
+		pending: map[string][]func(){},
+	}
+}
+
+// run the function after those already added for the key.
+func (l *lanes) run(key string, fn func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	queue, busy := l.pending[key]
+	l.pending[key] = append(queue, fn)
+	if !busy {
+		go l.drain(key)
+	}
+}
+
+// drain the functions of the key, until it has none.
+func (l *lanes) drain(key string) {
+	for {
+		l.mu.Lock()
+		queue := l.pending[key]
+		if len(queue) == 0 {
+			delete(l.pending, key)
+			l.mu.Unlock()
+			return
+		}
+		fn := queue[0]
+		queue[0] = nil
+		l.pending[key] = queue[1:]
+		l.mu.Unlock()
+		fn()
+	}
+}
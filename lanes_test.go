@@ -0,0 +1,41 @@
+package grid
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLanes(t *testing.T) {
+	l := newLanes()
+
+	// A blocked key does not hold up the others.
+	blocked := make(chan struct{})
+	l.run("slow", func() { <-blocked })
+
+	var mu sync.Mutex
+	var order []int
+	done := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		i := i
+		l.run("fast", func() {
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			if i == 9 {
+				close(done)
+			}
+		})
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected fast key to run while slow key is blocked")
+	}
+	for i, v := range order {
+		if v != i {
+			t.Fatalf("expected functions of a key to run in order, got: %v", order)
+		}
+	}
+	close(blocked)
+}
@@ -0,0 +1,102 @@
+package grid
+
+import "context"
+
+// leaderName of the namespace's leader actor.
+const leaderName = "leader"
+
+// LeaderChange of the peer running the namespace's leader.
+type LeaderChange struct {
+	// Peer running the leader, empty when the leader
+	// has been lost and not yet started again.
+	Peer string
+	// Err when watching the leader failed, no more
+	// changes are sent after an error.
+	Err error
+}
+
+// ContextLeaderChanges returns the changes of the peer running the
+// leader of the actor's namespace, starting with the current peer.
+// Actors that cache assignments made by the leader can use it to
+// refresh them after a failover. The channel is closed when the
+// context finishes, or after an error.
+//
+// Example usage:
+//
+//     changes, err := grid.ContextLeaderChanges(ctx)
+//     ...
+//     for {
+//         select {
+//         case change, ok := <-changes:
+//             if !ok || change.Err != nil {
+//                 return
+//             }
+//             // Refresh assignments from the new leader.
+//         case req := <-mailbox.C:
+//             ...
+//         }
+//     }
+//
+func ContextLeaderChanges(c context.Context) (<-chan *LeaderChange, error) {
+	client, err := ContextClient(c)
+	if err != nil {
+		return nil, err
+	}
+	current, events, err := client.QueryWatch(c, Actors)
+	if err != nil {
+		return nil, err
+	}
+	return leaderChanges(c, current, events), nil
+}
+
+// leaderChanges from the watch of actors.
+func leaderChanges(ctx context.Context, current []*QueryEvent, events <-chan *QueryEvent) <-chan *LeaderChange {
+	changes := make(chan *LeaderChange)
+	put := func(change *LeaderChange) bool {
+		select {
+		case changes <- change:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	go func() {
+		defer close(changes)
+
+		peer := ""
+		for _, e := range current {
+			if e.Name() == leaderName {
+				peer = e.Peer()
+			}
+		}
+		if !put(&LeaderChange{Peer: peer}) {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-events:
+				if e.Err() != nil {
+					put(&LeaderChange{Err: e.Err()})
+					return
+				}
+				if e.Name() != leaderName {
+					continue
+				}
+				next := ""
+				if e.Type == EntityFound {
+					next = e.Peer()
+				}
+				if next == peer {
+					continue
+				}
+				peer = next
+				if !put(&LeaderChange{Peer: peer}) {
+					return
+				}
+			}
+		}
+	}()
+	return changes
+}
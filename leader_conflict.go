@@ -0,0 +1,203 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lytics/grid/registry"
+)
+
+// leaderBeats is the key space in which each peer running the
+// leader keeps a heartbeat. The create revision of a peer's
+// heartbeat is the epoch of its leadership.
+const leaderBeats EntityType = "leaderbeat"
+
+// LeaderConflictPolicy decides how a peer reacts when it finds
+// another peer also running the leader of the namespace, which
+// can happen through clock skew or at the edges of leases.
+type LeaderConflictPolicy int
+
+const (
+	// LeaderStepDown stops the younger of the two leaders, ie:
+	// the one with the larger epoch, the older keeps running.
+	LeaderStepDown LeaderConflictPolicy = 0
+	// LeaderAlertOnly reports the conflict, but keeps both
+	// leaders running.
+	LeaderAlertOnly LeaderConflictPolicy = 1
+)
+
+// LeaderConflict found by a peer running the leader, with another
+// peer also running it. Both peers find the conflict, each with
+// itself as the Peer.
+type LeaderConflict struct {
+	Time      time.Time
+	Namespace string
+	// Peer finding the conflict, and the epoch of its leadership.
+	Peer  string
+	Epoch int64
+	// Other peer running the leader, and the epoch of its leadership.
+	Other      string
+	OtherEpoch int64
+	// SteppedDown if the peer stopped its leader because of
+	// the conflict.
+	SteppedDown bool
+}
+
+// String of the conflict, for logs.
+func (lc *LeaderConflict) String() string {
+	return fmt.Sprintf("%v: duplicate leader, peer: %v, epoch: %v, other: %v, other epoch: %v, stepped down: %v",
+		lc.Namespace, lc.Peer, lc.Epoch, lc.Other, lc.OtherEpoch, lc.SteppedDown)
+}
+
+// leaderTerm of the leader running on this peer.
+type leaderTerm struct {
+	ctx    context.Context
+	cancel func()
+}
+
+// beginLeaderTerm of the leader starting on this peer, the leader
+// runs in the term's context, which is cancelled to step down.
+func (s *Server) beginLeaderTerm() *leaderTerm {
+	ctx, cancel := context.WithCancel(s.ctx)
+	term := &leaderTerm{ctx: ctx, cancel: cancel}
+	s.mu.Lock()
+	s.leader = term
+	s.mu.Unlock()
+	return term
+}
+
+// endLeaderTerm of the leader that stopped on this peer, and
+// remove its heartbeat.
+func (s *Server) endLeaderTerm(term *leaderTerm) {
+	term.cancel()
+	s.mu.Lock()
+	if s.leader == term {
+		s.leader = nil
+	}
+	s.mu.Unlock()
+
+	key, err := namespaceName(leaderBeats, s.cfg.Namespace, s.name())
+	if err != nil {
+		return
+	}
+	timeout, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	s.registry.Deregister(timeout, key)
+	cancel()
+}
+
+// monitorLeaderConflicts periodically, while the leader is
+// running on this peer.
+func (s *Server) monitorLeaderConflicts() {
+	go func() {
+		ticker := time.NewTicker(s.cfg.LeaderHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.leaderHeartbeat()
+			}
+		}
+	}()
+}
+
+// leaderHeartbeat of the leader running on this peer, if any,
+// and comparison of its epoch with those of other leaders.
+func (s *Server) leaderHeartbeat() {
+	s.mu.Lock()
+	term := s.leader
+	s.mu.Unlock()
+	if term == nil {
+		return
+	}
+
+	key, err := namespaceName(leaderBeats, s.cfg.Namespace, s.name())
+	if err != nil {
+		s.logf("%v: invalid leader heartbeat key: %v", s.cfg.Namespace, err)
+		return
+	}
+	prefix, err := namespacePrefix(leaderBeats, s.cfg.Namespace)
+	if err != nil {
+		s.logf("%v: invalid leader heartbeat prefix: %v", s.cfg.Namespace, err)
+		return
+	}
+
+	timeout, cancel := context.WithTimeout(s.ctx, s.cfg.Timeout)
+	defer cancel()
+
+	err = s.registry.Register(timeout, key, registry.OpAllowReentrantRegistration)
+	if err != nil {
+		s.logf("%v: failed leader heartbeat: %v", s.cfg.Namespace, err)
+		return
+	}
+
+	// The term may have ended while registering, after
+	// its heartbeat was removed, in which case the beat
+	// just registered is stale, and would outrank the
+	// leaders of later terms, which would step down.
+	s.mu.Lock()
+	current := s.leader
+	s.mu.Unlock()
+	if current != term {
+		s.registry.Deregister(timeout, key)
+		return
+	}
+
+	beats, err := s.registry.FindRegistrations(timeout, prefix)
+	if err != nil {
+		s.logf("%v: failed finding leader heartbeats: %v", s.cfg.Namespace, err)
+		return
+	}
+
+	conflicts := leaderConflicts(s.cfg.Namespace, s.name(), s.cfg.LeaderConflictPolicy, beats)
+	for _, lc := range conflicts {
+		s.reportLeaderConflict(lc)
+		if lc.SteppedDown {
+			term.cancel()
+		}
+	}
+}
+
+// leaderConflicts of the peer with the other peers that have
+// leader heartbeats. Under the LeaderStepDown policy the peer
+// steps down if any other leader is older than it.
+func leaderConflicts(namespace, peer string, policy LeaderConflictPolicy, beats []*registry.Registration) []*LeaderConflict {
+	var epoch int64
+	for _, beat := range beats {
+		if beat.Registry == peer {
+			epoch = beat.Revision
+		}
+	}
+	if epoch == 0 {
+		return nil
+	}
+
+	var conflicts []*LeaderConflict
+	now := time.Now()
+	for _, beat := range beats {
+		if beat.Registry == peer {
+			continue
+		}
+		conflicts = append(conflicts, &LeaderConflict{
+			Time:        now,
+			Namespace:   namespace,
+			Peer:        peer,
+			Epoch:       epoch,
+			Other:       beat.Registry,
+			OtherEpoch:  beat.Revision,
+			SteppedDown: policy == LeaderStepDown && epoch > beat.Revision,
+		})
+	}
+	return conflicts
+}
+
+// reportLeaderConflict to the server's handler, default is to log it.
+func (s *Server) reportLeaderConflict(lc *LeaderConflict) {
+	if s.cfg.OnLeaderConflict != nil {
+		s.cfg.OnLeaderConflict(lc)
+		return
+	}
+	s.logf("%v", lc)
+}
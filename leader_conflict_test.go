@@ -0,0 +1,47 @@
+package grid
+
+import (
+	"testing"
+
+	"github.com/lytics/grid/registry"
+)
+
+func TestLeaderConflicts(t *testing.T) {
+	beats := []*registry.Registration{
+		{Registry: "peer-1", Revision: 10},
+		{Registry: "peer-2", Revision: 20},
+	}
+
+	// The older leader keeps running.
+	conflicts := leaderConflicts("testing", "peer-1", LeaderStepDown, beats)
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got: %v", len(conflicts))
+	}
+	lc := conflicts[0]
+	if lc.Other != "peer-2" || lc.Epoch != 10 || lc.OtherEpoch != 20 {
+		t.Fatalf("unexpected conflict: %v", lc)
+	}
+	if lc.SteppedDown {
+		t.Fatal("expected older leader to keep running")
+	}
+
+	// The younger leader steps down.
+	conflicts = leaderConflicts("testing", "peer-2", LeaderStepDown, beats)
+	if len(conflicts) != 1 || !conflicts[0].SteppedDown {
+		t.Fatalf("expected younger leader to step down, got: %v", conflicts)
+	}
+
+	// Unless only alerting.
+	conflicts = leaderConflicts("testing", "peer-2", LeaderAlertOnly, beats)
+	if len(conflicts) != 1 || conflicts[0].SteppedDown {
+		t.Fatalf("expected only an alert, got: %v", conflicts)
+	}
+
+	// No conflict for a single leader, nor for a peer without a heartbeat.
+	if conflicts := leaderConflicts("testing", "peer-1", LeaderStepDown, beats[:1]); len(conflicts) != 0 {
+		t.Fatalf("expected no conflicts, got: %v", conflicts)
+	}
+	if conflicts := leaderConflicts("testing", "peer-3", LeaderStepDown, beats); len(conflicts) != 0 {
+		t.Fatalf("expected no conflicts, got: %v", conflicts)
+	}
+}
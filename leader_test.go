@@ -0,0 +1,43 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLeaderChanges(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	current := []*QueryEvent{
+		{name: "worker-1", peer: "peer-2", entity: Actors, Type: EntityFound},
+		{name: leaderName, peer: "peer-1", entity: Actors, Type: EntityFound},
+	}
+	events := make(chan *QueryEvent)
+	changes := leaderChanges(ctx, current, events)
+
+	expect := func(peer string) {
+		t.Helper()
+		change := <-changes
+		if change.Err != nil || change.Peer != peer {
+			t.Fatalf("expected leader on: %q, got: %+v", peer, change)
+		}
+	}
+	expect("peer-1")
+
+	// Other actors are ignored.
+	events <- &QueryEvent{name: "worker-2", peer: "peer-1", entity: Actors, Type: EntityFound}
+	events <- &QueryEvent{name: leaderName, entity: Actors, Type: EntityLost}
+	expect("")
+	events <- &QueryEvent{name: leaderName, peer: "peer-2", entity: Actors, Type: EntityFound}
+	expect("peer-2")
+
+	events <- &QueryEvent{err: ErrWatchClosedUnexpectedly}
+	if change := <-changes; change.Err != ErrWatchClosedUnexpectedly {
+		t.Fatalf("expected watch error, got: %+v", change)
+	}
+	if _, ok := <-changes; ok {
+		t.Fatal("expected changes to be closed after an error")
+	}
+}
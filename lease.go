@@ -0,0 +1,146 @@
+package grid
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+)
+
+// leases is the key space of leases on external resources.
+const leases EntityType = "lease"
+
+// Lease on an external resource, such as a Kinesis shard or a
+// directory. The lease is kept alive in etcd for as long as the
+// process holding it is alive, and is released automatically
+// when the process dies or loses contact with etcd.
+type Lease struct {
+	mu       sync.Mutex
+	resource string
+	key      string
+	id       etcdv3.LeaseID
+	lease    etcdv3.Lease
+	timeout  time.Duration
+	cancel   func()
+	done     chan struct{}
+	released bool
+}
+
+// AcquireLease on the named resource with the given time-to-live.
+// The TTL is how long the lease will outlive its holder if the
+// holder dies without releasing it. If another holder already
+// has the lease ErrLeaseHeld is returned.
+//
+// Example usage:
+//
+//     lease, err := client.AcquireLease(ctx, "shard-0001", 30*time.Second)
+//     ...
+//     defer lease.Release()
+//
+//     for {
+//         select {
+//         case <-lease.Done():
+//             // Ownership lost, stop using the resource.
+//             return
+//         ...
+//         }
+//     }
+//
+func (c *Client) AcquireLease(ctx context.Context, resource string, ttl time.Duration) (*Lease, error) {
+	if ttl < time.Second {
+		return nil, ErrInvalidLeaseTTL
+	}
+	key, err := namespaceName(leases, c.cfg.Namespace, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lease client is closed when the lease is
+	// released, or when acquiring it fails, since it
+	// keeps goroutines of its own.
+	lease := etcdv3.NewLease(c.etcd)
+	grant, err := lease.Grant(ctx, int64(ttl.Seconds()))
+	if err != nil {
+		lease.Close()
+		return nil, err
+	}
+
+	// Claim the key only if no one else has, the key
+	// is bound to the lease, so it disappears with
+	// the lease.
+	txnRes, err := c.etcd.Txn(ctx).
+		If(etcdv3.Compare(etcdv3.Version(key), "=", 0)).
+		Then(etcdv3.OpPut(key, resource, etcdv3.WithLease(grant.ID))).
+		Commit()
+	if err == nil && !txnRes.Succeeded {
+		err = ErrLeaseHeld
+	}
+	if err != nil {
+		timeout, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+		lease.Revoke(timeout, grant.ID)
+		cancel()
+		lease.Close()
+		return nil, err
+	}
+
+	keepAliveCtx, cancel := context.WithCancel(context.Background())
+	keepAlive, err := lease.KeepAlive(keepAliveCtx, grant.ID)
+	if err != nil {
+		cancel()
+		timeout, cancelRevoke := context.WithTimeout(context.Background(), c.cfg.Timeout)
+		lease.Revoke(timeout, grant.ID)
+		cancelRevoke()
+		lease.Close()
+		return nil, err
+	}
+
+	l := &Lease{
+		resource: resource,
+		key:      key,
+		id:       grant.ID,
+		lease:    lease,
+		timeout:  c.cfg.Timeout,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(l.done)
+		for range keepAlive {
+		}
+	}()
+	return l, nil
+}
+
+// Resource name of the lease.
+func (l *Lease) Resource() string {
+	return l.resource
+}
+
+// Done is closed when the lease is no longer held, either
+// because it was released, or because it could not be
+// kept alive.
+func (l *Lease) Done() <-chan struct{} {
+	return l.done
+}
+
+// Release the lease, so that others may acquire it.
+func (l *Lease) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.released {
+		return nil
+	}
+	l.released = true
+	l.cancel()
+
+	timeout, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+	_, err := l.lease.Revoke(timeout, l.id)
+	closeErr := l.lease.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,61 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lytics/grid/testetcd"
+)
+
+func TestAcquireLease(t *testing.T) {
+	const timeout = 2 * time.Second
+
+	etcd := testetcd.StartAndConnect(t)
+	defer etcd.Close()
+
+	client, err := NewClient(etcd, ClientCfg{Namespace: newNamespace()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	lease, err := client.AcquireLease(ctx, "shard-0001", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Second acquire must fail while the first is held.
+	_, err = client.AcquireLease(ctx, "shard-0001", 10*time.Second)
+	if err != ErrLeaseHeld {
+		t.Fatalf("expected lease held error, got: %v", err)
+	}
+
+	err = lease.Release()
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-time.After(timeout):
+		t.Fatal("timeout")
+	case <-lease.Done():
+	}
+
+	// After release someone else can acquire it.
+	lease, err = client.AcquireLease(ctx, "shard-0001", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lease.Release()
+}
+
+func TestAcquireLeaseInvalidTTL(t *testing.T) {
+	client := &Client{cfg: ClientCfg{Namespace: newNamespace()}}
+	_, err := client.AcquireLease(context.Background(), "shard-0001", 0)
+	if err != ErrInvalidLeaseTTL {
+		t.Fatal("expected invalid ttl error")
+	}
+}
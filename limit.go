@@ -0,0 +1,106 @@
+package grid
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/peer"
+)
+
+// rateLimiter of requests, keyed by caller, using a token bucket
+// per caller, so that one noisy client cannot monopolize shared
+// actors.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow one request of the caller, at the given time.
+func (rl *rateLimiter) allow(caller string, now time.Time) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.sweep(now)
+
+	b, ok := rl.buckets[caller]
+	if !ok {
+		b = &bucket{tokens: rl.burst, last: now}
+		rl.buckets[caller] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * rl.rate
+	if b.tokens > rl.burst {
+		b.tokens = rl.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// sweep buckets that have refilled completely, since they are
+// no different from a new bucket, so the map does not grow with
+// every caller ever seen.
+func (rl *rateLimiter) sweep(now time.Time) {
+	full := time.Duration(rl.burst / rl.rate * float64(time.Second))
+	if now.Sub(rl.swept) < full {
+		return
+	}
+	rl.swept = now
+	for caller, b := range rl.buckets {
+		if now.Sub(b.last) >= full {
+			delete(rl.buckets, caller)
+		}
+	}
+}
+
+// throttle the request if its caller is over its rate. Callers
+// without an identity are limited by their address.
+func (s *Server) throttle(ctx context.Context, caller string) error {
+	if s.limiter == nil {
+		return nil
+	}
+	if caller == "" {
+		caller = callerAddress(ctx)
+	}
+	if s.limiter.allow(caller, time.Now()) {
+		return nil
+	}
+	return ErrThrottled
+}
+
+// callerAddress is the host of the caller, without the port,
+// since each of a caller's connections has its own port.
+func callerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,35 @@
+package grid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	rl := newRateLimiter(10, 2)
+	now := time.Now()
+
+	// The burst is allowed at once.
+	if !rl.allow("a", now) || !rl.allow("a", now) {
+		t.Fatal("expected burst to be allowed")
+	}
+	if rl.allow("a", now) {
+		t.Fatal("expected caller over rate to be throttled")
+	}
+
+	// Other callers have their own bucket.
+	if !rl.allow("b", now) {
+		t.Fatal("expected other caller to be allowed")
+	}
+
+	// At 10 per second a token returns after 100ms.
+	if !rl.allow("a", now.Add(100*time.Millisecond)) {
+		t.Fatal("expected caller to be allowed after refill")
+	}
+
+	// Idle callers are swept.
+	rl.allow("c", now.Add(time.Minute))
+	if len(rl.buckets) != 1 {
+		t.Fatalf("expected idle buckets to be swept, got: %v", len(rl.buckets))
+	}
+}
@@ -0,0 +1,67 @@
+package grid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// WithLineage returns a copy of the context carrying the lineage,
+// requests sent with the context, or one derived from it, carry
+// the lineage to their receivers, see Provenance. Requests sent
+// with a context without a lineage start a new one.
+func WithLineage(ctx context.Context, lineage string) context.Context {
+	return context.WithValue(ctx, lineageKey, lineage)
+}
+
+// ContextLineage of the context, either the one set by WithLineage,
+// or else that of the request whose context it is, or else empty.
+func ContextLineage(ctx context.Context) string {
+	if lineage, ok := ctx.Value(lineageKey).(string); ok {
+		return lineage
+	}
+	if from, ok := ctx.Value(provenanceKey).(*Provenance); ok {
+		return from.Lineage
+	}
+	return ""
+}
+
+// Derive the context of an actor for sending the messages derived
+// from the request, so that they carry the request's lineage and
+// tenant, see WithTenant, as well as the actor's provenance. The path of a record across actors
+// can then be pieced together by its lineage.
+//
+// Example usage:
+//
+//     func (a *stage) Act(ctx context.Context) {
+//         ...
+//         case req := <-mailbox.C:
+//             next := transform(req.Msg())
+//             res, err := client.RequestC(grid.Derive(ctx, req), "sink", next)
+//             ...
+//             logger.Printf("lineage: %v, sent: %v", req.From().Lineage, next)
+//     }
+//
+func Derive(ctx context.Context, req Request) context.Context {
+	from := req.From()
+	if from == nil {
+		return ctx
+	}
+	if from.Lineage != "" {
+		ctx = WithLineage(ctx, from.Lineage)
+	}
+	if from.Tenant != "" {
+		ctx = WithTenant(ctx, from.Tenant)
+	}
+	return ctx
+}
+
+// newLineage of random hex digits.
+func newLineage() string {
+	var id [16]byte
+	_, err := rand.Read(id[:])
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,46 @@
+package grid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLineageStamp(t *testing.T) {
+	c := &Client{peer: "peer-1"}
+
+	d := &Delivery{}
+	c.stamp(context.Background(), d)
+	if len(d.Lineage) != 32 {
+		t.Fatalf("expected new lineage, got: %q", d.Lineage)
+	}
+
+	d = &Delivery{}
+	c.stamp(WithLineage(context.Background(), "order-1"), d)
+	if d.Lineage != "order-1" {
+		t.Fatalf("expected lineage of context, got: %q", d.Lineage)
+	}
+}
+
+func TestLineageDerive(t *testing.T) {
+	c := &Client{peer: "peer-1"}
+
+	req := newRequest(context.Background(), &EchoMsg{}, &Provenance{Lineage: "order-1"})
+	if lineage := ContextLineage(req.Context()); lineage != "order-1" {
+		t.Fatalf("expected lineage of request context, got: %q", lineage)
+	}
+
+	actor := context.WithValue(context.Background(), contextKey, &contextVal{
+		server:    &Server{},
+		actorName: "stage-1",
+	})
+	d := &Delivery{}
+	c.stamp(Derive(actor, req), d)
+	if d.Lineage != "order-1" || d.FromActor != "stage-1" {
+		t.Fatalf("expected lineage of request from actor, got: %q, %q", d.Lineage, d.FromActor)
+	}
+
+	req = newRequest(context.Background(), &EchoMsg{}, &Provenance{})
+	if ctx := Derive(actor, req); ctx != actor {
+		t.Fatal("expected context unchanged without lineage")
+	}
+}
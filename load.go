@@ -0,0 +1,69 @@
+package grid
+
+import (
+	"sync"
+	"time"
+)
+
+// loadStaleAfter is how long the depth a peer advertised for a
+// mailbox is trusted, after which the mailbox is tried again.
+const loadStaleAfter = 5 * time.Second
+
+// mailboxLoad as last advertised by the mailbox's peer.
+type mailboxLoad struct {
+	depth int
+	at    time.Time
+}
+
+// loadTable of the mailboxes a client sends to, by the depths
+// their peers advertise with each response, so that requests
+// to a group can avoid members queuing behind a hot instance.
+type loadTable struct {
+	mu    sync.Mutex
+	loads map[string]mailboxLoad
+}
+
+func newLoadTable() *loadTable {
+	return &loadTable{loads: map[string]mailboxLoad{}}
+}
+
+// update the load of the receiver from a response to one of its
+// requests, responses of peers that do not advertise their flow,
+// ie: older peers, are ignored.
+func (lt *loadTable) update(receiver string, res *Delivery, now time.Time) {
+	if lt == nil || !res.Flow {
+		return
+	}
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	lt.loads[receiver] = mailboxLoad{depth: int(res.Depth), at: now}
+}
+
+// saturated if the receiver's depth, advertised no longer than
+// loadStaleAfter ago, is at least the depth given.
+func (lt *loadTable) saturated(receiver string, depth int, now time.Time) bool {
+	if lt == nil || depth <= 0 {
+		return false
+	}
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	load, ok := lt.loads[receiver]
+	if !ok || now.Sub(load.at) > loadStaleAfter {
+		return false
+	}
+	return load.depth >= depth
+}
+
+// avoidSaturated members, whose mailboxes have at least the
+// client's SaturatedDepth of requests queued, it returns the
+// members below the depth and those at or above it.
+func (c *Client) avoidSaturated(members []string) ([]string, []string) {
+	if c.cfg.SaturatedDepth <= 0 {
+		return members, nil
+	}
+	now := time.Now()
+	return partitionMembers(members, func(member string) bool {
+		nsName, err := namespaceName(Mailboxes, c.cfg.Namespace, member)
+		return err == nil && c.loads.saturated(nsName, c.cfg.SaturatedDepth, now)
+	})
+}
@@ -0,0 +1,62 @@
+package grid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadTableSaturated(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	lt := newLoadTable()
+	lt.update("ns.mailbox.worker-0", &Delivery{Depth: 10, Flow: true}, now)
+	lt.update("ns.mailbox.worker-1", &Delivery{Depth: 2, Flow: true}, now)
+	lt.update("ns.mailbox.worker-2", &Delivery{Depth: 10}, now)
+
+	if !lt.saturated("ns.mailbox.worker-0", 10, now) {
+		t.Fatal("expected worker-0 saturated")
+	}
+	if lt.saturated("ns.mailbox.worker-1", 10, now) {
+		t.Fatal("expected worker-1 below the depth")
+	}
+	if lt.saturated("ns.mailbox.worker-2", 10, now) {
+		t.Fatal("expected depth of older peer to be ignored")
+	}
+	if lt.saturated("ns.mailbox.worker-0", 10, now.Add(2*loadStaleAfter)) {
+		t.Fatal("expected stale depth to be ignored")
+	}
+	if lt.saturated("ns.mailbox.worker-0", 0, now) {
+		t.Fatal("expected zero depth to disable saturation")
+	}
+
+	var nilTable *loadTable
+	nilTable.update("ns.mailbox.worker-0", &Delivery{Depth: 10, Flow: true}, now)
+	if nilTable.saturated("ns.mailbox.worker-0", 1, now) {
+		t.Fatal("expected nil table to never be saturated")
+	}
+}
+
+func TestAvoidSaturated(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{
+		cfg:   ClientCfg{Namespace: "ns", SaturatedDepth: 5},
+		loads: newLoadTable(),
+	}
+	c.loads.update("ns.mailbox.worker-1", &Delivery{Depth: 8, Flow: true}, time.Now())
+
+	live, hot := c.avoidSaturated([]string{"worker-0", "worker-1", "worker-2"})
+	if len(live) != 2 || live[0] != "worker-0" || live[1] != "worker-2" {
+		t.Fatalf("expected worker-0 and worker-2 live, got: %v", live)
+	}
+	if len(hot) != 1 || hot[0] != "worker-1" {
+		t.Fatalf("expected worker-1 hot, got: %v", hot)
+	}
+
+	c.cfg.SaturatedDepth = 0
+	live, hot = c.avoidSaturated([]string{"worker-0", "worker-1"})
+	if len(live) != 2 || len(hot) != 0 {
+		t.Fatalf("expected no members avoided, got: %v, %v", live, hot)
+	}
+}
@@ -0,0 +1,123 @@
+package grid
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/lytics/grid/codec"
+)
+
+// requestLocal delivers the request in process if the receiver's
+// mailbox is hosted by the client's server, skipping gRPC and the
+// encoding of the message, and returns the response, still encoded.
+// It returns false if the receiver is not
+// local, or is busy, paused or throttled, in which case the request
+// should be sent the usual way, which retries such receivers.
+//
+// Local requests are not authenticated, authorized nor limited by
+// the caller's rate, since the client shares its server's process
+// and credentials. The quotas of their tenant, the faults injected
+// into the server, and the turns taken with the deliveries to other
+// mailboxes apply to them as they do to remote requests.
+func (c *Client) requestLocal(ctx context.Context, nsReceiver string, msg interface{}) (*Delivery, bool, error) {
+	s := c.cfg.Server
+	if s == nil {
+		return nil, false, nil
+	}
+	s.mu.Lock()
+	mailboxes := s.mailboxes
+	s.mu.Unlock()
+	if mailboxes == nil {
+		return nil, false, nil
+	}
+	mailbox, ok := mailboxes.get(nsReceiver)
+	if !ok {
+		return nil, false, nil
+	}
+
+	// The receiver gets its own copy of the message,
+	// as it would when the message is sent over gRPC.
+	local, err := cloneMsg(c.cfg.Codec, msg)
+	if err != nil {
+		return nil, true, err
+	}
+
+	from := &Delivery{}
+	c.stamp(ctx, from)
+	req := newRequest(ctx, local, &Provenance{
+		Peer:    from.FromPeer,
+		Actor:   from.FromActor,
+		Lineage: from.Lineage,
+		Tenant:  from.Tenant,
+	})
+	req.codec = c.cfg.Codec
+	err = s.deliverLocal(ctx, mailbox, req)
+	if err == ErrReceiverBusy || err == ErrDeliveryPaused || err == ErrThrottled {
+		return nil, false, nil
+	}
+	if err == errDropped {
+		// Dropped requests are never answered, so
+		// their senders time out, as remote ones do.
+		<-ctx.Done()
+		return nil, true, ErrContextFinished
+	}
+	if err != nil {
+		return nil, true, err
+	}
+
+	res, err := s.await(ctx, req)
+	if err != nil {
+		return nil, true, err
+	}
+	return res, true, nil
+}
+
+// deliverLocal the request into the mailbox, as deliver does for
+// remote requests, once the client has been trusted.
+func (s *Server) deliverLocal(ctx context.Context, mailbox *Mailbox, req *request) error {
+	err := s.tenants.throttle(req.from.Tenant, time.Now())
+	if err != nil {
+		return err
+	}
+	drop, err := s.injectDelivery(ctx, mailbox)
+	if err != nil {
+		return err
+	}
+	if drop {
+		return errDropped
+	}
+	err = s.sched.acquire(ctx, mailbox.Name())
+	if err != nil {
+		return err
+	}
+	defer s.sched.release()
+
+	err = s.paused(mailbox)
+	if err != nil {
+		return err
+	}
+	req.release, err = s.tenants.hold(req.from.Tenant)
+	if err != nil {
+		return err
+	}
+	err = mailbox.put(req)
+	if err != nil {
+		req.unhold()
+		return err
+	}
+	return nil
+}
+
+// cloneMsg for a receiver in the same process. Protobuf messages
+// are copied directly, others through their encoding with the codec.
+func cloneMsg(c codec.Codec, msg interface{}) (interface{}, error) {
+	if m, ok := msg.(proto.Message); ok {
+		return proto.Clone(m), nil
+	}
+	raw, err := encode(c, msg)
+	if err != nil {
+		return nil, err
+	}
+	return decode(raw.Codec, raw.TypeName, raw.Data)
+}
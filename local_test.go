@@ -0,0 +1,98 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRequestLocal(t *testing.T) {
+	Register(EchoMsg{})
+
+	s := &Server{mailboxes: newMailboxMap(), maint: newMaintenanceState()}
+	boxC := make(chan Request, 1)
+	box := &Mailbox{name: "echo", nsName: "testing.mailbox.echo", C: boxC, c: boxC}
+	s.mailboxes.reserve(box.nsName)
+	s.mailboxes.set(box.nsName, box)
+
+	sent := &EchoMsg{Msg: "hi"}
+	go func() {
+		req := <-box.C
+		if req.Msg() == sent {
+			req.Respond(&EchoMsg{Msg: "not a copy"})
+			return
+		}
+		req.Respond(req.Msg())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	c := &Client{cfg: ClientCfg{Namespace: "testing", Server: s}}
+	res, ok, err := c.requestLocal(ctx, box.nsName, sent)
+	if !ok {
+		t.Fatal("expected local delivery")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := decode(res.Codec, res.TypeName, res.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if echo, ok := reply.(*EchoMsg); !ok || echo.Msg != "hi" {
+		t.Fatalf("unexpected response: %v", reply)
+	}
+
+	_, ok, _ = c.requestLocal(ctx, "testing.mailbox.remote", sent)
+	if ok {
+		t.Fatal("expected remote receiver to not be delivered locally")
+	}
+}
+
+func TestRequestLocalQuotasAndFaults(t *testing.T) {
+	Register(EchoMsg{})
+
+	s := &Server{
+		mailboxes: newMailboxMap(),
+		maint:     newMaintenanceState(),
+		tenants:   newTenantQuotas(TenantQuota{MaxQueued: 1}, nil),
+		faults:    newFaultTable(),
+	}
+	boxC := make(chan Request, 2)
+	box := &Mailbox{name: "echo", nsName: "testing.mailbox.echo", C: boxC, c: boxC}
+	s.mailboxes.reserve(box.nsName)
+	s.mailboxes.set(box.nsName, box)
+
+	c := &Client{cfg: ClientCfg{Namespace: "testing", Server: s}}
+
+	// The tenant's only queued request is held until
+	// it is answered, so the next falls back to the
+	// usual path.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := c.requestLocal(ctx, box.nsName, &EchoMsg{Msg: "held"})
+		done <- err
+	}()
+	held := <-box.C
+	_, ok, _ := c.requestLocal(context.Background(), box.nsName, &EchoMsg{Msg: "over"})
+	if ok {
+		t.Fatal("expected request over the tenant's quota to not be delivered locally")
+	}
+	cancel()
+	<-done
+	held.Respond(held.Msg())
+
+	// Dropped requests are never answered.
+	s.faults.add(Fault{Mailbox: "echo", Drop: 1}, time.Now())
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, ok, err := c.requestLocal(ctx, box.nsName, &EchoMsg{Msg: "dropped"})
+	if !ok || err != ErrContextFinished {
+		t.Fatalf("expected dropped request to time out, got: %v, %v", ok, err)
+	}
+	if len(box.C) != 0 {
+		t.Fatal("expected dropped request to not be put into the mailbox")
+	}
+}
@@ -0,0 +1,87 @@
+package grid
+
+import (
+	"context"
+	"time"
+)
+
+// Well known labels of peers, see WithLabels.
+const (
+	// LabelHost of the machine the peer runs on.
+	LabelHost = "host"
+	// LabelZone of the peer, such as its cloud availability zone.
+	LabelZone = "zone"
+)
+
+// peerLabelCache of the labels of the peers of a namespace, by
+// address, read from etcd at most once per refresh interval.
+type peerLabelCache struct {
+	fetched time.Time
+	labels  map[string]map[string]string
+}
+
+// cachedPeerLabels of the namespace, refreshed if older than
+// the PeersRefreshInterval.
+func (c *Client) cachedPeerLabels(ctx context.Context) (*peerLabelCache, error) {
+	c.mu.Lock()
+	cache := c.peerLabels
+	c.mu.Unlock()
+	if cache != nil && time.Since(cache.fetched) < c.cfg.PeersRefreshInterval {
+		return cache, nil
+	}
+
+	prefix, err := namespacePrefix(Peers, c.cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	regs, err := c.registry.FindRegistrations(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	cache = &peerLabelCache{
+		fetched: time.Now(),
+		labels:  make(map[string]map[string]string, len(regs)),
+	}
+	for _, reg := range regs {
+		cache.labels[reg.Address] = reg.Labels
+	}
+	c.mu.Lock()
+	c.peerLabels = cache
+	c.mu.Unlock()
+	return cache, nil
+}
+
+// byLocality of the members, split into tiers, nearest to the client
+// first: members on peers sharing the client's value of the first
+// label of its Locality, then of the second, and so on, then the
+// rest. Members whose peer cannot be found are in the last tier.
+func (c *Client) byLocality(ctx context.Context, members []string) [][]string {
+	if len(c.cfg.Locality) == 0 || len(c.cfg.Labels) == 0 || len(members) < 2 {
+		return [][]string{members}
+	}
+	cache, err := c.cachedPeerLabels(ctx)
+	if err != nil {
+		return [][]string{members}
+	}
+	tiers := make([][]string, len(c.cfg.Locality)+1)
+	for _, m := range members {
+		rank := len(c.cfg.Locality)
+		if address, err := c.mailboxAddress(ctx, m); err == nil {
+			rank = localityRank(c.cfg.Locality, c.cfg.Labels, cache.labels[address])
+		}
+		tiers[rank] = append(tiers[rank], m)
+	}
+	return tiers
+}
+
+// localityRank of a peer with the labels, relative to the client with
+// its own labels: the index of the first of the keys whose value they
+// share, or the number of keys if they share none.
+func localityRank(keys []string, own, labels map[string]string) int {
+	for i, k := range keys {
+		if v := own[k]; v != "" && labels[k] == v {
+			return i
+		}
+	}
+	return len(keys)
+}
@@ -0,0 +1,69 @@
+package grid
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLocalityRank(t *testing.T) {
+	keys := []string{LabelHost, LabelZone}
+	own := map[string]string{LabelHost: "node-1", LabelZone: "us-east-1a"}
+
+	cases := []struct {
+		labels map[string]string
+		rank   int
+	}{
+		{map[string]string{LabelHost: "node-1", LabelZone: "us-east-1a"}, 0},
+		{map[string]string{LabelHost: "node-2", LabelZone: "us-east-1a"}, 1},
+		{map[string]string{LabelHost: "node-3", LabelZone: "us-east-1b"}, 2},
+		{nil, 2},
+	}
+	for _, c := range cases {
+		if rank := localityRank(keys, own, c.labels); rank != c.rank {
+			t.Fatalf("labels: %v, expected rank: %v, got: %v", c.labels, c.rank, rank)
+		}
+	}
+
+	// Labels the client does not have never match.
+	if rank := localityRank(keys, map[string]string{LabelZone: "us-east-1a"}, map[string]string{LabelHost: ""}); rank != 2 {
+		t.Fatalf("expected no match on missing label, got: %v", rank)
+	}
+}
+
+func TestByLocality(t *testing.T) {
+	c := &Client{
+		cfg: ClientCfg{
+			Namespace:            "testing",
+			PeersRefreshInterval: time.Minute,
+			Labels:               map[string]string{LabelHost: "node-1", LabelZone: "us-east-1a"},
+			Locality:             []string{LabelHost, LabelZone},
+		},
+		addresses: map[string]string{
+			"testing.mailbox.w-0": "10.0.0.3:7777",
+			"testing.mailbox.w-1": "10.0.0.2:7777",
+			"testing.mailbox.w-2": "10.0.0.1:7777",
+		},
+		peerLabels: &peerLabelCache{
+			fetched: time.Now(),
+			labels: map[string]map[string]string{
+				"10.0.0.1:7777": {LabelHost: "node-1", LabelZone: "us-east-1a"},
+				"10.0.0.2:7777": {LabelHost: "node-2", LabelZone: "us-east-1a"},
+				"10.0.0.3:7777": {LabelHost: "node-3", LabelZone: "us-east-1b"},
+			},
+		},
+	}
+	tiers := c.byLocality(context.Background(), []string{"w-0", "w-1", "w-2"})
+	expected := [][]string{{"w-2"}, {"w-1"}, {"w-0"}}
+	if !reflect.DeepEqual(tiers, expected) {
+		t.Fatalf("expected tiers: %v, got: %v", expected, tiers)
+	}
+
+	// Without a locality, members are in one tier.
+	c.cfg.Locality = nil
+	tiers = c.byLocality(context.Background(), []string{"w-0", "w-1", "w-2"})
+	if len(tiers) != 1 || len(tiers[0]) != 3 {
+		t.Fatalf("expected one tier, got: %v", tiers)
+	}
+}
@@ -0,0 +1,150 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// logBuffer of actor logs waiting to be forwarded,
+	// beyond which logs are dropped, rather than
+	// slowing down the actors.
+	logBuffer = 1000
+	// logBatch is the most actor logs forwarded
+	// in one request to the collector.
+	logBatch = 100
+)
+
+// ActorLogger of an actor, whose lines are tagged with the actor's
+// name and type, so that the interleaved logs of the many actors of
+// a peer can be told apart. Lines are written to the server's Logger,
+// and forwarded to its LogCollector, if it has one.
+type ActorLogger struct {
+	server    *Server
+	name      string
+	actorType string
+	forward   bool
+}
+
+// ContextLogger returns the logger of the actor associated with this
+// context. It is also an io.Writer, so that it can be used wherever
+// an actor's output would otherwise go to stdout or stderr.
+//
+// Example usage:
+//
+//     func (a *worker) Act(ctx context.Context) {
+//         logger, err := grid.ContextLogger(ctx)
+//         ...
+//         logger.Printf("starting work: %v", id)
+//     }
+//
+func ContextLogger(c context.Context) (*ActorLogger, error) {
+	v := c.Value(contextKey)
+	if v == nil {
+		return nil, ErrInvalidContext
+	}
+	cv, ok := v.(*contextVal)
+	if !ok || cv.logger == nil {
+		return nil, ErrInvalidContext
+	}
+	return cv.logger, nil
+}
+
+// actorLogger of the actor. The logs of the collector itself are
+// not forwarded, since logging them would forward more logs.
+func (s *Server) actorLogger(start *ActorStart) *ActorLogger {
+	return &ActorLogger{
+		server:    s,
+		name:      start.Name,
+		actorType: start.Type,
+		forward:   s.cfg.LogCollector != "" && s.cfg.LogCollector != start.Name,
+	}
+}
+
+// Printf a line of the actor's log.
+func (l *ActorLogger) Printf(format string, v ...interface{}) {
+	line := strings.TrimSuffix(fmt.Sprintf(format, v...), "\n")
+	l.server.logf("%v: actor: %v, type: %v: %v", l.server.cfg.Namespace, l.name, l.actorType, line)
+	if l.forward {
+		l.server.logs.put(&ActorLog{
+			Peer:  l.server.name(),
+			Actor: l.name,
+			Type:  l.actorType,
+			Time:  time.Now().UnixNano(),
+			Line:  line,
+		})
+	}
+}
+
+// Write the output as lines of the actor's log.
+func (l *ActorLogger) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		l.Printf("%s", line)
+	}
+	return len(p), nil
+}
+
+// logForwarder of actor logs, to the server's log collector.
+type logForwarder struct {
+	c       chan *ActorLog
+	dropped int64
+}
+
+func newLogForwarder(size int) *logForwarder {
+	return &logForwarder{c: make(chan *ActorLog, size)}
+}
+
+// put the log, dropping it if the buffer is full.
+func (f *logForwarder) put(log *ActorLog) {
+	select {
+	case f.c <- log:
+	default:
+		atomic.AddInt64(&f.dropped, 1)
+	}
+}
+
+// batch of the first log and those waiting behind it,
+// with the number of logs dropped since the last batch.
+func (f *logForwarder) batch(first *ActorLog) *ActorLogs {
+	batch := &ActorLogs{Logs: []*ActorLog{first}}
+more:
+	for len(batch.Logs) < logBatch {
+		select {
+		case log := <-f.c:
+			batch.Logs = append(batch.Logs, log)
+		default:
+			break more
+		}
+	}
+	batch.Dropped = atomic.SwapInt64(&f.dropped, 0)
+	return batch
+}
+
+// forwardLogs of actors to the log collector, if the server has
+// one. Batches the collector fails to take count as dropped. The
+// collector must respond to each batch, for example with an Ack.
+func (s *Server) forwardLogs() {
+	if s.cfg.LogCollector == "" {
+		return
+	}
+	go func() {
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case first := <-s.logs.c:
+				batch := s.logs.batch(first)
+				timeout, cancel := context.WithTimeout(s.ctx, s.cfg.Timeout)
+				_, err := s.client.RequestC(timeout, s.cfg.LogCollector, batch)
+				cancel()
+				if err != nil {
+					atomic.AddInt64(&s.logs.dropped, int64(len(batch.Logs))+batch.Dropped)
+					s.logf("%v: failed forwarding actor logs to collector: %v: %v", s.cfg.Namespace, s.cfg.LogCollector, err)
+				}
+			}
+		}
+	}()
+}
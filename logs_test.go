@@ -0,0 +1,64 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+)
+
+type captureLogger struct {
+	lines []string
+}
+
+func (l *captureLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestContextLogger(t *testing.T) {
+	captured := &captureLogger{}
+	s := &Server{
+		cfg:  ServerCfg{Namespace: "testing", Logger: captured, LogCollector: "collector"},
+		logs: newLogForwarder(2),
+	}
+	worker := &contextVal{server: s, logger: s.actorLogger(&ActorStart{Name: "worker-1", Type: "worker"})}
+	ctx := context.WithValue(context.Background(), contextKey, worker)
+
+	logger, err := ContextLogger(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Printf("started: %v", 1)
+	log.New(logger, "", 0).Println("from stdlib")
+	logger.Printf("dropped")
+
+	expected := []string{
+		"testing: actor: worker-1, type: worker: started: 1",
+		"testing: actor: worker-1, type: worker: from stdlib",
+		"testing: actor: worker-1, type: worker: dropped",
+	}
+	if strings.Join(captured.lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected: %v, got: %v", expected, captured.lines)
+	}
+
+	// The buffer holds two logs, the third is dropped.
+	batch := s.logs.batch(<-s.logs.c)
+	if len(batch.Logs) != 2 || batch.Dropped != 1 {
+		t.Fatalf("expected 2 logs and 1 dropped, got: %v", batch)
+	}
+	if l := batch.Logs[1]; l.Actor != "worker-1" || l.Type != "worker" || l.Line != "from stdlib" {
+		t.Fatalf("unexpected log: %v", l)
+	}
+
+	// The collector's own logs are not forwarded.
+	collector := s.actorLogger(&ActorStart{Name: "collector", Type: "collector"})
+	collector.Printf("received batch")
+	if len(s.logs.c) != 0 {
+		t.Fatal("expected collector logs not to be forwarded")
+	}
+
+	if _, err := ContextLogger(context.Background()); err != ErrInvalidContext {
+		t.Fatalf("expected invalid context, got: %v", err)
+	}
+}
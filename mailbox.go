@@ -4,26 +4,53 @@ import (
 	"context"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lytics/grid/registry"
 )
 
 // Mailbox for receiving messages.
 type Mailbox struct {
-	mu      sync.RWMutex
-	name    string
-	nsName  string
-	C       <-chan Request
-	c       chan Request
-	closed  bool
-	cleanup func() error
+	mu       sync.RWMutex
+	name     string
+	nsName   string
+	C        <-chan Request
+	c        chan Request
+	closed   bool
+	critical bool
+	queue    *admissionQueue
+	seq      uint64
+	server   *Server
+	poison   poisonPolicy
+	replay   *replayBuffer
+	pace     mailboxPace
+	inbox    *inbox
+	cleanup  func() error
 }
 
-// Close the mailbox.
+// Close the mailbox. Closing a mailbox that is already
+// closed, for example by TransferMailbox, does nothing.
 func (box *Mailbox) Close() error {
 	box.mu.Lock()
 	defer box.mu.Unlock()
+	return box.closeLocked()
+}
 
-	// Close mailbox.
+// closeLocked is Close with the mailbox already locked.
+func (box *Mailbox) closeLocked() error {
+	if box.closed {
+		return nil
+	}
+
+	// Close mailbox, counting the requests the
+	// receiver abandoned, if it has a policy
+	// on poison messages.
 	box.closed = true
+	if box.queue != nil {
+		box.queue.close()
+	}
+	box.abandon()
 	close(box.c)
 
 	// Run server provided clean up.
@@ -40,16 +67,165 @@ func (box *Mailbox) String() string {
 	return box.nsName
 }
 
-// put a request into the mailbox if it is not closed,
-// otherwise return an error indicating that the
-// receiver is busy.
+// Recv the next request, blocking until one is available, the
+// context finishes, or the mailbox is closed.
+//
+// Example Usage:
+//
+//     for {
+//         req, err := mailbox.Recv(ctx)
+//         if err != nil {
+//             return
+//         }
+//         ...
+//         req.Ack()
+//     }
+//
+func (box *Mailbox) Recv(ctx context.Context) (Request, error) {
+	select {
+	case req, ok := <-box.C:
+		if !ok {
+			return nil, ErrMailboxClosed
+		}
+		return req, nil
+	case <-ctx.Done():
+		return nil, ErrContextFinished
+	}
+}
+
+// RecvBatch blocks until at least one request is available, and then
+// returns up to max requests, waiting at most maxWait for more to
+// arrive. It amortizes the per-message overhead for actors that
+// process homogeneous messages. An empty batch is returned when
+// the mailbox is closed. Each request in the batch must still be
+// responded to or acked.
+//
+// Example Usage:
+//
+//     for {
+//         batch := mailbox.RecvBatch(100, 10*time.Millisecond)
+//         if len(batch) == 0 {
+//             return
+//         }
+//         for _, req := range batch {
+//             ...
+//             req.Ack()
+//         }
+//     }
+//
+func (box *Mailbox) RecvBatch(max int, maxWait time.Duration) []Request {
+	if max < 1 {
+		max = 1
+	}
+	req, ok := <-box.C
+	if !ok {
+		return nil
+	}
+	batch := make([]Request, 1, max)
+	batch[0] = req
+
+	// Take whatever is already buffered before
+	// starting a timer.
+buffered:
+	for len(batch) < max {
+		select {
+		case req, ok := <-box.C:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, req)
+		default:
+			break buffered
+		}
+	}
+	if len(batch) == max || maxWait <= 0 {
+		return batch
+	}
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	for len(batch) < max {
+		select {
+		case req, ok := <-box.C:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, req)
+		case <-timer.C:
+			return batch
+		}
+	}
+	return batch
+}
+
+// put a request into the mailbox if it is not closed, and
+// the request is expected to be served before its deadline,
+// otherwise return an error indicating that the receiver
+// is busy. Messages of durable mailboxes are persisted
+// outside the mailbox's lock, once it has room for them.
 func (box *Mailbox) put(req *request) error {
+	now := time.Now()
+	err := box.admit(req, now)
+	if err != nil {
+		return err
+	}
+	req.seq = atomic.AddUint64(&box.seq, 1)
+	req.queued = now
+	req.pace = &box.pace
+	retained := box.encodeRetained(req)
+	err = box.track(req)
+	if err != nil {
+		return err
+	}
+	err = box.persist(req)
+	if err != nil {
+		box.untrack(req)
+		return err
+	}
+	err = box.enqueue(req)
+	if err != nil {
+		box.unpersist(req)
+		box.untrack(req)
+		return err
+	}
+	if retained != nil {
+		box.replay.add(*retained)
+	}
+	if box.server != nil {
+		atomic.AddInt64(&box.server.delivered, 1)
+	}
+	return nil
+}
+
+// admit the request if the mailbox is not closed, and is
+// expected to serve it before its deadline. Buffered durable
+// mailboxes must also have room for it, so that its message
+// is not persisted only to be removed again once rejected.
+func (box *Mailbox) admit(req *request, now time.Time) error {
+	box.mu.RLock()
+	defer box.mu.RUnlock()
+
+	if box.closed || box.late(req, now) {
+		return ErrReceiverBusy
+	}
+	if box.inbox != nil && (box.queue != nil || cap(box.c) > 0) && box.credit() <= 0 {
+		return ErrReceiverBusy
+	}
+	return nil
+}
+
+// enqueue the request if the mailbox is not closed, and
+// has room for it.
+func (box *Mailbox) enqueue(req *request) error {
 	box.mu.RLock()
 	defer box.mu.RUnlock()
 
 	if box.closed {
 		return ErrReceiverBusy
 	}
+	if box.queue != nil {
+		return box.queue.put(req)
+	}
 	select {
 	case box.c <- req:
 		return nil
@@ -58,6 +234,106 @@ func (box *Mailbox) put(req *request) error {
 	}
 }
 
+// credit of the mailbox, ie: how many more requests
+// its buffer has room for.
+func (box *Mailbox) credit() int {
+	if box.queue != nil {
+		return box.queue.credit()
+	}
+	return cap(box.c) - len(box.c)
+}
+
+// depth of the mailbox, ie: how many requests are queued in it.
+func (box *Mailbox) depth() int {
+	if box.queue != nil {
+		return box.queue.depth()
+	}
+	return len(box.c)
+}
+
+// MailboxOption of a mailbox, either a TakeoverPolicy, of which
+// at most one is given, or a MailboxFlag.
+type MailboxOption interface {
+	applyMailbox(opts *mailboxOptions) error
+}
+
+// TakeoverPolicy of a mailbox, deciding what happens when its
+// name is already registered, for example by an actor that is
+// restarting, or that died but whose registration has not yet
+// expired.
+type TakeoverPolicy int
+
+const (
+	// OpTakeoverError fails to create the mailbox, with
+	// ErrAlreadyRegistered. The default.
+	OpTakeoverError TakeoverPolicy = 0
+	// OpTakeoverWait waits for the existing registration to go
+	// away, for at most the server's LeaseDuration, after which
+	// the registration of a dead process has expired.
+	OpTakeoverWait TakeoverPolicy = 1
+	// OpTakeoverSteal takes over the existing registration,
+	// bumping its epoch. A mailbox of the same name on this
+	// server receives no more requests, and closing it does
+	// not deregister the name.
+	OpTakeoverSteal TakeoverPolicy = 2
+)
+
+// MailboxFlag of a mailbox, flags combine with each other and
+// with a TakeoverPolicy.
+type MailboxFlag int
+
+const (
+	// OpCritical marks the mailbox as critical, its requests
+	// are delivered even while the namespace is in maintenance
+	// with delivery paused.
+	OpCritical MailboxFlag = 1
+)
+
+// mailboxOptions of a mailbox, as given to NewMailbox.
+type mailboxOptions struct {
+	takeover    TakeoverPolicy
+	hasTakeover bool
+	critical    bool
+}
+
+func (p TakeoverPolicy) applyMailbox(opts *mailboxOptions) error {
+	if p < OpTakeoverError || p > OpTakeoverSteal {
+		return ErrInvalidMailboxOptions
+	}
+	if opts.hasTakeover && opts.takeover != p {
+		return ErrInvalidMailboxOptions
+	}
+	opts.takeover = p
+	opts.hasTakeover = true
+	return nil
+}
+
+func (f MailboxFlag) applyMailbox(opts *mailboxOptions) error {
+	switch f {
+	case OpCritical:
+		opts.critical = true
+	default:
+		return ErrInvalidMailboxOptions
+	}
+	return nil
+}
+
+// newMailboxOptions from the options, failing with the error
+// ErrInvalidMailboxOptions if they conflict.
+func newMailboxOptions(options []MailboxOption) (mailboxOptions, error) {
+	var opts mailboxOptions
+	for _, opt := range options {
+		if opt == nil {
+			return mailboxOptions{}, ErrInvalidMailboxOptions
+		}
+		err := opt.applyMailbox(&opts)
+		if err != nil {
+			return mailboxOptions{}, err
+		}
+	}
+	return opts, nil
+}
+
 // NewMailbox for requests addressed to name. Size will be the mailbox's
 // channel size.
 //
@@ -81,11 +357,24 @@ func (box *Mailbox) put(req *request) error {
 //
 // If the mailbox has already been created, in the calling process or
 // any other process, an error is returned, since only one mailbox
-// can claim a particular name.
+// can claim a particular name, unless an option to wait for or to
+// take over the name is given:
+//
+//     mailbox, err := NewMailbox(server, "incoming", 10, OpTakeoverWait)
+//
+// At most one takeover policy is given, it combines with flags such
+// as OpCritical, other combinations fail with ErrInvalidMailboxOptions.
 //
 // Using a mailbox requires that the process creating the mailbox also
 // started a grid Server.
-func NewMailbox(s *Server, name string, size int) (*Mailbox, error) {
+func NewMailbox(s *Server, name string, size int, options ...MailboxOption) (*Mailbox, error) {
+	return openMailbox(s, name, size, nil, 0, options)
+}
+
+// openMailbox of the name, durable if it has an inbox, and
+// retaining the last messages put into it if retain is not
+// zero.
+func openMailbox(s *Server, name string, size int, in *inbox, retain int, options []MailboxOption) (*Mailbox, error) {
 	if !isNameValid(name) {
 		return nil, ErrInvalidMailboxName
 	}
@@ -96,65 +385,166 @@ func NewMailbox(s *Server, name string, size int) (*Mailbox, error) {
 		return nil, err
 	}
 
-	return newMailbox(s, name, nsName, size)
+	opts, err := newMailboxOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return newMailbox(s, name, nsName, size, opts.takeover, opts.critical, in, retain)
 }
 
-func newMailbox(s *Server, name, nsName string, size int) (*Mailbox, error) {
+func newMailbox(s *Server, name, nsName string, size int, takeover TakeoverPolicy, critical bool, in *inbox, retain int) (*Mailbox, error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	mailboxes := s.mailboxes
+	s.mu.Unlock()
 
-	if s.mailboxes == nil {
+	if mailboxes == nil {
 		return nil, ErrServerNotRunning
 	}
-
-	_, ok := s.mailboxes[nsName]
-	if ok {
-		return nil, ErrAlreadyRegistered
+	if s.faults.etcdLost(time.Now()) {
+		return nil, ErrFaultInjected
 	}
 
-	timeout, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
-	err := s.registry.Register(timeout, nsName)
-	cancel()
-	// Check if the error is a particular fatal error
-	// from etcd. Some errors have no recovery. See
-	// the list of all possible errors here:
-	//
-	// https://github.com/coreos/etcd/blob/master/etcdserver/api/v3rpc/rpctypes/error.go
-	//
-	// They are unfortunately not classidied into
-	// recoverable or non-recoverable.
-	if err != nil && strings.Contains(err.Error(), "etcdserver: requested lease not found") {
-		s.reportFatalError(err)
-		return nil, err
+	// The epoch of the registration, so that it is only
+	// deregistered by this mailbox if it has not been
+	// taken over since.
+	var epoch int64
+	var err error
+	switch takeover {
+	case OpTakeoverWait:
+		err = s.awaitMailbox(mailboxes, nsName)
+	case OpTakeoverSteal:
+		epoch, err = s.stealMailbox(mailboxes, nsName)
+	default:
+		err = s.registerMailbox(mailboxes, nsName)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	boxC := make(chan Request, size)
-	cleanup := func() error {
-		s.mu.Lock()
-		defer s.mu.Unlock()
-
-		// Immediately delete the subscription so that no one
-		// can send to it, at least from this host.
-		delete(s.mailboxes, nsName)
+	// With an admission policy requests wait in its queue,
+	// and are handed over only once the actor receives.
+	var queue *admissionQueue
+	var boxC chan Request
+	if s.cfg.Admission != nil {
+		boxC = make(chan Request)
+		queue = newAdmissionQueue(s.cfg.Admission, size, boxC)
+	} else {
+		boxC = make(chan Request, size)
+	}
+	box := &Mailbox{
+		name:     name,
+		nsName:   nsName,
+		C:        boxC,
+		c:        boxC,
+		queue:    queue,
+		critical: critical,
+		server:   s,
+		inbox:    in,
+	}
+	box.cleanup = func() error {
+		// Immediately hide the subscription so that no one
+		// can send to it, at least from this host. The name
+		// stays reserved until it is deregistered. If the
+		// name has been taken over, it and its registration
+		// belong to the new mailbox.
+		if !mailboxes.hide(nsName, box) {
+			return nil
+		}
+		defer mailboxes.release(nsName)
 
-		// Deregister the name.
+		// Deregister the name, unless it was taken over
+		// after it was hidden.
 		timeout, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
 		defer cancel()
-		err := s.registry.Deregister(timeout, nsName)
+		err := s.registry.DeregisterEpoch(timeout, nsName, epoch)
 
 		// Return any error from the deregister call.
 		return err
 	}
-	box := &Mailbox{
-		name:    name,
-		nsName:  nsName,
-		C:       boxC,
-		c:       boxC,
-		cleanup: cleanup,
-	}
-	s.mailboxes[nsName] = box
+
+	// Replay the retained messages before the mailbox
+	// can be found, so that they are received before
+	// any new ones.
+	box.retainMessages(retain)
+	mailboxes.set(nsName, box)
 	return box, nil
 }
+
+// registerMailbox name, failing if it is already registered.
+func (s *Server) registerMailbox(mailboxes *mailboxMap, nsName string) error {
+	// Reserve the name, so that the registration
+	// below is done without holding any lock.
+	if !mailboxes.reserve(nsName) {
+		return ErrAlreadyRegistered
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	err := s.registry.Register(timeout, nsName)
+	cancel()
+	if err != nil {
+		mailboxes.release(nsName)
+		s.checkLeaseFault(err)
+		return err
+	}
+	return nil
+}
+
+// awaitMailbox name, registering it once any existing
+// registration has gone away.
+func (s *Server) awaitMailbox(mailboxes *mailboxMap, nsName string) error {
+	deadline := time.Now().Add(s.cfg.LeaseDuration)
+	for {
+		err := s.registerMailbox(mailboxes, nsName)
+		if !isAlreadyRegistered(err) || time.Now().After(deadline) {
+			return err
+		}
+		select {
+		case <-s.ctx.Done():
+			return ErrServerNotRunning
+		case <-time.After(1 * time.Second):
+		}
+	}
+}
+
+// stealMailbox name, taking over any existing registration,
+// and return the epoch of the registration.
+func (s *Server) stealMailbox(mailboxes *mailboxMap, nsName string) (int64, error) {
+	prev := mailboxes.steal(nsName)
+
+	timeout, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	epoch, err := s.registry.TakeoverEpoch(timeout, nsName)
+	cancel()
+	if err != nil {
+		// Give the name back to the mailbox
+		// that had it, if there was one.
+		if prev != nil {
+			mailboxes.set(nsName, prev)
+		} else {
+			mailboxes.release(nsName)
+		}
+		s.checkLeaseFault(err)
+		return 0, err
+	}
+	return epoch, nil
+}
+
+// checkLeaseFault of a registration. Some errors from etcd
+// have no recovery. See the list of all possible errors here:
+//
+// https://github.com/coreos/etcd/blob/master/etcdserver/api/v3rpc/rpctypes/error.go
+//
+// They are unfortunately not classidied into
+// recoverable or non-recoverable.
+func (s *Server) checkLeaseFault(err error) {
+	if strings.Contains(err.Error(), "etcdserver: requested lease not found") {
+		s.reportFatalError(err)
+	}
+}
+
+// isAlreadyRegistered if the error is from a registration
+// of a name that is already registered, here or in etcd.
+func isAlreadyRegistered(err error) bool {
+	return err == ErrAlreadyRegistered ||
+		err == registry.ErrAlreadyRegistered ||
+		err == registry.ErrFailedRegistration
+}
@@ -0,0 +1,240 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMailboxRecv(t *testing.T) {
+	boxC := make(chan Request, 1)
+	box := &Mailbox{C: boxC, c: boxC}
+
+	boxC <- newRequest(context.Background(), 1, &Provenance{})
+	req, err := box.Recv(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Msg().(int) != 1 {
+		t.Fatalf("expected message: 1, got: %v", req.Msg())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := box.Recv(ctx); err != ErrContextFinished {
+		t.Fatalf("expected context finished, got: %v", err)
+	}
+
+	close(boxC)
+	if _, err := box.Recv(context.Background()); err != ErrMailboxClosed {
+		t.Fatalf("expected mailbox closed, got: %v", err)
+	}
+}
+
+func TestMailboxRecvBatch(t *testing.T) {
+	boxC := make(chan Request, 10)
+	box := &Mailbox{C: boxC, c: boxC}
+
+	for i := 0; i < 5; i++ {
+		boxC <- newRequest(context.Background(), i, &Provenance{})
+	}
+
+	batch := box.RecvBatch(3, time.Second)
+	if len(batch) != 3 {
+		t.Fatalf("expected batch of 3, got: %v", len(batch))
+	}
+	for i, req := range batch {
+		if req.Msg().(int) != i {
+			t.Fatalf("expected message: %v, got: %v", i, req.Msg())
+		}
+	}
+
+	// Only two remain, so the batch is returned
+	// once the wait is over.
+	batch = box.RecvBatch(3, 10*time.Millisecond)
+	if len(batch) != 2 {
+		t.Fatalf("expected batch of 2, got: %v", len(batch))
+	}
+
+	close(boxC)
+	batch = box.RecvBatch(3, time.Second)
+	if len(batch) != 0 {
+		t.Fatalf("expected empty batch, got: %v", len(batch))
+	}
+}
+
+func TestMailboxMap(t *testing.T) {
+	mm := newMailboxMap()
+
+	if !mm.reserve("a") {
+		t.Fatal("expected reserve")
+	}
+	if mm.reserve("a") {
+		t.Fatal("expected reserved name to be refused")
+	}
+	if _, ok := mm.get("a"); ok {
+		t.Fatal("expected reserved name to not be found")
+	}
+
+	box := &Mailbox{name: "a"}
+	mm.set("a", box)
+	if found, ok := mm.get("a"); !ok || found != box {
+		t.Fatal("expected mailbox")
+	}
+	if mm.len() != 1 {
+		t.Fatalf("expected length 1, got: %v", mm.len())
+	}
+
+	mm.delete("a")
+	if _, ok := mm.get("a"); ok {
+		t.Fatal("expected deleted mailbox to not be found")
+	}
+	if mm.len() != 0 {
+		t.Fatalf("expected length 0, got: %v", mm.len())
+	}
+}
+
+// lockedMailboxMap is a map guarded by one lock, the way the
+// server's mailboxes were kept before being sharded, used
+// as a baseline for the benchmarks.
+type lockedMailboxMap struct {
+	mu    sync.Mutex
+	boxes map[string]*Mailbox
+}
+
+func (lm *lockedMailboxMap) get(name string) (*Mailbox, bool) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	box, ok := lm.boxes[name]
+	return box, ok
+}
+
+func (lm *lockedMailboxMap) set(name string, box *Mailbox) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm.boxes[name] = box
+}
+
+func benchmarkNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("testing.mailbox.box-%d", i)
+	}
+	return names
+}
+
+func BenchmarkMailboxMapLocked(b *testing.B) {
+	names := benchmarkNames(10000)
+	lm := &lockedMailboxMap{boxes: make(map[string]*Mailbox)}
+	for _, name := range names {
+		lm.set(name, &Mailbox{})
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			name := names[i%len(names)]
+			if i%100 == 0 {
+				lm.set(name, &Mailbox{})
+			} else {
+				lm.get(name)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkMailboxMapSharded(b *testing.B) {
+	names := benchmarkNames(10000)
+	mm := newMailboxMap()
+	for _, name := range names {
+		mm.set(name, &Mailbox{})
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			name := names[i%len(names)]
+			if i%100 == 0 {
+				mm.set(name, &Mailbox{})
+			} else {
+				mm.get(name)
+			}
+			i++
+		}
+	})
+}
+
+func TestMailboxMapTakeover(t *testing.T) {
+	mm := newMailboxMap()
+	old := &Mailbox{nsName: "a"}
+	mm.reserve("a")
+	mm.set("a", old)
+
+	// Stealing hides the old mailbox, which then
+	// cannot hide the name of the new one.
+	if prev := mm.steal("a"); prev != old {
+		t.Fatalf("expected old mailbox, got: %v", prev)
+	}
+	box := &Mailbox{nsName: "a"}
+	mm.set("a", box)
+	if mm.hide("a", old) {
+		t.Fatal("expected old mailbox to not hide new one")
+	}
+	if got, _ := mm.get("a"); got != box {
+		t.Fatalf("expected new mailbox, got: %v", got)
+	}
+
+	// Releasing the name after hiding the mailbox
+	// does not release a name set since.
+	if !mm.hide("a", box) {
+		t.Fatal("expected mailbox to be hidden")
+	}
+	next := &Mailbox{nsName: "a"}
+	mm.set("a", next)
+	mm.release("a")
+	if got, _ := mm.get("a"); got != next {
+		t.Fatalf("expected next mailbox, got: %v", got)
+	}
+	mm.hide("a", next)
+	mm.release("a")
+	if mm.len() != 0 {
+		t.Fatalf("expected name to be released, got: %v names", mm.len())
+	}
+}
+
+func TestMailboxOptions(t *testing.T) {
+	opts, err := newMailboxOptions([]MailboxOption{OpCritical, OpTakeoverSteal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.takeover != OpTakeoverSteal || !opts.critical {
+		t.Fatalf("expected critical mailbox taking over, got: %+v", opts)
+	}
+
+	opts, err = newMailboxOptions(nil)
+	if err != nil || opts.takeover != OpTakeoverError || opts.critical {
+		t.Fatalf("expected defaults, got: %+v, %v", opts, err)
+	}
+
+	// The same policy given twice does not conflict.
+	if _, err := newMailboxOptions([]MailboxOption{OpTakeoverWait, OpTakeoverWait}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, invalid := range [][]MailboxOption{
+		{OpTakeoverWait, OpTakeoverSteal},
+		{OpTakeoverError, OpCritical, OpTakeoverWait},
+		{TakeoverPolicy(3)},
+		{MailboxFlag(2)},
+		{nil},
+	} {
+		if _, err := newMailboxOptions(invalid); err != ErrInvalidMailboxOptions {
+			t.Fatalf("expected invalid options: %v, got: %v", invalid, err)
+		}
+	}
+}
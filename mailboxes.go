@@ -0,0 +1,139 @@
+package grid
+
+import "sync"
+
+// mailboxShards is the number of independently locked
+// shards of the server's mailbox map.
+const mailboxShards = 32
+
+// mailboxMap of the mailboxes registered with a server. It is
+// sharded by name so that registering, closing, and delivering
+// to unrelated mailboxes do not contend on one lock.
+type mailboxMap struct {
+	shards [mailboxShards]mailboxShard
+}
+
+type mailboxShard struct {
+	mu    sync.RWMutex
+	boxes map[string]*Mailbox
+}
+
+func newMailboxMap() *mailboxMap {
+	mm := &mailboxMap{}
+	for i := range mm.shards {
+		mm.shards[i].boxes = make(map[string]*Mailbox)
+	}
+	return mm
+}
+
+// shard of the name, chosen by its FNV-1a hash, computed
+// inline since hash/fnv would allocate on every lookup.
+func (mm *mailboxMap) shard(name string) *mailboxShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(name); i++ {
+		h ^= uint32(name[i])
+		h *= 16777619
+	}
+	return &mm.shards[h%mailboxShards]
+}
+
+// get the named mailbox, a reserved name that has
+// not yet been set is not found.
+func (mm *mailboxMap) get(name string) (*Mailbox, bool) {
+	shard := mm.shard(name)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	box := shard.boxes[name]
+	return box, box != nil
+}
+
+// reserve the name, returns false if it is already
+// reserved or set. Reserving allows the slow work of
+// registering a name to happen without holding a lock.
+func (mm *mailboxMap) reserve(name string) bool {
+	shard := mm.shard(name)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if _, ok := shard.boxes[name]; ok {
+		return false
+	}
+	shard.boxes[name] = nil
+	return true
+}
+
+// set the mailbox of a reserved name.
+func (mm *mailboxMap) set(name string, box *Mailbox) {
+	shard := mm.shard(name)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.boxes[name] = box
+}
+
+// steal the name, reserving it whether or not it is already
+// reserved or set, and return the mailbox that was set, if any.
+func (mm *mailboxMap) steal(name string) *Mailbox {
+	shard := mm.shard(name)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	prev := shard.boxes[name]
+	shard.boxes[name] = nil
+	return prev
+}
+
+// hide the mailbox, leaving its name reserved, returns false
+// if the name is no longer set to the mailbox, ie: if it has
+// been taken over by another mailbox.
+func (mm *mailboxMap) hide(name string, box *Mailbox) bool {
+	shard := mm.shard(name)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if shard.boxes[name] != box {
+		return false
+	}
+	shard.boxes[name] = nil
+	return true
+}
+
+// release the reserved name, unless it has been set since.
+func (mm *mailboxMap) release(name string) {
+	shard := mm.shard(name)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if box, ok := shard.boxes[name]; ok && box == nil {
+		delete(shard.boxes, name)
+	}
+}
+
+// delete the name, whether reserved or set.
+func (mm *mailboxMap) delete(name string) {
+	shard := mm.shard(name)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	delete(shard.boxes, name)
+}
+
+// len of the map, including reserved names.
+func (mm *mailboxMap) len() int {
+	n := 0
+	for i := range mm.shards {
+		shard := &mm.shards[i]
+		shard.mu.RLock()
+		n += len(shard.boxes)
+		shard.mu.RUnlock()
+	}
+	return n
+}
+
+// each mailbox that has been set.
+func (mm *mailboxMap) each(f func(*Mailbox)) {
+	for i := range mm.shards {
+		shard := &mm.shards[i]
+		shard.mu.RLock()
+		for _, box := range shard.boxes {
+			if box != nil {
+				f(box)
+			}
+		}
+		shard.mu.RUnlock()
+	}
+}
@@ -0,0 +1,140 @@
+package grid
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+const (
+	// maintenances is the key space of namespace maintenance.
+	maintenances EntityType = "maintenance"
+	// maintenanceName of the namespace's maintenance flag.
+	maintenanceName = "flag"
+)
+
+// Maintenance of a namespace, while it is set servers of the
+// namespace reject new actor starts with ErrMaintenance, so that
+// operators can quiesce a cluster, for example before etcd
+// maintenance. Actors already running keep running.
+type Maintenance struct {
+	// PauseDelivery of requests to mailboxes not created with
+	// OpCritical, such requests are rejected with the error
+	// ErrDeliveryPaused, which clients retry for a while.
+	PauseDelivery bool `json:"pauseDelivery,omitempty"`
+	// Reason for the maintenance, for other operators.
+	Reason string `json:"reason,omitempty"`
+}
+
+// maintenanceState of the namespace, as last seen in etcd.
+type maintenanceState struct {
+	mu  sync.Mutex
+	m   *Maintenance
+	rev int64
+}
+
+func newMaintenanceState() *maintenanceState {
+	return &maintenanceState{}
+}
+
+// current maintenance, nil when not in maintenance.
+func (ms *maintenanceState) current() *Maintenance {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.m
+}
+
+// set the maintenance as of the etcd revision, unless the
+// state is already of a later revision. A flag that cannot
+// be decoded still puts the namespace in maintenance, but
+// without pausing delivery.
+func (ms *maintenanceState) set(doc []byte, rev int64) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if rev <= ms.rev {
+		return
+	}
+	ms.rev = rev
+	if doc == nil {
+		ms.m = nil
+		return
+	}
+	m := &Maintenance{}
+	if err := json.Unmarshal(doc, m); err != nil {
+		m = &Maintenance{}
+	}
+	ms.m = m
+}
+
+// watchMaintenance flag of the namespace.
+func (s *Server) watchMaintenance() {
+	s.watchDocument(maintenances, maintenanceName, s.maint.set)
+}
+
+// paused returns ErrDeliveryPaused if requests to the
+// mailbox are paused by the namespace's maintenance.
+func (s *Server) paused(mailbox *Mailbox) error {
+	if mailbox.critical {
+		return nil
+	}
+	if m := s.maint.current(); m != nil && m.PauseDelivery {
+		return ErrDeliveryPaused
+	}
+	return nil
+}
+
+// StartMaintenance of the namespace. Servers see the flag shortly
+// after it is set, and keep it until EndMaintenance is called.
+//
+// Example usage:
+//
+//     err := client.StartMaintenance(ctx, &grid.Maintenance{
+//         PauseDelivery: true,
+//         Reason:        "etcd defrag",
+//     })
+//     ...
+//     defer client.EndMaintenance(ctx)
+//
+func (c *Client) StartMaintenance(ctx context.Context, m *Maintenance) error {
+	key, err := namespaceName(maintenances, c.cfg.Namespace, maintenanceName)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Put(ctx, key, string(buf))
+	return err
+}
+
+// EndMaintenance of the namespace.
+func (c *Client) EndMaintenance(ctx context.Context) error {
+	key, err := namespaceName(maintenances, c.cfg.Namespace, maintenanceName)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Delete(ctx, key)
+	return err
+}
+
+// Maintenance of the namespace, nil if it is not in maintenance.
+func (c *Client) Maintenance(ctx context.Context) (*Maintenance, error) {
+	key, err := namespaceName(maintenances, c.cfg.Namespace, maintenanceName)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.etcd.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if res.Count == 0 {
+		return nil, nil
+	}
+	m := &Maintenance{}
+	err = json.Unmarshal(res.Kvs[0].Value, m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
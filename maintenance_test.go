@@ -0,0 +1,46 @@
+package grid
+
+import "testing"
+
+func TestMaintenance(t *testing.T) {
+	s := &Server{maint: newMaintenanceState()}
+	box := &Mailbox{}
+	critical := &Mailbox{critical: true}
+
+	if s.maint.current() != nil || s.paused(box) != nil {
+		t.Fatalf("expected no maintenance")
+	}
+
+	// In maintenance actors are not started,
+	// but delivery continues unless paused.
+	s.maint.set([]byte(`{"reason": "etcd defrag"}`), 2)
+	if m := s.maint.current(); m == nil || m.Reason != "etcd defrag" {
+		t.Fatalf("expected maintenance, got: %v", m)
+	}
+	if err := s.startActorC(nil, &ActorStart{Type: "worker", Name: "worker"}); err != ErrMaintenance {
+		t.Fatalf("expected maintenance, got: %v", err)
+	}
+	if err := s.paused(box); err != nil {
+		t.Fatalf("expected delivery, got: %v", err)
+	}
+
+	// Paused delivery is only to mailboxes
+	// that are not critical.
+	s.maint.set([]byte(`{"pauseDelivery": true}`), 3)
+	if err := s.paused(box); err != ErrDeliveryPaused {
+		t.Fatalf("expected delivery paused, got: %v", err)
+	}
+	if err := s.paused(critical); err != nil {
+		t.Fatalf("expected delivery to critical mailbox, got: %v", err)
+	}
+
+	// Older revisions are ignored.
+	s.maint.set(nil, 1)
+	if s.maint.current() == nil {
+		t.Fatalf("expected maintenance to remain")
+	}
+	s.maint.set(nil, 4)
+	if s.maint.current() != nil || s.paused(box) != nil {
+		t.Fatalf("expected maintenance to end")
+	}
+}
@@ -0,0 +1,93 @@
+package grid
+
+import (
+	"context"
+)
+
+// MetricsBackend receives the metrics reported by actors, see
+// ContextMetrics, for example to forward them to Prometheus or
+// StatsD. Labels must not be modified by the backend.
+type MetricsBackend interface {
+	// Count adds delta to the counter.
+	Count(name string, delta float64, labels map[string]string)
+	// Gauge sets the gauge to value.
+	Gauge(name string, value float64, labels map[string]string)
+	// Observe value in the histogram.
+	Observe(name string, value float64, labels map[string]string)
+}
+
+// ActorMetrics of an actor, reported to the server's metrics
+// backend with the namespace, and the actor's name and type,
+// as labels. Without a backend the metrics are discarded.
+type ActorMetrics struct {
+	backend MetricsBackend
+	labels  map[string]string
+}
+
+// ContextMetrics returns the metrics of the actor associated with
+// this context.
+//
+// Example usage:
+//
+//     func (a *worker) Act(ctx context.Context) {
+//         metrics, err := grid.ContextMetrics(ctx)
+//         ...
+//         metrics.Count("jobs_done", 1)
+//         metrics.With("queue", "fast").Observe("job_seconds", elapsed.Seconds())
+//     }
+//
+func ContextMetrics(c context.Context) (*ActorMetrics, error) {
+	v := c.Value(contextKey)
+	if v == nil {
+		return nil, ErrInvalidContext
+	}
+	cv, ok := v.(*contextVal)
+	if !ok || cv.metrics == nil {
+		return nil, ErrInvalidContext
+	}
+	return cv.metrics, nil
+}
+
+// actorMetrics of the actor.
+func (s *Server) actorMetrics(start *ActorStart) *ActorMetrics {
+	return &ActorMetrics{
+		backend: s.cfg.Metrics,
+		labels: map[string]string{
+			"namespace":  s.cfg.Namespace,
+			"actor":      start.Name,
+			"actor_type": start.Type,
+		},
+	}
+}
+
+// With the label added to the labels of the metrics, the
+// labels of the actor itself can not be replaced.
+func (m *ActorMetrics) With(key, value string) *ActorMetrics {
+	labels := make(map[string]string, len(m.labels)+1)
+	labels[key] = value
+	for k, v := range m.labels {
+		labels[k] = v
+	}
+	return &ActorMetrics{backend: m.backend, labels: labels}
+}
+
+// Count adds delta to the named counter.
+func (m *ActorMetrics) Count(name string, delta float64) {
+	if m.backend != nil {
+		m.backend.Count(name, delta, m.labels)
+	}
+}
+
+// Gauge sets the named gauge to value.
+func (m *ActorMetrics) Gauge(name string, value float64) {
+	if m.backend != nil {
+		m.backend.Gauge(name, value, m.labels)
+	}
+}
+
+// Observe value in the named histogram.
+func (m *ActorMetrics) Observe(name string, value float64) {
+	if m.backend != nil {
+		m.backend.Observe(name, value, m.labels)
+	}
+}
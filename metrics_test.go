@@ -0,0 +1,78 @@
+package grid
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type recordedMetric struct {
+	kind   string
+	name   string
+	value  float64
+	labels map[string]string
+}
+
+type recordingMetrics struct {
+	mu      sync.Mutex
+	metrics []recordedMetric
+}
+
+func (rm *recordingMetrics) record(kind, name string, value float64, labels map[string]string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.metrics = append(rm.metrics, recordedMetric{kind, name, value, labels})
+}
+
+func (rm *recordingMetrics) Count(name string, delta float64, labels map[string]string) {
+	rm.record("count", name, delta, labels)
+}
+
+func (rm *recordingMetrics) Gauge(name string, value float64, labels map[string]string) {
+	rm.record("gauge", name, value, labels)
+}
+
+func (rm *recordingMetrics) Observe(name string, value float64, labels map[string]string) {
+	rm.record("observe", name, value, labels)
+}
+
+func TestContextMetrics(t *testing.T) {
+	if _, err := ContextMetrics(context.Background()); err != ErrInvalidContext {
+		t.Fatalf("expected invalid context, got: %v", err)
+	}
+
+	backend := &recordingMetrics{}
+	s := &Server{cfg: ServerCfg{Namespace: "testing", Metrics: backend}}
+	worker := &contextVal{server: s, metrics: s.actorMetrics(&ActorStart{Name: "worker-1", Type: "worker"})}
+	ctx := context.WithValue(context.Background(), contextKey, worker)
+
+	metrics, err := ContextMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics.Count("jobs_done", 1)
+	metrics.Gauge("backlog", 7)
+	metrics.With("queue", "fast").With("actor", "forged").Observe("job_seconds", 0.5)
+
+	if len(backend.metrics) != 3 {
+		t.Fatalf("expected 3 metrics, got: %v", len(backend.metrics))
+	}
+	count := backend.metrics[0]
+	if count.kind != "count" || count.name != "jobs_done" || count.value != 1 {
+		t.Fatalf("expected counter, got: %+v", count)
+	}
+	if count.labels["namespace"] != "testing" || count.labels["actor"] != "worker-1" || count.labels["actor_type"] != "worker" {
+		t.Fatalf("expected labels of actor, got: %v", count.labels)
+	}
+	observed := backend.metrics[2]
+	if observed.labels["queue"] != "fast" || observed.labels["actor"] != "worker-1" {
+		t.Fatalf("expected added label, and actor's labels kept, got: %v", observed.labels)
+	}
+	if len(count.labels) != 3 {
+		t.Fatalf("expected labels of metrics unchanged by With, got: %v", count.labels)
+	}
+
+	// Without a backend the metrics are discarded.
+	s.cfg.Metrics = nil
+	s.actorMetrics(&ActorStart{Name: "worker-2", Type: "worker"}).Count("jobs_done", 1)
+}
@@ -0,0 +1,159 @@
+package grid
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"time"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+)
+
+// mirrors is the key space of the mirrors of mailboxes.
+const mirrors EntityType = "mirror"
+
+// Mirror of the requests to a mailbox, a fraction of which are
+// also sent to the canary's mailbox, for example to validate a
+// new version of an actor against production traffic.
+type Mirror struct {
+	// Canary mailbox the requests are mirrored to.
+	Canary string `json:"canary"`
+	// Fraction of the requests mirrored, from 0 to 1.
+	Fraction float64 `json:"fraction"`
+}
+
+// mirrorCache of the mirrors of a namespace, read from etcd
+// at most once per refresh interval.
+type mirrorCache struct {
+	fetched time.Time
+	mirrors map[string]Mirror
+	err     error
+}
+
+// SetMirror of the requests to the mailbox. Clients with mirroring
+// enabled, see WithMirroring, send the fraction of their requests
+// to the mailbox to the canary as well, and discard the canary's
+// responses and failures. Clients see the mirror within their
+// PeersRefreshInterval. Senders only know receivers by mailbox, not
+// by actor type, so each mailbox of a type is mirrored on its own.
+//
+// Example usage:
+//
+//     err := client.SetMirror(ctx, "scorer-0", grid.Mirror{
+//         Canary:   "scorer-canary-0",
+//         Fraction: 0.05,
+//     })
+//
+func (c *Client) SetMirror(ctx context.Context, mailbox string, m Mirror) error {
+	if m.Fraction < 0 || m.Fraction > 1 || !isNameValid(m.Canary) || m.Canary == mailbox {
+		return ErrInvalidMirror
+	}
+	key, err := namespaceName(mirrors, c.cfg.Namespace, mailbox)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Put(ctx, key, string(data))
+	return err
+}
+
+// DeleteMirror of the mailbox.
+func (c *Client) DeleteMirror(ctx context.Context, mailbox string) error {
+	key, err := namespaceName(mirrors, c.cfg.Namespace, mailbox)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Delete(ctx, key)
+	return err
+}
+
+// Mirrors of the mailboxes of the namespace that have one set.
+func (c *Client) Mirrors(ctx context.Context) (map[string]Mirror, error) {
+	prefix, err := namespacePrefix(mirrors, c.cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.etcd.Get(ctx, prefix, etcdv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	ms := make(map[string]Mirror, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		var m Mirror
+		err := json.Unmarshal(kv.Value, &m)
+		if err != nil {
+			return nil, err
+		}
+		ms[strings.TrimPrefix(string(kv.Key), prefix)] = m
+	}
+	return ms, nil
+}
+
+// cachedMirrors of the namespace, refreshed if older than
+// the PeersRefreshInterval. Failures to refresh are cached
+// too, as for quarantines, see cachedQuarantines.
+func (c *Client) cachedMirrors(ctx context.Context) (map[string]Mirror, error) {
+	c.mu.Lock()
+	cache := c.mirrors
+	c.mu.Unlock()
+	if cache != nil && time.Since(cache.fetched) < c.cfg.PeersRefreshInterval {
+		return cache.mirrors, cache.err
+	}
+
+	ms, err := c.Mirrors(ctx)
+	switch {
+	case err == nil:
+		cache = &mirrorCache{fetched: time.Now(), mirrors: ms}
+	case cache == nil || cache.err != nil:
+		cache = &mirrorCache{fetched: time.Now(), err: err}
+	default:
+		cache = &mirrorCache{fetched: time.Now(), mirrors: cache.mirrors}
+	}
+	c.mu.Lock()
+	c.mirrors = cache
+	c.mu.Unlock()
+	return cache.mirrors, cache.err
+}
+
+// mirror the request to the receiver's canary, if it has a
+// mirror and the request is in the mirrored fraction. The
+// canary is sent the request in the background, with the
+// request's lineage, and its response is discarded.
+func (c *Client) mirror(ctx context.Context, receiver string, msg interface{}) {
+	canary, ok := c.canaryOf(ctx, receiver, rand.Float64)
+	if !ok {
+		return
+	}
+	// The message is encoded before the request is
+	// sent, since the caller may change it after.
+	raw, err := encode(c.cfg.Codec, msg)
+	if err != nil {
+		return
+	}
+	go func() {
+		timeout, cancel := context.WithTimeout(WithLineage(context.Background(), ContextLineage(ctx)), c.cfg.DefaultRequestTimeout)
+		defer cancel()
+		c.requestC(timeout, canary, &raw)
+	}()
+}
+
+// canaryOf the receiver, if its request is mirrored, decided
+// by drawing from random.
+func (c *Client) canaryOf(ctx context.Context, receiver string, random func() float64) (string, bool) {
+	if !c.cfg.Mirroring {
+		return "", false
+	}
+	ms, err := c.cachedMirrors(ctx)
+	if err != nil {
+		return "", false
+	}
+	m, ok := ms[receiver]
+	if !ok || random() >= m.Fraction {
+		return "", false
+	}
+	return m.Canary, true
+}
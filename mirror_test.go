@@ -0,0 +1,53 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMirrorCanaryOf(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{
+		cfg: ClientCfg{Namespace: "testing", Mirroring: true, PeersRefreshInterval: time.Minute},
+		mirrors: &mirrorCache{
+			fetched: time.Now(),
+			mirrors: map[string]Mirror{"scorer": {Canary: "scorer-canary", Fraction: 0.25}},
+		},
+	}
+	ctx := context.Background()
+
+	canary, ok := c.canaryOf(ctx, "scorer", func() float64 { return 0.1 })
+	if !ok || canary != "scorer-canary" {
+		t.Fatalf("expected request mirrored to canary, got: %q, %v", canary, ok)
+	}
+	if _, ok := c.canaryOf(ctx, "scorer", func() float64 { return 0.5 }); ok {
+		t.Fatal("expected request outside of fraction not mirrored")
+	}
+	if _, ok := c.canaryOf(ctx, "ranker", func() float64 { return 0 }); ok {
+		t.Fatal("expected request to mailbox without mirror not mirrored")
+	}
+
+	c.cfg.Mirroring = false
+	if _, ok := c.canaryOf(ctx, "scorer", func() float64 { return 0 }); ok {
+		t.Fatal("expected no mirroring when disabled")
+	}
+}
+
+func TestSetMirrorInvalid(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{cfg: ClientCfg{Namespace: "testing"}}
+	for _, m := range []Mirror{
+		{Canary: "scorer-canary", Fraction: -0.1},
+		{Canary: "scorer-canary", Fraction: 1.1},
+		{Canary: "scorer.canary", Fraction: 0.1},
+		{Canary: "scorer", Fraction: 0.1},
+	} {
+		err := c.SetMirror(context.Background(), "scorer", m)
+		if err != ErrInvalidMirror {
+			t.Fatalf("expected invalid mirror: %+v, got: %v", m, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package grid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errStreamClosed when the multiplexed stream to a peer broke
+// before a request could be sent on it, so that it is safe to
+// send the request again, see ErrStreamBroken otherwise.
+var errStreamClosed = errors.New("grid: stream closed")
+
+// muxStream carries many requests and responses over one
+// bidirectional gRPC stream. Each request is given an ID
+// which the response carries back, so responses can
+// arrive in any order.
+type muxStream struct {
+	mu      sync.Mutex
+	sendMu  sync.Mutex
+	stream  Wire_StreamClient
+	cancel  func()
+	nextID  uint64
+	pending map[uint64]*pendingRequest
+	flow    *flowControl
+	err     error
+	// sentErr of the requests that were sent, and
+	// waiting for their responses, when it broke.
+	sentErr error
+	// unimplemented is true when the peer does
+	// not serve streams, ie: an older peer.
+	unimplemented bool
+	// co coalesces small deliveries, it is
+	// nil when coalescing is not enabled.
+	co *coalescer
+	// features negotiated with the peer, none
+	// until the peer's header is received.
+	features uint32
+	// loads of the mailboxes, updated from the
+	// responses, nil when they are not kept.
+	loads *loadTable
+}
+
+// pendingRequest waiting for its response.
+type pendingRequest struct {
+	resC     chan *Delivery
+	receiver string
+}
+
+// newMuxStream opens a stream on the client, keeping the loads
+// of the mailboxes it sends to, if loads is not nil.
+func newMuxStream(client WireClient, loads *loadTable) (*muxStream, error) {
+	ctx, cancel := context.WithCancel(withFeatures(context.Background()))
+	stream, err := client.Stream(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	ms := &muxStream{
+		stream:  stream,
+		cancel:  cancel,
+		pending: make(map[uint64]*pendingRequest),
+		flow:    newFlowControl(),
+		loads:   loads,
+	}
+	go ms.recvLoop()
+	return ms, nil
+}
+
+// request sends the delivery and waits for its response,
+// or for the context to finish.
+func (ms *muxStream) request(ctx context.Context, req *Delivery) (*Delivery, error) {
+	id, resC, err := ms.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return ms.wait(ctx, id, resC)
+}
+
+// send the delivery without waiting for its response, which
+// arrives on the returned channel. Deliveries sent one after
+// the other are put into the receiver's mailbox in order.
+func (ms *muxStream) send(ctx context.Context, req *Delivery) (uint64, chan *Delivery, error) {
+	id, resC, d, err := ms.prepare(ctx, req)
+	if err != nil {
+		return 0, nil, err
+	}
+	err = ms.write(d)
+	if err != nil {
+		ms.forget(id)
+		return 0, nil, fmt.Errorf("%v: %v", errStreamClosed, err)
+	}
+	return id, resC, nil
+}
+
+// sendCoalesced is like send, but small deliveries may be held
+// for a short while, and sent together with others in one frame.
+func (ms *muxStream) sendCoalesced(ctx context.Context, req *Delivery) (uint64, chan *Delivery, error) {
+	if ms.co == nil || !ms.supports(featureBatch) {
+		return ms.send(ctx, req)
+	}
+	id, resC, d, err := ms.prepare(ctx, req)
+	if err != nil {
+		return 0, nil, err
+	}
+	err = ms.co.add(d)
+	if err != nil {
+		ms.forget(id)
+		return 0, nil, err
+	}
+	return id, resC, nil
+}
+
+// prepare a delivery for sending, by giving it an ID and a
+// channel on which its response will arrive.
+func (ms *muxStream) prepare(ctx context.Context, req *Delivery) (uint64, chan *Delivery, *Delivery, error) {
+	// Wait for the receiver to have room, rather
+	// than sending a request that would be busy.
+	err := ms.flow.acquire(ctx, req.Receiver)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	ms.mu.Lock()
+	if ms.err != nil {
+		err := ms.err
+		ms.mu.Unlock()
+		ms.flow.release(req.Receiver)
+		return 0, nil, nil, err
+	}
+	ms.nextID++
+	id := ms.nextID
+	resC := make(chan *Delivery, 1)
+	ms.pending[id] = &pendingRequest{resC: resC, receiver: req.Receiver}
+	ms.mu.Unlock()
+
+	// The delivery may be shared by retries, so
+	// the stream specific fields are set on a copy.
+	d := &Delivery{}
+	*d = *req
+	d.Id = id
+	setDeadline(ctx, d)
+	return id, resC, d, nil
+}
+
+// write the delivery to the stream.
+func (ms *muxStream) write(d *Delivery) error {
+	ms.sendMu.Lock()
+	defer ms.sendMu.Unlock()
+	return ms.stream.Send(d)
+}
+
+// wait for the response of a sent delivery, or for the
+// context to finish.
+func (ms *muxStream) wait(ctx context.Context, id uint64, resC chan *Delivery) (*Delivery, error) {
+	select {
+	case <-ctx.Done():
+		ms.forget(id)
+		return nil, ErrContextFinished
+	case res, ok := <-resC:
+		if !ok {
+			ms.mu.Lock()
+			defer ms.mu.Unlock()
+			return nil, ms.sentErr
+		}
+		if res.Failure != "" {
+			return nil, errors.New(res.Failure)
+		}
+		return res, nil
+	}
+}
+
+// recvLoop dispatches responses to the waiting requests.
+func (ms *muxStream) recvLoop() {
+	// Peers send their header when the stream opens,
+	// older peers with their first response.
+	md, err := ms.stream.Header()
+	if err != nil {
+		ms.fail(err)
+		return
+	}
+	ms.mu.Lock()
+	ms.features = negotiate(md)
+	ms.mu.Unlock()
+
+	for {
+		res, err := ms.stream.Recv()
+		if err != nil {
+			ms.fail(err)
+			return
+		}
+		ms.mu.Lock()
+		p, ok := ms.pending[res.Id]
+		delete(ms.pending, res.Id)
+		ms.mu.Unlock()
+		if ok {
+			ms.flow.update(p.receiver, res)
+			ms.loads.update(p.receiver, res, time.Now())
+			p.resC <- res
+		}
+	}
+}
+
+// fail all pending requests, and any future requests.
+func (ms *muxStream) fail(err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.err = fmt.Errorf("%v: %v", errStreamClosed, err)
+	ms.sentErr = fmt.Errorf("%v: %v", ErrStreamBroken, err)
+	ms.unimplemented = status.Code(err) == codes.Unimplemented
+	for id, p := range ms.pending {
+		close(p.resC)
+		delete(ms.pending, id)
+	}
+	ms.flow.reset()
+}
+
+// failure the stream failed with.
+func (ms *muxStream) failure() error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.err
+}
+
+// broken returns true if the stream has failed.
+func (ms *muxStream) broken() bool {
+	return ms.failure() != nil
+}
+
+// isUnimplemented returns true if the peer does not serve streams.
+func (ms *muxStream) isUnimplemented() bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.unimplemented
+}
+
+// supports returns true if the feature was negotiated.
+func (ms *muxStream) supports(feature uint32) bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.features&feature != 0
+}
+
+// forget a request that will no longer wait for its response.
+func (ms *muxStream) forget(id uint64) {
+	ms.mu.Lock()
+	p, ok := ms.pending[id]
+	delete(ms.pending, id)
+	ms.mu.Unlock()
+	if ok {
+		ms.flow.release(p.receiver)
+	}
+}
+
+// close the stream.
+func (ms *muxStream) close() {
+	ms.cancel()
+}
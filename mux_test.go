@@ -0,0 +1,338 @@
+package grid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// echoStreamClient answers each sent delivery, in reverse
+// order of sending, once two have been sent.
+type echoStreamClient struct {
+	grpc.ClientStream
+	sent chan *Delivery
+	recv chan *Delivery
+}
+
+func (x *echoStreamClient) Send(d *Delivery) error {
+	// Like gRPC, the delivery is encoded, ie: copied,
+	// before send returns, since the caller reuses it.
+	c := *d
+	c.Batch = nil
+	for _, b := range d.Batch {
+		bc := *b
+		c.Batch = append(c.Batch, &bc)
+	}
+	x.sent <- &c
+	return nil
+}
+
+func (x *echoStreamClient) Header() (metadata.MD, error) {
+	return featuresMetadata(), nil
+}
+
+func (x *echoStreamClient) Recv() (*Delivery, error) {
+	d, ok := <-x.recv
+	if !ok {
+		return nil, io.EOF
+	}
+	return d, nil
+}
+
+type echoWireClient struct {
+	stream *echoStreamClient
+}
+
+func (c *echoWireClient) Process(ctx context.Context, in *Delivery, opts ...grpc.CallOption) (*Delivery, error) {
+	return nil, errors.New("unary not expected")
+}
+
+func (c *echoWireClient) Stream(ctx context.Context, opts ...grpc.CallOption) (Wire_StreamClient, error) {
+	return c.stream, nil
+}
+
+func (c *echoWireClient) Connect(ctx context.Context, opts ...grpc.CallOption) (Wire_ConnectClient, error) {
+	return nil, errors.New("connect not expected")
+}
+
+func TestMuxStreamOutOfOrder(t *testing.T) {
+	stream := &echoStreamClient{
+		sent: make(chan *Delivery, 2),
+		recv: make(chan *Delivery),
+	}
+	ms, err := newMuxStream(&echoWireClient{stream: stream}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.close()
+
+	go func() {
+		first := <-stream.sent
+		second := <-stream.sent
+		stream.recv <- &Delivery{Id: second.Id, TypeName: second.TypeName}
+		stream.recv <- &Delivery{Id: first.Id, TypeName: first.TypeName}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	results := make(chan error, 2)
+	for _, name := range []string{"a", "b"} {
+		go func(name string) {
+			res, err := ms.request(ctx, &Delivery{TypeName: name, Receiver: name})
+			if err == nil && res.TypeName != name {
+				err = errors.New("response for wrong request: " + res.TypeName)
+			}
+			results <- err
+		}(name)
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMuxStreamBroken(t *testing.T) {
+	stream := &echoStreamClient{
+		sent: make(chan *Delivery, 1),
+		recv: make(chan *Delivery),
+	}
+	ms, err := newMuxStream(&echoWireClient{stream: stream}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.close()
+
+	go func() {
+		<-stream.sent
+		close(stream.recv)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err = ms.request(ctx, &Delivery{})
+	if err == nil || !strings.Contains(err.Error(), ErrStreamBroken.Error()) {
+		t.Fatalf("expected stream broken after send, got: %v", err)
+	}
+	if !ms.broken() {
+		t.Fatal("expected broken stream")
+	}
+
+	// Requests sent once the stream is broken were
+	// never written, so they may be sent again.
+	_, err = ms.request(ctx, &Delivery{})
+	if err == nil || !strings.Contains(err.Error(), errStreamClosed.Error()) {
+		t.Fatalf("expected stream closed before send, got: %v", err)
+	}
+}
+
+// failingStreamClient fails every send.
+type failingStreamClient struct {
+	echoStreamClient
+}
+
+func (x *failingStreamClient) Send(d *Delivery) error {
+	return io.EOF
+}
+
+type failingWireClient struct {
+	echoWireClient
+	stream *failingStreamClient
+}
+
+func (c *failingWireClient) Stream(ctx context.Context, opts ...grpc.CallOption) (Wire_StreamClient, error) {
+	return c.stream, nil
+}
+
+func TestMuxStreamSendFailed(t *testing.T) {
+	stream := &failingStreamClient{echoStreamClient{recv: make(chan *Delivery)}}
+	ms, err := newMuxStream(&failingWireClient{stream: stream}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err = ms.request(ctx, &Delivery{})
+	if err == nil || !strings.Contains(err.Error(), errStreamClosed.Error()) {
+		t.Fatalf("expected stream closed before send, got: %v", err)
+	}
+}
+
+// loopStreamClient answers each sent delivery immediately.
+type loopStreamClient struct {
+	grpc.ClientStream
+	recv chan *Delivery
+}
+
+func (x *loopStreamClient) Send(d *Delivery) error {
+	x.recv <- &Delivery{Id: d.Id}
+	return nil
+}
+
+func (x *loopStreamClient) Header() (metadata.MD, error) {
+	return featuresMetadata(), nil
+}
+
+func (x *loopStreamClient) Recv() (*Delivery, error) {
+	return <-x.recv, nil
+}
+
+type loopWireClient struct {
+	echoWireClient
+	stream *loopStreamClient
+}
+
+func (c *loopWireClient) Stream(ctx context.Context, opts ...grpc.CallOption) (Wire_StreamClient, error) {
+	return c.stream, nil
+}
+
+// BenchmarkMuxStream checks the per request overhead of
+// the multiplexed stream, without any network in between.
+func BenchmarkMuxStream(b *testing.B) {
+	stream := &loopStreamClient{recv: make(chan *Delivery, 1)}
+	ms, err := newMuxStream(&loopWireClient{stream: stream}, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer ms.close()
+
+	ctx := context.Background()
+	req := &Delivery{Data: make([]byte, 128), TypeName: "bench"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := ms.request(ctx, req)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMuxStreamCoalesced(t *testing.T) {
+	stream := &echoStreamClient{
+		sent: make(chan *Delivery, 3),
+		recv: make(chan *Delivery),
+	}
+	ms, err := newMuxStream(&echoWireClient{stream: stream}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.close()
+	ms.co = newCoalescer(ms, time.Hour, 1024)
+
+	// Deliveries are only coalesced once the peer is
+	// known to understand batches.
+	for !ms.supports(featureBatch) {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Two small deliveries are held, the large one
+	// sends them first as one batch, then itself.
+	var ids []uint64
+	for i, size := range []int{10, 10, 2048} {
+		receiver := fmt.Sprintf("receiver-%d", i)
+		id, _, err := ms.sendCoalesced(ctx, &Delivery{Data: make([]byte, size), Receiver: receiver})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	batch := <-stream.sent
+	if len(batch.Batch) != 2 {
+		t.Fatalf("expected batch of 2, got: %v", len(batch.Batch))
+	}
+	if batch.Batch[0].Id != ids[0] || batch.Batch[1].Id != ids[1] {
+		t.Fatal("expected batch in order of sending")
+	}
+	large := <-stream.sent
+	if large.Id != ids[2] || len(large.Batch) != 0 {
+		t.Fatal("expected large delivery sent on its own")
+	}
+}
+
+func TestMuxStreamFlowControl(t *testing.T) {
+	stream := &echoStreamClient{
+		sent: make(chan *Delivery, 2),
+		recv: make(chan *Delivery),
+	}
+	ms, err := newMuxStream(&echoWireClient{stream: stream}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ms.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Before any credit is advertised only one
+	// request is sent to the mailbox.
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := ms.request(ctx, &Delivery{Receiver: "a"})
+			results <- err
+		}()
+	}
+	first := <-stream.sent
+	select {
+	case <-stream.sent:
+		t.Fatal("expected second request to wait for credit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Other mailboxes are not held up.
+	go func() {
+		_, err := ms.request(ctx, &Delivery{Receiver: "b"})
+		results <- err
+	}()
+	other := <-stream.sent
+	if other.Receiver != "b" {
+		t.Fatal("expected request to other mailbox")
+	}
+	stream.recv <- &Delivery{Id: other.Id, Credit: 10, Flow: true}
+
+	// Advertised credit lets the waiting request go.
+	stream.recv <- &Delivery{Id: first.Id, Credit: 10, Flow: true}
+	second := <-stream.sent
+	stream.recv <- &Delivery{Id: second.Id, Credit: 10, Flow: true}
+
+	for i := 0; i < 3; i++ {
+		if err := <-results; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	if f := negotiate(featuresMetadata()); f != supportedFeatures {
+		t.Fatalf("expected all features with a peer of this version, got: %v", f)
+	}
+	if f := negotiate(metadata.MD{}); f != 0 {
+		t.Fatalf("expected no features with an older peer, got: %v", f)
+	}
+	if f := negotiate(metadata.Pairs(featuresMetadataKey, "1")); f != featureBatch {
+		t.Fatalf("expected only features both support, got: %v", f)
+	}
+	if err := checkVersion(&Delivery{Ver: protocolVersion + 1}); err == nil || !strings.Contains(err.Error(), ErrUnsupportedVersion.Error()) {
+		t.Fatalf("expected unsupported version, got: %v", err)
+	}
+	if err := checkVersion(&Delivery{Ver: Delivery_V1}); err != nil {
+		t.Fatalf("expected older version to be supported, got: %v", err)
+	}
+}
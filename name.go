@@ -2,22 +2,91 @@ package grid
 
 import (
 	"fmt"
-	"regexp"
+	"strings"
 )
 
+// MaxNameLength of namespaces, actor types, and names.
+const MaxNameLength = 255
+
+// nameSeparator between the parts of hierarchical names.
+const nameSeparator = "-"
+
 // isNameValid returns true if the name matches the
-// regular expression "^[a-zA-Z0-9-_]+$".
+// regular expression "^[a-zA-Z0-9-_]+$", and is no
+// longer than MaxNameLength.
 func isNameValid(name string) bool {
-	const validActorName = "^[a-zA-Z0-9-_]+$"
+	return ValidateName(name) == nil
+}
 
+// ValidateName returns an error, wrapping ErrInvalidName, that
+// describes why the name is not valid, or nil if it is. Valid
+// names are not empty, no longer than MaxNameLength, and only
+// contain characters in the set: [a-zA-Z0-9-_]. In particular
+// the "." separating the parts of registry keys is not valid.
+func ValidateName(name string) error {
 	if name == "" {
-		return false
+		return fmt.Errorf("%w: empty", ErrInvalidName)
+	}
+	if len(name) > MaxNameLength {
+		return fmt.Errorf("%w: %q is longer than %v", ErrInvalidName, name, MaxNameLength)
+	}
+	for i, r := range name {
+		switch {
+		case 'a' <= r && r <= 'z':
+		case 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9':
+		case r == '-' || r == '_':
+		default:
+			return fmt.Errorf("%w: %q has character %q at %v, only [a-zA-Z0-9-_] are allowed", ErrInvalidName, name, r, i)
+		}
+	}
+	return nil
+}
+
+// JoinName of the parts of a hierarchical name, such as an actor
+// type, shard, and index. The parts are joined by "-", the same as
+// the names of ring members, and the result is validated.
+//
+//     name, err := grid.JoinName("worker", "3", "17") // "worker-3-17"
+//
+func JoinName(parts ...string) (string, error) {
+	for _, part := range parts {
+		if part == "" {
+			return "", fmt.Errorf("%w: empty part in %q", ErrInvalidName, parts)
+		}
+	}
+	name := strings.Join(parts, nameSeparator)
+	err := ValidateName(name)
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// SplitName into n parts, the reverse of JoinName. The last n-1
+// parts must not contain "-", but the first part may, so that a
+// name such as "user-sessions-3-17" splits into 3 parts as the
+// type "user-sessions", shard "3", and index "17".
+func SplitName(name string, n int) ([]string, error) {
+	err := ValidateName(name)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("%w: cannot split %q into %v parts", ErrInvalidName, name, n)
 	}
-	if matched, err := regexp.MatchString(validActorName, name); err != nil {
-		return false
-	} else {
-		return matched
+	parts := make([]string, n)
+	rest := name
+	for i := n - 1; i > 0; i-- {
+		j := strings.LastIndex(rest, nameSeparator)
+		if j <= 0 || j == len(rest)-1 {
+			return nil, fmt.Errorf("%w: %q has fewer than %v parts", ErrInvalidName, name, n)
+		}
+		parts[i] = rest[j+1:]
+		rest = rest[:j]
 	}
+	parts[0] = rest
+	return parts, nil
 }
 
 func stripNamespace(t EntityType, namespace, fullname string) (string, error) {
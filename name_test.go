@@ -1,6 +1,7 @@
 package grid
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -83,3 +84,56 @@ func TestNamespacePrefixInvalidNamespace(t *testing.T) {
 		t.Fatal("expected invalid namespace error")
 	}
 }
+
+func TestValidateName(t *testing.T) {
+	if err := ValidateName("worker-3_a"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"", "ns.mailbox.worker", "worker/3", strings.Repeat("a", MaxNameLength+1)} {
+		err := ValidateName(name)
+		if !errors.Is(err, ErrInvalidName) {
+			t.Fatalf("expected invalid name for: %q, got: %v", name, err)
+		}
+	}
+	if isNameValid(strings.Repeat("a", MaxNameLength+1)) {
+		t.Fatal("expected false for long name")
+	}
+}
+
+func TestJoinSplitName(t *testing.T) {
+	name, err := JoinName("user-sessions", "3", "17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "user-sessions-3-17" {
+		t.Fatalf("expected joined name, got: %v", name)
+	}
+	parts, err := SplitName(name, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts[0] != "user-sessions" || parts[1] != "3" || parts[2] != "17" {
+		t.Fatalf("expected parts of name, got: %v", parts)
+	}
+
+	if _, err := JoinName("worker", ""); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected invalid name for empty part, got: %v", err)
+	}
+	if _, err := JoinName("worker", "a.b"); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected invalid name for separator, got: %v", err)
+	}
+	if _, err := SplitName("worker-3", 3); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected too few parts, got: %v", err)
+	}
+}
+
+func TestActorStartValidate(t *testing.T) {
+	start := NewActorStart("worker-%d", 1)
+	if err := start.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	start.Name = "worker.1"
+	if err := start.Validate(); !errors.Is(err, ErrInvalidActorName) {
+		t.Fatalf("expected invalid actor name, got: %v", err)
+	}
+}
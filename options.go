@@ -0,0 +1,387 @@
+package grid
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/lytics/grid/codec"
+	"github.com/lytics/grid/registry"
+)
+
+// ServerOption configures a server, see NewServer. A ServerCfg
+// is itself an option, which sets all fields of the config.
+type ServerOption interface {
+	applyServer(cfg *ServerCfg)
+}
+
+// ClientOption configures a client, see NewClient. A ClientCfg
+// is itself an option, which sets all fields of the config.
+type ClientOption interface {
+	applyClient(cfg *ClientCfg)
+}
+
+// Option configures both servers and clients.
+type Option interface {
+	ServerOption
+	ClientOption
+}
+
+func (c ServerCfg) applyServer(cfg *ServerCfg) { *cfg = c }
+func (c ClientCfg) applyClient(cfg *ClientCfg) { *cfg = c }
+
+type serverOption func(cfg *ServerCfg)
+
+func (f serverOption) applyServer(cfg *ServerCfg) { f(cfg) }
+
+type clientOption func(cfg *ClientCfg)
+
+func (f clientOption) applyClient(cfg *ClientCfg) { f(cfg) }
+
+type option struct {
+	serverOption
+	clientOption
+}
+
+// newServerCfg from the options, in order, so later
+// options override earlier ones.
+func newServerCfg(options []ServerOption) ServerCfg {
+	var cfg ServerCfg
+	for _, opt := range options {
+		opt.applyServer(&cfg)
+	}
+	return cfg
+}
+
+// newClientCfg from the options, in order, so later
+// options override earlier ones.
+func newClientCfg(options []ClientOption) ClientCfg {
+	var cfg ClientCfg
+	for _, opt := range options {
+		opt.applyClient(&cfg)
+	}
+	return cfg
+}
+
+// WithNamespace of the grid, the only required option.
+func WithNamespace(namespace string) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.Namespace = namespace },
+		func(cfg *ClientCfg) { cfg.Namespace = namespace },
+	}
+}
+
+// WithTimeout for communication with etcd, and internal gossip.
+func WithTimeout(timeout time.Duration) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.Timeout = timeout },
+		func(cfg *ClientCfg) { cfg.Timeout = timeout },
+	}
+}
+
+// WithLogger used for logging.
+func WithLogger(logger Logger) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.Logger = logger },
+		func(cfg *ClientCfg) { cfg.Logger = logger },
+	}
+}
+
+// WithTLS used to serve, and to connect to peers.
+func WithTLS(config *tls.Config) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.TLS = config },
+		func(cfg *ClientCfg) { cfg.TLS = config },
+	}
+}
+
+// WithSigner used to sign, and to verify, requests.
+func WithSigner(signer Signer) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.Signer = signer },
+		func(cfg *ClientCfg) { cfg.Signer = signer },
+	}
+}
+
+// WithKMS used to encrypt actor state and durable actor definitions.
+func WithKMS(kms KMS) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.KMS = kms },
+		func(cfg *ClientCfg) { cfg.KMS = kms },
+	}
+}
+
+// WithValueCodec of registrations and durable actor definitions
+// written to etcd, such as registry.Protobuf.
+func WithValueCodec(codec registry.Codec) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.ValueCodec = codec },
+		func(cfg *ClientCfg) { cfg.ValueCodec = codec },
+	}
+}
+
+// WithLabels of the peer, or client, such as its LabelHost
+// and LabelZone, see ServerCfg.Labels.
+func WithLabels(labels map[string]string) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.Labels = labels },
+		func(cfg *ClientCfg) { cfg.Labels = labels },
+	}
+}
+
+// WithLocality preferring members on peers that share the values
+// of the labels, in order, see ClientCfg.Locality.
+//
+// Example usage:
+//
+//     client, err := grid.NewClient(etcd,
+//         grid.WithNamespace("x"),
+//         grid.WithLabels(map[string]string{grid.LabelZone: "us-east-1a"}),
+//         grid.WithLocality(grid.LabelHost, grid.LabelZone),
+//     )
+//
+func WithLocality(labels ...string) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.Locality = labels },
+		func(cfg *ClientCfg) { cfg.Locality = labels },
+	}
+}
+
+// WithBuild of the application, written into the peer's
+// registrations, see ServerCfg.Build.
+func WithBuild(build string) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.Build = build })
+}
+
+// WithCodec of requests, such as codec.JSON.
+func WithCodec(c codec.Codec) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.Codec = c },
+		func(cfg *ClientCfg) { cfg.Codec = c },
+	}
+}
+
+// WithToken sent with every request.
+func WithToken(token string) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.Token = token },
+		func(cfg *ClientCfg) { cfg.Token = token },
+	}
+}
+
+// WithMaxMsgSize in bytes that can be received and sent.
+func WithMaxMsgSize(recv, send int) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.MaxRecvMsgSize, cfg.MaxSendMsgSize = recv, send },
+		func(cfg *ClientCfg) { cfg.MaxRecvMsgSize, cfg.MaxSendMsgSize = recv, send },
+	}
+}
+
+// WithKeepalive pings after the interval without activity, closing
+// the connection if a ping is not answered within the timeout.
+func WithKeepalive(interval, timeout time.Duration) Option {
+	return option{
+		func(cfg *ServerCfg) { cfg.KeepaliveTime, cfg.KeepaliveTimeout = interval, timeout },
+		func(cfg *ClientCfg) { cfg.KeepaliveTime, cfg.KeepaliveTimeout = interval, timeout },
+	}
+}
+
+// WithoutLeadership prevents the leader from running on the server.
+func WithoutLeadership() ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.DisalowLeadership = true })
+}
+
+// WithPartialStart keeps the server serving when the leader fails
+// to start, instead of stopping it.
+func WithPartialStart() ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.PartialStart = true })
+}
+
+// WithLeaderConflicts checked at the heartbeat interval, and
+// reacted to by the policy.
+func WithLeaderConflicts(heartbeat time.Duration, policy LeaderConflictPolicy) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.LeaderHeartbeat, cfg.LeaderConflictPolicy = heartbeat, policy })
+}
+
+// WithGossip of heartbeats to fanout peers at the interval,
+// peers unheard of for suspectAfter are suspect.
+func WithGossip(interval time.Duration, fanout int, suspectAfter time.Duration) ServerOption {
+	return serverOption(func(cfg *ServerCfg) {
+		cfg.GossipInterval, cfg.GossipFanout, cfg.SuspectAfter = interval, fanout, suspectAfter
+	})
+}
+
+// WithFailureDetector deciding which peers heard of by gossip
+// are suspect, instead of the timeout given to WithGossip.
+func WithFailureDetector(detector FailureDetector) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.FailureDetector = detector })
+}
+
+// WithMaxClockSkew between the clocks of peers heard of by
+// gossip, beyond which a warning is logged.
+func WithMaxClockSkew(d time.Duration) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.MaxClockSkew = d })
+}
+
+// WithLeaseDuration for data in etcd.
+func WithLeaseDuration(d time.Duration) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.LeaseDuration = d })
+}
+
+// WithReconcileInterval for checking that durable actors are running.
+func WithReconcileInterval(d time.Duration) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.ReconcileInterval = d })
+}
+
+// WithDrainTimeout actors are given to finish when the server stops.
+func WithDrainTimeout(d time.Duration) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.DrainTimeout = d })
+}
+
+// WithLameDuckDelay the server keeps serving, after announcing
+// it is a lame duck, when it stops.
+func WithLameDuckDelay(d time.Duration) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.LameDuckDelay = d })
+}
+
+// WithCPUWorkers shared by actors defined with OpExecCPU.
+func WithCPUWorkers(n int) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.CPUWorkers = n })
+}
+
+// WithKeepaliveMinTime clients must wait between pings.
+func WithKeepaliveMinTime(d time.Duration) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.KeepaliveMinTime = d })
+}
+
+// WithMaxConnectionAge after which connections are gracefully
+// closed, giving requests in flight the grace to finish.
+func WithMaxConnectionAge(age, grace time.Duration) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.MaxConnectionAge, cfg.MaxConnectionAgeGrace = age, grace })
+}
+
+// WithSecrets resolving the secret references of actor starts.
+func WithSecrets(provider SecretProvider) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.Secrets = provider })
+}
+
+// WithMessageStore of the messages of durable mailboxes.
+func WithMessageStore(store MessageStore) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.MessageStore = store })
+}
+
+// WithCheckpoints of actors stored in the store, instead of etcd.
+func WithCheckpoints(store BlobStore) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.Checkpoints = store })
+}
+
+// WithAuth verifying the token of each request.
+func WithAuth(auth AuthFunc) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.Auth = auth })
+}
+
+// WithPolicy deciding which callers may manage actors.
+func WithPolicy(policy Policy) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.Policy = policy })
+}
+
+// WithFaultInjection allowing faults to be injected into the
+// server, see Client.InjectFault.
+func WithFaultInjection() ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.AllowFaults = true })
+}
+
+// WithIdentify mapping callers' tokens to identities.
+func WithIdentify(identify IdentityFunc) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.Identify = identify })
+}
+
+// WithAuditor recording audit events.
+func WithAuditor(auditor Auditor) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.Auditor = auditor })
+}
+
+// WithMetrics backend receiving the metrics reported by actors.
+func WithMetrics(backend MetricsBackend) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.Metrics = backend })
+}
+
+// WithTenantQuotas of each tenant, and of particular tenants.
+func WithTenantQuotas(quota TenantQuota, quotas map[string]TenantQuota) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.TenantQuota, cfg.TenantQuotas = quota, quotas })
+}
+
+// WithRateLimit of requests per second from each caller,
+// who may send a burst of requests at once above it.
+func WithRateLimit(limit float64, burst int) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.RateLimit, cfg.RateBurst = limit, burst })
+}
+
+// WithDeliverySlots limiting the requests being delivered at
+// once, which are shared fairly among the waiting mailboxes.
+func WithDeliverySlots(slots int) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.DeliverySlots = slots })
+}
+
+// WithLogCollector mailbox, to which the logs of actors are forwarded.
+func WithLogCollector(mailbox string) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.LogCollector = mailbox })
+}
+
+// WithAdmission policy of the server's mailboxes.
+func WithAdmission(policy *AdmissionPolicy) ServerOption {
+	return serverOption(func(cfg *ServerCfg) { cfg.Admission = policy })
+}
+
+// WithPeersRefreshInterval for polling the list of peers in etcd.
+func WithPeersRefreshInterval(d time.Duration) ClientOption {
+	return clientOption(func(cfg *ClientCfg) { cfg.PeersRefreshInterval = d })
+}
+
+// WithConnectionsPerPeer of gRPC connections to each peer.
+func WithConnectionsPerPeer(n int) ClientOption {
+	return clientOption(func(cfg *ClientCfg) { cfg.ConnectionsPerPeer = n })
+}
+
+// WithDefaultTimeouts of requests and queries made with a
+// timeout of zero.
+func WithDefaultTimeouts(request, query time.Duration) ClientOption {
+	return clientOption(func(cfg *ClientCfg) { cfg.DefaultRequestTimeout, cfg.DefaultQueryTimeout = request, query })
+}
+
+// WithRetryBudget of the largest fraction of requests retried.
+func WithRetryBudget(ratio float64) ClientOption {
+	return clientOption(func(cfg *ClientCfg) { cfg.RetryBudget = ratio })
+}
+
+// WithDefaultTenant on whose behalf requests are sent.
+func WithDefaultTenant(tenant string) ClientOption {
+	return clientOption(func(cfg *ClientCfg) { cfg.Tenant = tenant })
+}
+
+// WithMirroring of requests to canaries, see SetMirror.
+func WithMirroring() ClientOption {
+	return clientOption(func(cfg *ClientCfg) { cfg.Mirroring = true })
+}
+
+// WithWarmConnections to every registered peer, dialing
+// concurrency of them at once.
+func WithWarmConnections(concurrency int) ClientOption {
+	return clientOption(func(cfg *ClientCfg) { cfg.WarmConnections = concurrency })
+}
+
+// WithSaturatedDepth of mailboxes, which RequestGroup avoids.
+func WithSaturatedDepth(depth int) ClientOption {
+	return clientOption(func(cfg *ClientCfg) { cfg.SaturatedDepth = depth })
+}
+
+// WithServer in the same process, to whose mailboxes
+// requests are delivered in process.
+func WithServer(server *Server) ClientOption {
+	return clientOption(func(cfg *ClientCfg) { cfg.Server = server })
+}
+
+// WithCoalesce of small messages sent by sinks, held for at most
+// the delay, or until the batch reaches the size in bytes.
+func WithCoalesce(delay time.Duration, size int) ClientOption {
+	return clientOption(func(cfg *ClientCfg) { cfg.CoalesceDelay, cfg.CoalesceSize = delay, size })
+}
@@ -0,0 +1,51 @@
+package grid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lytics/grid/codec"
+)
+
+func TestServerOptionsOrder(t *testing.T) {
+	cfg := newServerCfg([]ServerOption{
+		ServerCfg{Namespace: "testing", Timeout: time.Second},
+		WithTimeout(2 * time.Second),
+		WithRateLimit(100, 10),
+		WithoutLeadership(),
+	})
+	if cfg.Namespace != "testing" {
+		t.Fatalf("expected namespace of config, got: %v", cfg.Namespace)
+	}
+	if cfg.Timeout != 2*time.Second {
+		t.Fatalf("expected later option to override config, got: %v", cfg.Timeout)
+	}
+	if cfg.RateLimit != 100 || cfg.RateBurst != 10 || !cfg.DisalowLeadership {
+		t.Fatal("expected server options to be applied")
+	}
+
+	// A config replaces everything before it.
+	cfg = newServerCfg([]ServerOption{WithTimeout(time.Second), ServerCfg{Namespace: "testing"}})
+	if cfg.Timeout != 0 {
+		t.Fatalf("expected config to replace earlier options, got: %v", cfg.Timeout)
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	cfg := newClientCfg([]ClientOption{
+		WithNamespace("testing"),
+		WithToken("token"),
+		WithCoalesce(time.Millisecond, 1024),
+		WithWarmConnections(4),
+		WithCodec(codec.JSON),
+	})
+	if cfg.Namespace != "testing" || cfg.Token != "token" {
+		t.Fatal("expected shared options to be applied")
+	}
+	if cfg.CoalesceDelay != time.Millisecond || cfg.CoalesceSize != 1024 || cfg.WarmConnections != 4 {
+		t.Fatal("expected client options to be applied")
+	}
+	if cfg.Codec != codec.JSON {
+		t.Fatal("expected codec to be applied")
+	}
+}
@@ -0,0 +1,70 @@
+package grid
+
+import (
+	"sync"
+	"time"
+)
+
+// paceWeight of the latest service time in the moving average.
+const paceWeight = 0.2
+
+// mailboxPace at which the receiver of a mailbox serves requests,
+// a moving average of the time it takes to respond to one. The
+// service of a request begins when it was queued, or when the
+// previous response was sent, whichever is later, so that the
+// time requests wait in the queue, or the receiver sits idle,
+// is not counted.
+type mailboxPace struct {
+	mu      sync.Mutex
+	last    time.Time
+	service time.Duration
+}
+
+// served a request queued at the time, responded to now.
+func (p *mailboxPace) served(queued, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	began := queued
+	if p.last.After(began) {
+		began = p.last
+	}
+	p.last = now
+	took := now.Sub(began)
+	if p.service == 0 {
+		p.service = took
+		return
+	}
+	p.service += time.Duration(paceWeight * float64(took-p.service))
+}
+
+// wait expected of a request behind the queued requests.
+func (p *mailboxPace) wait(queued int) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Duration(queued) * p.service
+}
+
+// late if the request, behind the requests queued in the mailbox,
+// is not expected to be served before its deadline, in which case
+// it is rejected at once as busy, rather than delivered stale, so
+// that the sender can try elsewhere or back off while it still
+// has time.
+func (box *Mailbox) late(req *request, now time.Time) bool {
+	if req.ctx == nil {
+		return false
+	}
+	deadline, ok := req.ctx.Deadline()
+	if !ok {
+		return false
+	}
+	queued := 0
+	if box.queue != nil {
+		queued = box.queue.size - box.queue.credit()
+	} else {
+		queued = len(box.c)
+	}
+	if queued == 0 {
+		return false
+	}
+	return now.Add(box.pace.wait(queued)).After(deadline)
+}
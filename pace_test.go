@@ -0,0 +1,55 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMailboxPace(t *testing.T) {
+	var p mailboxPace
+	t0 := time.Now()
+
+	// Requests queued together are served one after the
+	// other, the wait in the queue is not service time.
+	p.served(t0, t0.Add(100*time.Millisecond))
+	p.served(t0, t0.Add(200*time.Millisecond))
+	if p.service != 100*time.Millisecond {
+		t.Fatalf("expected service of 100ms, got: %v", p.service)
+	}
+
+	// Idle time of the receiver is not service time.
+	p.served(t0.Add(time.Hour), t0.Add(time.Hour+100*time.Millisecond))
+	if p.service != 100*time.Millisecond {
+		t.Fatalf("expected service of 100ms, got: %v", p.service)
+	}
+	if wait := p.wait(3); wait != 300*time.Millisecond {
+		t.Fatalf("expected wait of 300ms, got: %v", wait)
+	}
+}
+
+func TestMailboxRejectsLate(t *testing.T) {
+	box, c := newTestMailbox(10)
+	box.pace.service = 100 * time.Millisecond
+	for i := 0; i < 5; i++ {
+		c <- newRequest(context.Background(), &EchoMsg{}, &Provenance{})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := box.put(newRequest(ctx, &EchoMsg{}, &Provenance{}))
+	if err != ErrReceiverBusy {
+		t.Fatalf("expected receiver busy, got: %v", err)
+	}
+
+	// Requests with time to spare, or
+	// without deadline, are delivered.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := box.put(newRequest(ctx, &EchoMsg{}, &Provenance{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := box.put(newRequest(context.Background(), &EchoMsg{}, &Provenance{})); err != nil {
+		t.Fatal(err)
+	}
+}
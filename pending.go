@@ -0,0 +1,154 @@
+package grid
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lytics/grid/registry"
+)
+
+// maxStartBackoff between attempts to start a durable actor,
+// however many attempts have failed.
+const maxStartBackoff = 5 * time.Minute
+
+// pendingStart of a durable actor whose last attempt to start
+// failed.
+type pendingStart struct {
+	attempts int
+	next     time.Time
+	peer     string
+	err      string
+}
+
+// startQueue of the durable actors that the reconciler failed to
+// start, so that each is retried with exponential backoff, rather
+// than on every round, while its definition stays in place. It
+// holds at most one entry per durable actor, and the entries of
+// actors that are running, or no longer defined, are pruned each
+// round.
+type startQueue struct {
+	mu      sync.Mutex
+	pending map[string]*pendingStart
+}
+
+func newStartQueue() *startQueue {
+	return &startQueue{
+		pending: map[string]*pendingStart{},
+	}
+}
+
+// due if the actor may be started at now, either because it
+// has not failed to start, or because its backoff has passed.
+func (q *startQueue) due(name string, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p, ok := q.pending[name]
+	return !ok || !now.Before(p.next)
+}
+
+// failed attempt at now to start the actor on the peer, which is
+// empty if there was no peer to start it on. The next attempt is
+// backed off from least, doubling with each failure, up to the
+// maxStartBackoff.
+func (q *startQueue) failed(name, peer string, err error, now time.Time, least time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p, ok := q.pending[name]
+	if !ok {
+		p = &pendingStart{}
+		q.pending[name] = p
+	}
+	p.next = now.Add(restartBackoff(least, maxStartBackoff, p.attempts))
+	p.attempts++
+	p.peer = peer
+	p.err = err.Error()
+}
+
+// started actor, which is no longer pending.
+func (q *startQueue) started(name string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, name)
+}
+
+// prune the actors that are not among the stopped, because they
+// are running, or their definitions were deleted.
+func (q *startQueue) prune(stopped map[string]bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for name := range q.pending {
+		if !stopped[name] {
+			delete(q.pending, name)
+		}
+	}
+}
+
+// clear the queue, once this peer no longer reconciles.
+func (q *startQueue) clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = map[string]*pendingStart{}
+}
+
+// list of the pending starts, ordered by name.
+func (q *startQueue) list() []*PendingStart {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	starts := make([]*PendingStart, 0, len(q.pending))
+	for name, p := range q.pending {
+		starts = append(starts, &PendingStart{
+			Name:     name,
+			Peer:     p.peer,
+			Error:    p.err,
+			Attempts: int32(p.attempts),
+			Next:     p.next.UnixNano(),
+		})
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Name < starts[j].Name })
+	return starts
+}
+
+// PendingDurableActors that the grid failed to start, because
+// peers were full, unreachable, or there were none, with the
+// number of attempts, the last error, and the time of the next
+// attempt, in unix nanoseconds. Attempts back off exponentially,
+// from the ReconcileInterval up to five minutes. The queue is kept
+// by the peer that reconciles durable actors, and is empty while
+// no peer does.
+//
+// Example usage:
+//
+//     pending, err := client.PendingDurableActors(ctx)
+//     ...
+//     for _, p := range pending {
+//         fmt.Println(p.Name, p.Attempts, p.Error)
+//     }
+//
+func (c *Client) PendingDurableActors(ctx context.Context) ([]*PendingStart, error) {
+	key, err := namespaceName(reconcilers, c.cfg.Namespace, "durable")
+	if err != nil {
+		return nil, err
+	}
+	reg, err := c.registry.FindRegistration(ctx, key)
+	if err == registry.ErrUnknownKey {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res, err := RequestT[*PendingStarts](ctx, c, reg.Registry, &PendingStartsQuery{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Starts, nil
+}
+
+// pendingStarts of the request, responding with the queue.
+func (s *Server) pendingStarts(req Request) {
+	err := req.Respond(&PendingStarts{Starts: s.starts.list()})
+	if err != nil {
+		s.logf("%v: failed sending response for pending starts: %v", s.cfg.Namespace, err)
+	}
+}
@@ -0,0 +1,132 @@
+package grid
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lytics/grid/testetcd"
+	"github.com/lytics/retry"
+)
+
+func TestStartQueueBackoff(t *testing.T) {
+	q := newStartQueue()
+	now := time.Now()
+	if !q.due("a", now) {
+		t.Fatal("expected actor that never failed to be due")
+	}
+
+	failure := errors.New("peer full")
+	q.failed("a", "peer-1", failure, now, time.Second)
+	if q.due("a", now) {
+		t.Fatal("expected failed actor to back off")
+	}
+	if !q.due("a", now.Add(time.Second)) {
+		t.Fatal("expected failed actor to be due after its backoff")
+	}
+
+	// Each failure doubles the backoff.
+	q.failed("a", "peer-2", failure, now, time.Second)
+	if q.due("a", now.Add(time.Second)) || !q.due("a", now.Add(2*time.Second)) {
+		t.Fatal("expected backoff to double")
+	}
+
+	// However many failures, attempts are never further apart
+	// than the max backoff.
+	for i := 0; i < 20; i++ {
+		q.failed("a", "peer-2", failure, now, time.Second)
+	}
+	if !q.due("a", now.Add(maxStartBackoff)) {
+		t.Fatal("expected backoff to be bounded")
+	}
+
+	starts := q.list()
+	if len(starts) != 1 {
+		t.Fatalf("expected 1 pending start, got: %v", len(starts))
+	}
+	if p := starts[0]; p.Name != "a" || p.Peer != "peer-2" || p.Error != "peer full" || p.Attempts != 22 {
+		t.Fatalf("unexpected pending start: %v", p)
+	}
+
+	q.started("a")
+	if !q.due("a", now) || len(q.list()) != 0 {
+		t.Fatal("expected started actor to leave the queue")
+	}
+}
+
+func TestStartQueuePrune(t *testing.T) {
+	q := newStartQueue()
+	now := time.Now()
+	for _, name := range []string{"c", "b", "a"} {
+		q.failed(name, "", ErrNoPeers, now, time.Second)
+	}
+
+	// The actor b is running, and c is no longer defined.
+	q.prune(map[string]bool{"a": true})
+	starts := q.list()
+	if len(starts) != 1 || starts[0].Name != "a" {
+		t.Fatalf("expected only a pending, got: %v", starts)
+	}
+
+	q.clear()
+	if len(q.list()) != 0 {
+		t.Fatal("expected empty queue")
+	}
+}
+
+func TestPendingDurableActors(t *testing.T) {
+	const (
+		timeout = 2 * time.Second
+		backoff = time.Second
+	)
+
+	namespace := newNamespace()
+
+	etcd := testetcd.StartAndConnect(t)
+	defer etcd.Close()
+
+	server, err := NewServer(etcd, ServerCfg{Namespace: namespace, ReconcileInterval: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(lis)
+	defer server.Stop()
+
+	client, err := NewClient(etcd, ClientCfg{Namespace: namespace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// No definition of the actor's type is registered
+	// on the server, so every start of it fails.
+	start := NewActorStart("undefined")
+	start.Type = "undefined"
+	err = client.PutDurableActor(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pending []*PendingStart
+	retry.X(10, backoff, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		pending, err = client.PendingDurableActors(ctx)
+		return err != nil || len(pending) == 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Name != "undefined" || pending[0].Attempts < 1 || pending[0].Error == "" {
+		t.Fatalf("expected pending start of the undefined actor, got: %v", pending)
+	}
+}
@@ -0,0 +1,149 @@
+package grid
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+)
+
+// PeerLoad of a peer, as published through the registry, from
+// which a Placement chooses.
+type PeerLoad struct {
+	// Peer name.
+	Peer string
+	// Actors registered on the peer.
+	Actors int
+}
+
+// Placement chooses the peer to start the actor on, from the peers
+// that are neither suspect, nor lame ducks, nor in quarantine, see
+// Client.QuarantinePeer, ordered by name. It is never called without
+// peers. Placements of other needs, such as keeping actors in a zone,
+// can be written as functions of this type.
+//
+// Example usage:
+//
+//     spread := func(start *grid.ActorStart, peers []grid.PeerLoad) string {
+//         for _, p := range peers {
+//             if strings.HasPrefix(p.Peer, "us-east-") {
+//                 return p.Peer
+//             }
+//         }
+//         return peers[0].Peer
+//     }
+//     peer, err := client.StartActorWithPlacement(ctx, start, spread)
+//
+type Placement func(start *ActorStart, peers []PeerLoad) string
+
+// RandomPeer places each actor on a peer chosen at random.
+func RandomPeer() Placement {
+	return func(_ *ActorStart, peers []PeerLoad) string {
+		return peers[rand.Intn(len(peers))].Peer
+	}
+}
+
+// RoundRobin places each actor on the peer after the one
+// the previous actor was placed on.
+func RoundRobin() Placement {
+	var next uint64
+	return func(_ *ActorStart, peers []PeerLoad) string {
+		i := atomic.AddUint64(&next, 1) - 1
+		return peers[i%uint64(len(peers))].Peer
+	}
+}
+
+// LeastActors places each actor on the peer with the fewest
+// actors registered, ties broken by name.
+func LeastActors() Placement {
+	return func(_ *ActorStart, peers []PeerLoad) string {
+		least := peers[0]
+		for _, p := range peers[1:] {
+			if p.Actors < least.Actors {
+				least = p
+			}
+		}
+		return least.Peer
+	}
+}
+
+// ConsistentHash places actors with the same key on the same peer
+// for as long as it is live, and moves only the actors of a peer
+// that leaves when peers come and go. An empty key places each
+// actor by its name.
+func ConsistentHash(key string) Placement {
+	return func(start *ActorStart, peers []PeerLoad) string {
+		k := key
+		if k == "" {
+			k = start.Name
+		}
+		// Rendezvous hashing: the peer with the
+		// highest score for the key wins.
+		var best string
+		var bestScore uint64
+		for _, p := range peers {
+			h := fnv.New64a()
+			h.Write([]byte(k))
+			h.Write([]byte{0})
+			h.Write([]byte(p.Peer))
+			if score := h.Sum64(); best == "" || score > bestScore {
+				best, bestScore = p.Peer, score
+			}
+		}
+		return best
+	}
+}
+
+// StartActorWithPlacement on the peer chosen by the placement,
+// which is returned.
+//
+// Example usage:
+//
+//     start := grid.NewActorStart("worker-%d", i)
+//     start.Type = "worker"
+//     peer, err := client.StartActorWithPlacement(ctx, start, grid.LeastActors())
+//
+func (c *Client) StartActorWithPlacement(ctx context.Context, start *ActorStart, placement Placement) (string, error) {
+	peers, err := c.peerLoads(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(peers) == 0 {
+		return "", ErrNoPeers
+	}
+	return c.startOnPeer(ctx, start, placement(start, peers))
+}
+
+// startOnPeer the actor, and return the peer.
+func (c *Client) startOnPeer(ctx context.Context, start *ActorStart, peer string) (string, error) {
+	timeout, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	_, err := c.RequestC(timeout, peer, start)
+	if err != nil {
+		return "", err
+	}
+	return peer, nil
+}
+
+// peerLoads of the healthy peers, ordered by name.
+func (c *Client) peerLoads(ctx context.Context) ([]PeerLoad, error) {
+	peers, err := c.QueryC(ctx, Peers, Healthy())
+	if err != nil {
+		return nil, err
+	}
+	actors, err := c.QueryC(ctx, Actors)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(peers))
+	for _, a := range actors {
+		counts[a.Peer()]++
+	}
+	loads := make([]PeerLoad, 0, len(peers))
+	for _, p := range peers {
+		loads = append(loads, PeerLoad{Peer: p.Name(), Actors: counts[p.Name()]})
+	}
+	sort.Slice(loads, func(i, j int) bool { return loads[i].Peer < loads[j].Peer })
+	return loads, nil
+}
@@ -0,0 +1,63 @@
+package grid
+
+import (
+	"testing"
+)
+
+func TestPlacement(t *testing.T) {
+	peers := []PeerLoad{
+		{Peer: "peer-a", Actors: 3},
+		{Peer: "peer-b", Actors: 1},
+		{Peer: "peer-c", Actors: 1},
+	}
+	start := NewActorStart("worker-1")
+
+	rr := RoundRobin()
+	for i, expected := range []string{"peer-a", "peer-b", "peer-c", "peer-a"} {
+		if got := rr(start, peers); got != expected {
+			t.Fatalf("round robin %v: expected: %v, got: %v", i, expected, got)
+		}
+	}
+
+	if got := LeastActors()(start, peers); got != "peer-b" {
+		t.Fatalf("expected least actors on peer-b, got: %v", got)
+	}
+
+	if got := RandomPeer()(start, peers[:1]); got != "peer-a" {
+		t.Fatalf("expected only peer, got: %v", got)
+	}
+}
+
+func TestConsistentHashPlacement(t *testing.T) {
+	peers := []PeerLoad{{Peer: "peer-a"}, {Peer: "peer-b"}, {Peer: "peer-c"}, {Peer: "peer-d"}}
+
+	// Actors of the same key are placed together.
+	byKey := ConsistentHash("tenant-1")
+	first := byKey(NewActorStart("worker-1"), peers)
+	if got := byKey(NewActorStart("worker-2"), peers); got != first {
+		t.Fatalf("expected same peer for same key, got: %v and %v", first, got)
+	}
+
+	// Only the actors of a peer that leaves move.
+	byName := ConsistentHash("")
+	placed := map[string]string{}
+	for i := 0; i < 100; i++ {
+		start := NewActorStart("worker-%d", i)
+		placed[start.Name] = byName(start, peers)
+	}
+	var remaining []PeerLoad
+	for _, p := range peers {
+		if p.Peer != "peer-b" {
+			remaining = append(remaining, p)
+		}
+	}
+	for name, peer := range placed {
+		got := byName(NewActorStart(name), remaining)
+		if peer != "peer-b" && got != peer {
+			t.Fatalf("expected %v to stay on %v, moved to: %v", name, peer, got)
+		}
+		if got == "peer-b" {
+			t.Fatalf("expected %v to leave peer-b", name)
+		}
+	}
+}
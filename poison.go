@@ -0,0 +1,312 @@
+package grid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+	"github.com/golang/protobuf/proto"
+	"github.com/lytics/grid/codec"
+)
+
+// deadLetters is the key space of quarantined messages.
+const deadLetters EntityType = "deadletter"
+
+// poisonPolicy of a mailbox, the number of attempts at handling a
+// message before it is quarantined, zero if messages never are.
+type poisonPolicy struct {
+	attempts int32
+	mu       sync.Mutex
+	pending  map[uint64]*request
+}
+
+// QuarantinePoison messages of the mailbox. A message is poison when
+// the mailbox's receiver stops, for example by panicking, after taking
+// the message but before responding to it, since the actor will likely
+// stop again if it takes the same message again. After the given
+// number of such attempts, messages identical to it are no longer
+// delivered to the mailbox, but are rejected with ErrPoisonMessage,
+// and one copy is kept as a dead letter of the mailbox, so that the
+// actor can continue with other messages instead of crash-looping.
+// Identical messages are rejected until the server restarts.
+//
+// Example usage:
+//
+//     mailbox, err := grid.NewMailbox(server, name, 10)
+//     ...
+//     defer mailbox.Close()
+//     mailbox.QuarantinePoison(3)
+//
+// The dead letters can be listed with Client.DeadLetters. Attempts
+// of zero, the default, never quarantine messages.
+func (box *Mailbox) QuarantinePoison(attempts int) {
+	box.poison.mu.Lock()
+	defer box.poison.mu.Unlock()
+	if box.poison.pending == nil {
+		box.poison.pending = map[uint64]*request{}
+	}
+	atomic.StoreInt32(&box.poison.attempts, int32(attempts))
+}
+
+// track the request until it is responded to, if the mailbox
+// quarantines poison. Requests that are known to be poison are
+// not tracked but rejected.
+func (box *Mailbox) track(req *request) error {
+	attempts := atomic.LoadInt32(&box.poison.attempts)
+	if attempts <= 0 || box.server == nil {
+		return nil
+	}
+	fingerprint, err := poisonFingerprint(req.codec, req.msg)
+	if err != nil {
+		// Messages that cannot be encoded are
+		// never sent to the mailbox from afar.
+		return nil
+	}
+	if box.server.poison.attempts(box.nsName, fingerprint) >= int(attempts) {
+		box.server.poison.reject(box.nsName, box.name, fingerprint, req)
+		return ErrPoisonMessage
+	}
+	box.poison.mu.Lock()
+	defer box.poison.mu.Unlock()
+	req.box = box
+	req.fingerprint = fingerprint
+	box.poison.pending[req.seq] = req
+	return nil
+}
+
+// untrack the request, which was not put into the mailbox.
+func (box *Mailbox) untrack(req *request) {
+	if req.box == nil {
+		return
+	}
+	box.poison.mu.Lock()
+	defer box.poison.mu.Unlock()
+	delete(box.poison.pending, req.seq)
+}
+
+// handled request, which was responded to, so it is not poison.
+func (box *Mailbox) handled(req *request) {
+	box.poison.mu.Lock()
+	delete(box.poison.pending, req.seq)
+	box.poison.mu.Unlock()
+	box.server.poison.clear(box.nsName, req.fingerprint)
+}
+
+// abandon the tracked requests that the receiver took, but did
+// not respond to, counting them as attempts at poison. It is
+// called with the mailbox locked, while it closes.
+func (box *Mailbox) abandon() {
+	if box.server == nil {
+		return
+	}
+	box.poison.mu.Lock()
+	pending := len(box.poison.pending)
+	box.poison.mu.Unlock()
+	if pending == 0 {
+		return
+	}
+
+	// Requests still queued were never taken.
+	queued := map[*request]bool{}
+	if box.queue != nil {
+		for _, r := range box.queue.undelivered() {
+			queued[r.(*request)] = true
+		}
+	} else {
+		box.inspectLocked(func(reqs []*request) []*request {
+			for _, r := range reqs {
+				queued[r] = true
+			}
+			return reqs
+		})
+	}
+
+	// Requests responded to are no longer pending.
+	box.poison.mu.Lock()
+	defer box.poison.mu.Unlock()
+	for seq, r := range box.poison.pending {
+		delete(box.poison.pending, seq)
+		if !queued[r] {
+			box.server.poison.abandon(box.nsName, r.fingerprint)
+		}
+	}
+}
+
+// poisonFingerprint identifying messages that are the same,
+// when encoded with the codec of their request.
+func poisonFingerprint(c codec.Codec, msg interface{}) (string, error) {
+	raw, err := encode(c, msg)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(raw.Codec))
+	h.Write([]byte{0})
+	h.Write([]byte(raw.TypeName))
+	h.Write([]byte{0})
+	h.Write(raw.Data)
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// poisonCounts of the server, the number of times the receivers of
+// its mailboxes abandoned each message, by mailbox and fingerprint.
+type poisonCounts struct {
+	mu          sync.Mutex
+	counts      map[string]int
+	quarantined map[string]bool
+	quarantine  func(mailbox, fingerprint string, req *request)
+}
+
+func newPoisonCounts(quarantine func(mailbox, fingerprint string, req *request)) *poisonCounts {
+	return &poisonCounts{
+		counts:      map[string]int{},
+		quarantined: map[string]bool{},
+		quarantine:  quarantine,
+	}
+}
+
+func (pc *poisonCounts) abandon(mailbox, fingerprint string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.counts[mailbox+"/"+fingerprint]++
+}
+
+func (pc *poisonCounts) attempts(mailbox, fingerprint string) int {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.counts[mailbox+"/"+fingerprint]
+}
+
+// reject the poison request, quarantining the first
+// copy of it as a dead letter of the mailbox.
+func (pc *poisonCounts) reject(nsName, mailbox, fingerprint string, req *request) {
+	pc.mu.Lock()
+	key := nsName + "/" + fingerprint
+	first := !pc.quarantined[key]
+	pc.quarantined[key] = true
+	pc.mu.Unlock()
+	if first {
+		pc.quarantine(mailbox, fingerprint, req)
+	}
+}
+
+// clear the count of a message that was handled, unless
+// it is poison already.
+func (pc *poisonCounts) clear(mailbox, fingerprint string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	delete(pc.counts, mailbox+"/"+fingerprint)
+}
+
+// quarantine the poison request as a dead letter of the mailbox,
+// in the background, since it is rejected during delivery.
+func (s *Server) quarantine(mailbox, fingerprint string, req *request) {
+	key, err := deadLetterKey(s.cfg.Namespace, mailbox, fingerprint)
+	if err != nil {
+		s.logf("%v: invalid dead letter key: %v", s.cfg.Namespace, err)
+		return
+	}
+	qm, err := queuedMessage(req, true)
+	if err != nil {
+		s.logf("%v: failed quarantining poison message of mailbox: %v: %v", s.cfg.Namespace, mailbox, err)
+		return
+	}
+	go func() {
+		timeout, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+		defer cancel()
+		buf, err := proto.Marshal(qm)
+		if err == nil {
+			buf, err = seal(timeout, s.cfg.KMS, buf)
+		}
+		if err == nil {
+			_, err = s.etcd.Put(timeout, key, string(buf))
+		}
+		if err != nil {
+			s.logf("%v: failed quarantining poison message of mailbox: %v: %v", s.cfg.Namespace, mailbox, err)
+			return
+		}
+		s.logf("%v: quarantined poison message of mailbox: %v, type: %v, dead letter: %v", s.cfg.Namespace, mailbox, qm.TypeName, fingerprint)
+	}()
+}
+
+// DeadLetter quarantined from a mailbox, see QuarantinePoison.
+// Identical poison messages are kept as one dead letter.
+type DeadLetter struct {
+	ID      string
+	Mailbox string
+	*QueuedMessage
+}
+
+// DeadLetters of the mailbox.
+//
+// Example usage:
+//
+//     letters, err := client.DeadLetters(ctx, "worker-1")
+//     ...
+//     for _, letter := range letters {
+//         msg, err := codec.Unmarshal(letter.Data, letter.TypeName)
+//         ...
+//         err = client.DeleteDeadLetter(ctx, "worker-1", letter.ID)
+//     }
+//
+func (c *Client) DeadLetters(ctx context.Context, mailbox string) ([]*DeadLetter, error) {
+	prefix, err := deadLetterPrefix(c.cfg.Namespace, mailbox)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.etcd.Get(ctx, prefix, etcdv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	letters := make([]*DeadLetter, 0, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		buf, err := open(ctx, c.cfg.KMS, kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		qm := &QueuedMessage{}
+		err = proto.Unmarshal(buf, qm)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, &DeadLetter{
+			ID:            strings.TrimPrefix(string(kv.Key), prefix),
+			Mailbox:       mailbox,
+			QueuedMessage: qm,
+		})
+	}
+	return letters, nil
+}
+
+// DeleteDeadLetter of the mailbox, by its ID.
+func (c *Client) DeleteDeadLetter(ctx context.Context, mailbox, id string) error {
+	key, err := deadLetterKey(c.cfg.Namespace, mailbox, id)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Delete(ctx, key)
+	return err
+}
+
+func deadLetterKey(namespace, mailbox, id string) (string, error) {
+	prefix, err := deadLetterPrefix(namespace, mailbox)
+	if err != nil {
+		return "", err
+	}
+	if !isNameValid(id) {
+		return "", ErrInvalidName
+	}
+	return prefix + id, nil
+}
+
+func deadLetterPrefix(namespace, mailbox string) (string, error) {
+	nsName, err := namespaceName(deadLetters, namespace, mailbox)
+	if err != nil {
+		return "", err
+	}
+	return nsName + ".", nil
+}
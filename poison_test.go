@@ -0,0 +1,141 @@
+package grid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lytics/grid/codec"
+)
+
+func TestQuarantinePoison(t *testing.T) {
+	var quarantined []string
+	s := &Server{cfg: ServerCfg{Namespace: "testing"}}
+	s.poison = newPoisonCounts(func(mailbox, fingerprint string, req *request) {
+		quarantined = append(quarantined, mailbox+"/"+fingerprint)
+	})
+	newBox := func() *Mailbox {
+		boxC := make(chan Request, 10)
+		box := &Mailbox{
+			name:    "worker",
+			nsName:  "testing.mailbox.worker",
+			C:       boxC,
+			c:       boxC,
+			server:  s,
+			cleanup: func() error { return nil },
+		}
+		box.QuarantinePoison(2)
+		return box
+	}
+	send := func(box *Mailbox, msg string) (*request, error) {
+		req := newRequest(context.Background(), &EchoMsg{Msg: msg}, &Provenance{})
+		return req, box.put(req)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		box := newBox()
+		if _, err := send(box, "poison"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := send(box, "fine"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := send(box, "queued"); err != nil {
+			t.Fatal(err)
+		}
+
+		// The receiver handles one message, and
+		// stops while handling the poison.
+		<-box.C
+		fine := <-box.C
+		if err := fine.Ack(); err != nil {
+			t.Fatal(err)
+		}
+		box.Close()
+	}
+
+	// Only the poison was abandoned, the message
+	// still queued was never taken.
+	box := newBox()
+	fp, err := poisonFingerprint(nil, &EchoMsg{Msg: "poison"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.poison.attempts(box.nsName, fp); n != 2 {
+		t.Fatalf("expected 2 attempts, got: %v", n)
+	}
+	fp, err = poisonFingerprint(nil, &EchoMsg{Msg: "queued"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.poison.attempts(box.nsName, fp); n != 0 {
+		t.Fatalf("expected no attempts, got: %v", n)
+	}
+	if _, err := send(box, "fine"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := send(box, "poison"); err != ErrPoisonMessage {
+			t.Fatalf("expected poison message, got: %v", err)
+		}
+	}
+
+	// One copy is kept as a dead letter.
+	fp, err = poisonFingerprint(nil, &EchoMsg{Msg: "poison"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quarantined) != 1 || quarantined[0] != "worker/"+fp {
+		t.Fatalf("expected one dead letter, got: %v", quarantined)
+	}
+}
+
+// plainPoison is not a protobuf message, so only
+// the JSON codec encodes it.
+type plainPoison struct {
+	Msg string
+}
+
+func TestQuarantinePoisonJSON(t *testing.T) {
+	Register(plainPoison{})
+
+	s := &Server{cfg: ServerCfg{Namespace: "testing"}}
+	s.poison = newPoisonCounts(func(mailbox, fingerprint string, req *request) {})
+	newBox := func() *Mailbox {
+		boxC := make(chan Request, 10)
+		box := &Mailbox{
+			name:    "worker",
+			nsName:  "testing.mailbox.worker",
+			C:       boxC,
+			c:       boxC,
+			server:  s,
+			cleanup: func() error { return nil },
+		}
+		box.QuarantinePoison(1)
+		return box
+	}
+	send := func(box *Mailbox) error {
+		req := newRequest(context.Background(), &plainPoison{Msg: "poison"}, &Provenance{})
+		req.codec = codec.JSON
+		return box.put(req)
+	}
+
+	box := newBox()
+	if err := send(box); err != nil {
+		t.Fatal(err)
+	}
+	// The receiver stops while handling the poison.
+	<-box.C
+	box.Close()
+
+	fp, err := poisonFingerprint(codec.JSON, &plainPoison{Msg: "poison"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	box = newBox()
+	if n := s.poison.attempts(box.nsName, fp); n != 1 {
+		t.Fatalf("expected 1 attempt, got: %v", n)
+	}
+	if err := send(box); err != ErrPoisonMessage {
+		t.Fatalf("expected poison message, got: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// Action a caller performs through a request.
+type Action string
+
+const (
+	// ActionSend is sending a message to an actor's mailbox.
+	ActionSend Action = "send"
+	// ActionManage is managing actors, such as starting them,
+	// ie: any request to a peer's own mailbox.
+	ActionManage Action = "manage"
+	// ActionInspect is peeking at, requeueing, or discarding the
+	// messages queued in a mailbox, or diagnosing an actor.
+	ActionInspect Action = "inspect"
+	// ActionStart is an actor starting, it is only recorded
+	// in audit events, policies decide on ActionManage.
+	ActionStart Action = "start"
+	// ActionStop is an actor stopping, it is only recorded
+	// in audit events.
+	ActionStop Action = "stop"
+	// ActionRequeue, ActionDiscard, and ActionTransfer of queued
+	// messages are only recorded in audit events, policies decide
+	// on ActionInspect.
+	ActionRequeue  Action = "requeue"
+	ActionDiscard  Action = "discard"
+	ActionTransfer Action = "transfer"
+	// ActionInjectFault is injecting faults into a peer, or
+	// clearing them, see Client.InjectFault.
+	ActionInjectFault Action = "inject-fault"
+)
+
+// Policy decides if the caller may perform the action on the
+// target, which is the actor's name for management actions,
+// and the receiving mailbox for sends and inspections.
+type Policy interface {
+	Allow(ctx context.Context, caller string, action Action, target string) bool
+}
+
+// IdentityFunc returns the identity of the caller holding the
+// token, for example a subject from the token's claims.
+type IdentityFunc func(ctx context.Context, token string) (string, error)
+
+// RolePolicy allows managers to manage actors and to inspect
+// mailboxes, and senders to send messages. Managers may also send. If Senders is empty
+// anyone may send. Peers start actors on each other, for
+// example for the leader, so peers must be managers too.
+//
+// Example usage:
+//
+//     server, err := grid.NewServer(etcd, grid.ServerCfg{
+//         Namespace: "billing",
+//         Policy: &grid.RolePolicy{
+//             Managers: []string{"spiffe://example.com/deployer"},
+//         },
+//     })
+//
+type RolePolicy struct {
+	Managers []string
+	Senders  []string
+}
+
+// Allow the action if the caller has a role permitting it.
+func (rp *RolePolicy) Allow(ctx context.Context, caller string, action Action, target string) bool {
+	if contains(rp.Managers, caller) {
+		return true
+	}
+	if action != ActionSend {
+		return false
+	}
+	return len(rp.Senders) == 0 || contains(rp.Senders, caller)
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditEvent of a decision made about a caller's request, or
+// of an operation performed on an actor. Peer and Actor are the
+// provenance of the request, or the peer of the server itself
+// for operations it performs on its own, such as stops.
+type AuditEvent struct {
+	Time      time.Time
+	Namespace string
+	Caller    string
+	Peer      string
+	Actor     string
+	Action    Action
+	Target    string
+	Denied    bool
+}
+
+// String of the event, for logs.
+func (ev *AuditEvent) String() string {
+	decision := "allowed"
+	if ev.Denied {
+		decision = "denied"
+	}
+	return fmt.Sprintf("%v: %v %v caller: %q, peer: %q, actor: %q, target: %v",
+		ev.Namespace, decision, ev.Action, ev.Caller, ev.Peer, ev.Actor, ev.Target)
+}
+
+// Auditor records audit events. The default, when a server has
+// none, is to log the events through the server's logger.
+type Auditor interface {
+	Audit(ev *AuditEvent)
+}
+
+// audit the event.
+func (s *Server) audit(ev *AuditEvent) {
+	if s.cfg.Auditor != nil {
+		s.cfg.Auditor.Audit(ev)
+		return
+	}
+	s.logf("audit: %v", ev)
+}
+
+// callerIdentity is the subject of the caller's TLS certificate,
+// or else the identity of its token, or else empty.
+func (s *Server) callerIdentity(ctx context.Context) (string, error) {
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+			cert := info.State.PeerCertificates[0]
+			switch {
+			case len(cert.URIs) > 0:
+				return cert.URIs[0].String(), nil
+			case len(cert.DNSNames) > 0:
+				return cert.DNSNames[0], nil
+			default:
+				return cert.Subject.CommonName, nil
+			}
+		}
+	}
+	if s.cfg.Identify != nil {
+		return s.cfg.Identify(ctx, requestToken(ctx))
+	}
+	return "", nil
+}
+
+// authorize the request of the caller, which has been decoded
+// into msg, against the server's policy, if it has one.
+func (s *Server) authorize(ctx context.Context, caller string, d *Delivery, msg interface{}) error {
+	if s.cfg.Policy == nil {
+		return nil
+	}
+	action := ActionSend
+	target := d.Receiver
+	if d.Receiver == s.peerMailbox {
+		action = ActionManage
+		switch msg := msg.(type) {
+		case *ActorStart:
+			target = msg.Name
+		case *MailboxPeek:
+			action, target = ActionInspect, msg.Mailbox
+		case *MailboxRequeue:
+			action, target = ActionInspect, msg.Mailbox
+		case *MailboxTransfer:
+			action, target = ActionInspect, msg.Mailbox
+		case *ActorDiagnose:
+			action, target = ActionInspect, msg.Actor
+		case *Control:
+			if isFaultControl(msg) {
+				action, target = ActionInjectFault, msg.Command
+			}
+		}
+	}
+	if s.cfg.Policy.Allow(ctx, caller, action, target) {
+		return nil
+	}
+	s.audit(&AuditEvent{
+		Time:      time.Now(),
+		Namespace: s.cfg.Namespace,
+		Caller:    caller,
+		Peer:      d.FromPeer,
+		Actor:     d.FromActor,
+		Action:    action,
+		Target:    target,
+		Denied:    true,
+	})
+	return ErrUnauthorized
+}
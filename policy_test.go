@@ -0,0 +1,67 @@
+package grid
+
+import (
+	"context"
+	"testing"
+)
+
+type recordingAuditor struct {
+	events []*AuditEvent
+}
+
+func (ra *recordingAuditor) Audit(ev *AuditEvent) {
+	ra.events = append(ra.events, ev)
+}
+
+func TestRolePolicy(t *testing.T) {
+	ctx := context.Background()
+	rp := &RolePolicy{Managers: []string{"deployer"}}
+
+	if !rp.Allow(ctx, "deployer", ActionManage, "worker") {
+		t.Fatal("expected manager to manage")
+	}
+	if !rp.Allow(ctx, "anyone", ActionSend, "worker") {
+		t.Fatal("expected anyone to send")
+	}
+	if rp.Allow(ctx, "anyone", ActionManage, "worker") {
+		t.Fatal("expected non-manager to be denied")
+	}
+
+	rp.Senders = []string{"app"}
+	if rp.Allow(ctx, "anyone", ActionSend, "worker") {
+		t.Fatal("expected non-sender to be denied")
+	}
+	if !rp.Allow(ctx, "deployer", ActionSend, "worker") {
+		t.Fatal("expected manager to send")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	auditor := &recordingAuditor{}
+	s := &Server{
+		cfg: ServerCfg{
+			Namespace: "testing",
+			Policy:    &RolePolicy{Managers: []string{"deployer"}},
+			Auditor:   auditor,
+		},
+		peerMailbox: "testing.mailbox.peer-1",
+	}
+
+	start := NewActorStart("worker")
+	d := &Delivery{Receiver: s.peerMailbox, FromPeer: "peer-2"}
+
+	ctx := context.Background()
+	if err := s.authorize(ctx, "app", d, start); err != ErrUnauthorized {
+		t.Fatalf("expected unauthorized, got: %v", err)
+	}
+	if len(auditor.events) != 1 || auditor.events[0].Target != "worker" || auditor.events[0].Peer != "peer-2" || !auditor.events[0].Denied {
+		t.Fatalf("expected denied start to be audited, got: %v", auditor.events)
+	}
+	if err := s.authorize(ctx, "app", &Delivery{Receiver: "testing.mailbox.worker"}, &EchoMsg{}); err != nil {
+		t.Fatalf("expected send to be allowed, got: %v", err)
+	}
+
+	if err := s.authorize(ctx, "deployer", d, start); err != nil {
+		t.Fatalf("expected start to be allowed, got: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package grid
+
+import "sync"
+
+// deliveryPool of envelopes, reused on the hot path of receiving,
+// where an envelope is not needed once its message has been decoded.
+// Envelopes that are sent are not pooled, since gRPC may still read
+// them after Send returns.
+var deliveryPool = sync.Pool{
+	New: func() interface{} {
+		return &Delivery{}
+	},
+}
+
+// getDelivery from the pool.
+func getDelivery() *Delivery {
+	return deliveryPool.Get().(*Delivery)
+}
+
+// putDelivery back into the pool, the caller must not use
+// the delivery, or any slice taken from it, afterwards.
+func putDelivery(d *Delivery) {
+	*d = Delivery{}
+	deliveryPool.Put(d)
+}
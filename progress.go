@@ -0,0 +1,141 @@
+package grid
+
+import (
+	"context"
+	"strings"
+
+	netcontext "golang.org/x/net/context"
+)
+
+// Progress update for the request, sent to its sender before the
+// response, if the sender asked for updates with RequestWithProgress.
+// Otherwise ErrProgressUnsupported is returned, and the update can
+// be skipped. Updates cannot be sent once the request is responded
+// to, and the receiver waits for each update to be sent.
+//
+// Example usage:
+//
+//     case req := <-mailbox.C:
+//         for i, part := range parts {
+//             process(part)
+//             err := grid.Progress(req, &Done{Parts: int32(i + 1)})
+//             ...
+//         }
+//         req.Respond(&Result{})
+//
+func Progress(req Request, msg interface{}) error {
+	r, ok := req.(*request)
+	if !ok || r.progress == nil {
+		return ErrProgressUnsupported
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.finished {
+		return r.alreadyResponded()
+	}
+	raw, err := encode(r.codec, msg)
+	if err != nil {
+		return err
+	}
+	return r.progress(&Delivery{
+		Ver:      protocolVersion,
+		Data:     raw.Data,
+		TypeName: raw.TypeName,
+		Codec:    raw.Codec,
+		Progress: true,
+	})
+}
+
+// RequestWithProgress sends the request, and calls progress with each
+// update the receiver sends with Progress, in order, before returning
+// the response. The request goes over its own gRPC stream, even to a
+// mailbox in the same process, and it is not retried, since the
+// receiver may have started on it.
+//
+// Example usage:
+//
+//     res, err := client.RequestWithProgress(ctx, "worker-1", &Work{},
+//         func(update interface{}) {
+//             log.Printf("progress: %v", update)
+//         })
+//
+func (c *Client) RequestWithProgress(ctx context.Context, receiver string, msg interface{}, progress func(update interface{})) (interface{}, error) {
+	nsReceiver, err := namespaceName(Mailboxes, c.cfg.Namespace, receiver)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := encode(c.cfg.Codec, msg)
+	if err != nil {
+		return nil, err
+	}
+	req := &Delivery{
+		Ver:      protocolVersion,
+		Data:     raw.Data,
+		TypeName: raw.TypeName,
+		Codec:    raw.Codec,
+		Receiver: nsReceiver,
+	}
+	c.stamp(ctx, req)
+	err = sign(c.cfg.Signer, req)
+	if err != nil {
+		return nil, err
+	}
+
+	client, clientID, err := c.getWireClient(ctx, nsReceiver)
+	if err != nil {
+		if strings.Contains(err.Error(), ErrUnregisteredMailbox.Error()) {
+			c.deleteAddress(nsReceiver)
+		}
+		return nil, err
+	}
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.client.Connect(streamCtx)
+	if err != nil {
+		c.deleteClientAndConn(nsReceiver, clientID)
+		return nil, err
+	}
+	err = stream.Send(req)
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	for err == nil {
+		var res *Delivery
+		res, err = stream.Recv()
+		if err != nil {
+			break
+		}
+		var reply interface{}
+		reply, err = decode(res.Codec, res.TypeName, res.Data)
+		if err != nil {
+			break
+		}
+		if !res.Progress {
+			return reply, nil
+		}
+		progress(reply)
+	}
+	if strings.Contains(err.Error(), ErrUnknownMailbox.Error()) {
+		c.deleteAddress(nsReceiver)
+	}
+	return nil, err
+}
+
+// streamRequest of the delivery, sending the receiver's progress
+// updates on the stream, followed by its response.
+func (s *Server) streamRequest(c netcontext.Context, stream Wire_ConnectServer, caller string, mailbox *Mailbox, d *Delivery) error {
+	msg, err := decode(d.Codec, d.TypeName, d.Data)
+	if err != nil {
+		return err
+	}
+	req, err := s.enqueue(c, caller, mailbox, d, msg, stream.Send)
+	if err != nil {
+		return err
+	}
+	res, err := s.await(c, req)
+	if err != nil {
+		return err
+	}
+	return stream.Send(res)
+}
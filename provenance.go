@@ -0,0 +1,76 @@
+package grid
+
+import (
+	"context"
+	"time"
+)
+
+// Provenance of a request, ie: where it came from.
+type Provenance struct {
+	// Peer the request was sent from, empty if it was
+	// sent by a client outside of any peer.
+	Peer string
+	// Actor the request was sent from, empty if it was
+	// not sent from inside an actor.
+	Actor string
+	// Identity of the caller, as verified by the receiving
+	// server from the caller's TLS certificate or token. It
+	// is empty if the server has no means of identifying
+	// its callers.
+	Identity string
+	// Lineage of the request, shared by all the requests
+	// derived from the same original one, see WithLineage.
+	Lineage string
+	// Tenant the request was sent on behalf of, empty if
+	// none, see WithTenant.
+	Tenant string
+}
+
+// stamp the delivery with the peer and actor sending it. The
+// peer and actor are taken from the context when it is of an
+// actor, otherwise the peer is that of the client's server.
+// The identity is never stamped, since a receiver can only
+// trust the identity it verifies itself. The lineage is that
+// of the context, or else a new one, and the tenant is that of
+// the context, or else the client's default.
+func (c *Client) stamp(ctx context.Context, d *Delivery) {
+	d.FromPeer = c.peer
+	if cv, ok := ctx.Value(contextKey).(*contextVal); ok {
+		d.FromPeer = cv.server.name()
+		d.FromActor = cv.actorName
+	}
+	d.Lineage = ContextLineage(ctx)
+	if d.Lineage == "" {
+		d.Lineage = newLineage()
+	}
+	d.Tenant = ContextTenant(ctx)
+	if d.Tenant == "" {
+		d.Tenant = c.cfg.Tenant
+	}
+}
+
+// name of the server's peer, empty until it is serving.
+func (s *Server) name() string {
+	if s.registry == nil {
+		return ""
+	}
+	return s.registry.Registry()
+}
+
+// auditOperation of a management operation on the named actor,
+// such as its start or stop.
+func (s *Server) auditOperation(ctx context.Context, action Action, actor string) {
+	ev := &AuditEvent{
+		Time:      time.Now(),
+		Namespace: s.cfg.Namespace,
+		Action:    action,
+		Target:    actor,
+		Peer:      s.name(),
+	}
+	if from, ok := ctx.Value(provenanceKey).(*Provenance); ok {
+		ev.Caller = from.Identity
+		ev.Peer = from.Peer
+		ev.Actor = from.Actor
+	}
+	s.audit(ev)
+}
@@ -0,0 +1,64 @@
+package grid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProvenanceStamp(t *testing.T) {
+	c := &Client{peer: "peer-1"}
+
+	d := &Delivery{}
+	c.stamp(context.Background(), d)
+	if d.FromPeer != "peer-1" || d.FromActor != "" {
+		t.Fatalf("expected client's peer, got: %v, %v", d.FromPeer, d.FromActor)
+	}
+
+	ctx := context.WithValue(context.Background(), contextKey, &contextVal{
+		server:    &Server{},
+		actorName: "worker",
+	})
+	d = &Delivery{}
+	c.stamp(ctx, d)
+	if d.FromActor != "worker" {
+		t.Fatalf("expected actor of context, got: %v", d.FromActor)
+	}
+}
+
+func TestProvenanceSigned(t *testing.T) {
+	signer := NewHMACSigner([]byte("secret"))
+
+	d := &Delivery{Receiver: "testing.mailbox.worker", FromPeer: "peer-1", FromActor: "worker"}
+	if err := sign(signer, d); err != nil {
+		t.Fatal(err)
+	}
+	d.FromActor = "leader"
+	if err := verify(signer, d); err != ErrInvalidSignature {
+		t.Fatalf("expected forged provenance to be rejected, got: %v", err)
+	}
+}
+
+func TestAuditOperation(t *testing.T) {
+	auditor := &recordingAuditor{}
+	s := &Server{cfg: ServerCfg{Namespace: "testing", Auditor: auditor}}
+
+	from := &Provenance{Peer: "peer-2", Actor: "leader", Identity: "deployer"}
+	req := newRequest(context.Background(), NewActorStart("worker"), from)
+	if req.From() != from {
+		t.Fatal("expected provenance of request")
+	}
+
+	s.auditOperation(req.Context(), ActionStart, "worker")
+	s.auditOperation(context.Background(), ActionStop, "worker")
+
+	if len(auditor.events) != 2 {
+		t.Fatalf("expected 2 events, got: %v", len(auditor.events))
+	}
+	start := auditor.events[0]
+	if start.Action != ActionStart || start.Caller != "deployer" || start.Peer != "peer-2" || start.Actor != "leader" {
+		t.Fatalf("expected start with provenance, got: %v", start)
+	}
+	if stop := auditor.events[1]; stop.Action != ActionStop || stop.Caller != "" {
+		t.Fatalf("expected stop by the server, got: %v", stop)
+	}
+}
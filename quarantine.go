@@ -0,0 +1,179 @@
+package grid
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+)
+
+// quarantines is the key space of peers in quarantine.
+const quarantines EntityType = "quarantine"
+
+// Quarantine of a peer, see Client.QuarantinePeer.
+type Quarantine struct {
+	Peer   string    `json:"peer"`
+	Reason string    `json:"reason,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// quarantineCache of the peers of a namespace in quarantine,
+// read from etcd at most once per refresh interval.
+type quarantineCache struct {
+	fetched   time.Time
+	peers     map[string]bool
+	addresses map[string]bool
+	err       error
+}
+
+// QuarantinePeer of the namespace, for example one whose hardware is
+// degraded. The peer stays registered, and keeps serving the actors
+// and mailboxes it has, but it is excluded from new placements, see
+// Placement, from the durable actors the grid keeps running, and from
+// the members of groups clients route to, see RequestGroup, and
+// queries mark it, see QueryEvent.Quarantined. Clients notice within
+// their PeersRefreshInterval. Unlike a lame duck, the quarantine is
+// not bound to the peer's lifetime, it stays until it is cleared,
+// see ClearQuarantine.
+//
+// Example usage:
+//
+//     err := client.QuarantinePeer(ctx, peer, "ECC errors")
+//     ...
+//     // Once the hardware is replaced.
+//     err = client.ClearQuarantine(ctx, peer)
+//
+func (c *Client) QuarantinePeer(ctx context.Context, peer, reason string) error {
+	key, err := namespaceName(quarantines, c.cfg.Namespace, peer)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(&Quarantine{
+		Peer:   peer,
+		Reason: reason,
+		Time:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Put(ctx, key, string(buf))
+	return err
+}
+
+// ClearQuarantine of the peer, which then takes new work again.
+func (c *Client) ClearQuarantine(ctx context.Context, peer string) error {
+	key, err := namespaceName(quarantines, c.cfg.Namespace, peer)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Delete(ctx, key)
+	return err
+}
+
+// Quarantines of the peers of this client's namespace.
+func (c *Client) Quarantines(ctx context.Context) ([]*Quarantine, error) {
+	prefix, err := namespacePrefix(quarantines, c.cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.etcd.Get(ctx, prefix, etcdv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	qs := make([]*Quarantine, 0, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		q := &Quarantine{}
+		err := json.Unmarshal(kv.Value, q)
+		if err != nil {
+			return nil, err
+		}
+		qs = append(qs, q)
+	}
+	return qs, nil
+}
+
+// Quarantined if the query event's entity is on a peer in
+// quarantine, see Client.QuarantinePeer. New work should not
+// be routed to it. Only queries of current entities, not their
+// changes, mark peers in quarantine.
+func (e *QueryEvent) Quarantined() bool {
+	return e.quarantined
+}
+
+// cachedQuarantines of the namespace, refreshed if older than
+// the PeersRefreshInterval. Failures to refresh are cached too,
+// so that requests do not each wait on etcd while it is slow or
+// unavailable, and the quarantines last read, if any, are used
+// until the next refresh.
+func (c *Client) cachedQuarantines(ctx context.Context) (*quarantineCache, error) {
+	c.mu.Lock()
+	cache := c.quarantines
+	c.mu.Unlock()
+	if cache != nil && time.Since(cache.fetched) < c.cfg.PeersRefreshInterval {
+		return cache, cache.err
+	}
+
+	fresh, err := c.fetchQuarantines(ctx)
+	switch {
+	case err == nil:
+		cache = fresh
+	case cache == nil || cache.err != nil:
+		cache = &quarantineCache{fetched: time.Now(), err: err}
+	default:
+		last := *cache
+		last.fetched = time.Now()
+		cache = &last
+	}
+	c.mu.Lock()
+	c.quarantines = cache
+	c.mu.Unlock()
+	return cache, cache.err
+}
+
+// fetchQuarantines of the namespace, and the addresses
+// of the peers in quarantine.
+func (c *Client) fetchQuarantines(ctx context.Context) (*quarantineCache, error) {
+	qs, err := c.Quarantines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cache := &quarantineCache{
+		fetched:   time.Now(),
+		peers:     make(map[string]bool, len(qs)),
+		addresses: make(map[string]bool, len(qs)),
+	}
+	for _, q := range qs {
+		cache.peers[q.Peer] = true
+	}
+	if len(qs) > 0 {
+		prefix, err := namespacePrefix(Peers, c.cfg.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		regs, err := c.registry.FindRegistrations(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, reg := range regs {
+			if cache.peers[reg.Registry] {
+				cache.addresses[reg.Address] = true
+			}
+		}
+	}
+	return cache, nil
+}
+
+// avoidQuarantined among the members, dropping those on peers
+// in quarantine. Members whose peer cannot be found are kept.
+func (c *Client) avoidQuarantined(ctx context.Context, members []string) []string {
+	quarantined, err := c.cachedQuarantines(ctx)
+	if err != nil || len(quarantined.addresses) == 0 {
+		return members
+	}
+	kept, _ := partitionMembers(members, func(member string) bool {
+		address, err := c.mailboxAddress(ctx, member)
+		return err == nil && quarantined.addresses[address]
+	})
+	return kept
+}
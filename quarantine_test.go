@@ -0,0 +1,91 @@
+package grid
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lytics/grid/testetcd"
+)
+
+func TestAvoidQuarantined(t *testing.T) {
+	c := &Client{
+		cfg: ClientCfg{
+			Namespace:            "testing",
+			PeersRefreshInterval: time.Minute,
+		},
+		addresses: map[string]string{
+			"testing.mailbox.w-0": "10.0.0.1:7777",
+			"testing.mailbox.w-1": "10.0.0.2:7777",
+			"testing.mailbox.w-2": "10.0.0.1:7777",
+		},
+		quarantines: &quarantineCache{
+			fetched:   time.Now(),
+			peers:     map[string]bool{"peer-2": true},
+			addresses: map[string]bool{"10.0.0.2:7777": true},
+		},
+	}
+	kept := c.avoidQuarantined(context.Background(), []string{"w-0", "w-1", "w-2"})
+	if expected := []string{"w-0", "w-2"}; !reflect.DeepEqual(kept, expected) {
+		t.Fatalf("expected members: %v, got: %v", expected, kept)
+	}
+
+	// Entities on peers in quarantine are not healthy.
+	events := []*QueryEvent{
+		{name: "peer-1", peer: "peer-1"},
+		{name: "peer-2", peer: "peer-2", quarantined: true},
+	}
+	healthy := selectEvents(events, []Selector{Healthy()})
+	if len(healthy) != 1 || healthy[0].Name() != "peer-1" {
+		t.Fatalf("expected only the peer not in quarantine, got: %v", healthy)
+	}
+}
+
+func TestQuarantinePeer(t *testing.T) {
+	const timeout = 2 * time.Second
+
+	etcd := testetcd.StartAndConnect(t)
+	defer etcd.Close()
+
+	client, err := NewClient(etcd, ClientCfg{Namespace: newNamespace()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err = client.QuarantinePeer(ctx, "peer-1", "ECC errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs, err := client.Quarantines(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qs) != 1 || qs[0].Peer != "peer-1" || qs[0].Reason != "ECC errors" {
+		t.Fatalf("expected quarantine of peer-1, got: %v", qs)
+	}
+
+	cache, err := client.cachedQuarantines(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cache.peers["peer-1"] {
+		t.Fatal("expected peer-1 to be cached as in quarantine")
+	}
+
+	err = client.ClearQuarantine(ctx, "peer-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs, err = client.Quarantines(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qs) != 0 {
+		t.Fatalf("expected no quarantines, got: %v", qs)
+	}
+}
@@ -2,6 +2,7 @@ package grid
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -31,11 +32,18 @@ const (
 // QueryEvent indicating that an entity has been discovered,
 // lost, or some error has occured with the watch.
 type QueryEvent struct {
-	name   string
-	peer   string
-	err    error
-	entity EntityType
-	Type   EventType
+	name        string
+	peer        string
+	address     string
+	epoch       int64
+	registered  int64
+	labels      map[string]string
+	suspect     bool
+	lame        bool
+	quarantined bool
+	err         error
+	entity      EntityType
+	Type        EventType
 }
 
 // Name of entity that caused the event. For example, if
@@ -52,6 +60,49 @@ func (e *QueryEvent) Peer() string {
 	return e.peer
 }
 
+// Address of the peer of the named entity, at which it is served.
+// It is empty for lost entities.
+func (e *QueryEvent) Address() string {
+	return e.address
+}
+
+// Epoch of the entity's registration, bumped each time it is
+// taken over by another registration, see OpTakeoverSteal.
+func (e *QueryEvent) Epoch() int64 {
+	return e.epoch
+}
+
+// RegisteredAt is the time the entity was registered, or taken
+// over. It is zero for lost entities, and for entities registered
+// by peers that do not record the time.
+func (e *QueryEvent) RegisteredAt() time.Time {
+	if e.registered == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, e.registered)
+}
+
+// Labels of the peer of the named entity, such as its host or
+// zone, see WithLabels. They are nil for lost entities, and must
+// not be modified.
+func (e *QueryEvent) Labels() map[string]string {
+	return e.labels
+}
+
+// Entity type of the named entity, one of Peers, Actors,
+// or Mailboxes.
+func (e *QueryEvent) Entity() EntityType {
+	return e.entity
+}
+
+// Suspect if the peer of the named entity is suspected of having
+// failed, by gossip between peers, though it is still registered.
+// Only queries by clients of a server with gossip enabled, and only
+// queries of current entities, not their changes, mark suspects.
+func (e *QueryEvent) Suspect() bool {
+	return e.suspect
+}
+
 // Err caught watching query events. The error is
 // not associated with any particular entity, it's
 // an error with the watch itself or a result of
@@ -60,6 +111,52 @@ func (e *QueryEvent) Err() error {
 	return e.err
 }
 
+// queryEventJSON is the JSON of a query event.
+type queryEventJSON struct {
+	Type         string            `json:"type"`
+	Entity       EntityType        `json:"entity,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Peer         string            `json:"peer,omitempty"`
+	Address      string            `json:"address,omitempty"`
+	Epoch        int64             `json:"epoch,omitempty"`
+	RegisteredAt *time.Time        `json:"registered_at,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Suspect      bool              `json:"suspect,omitempty"`
+	LameDuck     bool              `json:"lame_duck,omitempty"`
+	Quarantined  bool              `json:"quarantined,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// MarshalJSON of the query event, for tooling.
+func (e *QueryEvent) MarshalJSON() ([]byte, error) {
+	v := queryEventJSON{
+		Entity:      e.entity,
+		Name:        e.name,
+		Peer:        e.peer,
+		Address:     e.address,
+		Epoch:       e.epoch,
+		Labels:      e.labels,
+		Suspect:     e.suspect,
+		LameDuck:    e.lame,
+		Quarantined: e.quarantined,
+	}
+	switch e.Type {
+	case EntityFound:
+		v.Type = "found"
+	case EntityLost:
+		v.Type = "lost"
+	default:
+		v.Type = "error"
+	}
+	if at := e.RegisteredAt(); !at.IsZero() {
+		v.RegisteredAt = &at
+	}
+	if e.err != nil {
+		v.Error = e.err.Error()
+	}
+	return json.Marshal(v)
+}
+
 // String representation of query event.
 func (e *QueryEvent) String() string {
 	if e == nil {
@@ -99,22 +196,49 @@ func (e *QueryEvent) String() string {
 //             // New peer found, assign work, get data, reschedule, etc.
 //         }
 //     }
-func (c *Client) QueryWatch(ctx context.Context, filter EntityType) ([]*QueryEvent, <-chan *QueryEvent, error) {
+//
+// Only entities selected by all of the selectors are returned, and
+// their loss is reported only if they were found by the watch.
+func (c *Client) QueryWatch(ctx context.Context, filter EntityType, selectors ...Selector) ([]*QueryEvent, <-chan *QueryEvent, error) {
 	nsName, err := namespacePrefix(filter, c.cfg.Namespace)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	lame, err := c.cachedLameDucks(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	quarantined, err := c.cachedQuarantines(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 	regs, changes, err := c.registry.Watch(ctx, nsName)
 	var current []*QueryEvent
 	for _, reg := range regs {
 		current = append(current, &QueryEvent{
-			name:   nameFromKey(filter, c.cfg.Namespace, reg.Key),
-			peer:   reg.Registry,
-			entity: filter,
-			Type:   EntityFound,
+			name:        nameFromKey(filter, c.cfg.Namespace, reg.Key),
+			peer:        reg.Registry,
+			address:     reg.Address,
+			epoch:       reg.Epoch,
+			registered:  reg.Registered,
+			labels:      reg.Labels,
+			suspect:     c.Suspect(reg.Registry),
+			lame:        lame.peers[reg.Registry],
+			quarantined: quarantined.peers[reg.Registry],
+			entity:      filter,
+			Type:        EntityFound,
 		})
 	}
+	current = selectEvents(current, selectors)
+
+	// Names of the entities selected, whose
+	// loss is reported. Lost entities have no
+	// peer, so selectors cannot apply to them.
+	found := map[string]bool{}
+	for _, e := range current {
+		found[e.name] = true
+	}
 
 	queryEvents := make(chan *QueryEvent)
 	put := func(change *QueryEvent) {
@@ -165,13 +289,23 @@ func (c *Client) QueryWatch(ctx context.Context, filter EntityType) ([]*QueryEve
 					if filter == Peers {
 						qe.peer = qe.name
 					}
+					if len(selectors) > 0 {
+						if !found[qe.name] {
+							continue
+						}
+						delete(found, qe.name)
+					}
 					put(qe)
 				case registry.Create, registry.Modify:
 					qe := &QueryEvent{
-						name:   nameFromKey(filter, c.cfg.Namespace, change.Key),
-						peer:   change.Reg.Registry,
-						entity: filter,
-						Type:   EntityFound,
+						name:       nameFromKey(filter, c.cfg.Namespace, change.Key),
+						peer:       change.Reg.Registry,
+						address:    change.Reg.Address,
+						epoch:      change.Reg.Epoch,
+						registered: change.Reg.Registered,
+						labels:     change.Reg.Labels,
+						entity:     filter,
+						Type:       EntityFound,
 					}
 					// Maintain contract that for peer events
 					// the Peer() and Name() methods return
@@ -184,6 +318,12 @@ func (c *Client) QueryWatch(ctx context.Context, filter EntityType) ([]*QueryEve
 					if filter == Peers {
 						qe.peer = qe.name
 					}
+					if len(selectors) > 0 {
+						if !selected(qe, selectors) {
+							continue
+						}
+						found[qe.name] = true
+					}
 					put(qe)
 				}
 			}
@@ -194,17 +334,23 @@ func (c *Client) QueryWatch(ctx context.Context, filter EntityType) ([]*QueryEve
 }
 
 // Query in this client's namespace. The filter can be any one of
-// Peers, Actors, or Mailboxes.
-func (c *Client) Query(timeout time.Duration, filter EntityType) ([]*QueryEvent, error) {
-	timeoutC, cancel := context.WithTimeout(context.Background(), timeout)
+// Peers, Actors, or Mailboxes. A timeout of zero means the client's
+// DefaultQueryTimeout. Only entities selected by all of the selectors
+// are returned.
+func (c *Client) Query(timeout time.Duration, filter EntityType, selectors ...Selector) ([]*QueryEvent, error) {
+	timeoutC, cancel := context.WithTimeout(context.Background(), orDefault(timeout, c.cfg.DefaultQueryTimeout))
 	defer cancel()
-	return c.QueryC(timeoutC, filter)
+	return c.QueryC(timeoutC, filter, selectors...)
 }
 
 // QueryC (query) in this client's namespace. The filter can be any
 // one of Peers, Actors, or Mailboxes. The context can be used to
-// control cancelation or timeouts.
-func (c *Client) QueryC(ctx context.Context, filter EntityType) ([]*QueryEvent, error) {
+// control cancelation or timeouts, a context without a deadline is
+// bounded by the client's DefaultQueryTimeout. Only entities selected
+// by all of the selectors are returned.
+func (c *Client) QueryC(ctx context.Context, filter EntityType, selectors ...Selector) ([]*QueryEvent, error) {
+	ctx, cancel := orDefaultDeadline(ctx, c.cfg.DefaultQueryTimeout)
+	defer cancel()
 	nsPrefix, err := namespacePrefix(filter, c.cfg.Namespace)
 	if err != nil {
 		return nil, err
@@ -213,18 +359,223 @@ func (c *Client) QueryC(ctx context.Context, filter EntityType) ([]*QueryEvent,
 	if err != nil {
 		return nil, err
 	}
+	lame, err := c.cachedLameDucks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	quarantined, err := c.cachedQuarantines(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	var result []*QueryEvent
 	for _, reg := range regs {
 		result = append(result, &QueryEvent{
-			name:   nameFromKey(filter, c.cfg.Namespace, reg.Key),
-			peer:   reg.Registry,
-			entity: filter,
-			Type:   EntityFound,
+			name:        nameFromKey(filter, c.cfg.Namespace, reg.Key),
+			peer:        reg.Registry,
+			address:     reg.Address,
+			epoch:       reg.Epoch,
+			registered:  reg.Registered,
+			labels:      reg.Labels,
+			suspect:     c.Suspect(reg.Registry),
+			lame:        lame.peers[reg.Registry],
+			quarantined: quarantined.peers[reg.Registry],
+			entity:      filter,
+			Type:        EntityFound,
+		})
+	}
+
+	return selectEvents(result, selectors), nil
+}
+
+// QueryPage (query) one page of at most limit entities in this
+// client's namespace, in order of their names. The token continues
+// from where the previous page ended, and is empty for the first
+// page. The token returned is empty once there are no more pages.
+// Entities found or lost while paging may or may not be included.
+//
+// Example usage:
+//
+//     token := ""
+//     for {
+//         actors, next, err := client.QueryPage(ctx, grid.Actors, token, 1000)
+//         ...
+//         if next == "" {
+//             break
+//         }
+//         token = next
+//     }
+//
+func (c *Client) QueryPage(ctx context.Context, filter EntityType, token string, limit int) ([]*QueryEvent, string, error) {
+	nsPrefix, err := namespacePrefix(filter, c.cfg.Namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	if limit < 1 {
+		return nil, "", ErrInvalidQueryLimit
+	}
+	after := ""
+	if token != "" {
+		if !isNameValid(token) {
+			return nil, "", ErrInvalidQueryToken
+		}
+		after = nsPrefix + token
+	}
+	regs, more, err := c.registry.FindRegistrationsPage(ctx, nsPrefix, after, int64(limit))
+	if err != nil {
+		return nil, "", err
+	}
+	lame, err := c.cachedLameDucks(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	quarantined, err := c.cachedQuarantines(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]*QueryEvent, 0, len(regs))
+	for _, reg := range regs {
+		result = append(result, &QueryEvent{
+			name:        nameFromKey(filter, c.cfg.Namespace, reg.Key),
+			peer:        reg.Registry,
+			address:     reg.Address,
+			epoch:       reg.Epoch,
+			registered:  reg.Registered,
+			labels:      reg.Labels,
+			suspect:     c.Suspect(reg.Registry),
+			lame:        lame.peers[reg.Registry],
+			quarantined: quarantined.peers[reg.Registry],
+			entity:      filter,
+			Type:        EntityFound,
 		})
 	}
 
-	return result, nil
+	next := ""
+	if more && len(result) > 0 {
+		next = result[len(result)-1].name
+	}
+	return result, next, nil
+}
+
+// QuerySnapshot of the entities in this client's namespace.
+type QuerySnapshot struct {
+	// Revision of etcd the entities were read at.
+	Revision  int64
+	Peers     []*QueryEvent
+	Actors    []*QueryEvent
+	Mailboxes []*QueryEvent
+}
+
+// QuerySnapshot (query) the peers, actors, and mailboxes in this
+// client's namespace, all read at the same revision of etcd, so that
+// schedulers reasoning about them together, for example about which
+// actors run on which peers, do not act on a mix of states that never
+// existed at once, as separate queries taken moments apart may be.
+// Only entities selected by all of the selectors are returned. Marks
+// of lame ducks and peers in quarantine are as last cached by the
+// client, not as of the revision.
+//
+// Example usage:
+//
+//     snap, err := client.QuerySnapshot(ctx, grid.Healthy())
+//     ...
+//     for _, actor := range snap.Actors {
+//         ...
+//     }
+//
+func (c *Client) QuerySnapshot(ctx context.Context, selectors ...Selector) (*QuerySnapshot, error) {
+	filters := []EntityType{Peers, Actors, Mailboxes}
+	prefixes := make([]string, len(filters))
+	for i, filter := range filters {
+		nsPrefix, err := namespacePrefix(filter, c.cfg.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		prefixes[i] = nsPrefix
+	}
+	snapshot, rev, err := c.registry.FindRegistrationsSnapshot(ctx, prefixes...)
+	if err != nil {
+		return nil, err
+	}
+	lame, err := c.cachedLameDucks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	quarantined, err := c.cachedQuarantines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]*QueryEvent, len(filters))
+	for i, filter := range filters {
+		result := make([]*QueryEvent, 0, len(snapshot[i]))
+		for _, reg := range snapshot[i] {
+			result = append(result, &QueryEvent{
+				name:        nameFromKey(filter, c.cfg.Namespace, reg.Key),
+				peer:        reg.Registry,
+				address:     reg.Address,
+				epoch:       reg.Epoch,
+				registered:  reg.Registered,
+				labels:      reg.Labels,
+				suspect:     c.Suspect(reg.Registry),
+				lame:        lame.peers[reg.Registry],
+				quarantined: quarantined.peers[reg.Registry],
+				entity:      filter,
+				Type:        EntityFound,
+			})
+		}
+		results[i] = selectEvents(result, selectors)
+	}
+	return &QuerySnapshot{
+		Revision:  rev,
+		Peers:     results[0],
+		Actors:    results[1],
+		Mailboxes: results[2],
+	}, nil
+}
+
+// QueryStream (query) the entities in this client's namespace, reading
+// them from etcd one page of the given size at a time. The channel is
+// closed after the last entity, or after a WatchError event.
+//
+// Example usage:
+//
+//     for event := range client.QueryStream(ctx, grid.Actors, 1000) {
+//         if event.Type == grid.WatchError {
+//             // Error occured reading actors, deal with error.
+//         }
+//         ...
+//     }
+//
+func (c *Client) QueryStream(ctx context.Context, filter EntityType, pageSize int) <-chan *QueryEvent {
+	events := make(chan *QueryEvent)
+	go func() {
+		defer close(events)
+		token := ""
+		for {
+			page, next, err := c.QueryPage(ctx, filter, token, pageSize)
+			if err != nil {
+				select {
+				case events <- &QueryEvent{err: err, entity: filter, Type: WatchError}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			for _, e := range page {
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			token = next
+		}
+	}()
+	return events
 }
 
 // nameFromKey returns the name from the data field of a registration.
@@ -2,6 +2,7 @@ package grid
 
 import (
 	"context"
+	"encoding/json"
 	"net"
 	"testing"
 	"time"
@@ -138,3 +139,102 @@ func TestQueryWatch(t *testing.T) {
 		}
 	}
 }
+
+func TestQuerySnapshot(t *testing.T) {
+	const timeout = 2 * time.Second
+
+	etcd, server, client := bootstrapClientTest(t)
+	defer etcd.Close()
+	defer server.Stop()
+	defer client.Close()
+
+	mailbox, err := NewMailbox(server, "snapshot", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mailbox.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	snap, err := client.QuerySnapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Revision <= 0 {
+		t.Fatalf("expected revision of the snapshot, got: %v", snap.Revision)
+	}
+	if len(snap.Peers) != 1 {
+		t.Fatalf("expected one peer, got: %v", snap.Peers)
+	}
+	found := false
+	for _, e := range snap.Mailboxes {
+		if e.Name() == "snapshot" {
+			found = true
+			if e.Peer() != snap.Peers[0].Name() {
+				t.Fatalf("expected mailbox on peer: %v, got: %v", snap.Peers[0].Name(), e.Peer())
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected mailbox in snapshot, got: %v", snap.Mailboxes)
+	}
+}
+
+func TestQueryPageInvalid(t *testing.T) {
+	client := &Client{cfg: ClientCfg{Namespace: "testing"}}
+	ctx := context.Background()
+
+	if _, _, err := client.QueryPage(ctx, Actors, "", 0); err != ErrInvalidQueryLimit {
+		t.Fatalf("expected invalid query limit, got: %v", err)
+	}
+	if _, _, err := client.QueryPage(ctx, Actors, "testing.actor.worker", 10); err != ErrInvalidQueryToken {
+		t.Fatalf("expected invalid query token, got: %v", err)
+	}
+
+	var events []*QueryEvent
+	for e := range client.QueryStream(ctx, Actors, 0) {
+		events = append(events, e)
+	}
+	if len(events) != 1 || events[0].Type != WatchError || events[0].Err() != ErrInvalidQueryLimit {
+		t.Fatalf("expected stream to end with an error, got: %v", events)
+	}
+}
+
+func TestQueryEventJSON(t *testing.T) {
+	e := &QueryEvent{
+		name:       "worker-1",
+		peer:       "peer-1",
+		address:    "localhost:7777",
+		epoch:      2,
+		registered: 1500000000000000000,
+		entity:     Actors,
+		Type:       EntityFound,
+	}
+	if !e.RegisteredAt().Equal(time.Unix(0, 1500000000000000000)) {
+		t.Fatalf("unexpected registration time: %v", e.RegisteredAt())
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]interface{}
+	err = json.Unmarshal(data, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v["type"] != "found" || v["entity"] != "actor" || v["address"] != "localhost:7777" || v["epoch"] != 2.0 {
+		t.Fatalf("unexpected json: %s", data)
+	}
+	if _, ok := v["registered_at"]; !ok {
+		t.Fatalf("expected registration time, got: %s", data)
+	}
+
+	data, err = json.Marshal(&QueryEvent{Type: EntityLost, name: "worker-1", entity: Actors})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"type":"lost","entity":"actor","name":"worker-1"}` {
+		t.Fatalf("unexpected json: %s", data)
+	}
+}
@@ -0,0 +1,88 @@
+package grid
+
+import "github.com/lytics/grid/codec"
+
+// RawMessage is a message that is already encoded. It is sent
+// as is, the type name and bytes are carried opaquely in the
+// envelope, so the receiver sees the decoded message just as
+// if the original message had been sent. Useful for callers
+// that already have the encoded bytes, or that send the same
+// message many times.
+type RawMessage struct {
+	TypeName string
+	Data     []byte
+	// Codec that encoded the data, by name,
+	// empty for protobuf.
+	Codec string
+}
+
+// NewRawMessage encodes the message once, so that it can be
+// sent many times without being encoded each time.
+func NewRawMessage(msg interface{}) (*RawMessage, error) {
+	typeName, data, err := codec.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &RawMessage{
+		TypeName: typeName,
+		Data:     data,
+	}, nil
+}
+
+// NewRawMessageWith the codec, see NewRawMessage.
+func NewRawMessageWith(c codec.Codec, msg interface{}) (*RawMessage, error) {
+	raw, err := encode(c, msg)
+	if err != nil {
+		return nil, err
+	}
+	return &raw, nil
+}
+
+// marshal the message, unless it is already encoded.
+func marshal(msg interface{}) (string, []byte, error) {
+	switch raw := msg.(type) {
+	case *RawMessage:
+		return raw.TypeName, raw.Data, nil
+	case RawMessage:
+		return raw.TypeName, raw.Data, nil
+	default:
+		return codec.Marshal(msg)
+	}
+}
+
+// encode the message with the codec, unless it is already
+// encoded, in which case the codec that encoded it is kept.
+func encode(c codec.Codec, msg interface{}) (RawMessage, error) {
+	switch raw := msg.(type) {
+	case *RawMessage:
+		return *raw, nil
+	case RawMessage:
+		return raw, nil
+	}
+	if c == nil {
+		c = codec.Protobuf
+	}
+	typeName, data, err := codec.MarshalWith(c, msg)
+	if err != nil {
+		return RawMessage{}, err
+	}
+	return RawMessage{TypeName: typeName, Data: data, Codec: codecName(c)}, nil
+}
+
+// decode the data with the codec named, which encoded it.
+func decode(name, typeName string, data []byte) (interface{}, error) {
+	c, err := codec.FindCodec(name)
+	if err != nil {
+		return nil, err
+	}
+	return codec.UnmarshalWith(c, data, typeName)
+}
+
+// codecName of the codec in envelopes, empty for protobuf, which
+// is how peers of versions without codecs encode.
+func codecName(c codec.Codec) string {
+	if c == nil || c == codec.Protobuf {
+		return ""
+	}
+	return c.Name()
+}
@@ -0,0 +1,73 @@
+package grid
+
+import (
+	"testing"
+
+	"github.com/lytics/grid/codec"
+)
+
+func TestRawMessageCodec(t *testing.T) {
+	raw, err := encode(codec.JSON, &EchoMsg{Msg: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.Codec != "json" {
+		t.Fatalf("expected json codec in envelope, got: %q", raw.Codec)
+	}
+
+	// Messages already encoded keep their codec.
+	again, err := encode(codec.Protobuf, &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Codec != "json" || &again.Data[0] != &raw.Data[0] {
+		t.Fatal("expected raw message to be sent as is")
+	}
+
+	msg, err := decode(again.Codec, again.TypeName, again.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.(*EchoMsg).Msg != "hello" {
+		t.Fatal("expected original message")
+	}
+
+	// Protobuf is left out of envelopes, so that peers
+	// without codecs can read them.
+	raw, err = encode(codec.Protobuf, &EchoMsg{Msg: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.Codec != "" {
+		t.Fatalf("expected no codec in envelope, got: %q", raw.Codec)
+	}
+	if _, err := decode("msgpack", raw.TypeName, raw.Data); err != codec.ErrUnknownCodec {
+		t.Fatalf("expected unknown codec, got: %v", err)
+	}
+}
+
+func TestRawMessageMarshal(t *testing.T) {
+	raw, err := NewRawMessage(&EchoMsg{Msg: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typeName, data, err := marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typeName != raw.TypeName {
+		t.Fatalf("expected type name: %v, got: %v", raw.TypeName, typeName)
+	}
+	if &data[0] != &raw.Data[0] {
+		t.Fatal("expected raw bytes to be used without copying")
+	}
+
+	msg, err := codec.Unmarshal(data, typeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.(*EchoMsg).Msg != "hello" {
+		t.Fatal("expected original message")
+	}
+}
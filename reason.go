@@ -0,0 +1,94 @@
+package grid
+
+import "context"
+
+// DoneReason of the cancellation of an actor's context.
+type DoneReason string
+
+const (
+	// DoneNot when the context is not done.
+	DoneNot DoneReason = ""
+	// DoneStopped when the server running the actor was stopped,
+	// without draining its actors first.
+	DoneStopped DoneReason = "stopped"
+	// DoneDrained when the server running the actor was stopped,
+	// after draining its actors, see ContextDrain.
+	DoneDrained DoneReason = "drained"
+	// DoneFailed when the server running the actor stopped on a
+	// fatal error, such as losing its lease in etcd, after which
+	// the actor's registration is likely already gone.
+	DoneFailed DoneReason = "failed"
+	// DoneSteppedDown when the leader stepped down, on finding
+	// that another peer runs the leader too.
+	DoneSteppedDown DoneReason = "stepped down"
+	// DoneMoved when the actor was stopped to be started again
+	// on another peer, see Client.RollingRestart.
+	DoneMoved DoneReason = "moved"
+	// DoneActorStopped when the actor alone was stopped, see
+	// Client.StopActor.
+	DoneActorStopped DoneReason = "actor stopped"
+	// DoneCanceled when the context was cancelled other than by
+	// the server, for example by the deadline of a context that
+	// the actor derived from its own.
+	DoneCanceled DoneReason = "canceled"
+)
+
+// ContextDoneReason returns why the context of the actor is done, so
+// that the actor can decide whether to checkpoint or to exit fast.
+// The reason is DoneNot while the context is not done.
+//
+// Example usage:
+//
+//     case <-ctx.Done():
+//         reason, err := grid.ContextDoneReason(ctx)
+//         ...
+//         if reason == grid.DoneDrained {
+//             checkpoint()
+//         }
+//         return
+//
+func ContextDoneReason(c context.Context) (DoneReason, error) {
+	v := c.Value(contextKey)
+	if v == nil {
+		return DoneNot, ErrInvalidContext
+	}
+	cv, ok := v.(*contextVal)
+	if !ok {
+		return DoneNot, ErrInvalidContext
+	}
+	if c.Err() == nil {
+		return DoneNot, nil
+	}
+	s := cv.server
+	if s.ctx != nil && s.ctx.Err() != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.reason != DoneNot {
+			return s.reason, nil
+		}
+		return DoneStopped, nil
+	}
+	s.mu.Lock()
+	moved, stopped := cv.moved, cv.stopped
+	s.mu.Unlock()
+	if moved {
+		return DoneMoved, nil
+	}
+	if stopped {
+		return DoneActorStopped, nil
+	}
+	if cv.actorName == leaderName {
+		return DoneSteppedDown, nil
+	}
+	return DoneCanceled, nil
+}
+
+// setDoneReason of the server's actors, unless it is already set,
+// since the first reason to stop is the cause of any later ones.
+func (s *Server) setDoneReason(reason DoneReason) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reason == DoneNot {
+		s.reason = reason
+	}
+}
@@ -0,0 +1,43 @@
+package grid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContextDoneReason(t *testing.T) {
+	if _, err := ContextDoneReason(context.Background()); err != ErrInvalidContext {
+		t.Fatalf("expected invalid context, got: %v", err)
+	}
+
+	serverCtx, stop := context.WithCancel(context.Background())
+	s := &Server{ctx: serverCtx}
+	actorCtx, cancel := context.WithCancel(context.WithValue(serverCtx, contextKey, &contextVal{
+		server:    s,
+		actorName: "worker",
+	}))
+	defer cancel()
+	if reason, _ := ContextDoneReason(actorCtx); reason != DoneNot {
+		t.Fatalf("expected not done, got: %v", reason)
+	}
+
+	// Stopped by the server, the
+	// first reason given wins.
+	s.setDoneReason(DoneFailed)
+	s.setDoneReason(DoneStopped)
+	stop()
+	if reason, _ := ContextDoneReason(actorCtx); reason != DoneFailed {
+		t.Fatalf("expected failed, got: %v", reason)
+	}
+
+	// Cancelled other than by the server.
+	s = &Server{ctx: context.Background()}
+	leaderCtx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey, &contextVal{
+		server:    s,
+		actorName: leaderName,
+	}))
+	cancel()
+	if reason, _ := ContextDoneReason(leaderCtx); reason != DoneSteppedDown {
+		t.Fatalf("expected stepped down, got: %v", reason)
+	}
+}
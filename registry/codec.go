@@ -0,0 +1,296 @@
+package registry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	ErrUnknownCodec     = errors.New("registry: unknown codec")
+	ErrCodecRegistered  = errors.New("registry: codec already registered")
+	ErrUnsupportedValue = errors.New("registry: unsupported value")
+	ErrMalformedValue   = errors.New("registry: malformed value")
+)
+
+// Codec of the values written to etcd. Values are prefixed with
+// the version of the codec that wrote them, so that values written
+// by any registered codec can be read, whichever codec a registry
+// writes with. Peers can then switch codecs one at a time.
+type Codec interface {
+	// Version prefixing the codec's values. Version zero is
+	// reserved for JSON, whose values are not prefixed, so that
+	// they remain readable by registries without codecs.
+	Version() byte
+	// Marshal the value.
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal the data into the value.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSON codec, the default, for debuggability, since its
+	// values can be read with etcdctl.
+	JSON Codec = jsonCodec{}
+	// Protobuf codec, for size. It encodes registrations,
+	// and any protobuf message, such as an ActorStart. Only
+	// registries that know of it can read its values, so
+	// every peer must be upgraded before it is used.
+	Protobuf Codec = protobufCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[byte]Codec{
+		JSON.Version():     JSON,
+		Protobuf.Version(): Protobuf,
+	}
+)
+
+// RegisterCodec so that its values can be read. A codec must be
+// registered by every process reading values it wrote.
+func RegisterCodec(c Codec) error {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	// Values starting with '{' are unprefixed JSON.
+	if _, ok := codecs[c.Version()]; ok || c.Version() == '{' {
+		return ErrCodecRegistered
+	}
+	codecs[c.Version()] = c
+	return nil
+}
+
+// Encode the value with the codec, prefixed with its version.
+// A nil codec is JSON.
+func Encode(c Codec, v interface{}) ([]byte, error) {
+	if c == nil {
+		c = JSON
+	}
+	data, err := c.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if c.Version() == JSON.Version() {
+		return data, nil
+	}
+	return append([]byte{c.Version()}, data...), nil
+}
+
+// Decode the value, with the codec that encoded it.
+func Decode(data []byte, v interface{}) error {
+	if len(data) == 0 || data[0] == '{' {
+		return JSON.Unmarshal(data, v)
+	}
+	codecsMu.RLock()
+	c, ok := codecs[data[0]]
+	codecsMu.RUnlock()
+	if !ok {
+		return ErrUnknownCodec
+	}
+	return c.Unmarshal(data[1:], v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Version() byte { return 0 }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Version() byte { return 1 }
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case *Registration:
+		return v.marshalProto(), nil
+	case proto.Message:
+		return proto.Marshal(v)
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	switch v := v.(type) {
+	case *Registration:
+		return v.unmarshalProto(data)
+	case proto.Message:
+		return proto.Unmarshal(data, v)
+	default:
+		return ErrUnsupportedValue
+	}
+}
+
+// Field numbers of a registration in protobuf.
+const (
+	fieldKey        = 1
+	fieldAddress    = 2
+	fieldRegistry   = 3
+	fieldEpoch      = 4
+	fieldRegistered = 5
+	fieldLabels     = 6
+)
+
+// Wire types of protobuf.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// marshalProto of the registration, as the message:
+//
+//     message Registration {
+//         string key = 1;
+//         string address = 2;
+//         string registry = 3;
+//         int64 epoch = 4;
+//         int64 registered = 5;
+//         map<string, string> labels = 6;
+//     }
+//
+func (r *Registration) marshalProto() []byte {
+	var buf []byte
+	putString := func(field uint64, s string) {
+		if s == "" {
+			return
+		}
+		buf = binary.AppendUvarint(buf, field<<3|wireBytes)
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		buf = append(buf, s...)
+	}
+	putString(fieldKey, r.Key)
+	putString(fieldAddress, r.Address)
+	putString(fieldRegistry, r.Registry)
+	putInt := func(field uint64, v int64) {
+		if v == 0 {
+			return
+		}
+		buf = binary.AppendUvarint(buf, field<<3|wireVarint)
+		buf = binary.AppendUvarint(buf, uint64(v))
+	}
+	putInt(fieldEpoch, r.Epoch)
+	putInt(fieldRegistered, r.Registered)
+	// Map entries are messages of the key, field 1,
+	// and the value, field 2, written in key order,
+	// so that equal labels are encoded alike.
+	keys := make([]string, 0, len(r.Labels))
+	for k := range r.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := r.Labels[k]
+		size := 2 + len(k) + len(v) + 2*binary.MaxVarintLen64
+		entry := make([]byte, 0, size)
+		entry = binary.AppendUvarint(entry, 1<<3|wireBytes)
+		entry = binary.AppendUvarint(entry, uint64(len(k)))
+		entry = append(entry, k...)
+		entry = binary.AppendUvarint(entry, 2<<3|wireBytes)
+		entry = binary.AppendUvarint(entry, uint64(len(v)))
+		entry = append(entry, v...)
+		putString(fieldLabels, string(entry))
+	}
+	return buf
+}
+
+// unmarshalProto of the registration, skipping unknown
+// fields, which later versions may add.
+func (r *Registration) unmarshalProto(data []byte) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrMalformedValue
+		}
+		data = data[n:]
+		field, wire := tag>>3, tag&7
+		switch wire {
+		case wireVarint:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return ErrMalformedValue
+			}
+			data = data[n:]
+			switch field {
+			case fieldEpoch:
+				r.Epoch = int64(v)
+			case fieldRegistered:
+				r.Registered = int64(v)
+			}
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return ErrMalformedValue
+			}
+			s := string(data[n : n+int(size)])
+			data = data[n+int(size):]
+			switch field {
+			case fieldKey:
+				r.Key = s
+			case fieldAddress:
+				r.Address = s
+			case fieldRegistry:
+				r.Registry = s
+			case fieldLabels:
+				k, v, err := unmarshalEntry([]byte(s))
+				if err != nil {
+					return err
+				}
+				if r.Labels == nil {
+					r.Labels = map[string]string{}
+				}
+				r.Labels[k] = v
+			}
+		case wireFixed64:
+			if len(data) < 8 {
+				return ErrMalformedValue
+			}
+			data = data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return ErrMalformedValue
+			}
+			data = data[4:]
+		default:
+			return ErrMalformedValue
+		}
+	}
+	return nil
+}
+
+// unmarshalEntry of a map of strings to strings.
+func unmarshalEntry(data []byte) (string, string, error) {
+	var k, v string
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 || tag&7 != wireBytes {
+			return "", "", ErrMalformedValue
+		}
+		data = data[n:]
+		size, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < size {
+			return "", "", ErrMalformedValue
+		}
+		s := string(data[n : n+int(size)])
+		data = data[n+int(size):]
+		switch tag >> 3 {
+		case 1:
+			k = s
+		case 2:
+			v = s
+		}
+	}
+	return k, v, nil
+}
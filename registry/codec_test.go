@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestCodecs(t *testing.T) {
+	reg := &Registration{
+		Key:        "testing.actor.worker-1",
+		Address:    "localhost:7777",
+		Registry:   "localhost-7777",
+		Epoch:      3,
+		Registered: 1500000000000000000,
+		Labels:     map[string]string{"host": "node-1", "zone": "us-east-1a"},
+	}
+	for _, c := range []Codec{JSON, Protobuf} {
+		data, err := Encode(c, reg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := &Registration{}
+		err = Decode(data, found)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(found, reg) {
+			t.Fatalf("codec: %v, expected: %v, got: %v", c.Version(), reg, found)
+		}
+	}
+
+	// Registrations written before codecs are plain JSON.
+	found := &Registration{}
+	err := Decode([]byte(`{"key":"k","address":"a","registry":"r"}`), found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Key != "k" || found.Address != "a" || found.Registry != "r" {
+		t.Fatalf("unexpected registration: %v", found)
+	}
+
+	err = Decode([]byte{99, 0}, found)
+	if err != ErrUnknownCodec {
+		t.Fatalf("expected unknown codec, got: %v", err)
+	}
+	err = Decode([]byte{Protobuf.Version(), 0x0a, 0x05, 'k'}, found)
+	if err != ErrMalformedValue {
+		t.Fatalf("expected malformed value, got: %v", err)
+	}
+	err = RegisterCodec(jsonCodec{})
+	if err != ErrCodecRegistered {
+		t.Fatalf("expected codec registered, got: %v", err)
+	}
+}
+
+func TestProtobufSkipsUnknownFields(t *testing.T) {
+	data := (&Registration{Key: "k", Epoch: 1}).marshalProto()
+	// Fields a later version might add.
+	data = binary.AppendUvarint(data, 9<<3|wireVarint)
+	data = binary.AppendUvarint(data, 42)
+	data = binary.AppendUvarint(data, 10<<3|wireBytes)
+	data = binary.AppendUvarint(data, 3)
+	data = append(data, "new"...)
+	data = binary.AppendUvarint(data, 11<<3|wireFixed64)
+	data = append(data, make([]byte, 8)...)
+
+	found := &Registration{}
+	err := found.unmarshalProto(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Key != "k" || found.Epoch != 1 {
+		t.Fatalf("unexpected registration: %v", found)
+	}
+}
@@ -2,7 +2,6 @@ package registry
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -53,11 +52,25 @@ type Registration struct {
 	Key      string `json:"key"`
 	Address  string `json:"address"`
 	Registry string `json:"registry"`
+	// Epoch of the registration, bumped each time
+	// the key is taken over by another registration.
+	Epoch int64 `json:"epoch,omitempty"`
+	// Registered is the time of the registration, or
+	// of the takeover, in Unix nanoseconds, zero for
+	// registrations written without it.
+	Registered int64 `json:"registered,omitempty"`
+	// Labels of the registry that made the registration,
+	// such as its host or zone.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Revision of etcd at which the registration was
+	// created, set only on registrations that are found.
+	// Later registrations have larger revisions.
+	Revision int64 `json:"-"`
 }
 
 // String descritpion of registration.
 func (r *Registration) String() string {
-	return fmt.Sprintf("key: %v, address: %v, registry: %v", r.Key, r.Address, r.Registry)
+	return fmt.Sprintf("key: %v, address: %v, registry: %v, epoch: %v", r.Key, r.Address, r.Registry, r.Epoch)
 }
 
 // EventType of a watch event.
@@ -107,6 +120,12 @@ type Registry struct {
 	Logger        Logger
 	Timeout       time.Duration
 	LeaseDuration time.Duration
+	// Codec of the registrations written, those
+	// written by any known codec can be read.
+	Codec Codec
+	// Labels written into every registration, they
+	// must not be modified once the registry starts.
+	Labels map[string]string
 	// Testing hook.
 	keepAliveStats *keepAliveStats
 }
@@ -124,6 +143,7 @@ func New(client *etcdv3.Client) (*Registry, error) {
 		client:        client,
 		Timeout:       10 * time.Second,
 		LeaseDuration: 60 * time.Second,
+		Codec:         JSON,
 	}, nil
 }
 
@@ -262,7 +282,7 @@ func (rr *Registry) Watch(c context.Context, prefix string) ([]*Registration, <-
 	registrations := make([]*Registration, 0, len(getRes.Kvs))
 	for _, kv := range getRes.Kvs {
 		reg := &Registration{}
-		err = json.Unmarshal(kv.Value, reg)
+		err = Decode(kv.Value, reg)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -304,7 +324,7 @@ func (rr *Registry) Watch(c context.Context, prefix string) ([]*Registration, <-
 			// any data to unmarshal.
 			return wev
 		}
-		err := json.Unmarshal(ev.Kv.Value, reg)
+		err := Decode(ev.Kv.Value, reg)
 		if err != nil {
 			wev.Error = fmt.Errorf("%v: failed unmarshaling value: '%s'", err, ev.Kv.Value)
 		} else {
@@ -356,15 +376,82 @@ func (rr *Registry) FindRegistrations(c context.Context, prefix string) ([]*Regi
 	registrations := make([]*Registration, 0, len(getRes.Kvs))
 	for _, kv := range getRes.Kvs {
 		reg := &Registration{}
-		err = json.Unmarshal(kv.Value, reg)
+		err = Decode(kv.Value, reg)
 		if err != nil {
 			return nil, err
 		}
+		reg.Revision = kv.CreateRevision
 		registrations = append(registrations, reg)
 	}
 	return registrations, nil
 }
 
+// FindRegistrationsSnapshot under each of the prefixes, all read at
+// the same revision of etcd, which is also returned, so that they are
+// consistent with each other.
+func (rr *Registry) FindRegistrationsSnapshot(c context.Context, prefixes ...string) ([][]*Registration, int64, error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	ops := make([]etcdv3.Op, len(prefixes))
+	for i, prefix := range prefixes {
+		ops[i] = etcdv3.OpGet(prefix, etcdv3.WithPrefix())
+	}
+	txnRes, err := rr.kv.Txn(c).Then(ops...).Commit()
+	if err != nil {
+		return nil, 0, err
+	}
+	snapshot := make([][]*Registration, len(prefixes))
+	for i, res := range txnRes.Responses {
+		getRes := res.GetResponseRange()
+		registrations := make([]*Registration, 0, len(getRes.Kvs))
+		for _, kv := range getRes.Kvs {
+			reg := &Registration{}
+			err = Decode(kv.Value, reg)
+			if err != nil {
+				return nil, 0, err
+			}
+			reg.Revision = kv.CreateRevision
+			registrations = append(registrations, reg)
+		}
+		snapshot[i] = registrations
+	}
+	return snapshot, txnRes.Header.Revision, nil
+}
+
+// FindRegistrationsPage of at most limit registrations under the
+// prefix, in order of their keys, starting after the key given,
+// or at the first key if it is empty. It also returns if more
+// registrations remain after the page.
+func (rr *Registry) FindRegistrationsPage(c context.Context, prefix, after string, limit int64) ([]*Registration, bool, error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	start := prefix
+	if after != "" {
+		// The smallest key greater than after.
+		start = after + "\x00"
+	}
+	getRes, err := rr.kv.Get(c, start,
+		etcdv3.WithRange(etcdv3.GetPrefixRangeEnd(prefix)),
+		etcdv3.WithSort(etcdv3.SortByKey, etcdv3.SortAscend),
+		etcdv3.WithLimit(limit))
+	if err != nil {
+		return nil, false, err
+	}
+	registrations := make([]*Registration, 0, len(getRes.Kvs))
+	for _, kv := range getRes.Kvs {
+		reg := &Registration{}
+		err = Decode(kv.Value, reg)
+		if err != nil {
+			return nil, false, err
+		}
+		reg.Revision = kv.CreateRevision
+		registrations = append(registrations, reg)
+	}
+	return registrations, getRes.More, nil
+}
+
 // FindRegistration associated with the given key.
 func (rr *Registry) FindRegistration(c context.Context, key string) (*Registration, error) {
 	rr.mu.Lock()
@@ -378,10 +465,11 @@ func (rr *Registry) FindRegistration(c context.Context, key string) (*Registrati
 		return nil, ErrUnknownKey
 	}
 	reg := &Registration{}
-	err = json.Unmarshal(getRes.Kvs[0].Value, reg)
+	err = Decode(getRes.Kvs[0].Value, reg)
 	if err != nil {
 		return nil, err
 	}
+	reg.Revision = getRes.Kvs[0].CreateRevision
 	return reg, nil
 }
 
@@ -416,7 +504,7 @@ func (rr *Registry) Register(c context.Context, key string, options ...Option) e
 		// from the same address, so check if the
 		// found record has the correct address.
 		reg := &Registration{}
-		err = json.Unmarshal(kv.Value, reg)
+		err = Decode(kv.Value, reg)
 		if err != nil {
 			return err
 		}
@@ -431,10 +519,12 @@ func (rr *Registry) Register(c context.Context, key string, options ...Option) e
 		return ErrAlreadyRegistered
 	}
 
-	value, err := json.Marshal(&Registration{
-		Key:      key,
-		Address:  rr.address,
-		Registry: rr.name,
+	value, err := Encode(rr.Codec, &Registration{
+		Key:        key,
+		Address:    rr.address,
+		Registry:   rr.name,
+		Registered: time.Now().UnixNano(),
+		Labels:     rr.Labels,
 	})
 	if err != nil {
 		return err
@@ -452,8 +542,134 @@ func (rr *Registry) Register(c context.Context, key string, options ...Option) e
 	return nil
 }
 
+// Takeover the key, registering it even if it is already registered,
+// for example by a registry that has died but whose lease has not
+// yet expired. The epoch of the registration is bumped, so that the
+// new registration is distinguished from the one taken over, and
+// it is bound to this registry's lease.
+func (rr *Registry) Takeover(c context.Context, key string) error {
+	_, err := rr.TakeoverEpoch(c, key)
+	return err
+}
+
+// TakeoverEpoch is Takeover, returning the epoch of the new
+// registration, see DeregisterEpoch.
+func (rr *Registry) TakeoverEpoch(c context.Context, key string) (int64, error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	if rr.leaseID < 0 {
+		return 0, ErrNotStarted
+	}
+
+	getRes, err := rr.kv.Get(c, key, etcdv3.WithLimit(1))
+	if err != nil {
+		return 0, err
+	}
+
+	reg := &Registration{
+		Key:        key,
+		Address:    rr.address,
+		Registry:   rr.name,
+		Registered: time.Now().UnixNano(),
+		Labels:     rr.Labels,
+	}
+	// The key is put only if it has not changed since
+	// it was read, otherwise another takeover or
+	// registration won the race.
+	cmp := etcdv3.Compare(etcdv3.Version(key), "=", 0)
+	if getRes.Count > 0 {
+		kv := getRes.Kvs[0]
+		prev := &Registration{}
+		err = Decode(kv.Value, prev)
+		if err != nil {
+			return 0, err
+		}
+		reg.Epoch = prev.Epoch + 1
+		cmp = etcdv3.Compare(etcdv3.ModRevision(key), "=", kv.ModRevision)
+	}
+
+	value, err := Encode(rr.Codec, reg)
+	if err != nil {
+		return 0, err
+	}
+	txnRes, err := rr.kv.Txn(c).
+		If(cmp).
+		Then(etcdv3.OpPut(key, string(value), etcdv3.WithLease(rr.leaseID))).
+		Commit()
+	if err != nil {
+		return 0, err
+	}
+	if !txnRes.Succeeded {
+		return 0, ErrFailedRegistration
+	}
+	return reg.Epoch, nil
+}
+
+// RegisterWithInit registers under the given key, and in the same
+// transaction initializes the given keys and values. The values are
+// only written if none of the keys exist yet, so that initialization
+// happens once, even if the registration happens many times. Unlike
+// the registration, the values are not bound to the registry's lease.
+func (rr *Registry) RegisterWithInit(c context.Context, key string, init map[string]string) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	if rr.leaseID < 0 {
+		return ErrNotStarted
+	}
+
+	value, err := Encode(rr.Codec, &Registration{
+		Key:        key,
+		Address:    rr.address,
+		Registry:   rr.name,
+		Registered: time.Now().UnixNano(),
+		Labels:     rr.Labels,
+	})
+	if err != nil {
+		return err
+	}
+
+	var initCmps []etcdv3.Cmp
+	var initOps []etcdv3.Op
+	for k, v := range init {
+		initCmps = append(initCmps, etcdv3.Compare(etcdv3.Version(k), "=", 0))
+		initOps = append(initOps, etcdv3.OpPut(k, v))
+	}
+
+	txnRes, err := rr.kv.Txn(c).
+		If(etcdv3.Compare(etcdv3.Version(key), "=", 0)).
+		Then(
+			etcdv3.OpPut(key, string(value), etcdv3.WithLease(rr.leaseID)),
+			etcdv3.OpTxn(initCmps, initOps, nil),
+		).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !txnRes.Succeeded {
+		return ErrAlreadyRegistered
+	}
+	return nil
+}
+
 // Deregister under the given key.
 func (rr *Registry) Deregister(c context.Context, key string) error {
+	return rr.deregister(c, key, func(*Registration) bool { return true })
+}
+
+// DeregisterEpoch under the given key, only if the registration is
+// of the epoch, otherwise ErrNotOwner is returned. Registrations made
+// by Register are of epoch zero, and those made by a takeover of the
+// epoch it returns, see TakeoverEpoch. This keeps a registration that
+// was taken over, even by this registry, from being deregistered by
+// its previous owner.
+func (rr *Registry) DeregisterEpoch(c context.Context, key string, epoch int64) error {
+	return rr.deregister(c, key, func(reg *Registration) bool { return reg.Epoch == epoch })
+}
+
+// deregister the key, if this registry owns its registration.
+func (rr *Registry) deregister(c context.Context, key string, owns func(*Registration) bool) error {
 	rr.mu.Lock()
 	defer rr.mu.Unlock()
 
@@ -478,11 +694,11 @@ func (rr *Registry) Deregister(c context.Context, key string) error {
 	if getRes.Count > 0 {
 		kv := getRes.Kvs[0]
 		rec := &Registration{}
-		err = json.Unmarshal(kv.Value, rec)
+		err = Decode(kv.Value, rec)
 		if err != nil {
 			return err
 		}
-		if rec.Address != rr.address {
+		if rec.Address != rr.address || !owns(rec) {
 			return ErrNotOwner
 		}
 
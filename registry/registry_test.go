@@ -71,6 +71,44 @@ func TestRegister(t *testing.T) {
 	}
 }
 
+func TestRegisterWithInit(t *testing.T) {
+	client, r, _ := bootstrap(t, start)
+	defer client.Close()
+	defer r.Stop()
+
+	timeout, cancel := timeoutContext()
+	defer cancel()
+
+	err := r.RegisterWithInit(timeout, "test-registration-init", map[string]string{"test-init-key": "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Deregister(timeout, "test-registration-init")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Registering again must not overwrite the
+	// already initialized value.
+	err = r.RegisterWithInit(timeout, "test-registration-init", map[string]string{"test-init-key": "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.Get(timeout, "test-init-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Kvs[0].Value) != "first" {
+		t.Fatalf("expected initial value to remain, got: %s", res.Kvs[0].Value)
+	}
+
+	// Registering while registered must fail.
+	err = r.RegisterWithInit(timeout, "test-registration-init", nil)
+	if err != ErrAlreadyRegistered {
+		t.Fatal("expected already registered error")
+	}
+}
+
 func TestDeregistration(t *testing.T) {
 	client, r, _ := bootstrap(t, start)
 	defer client.Close()
@@ -170,6 +208,83 @@ func TestRegisterTwiceNotAllowed(t *testing.T) {
 	}
 }
 
+func TestTakeover(t *testing.T) {
+	client, r, _ := bootstrap(t, start)
+	defer client.Close()
+	defer r.Stop()
+
+	timeout, cancel := timeoutContext()
+	err := r.Register(timeout, "test-takeover")
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		timeout, cancel = timeoutContext()
+		err = r.Takeover(timeout, "test-takeover")
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		timeout, cancel = timeoutContext()
+		reg, err := r.FindRegistration(timeout, "test-takeover")
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reg.Epoch != int64(i) {
+			t.Fatalf("expected epoch: %v, got: %v", i, reg.Epoch)
+		}
+	}
+}
+
+func TestDeregisterEpoch(t *testing.T) {
+	client, r, _ := bootstrap(t, start)
+	defer client.Close()
+	defer r.Stop()
+
+	timeout, cancel := timeoutContext()
+	err := r.Register(timeout, "test-deregister-epoch")
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout, cancel = timeoutContext()
+	epoch, err := r.TakeoverEpoch(timeout, "test-deregister-epoch")
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if epoch != 1 {
+		t.Fatalf("expected epoch: 1, got: %v", epoch)
+	}
+
+	// The registration taken over is not deregistered
+	// by its previous owner.
+	timeout, cancel = timeoutContext()
+	err = r.DeregisterEpoch(timeout, "test-deregister-epoch", 0)
+	cancel()
+	if err != ErrNotOwner {
+		t.Fatalf("expected not owner, got: %v", err)
+	}
+
+	timeout, cancel = timeoutContext()
+	err = r.DeregisterEpoch(timeout, "test-deregister-epoch", epoch)
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel = timeoutContext()
+	_, err = r.FindRegistration(timeout, "test-deregister-epoch")
+	cancel()
+	if err != ErrUnknownKey {
+		t.Fatalf("expected unknown key, got: %v", err)
+	}
+}
+
 func TestStop(t *testing.T) {
 	client, r, _ := bootstrap(t, start)
 	defer client.Close()
@@ -262,6 +377,74 @@ func TestFindRegistrations(t *testing.T) {
 	}
 }
 
+func TestFindRegistrationsSnapshot(t *testing.T) {
+	client, r, _ := bootstrap(t, start)
+	defer client.Close()
+	defer r.Stop()
+
+	for _, key := range []string{"test-snapshot-peer-a", "test-snapshot-actor-a", "test-snapshot-actor-b"} {
+		timeout, cancel := timeoutContext()
+		err := r.Register(timeout, key)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timeout, cancel := timeoutContext()
+	snapshot, rev, err := r.FindRegistrationsSnapshot(timeout, "test-snapshot-peer-", "test-snapshot-actor-")
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot) != 2 || len(snapshot[0]) != 1 || len(snapshot[1]) != 2 {
+		t.Fatalf("expected registrations under each prefix, got: %v", snapshot)
+	}
+	for _, regs := range snapshot {
+		for _, reg := range regs {
+			if reg.Revision > rev {
+				t.Fatalf("expected registrations at or before revision: %v, got: %v", rev, reg.Revision)
+			}
+		}
+	}
+}
+
+func TestFindRegistrationsPage(t *testing.T) {
+	client, r, _ := bootstrap(t, start)
+	defer client.Close()
+	defer r.Stop()
+
+	for _, key := range []string{"test-page-a", "test-page-b", "test-page-c"} {
+		timeout, cancel := timeoutContext()
+		err := r.Register(timeout, key)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var keys []string
+	after := ""
+	for {
+		timeout, cancel := timeoutContext()
+		regs, more, err := r.FindRegistrationsPage(timeout, "test-page-", after, 2)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, reg := range regs {
+			keys = append(keys, reg.Key)
+			after = reg.Key
+		}
+		if !more {
+			break
+		}
+	}
+	if len(keys) != 3 || keys[0] != "test-page-a" || keys[2] != "test-page-c" {
+		t.Fatalf("expected all registrations in order, got: %v", keys)
+	}
+}
+
 func TestKeepAlive(t *testing.T) {
 	client, r, addr := bootstrap(t, dontStart)
 	defer client.Close()
@@ -0,0 +1,45 @@
+package grid
+
+import "context"
+
+// Relay the message to the receiver, and respond to the request with
+// the receiver's response as it was encoded, without decoding it and
+// encoding it again. Actors that fulfil requests by requesting from
+// other actors, such as proxies and routers, save a copy and the time
+// of encoding, and their callers get the response just as if they had
+// sent their request to the receiver themselves, in the codec that
+// the receiver encoded it with. An error of the receiver is responded
+// to the request, and also returned. A context without a deadline is
+// bounded by the client's DefaultRequestTimeout, as with RequestC.
+//
+// Example usage:
+//
+//     case grid.Request:
+//         shard := shardOf(req.Msg())
+//         err := client.Relay(ctx, req, shard, req.Msg())
+//
+func (c *Client) Relay(ctx context.Context, req Request, receiver string, msg interface{}) error {
+	ctx, cancel := orDefaultDeadline(ctx, c.cfg.DefaultRequestTimeout)
+	defer cancel()
+	c.mirror(ctx, receiver, msg)
+	res, err := c.requestDelivery(ctx, receiver, msg)
+	return relay(req, res, err)
+}
+
+// relay the response, or error, of the receiver to the request.
+// The delivery of the response is not put back into the pool,
+// since the response to the request keeps its bytes until sent.
+func relay(req Request, res *Delivery, err error) error {
+	if err != nil {
+		if respondErr := req.Respond(err); respondErr != nil {
+			return respondErr
+		}
+		return err
+	}
+	raw := &RawMessage{
+		TypeName: res.TypeName,
+		Data:     res.Data,
+		Codec:    res.Codec,
+	}
+	return req.Respond(raw)
+}
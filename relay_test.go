@@ -0,0 +1,60 @@
+package grid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRelay(t *testing.T) {
+	data := []byte(`{"msg":"hello"}`)
+	res := &Delivery{TypeName: "echo", Data: data, Codec: "json"}
+
+	req := newRequest(context.Background(), &EchoMsg{}, &Provenance{})
+	if err := relay(req, res, nil); err != nil {
+		t.Fatal(err)
+	}
+	relayed := <-req.response
+	if &relayed.Data[0] != &data[0] {
+		t.Fatal("expected response bytes to be relayed without copying")
+	}
+	if relayed.TypeName != "echo" || relayed.Codec != "json" {
+		t.Fatalf("expected type and codec of the response, got: %v, %v", relayed.TypeName, relayed.Codec)
+	}
+
+	// Errors of the receiver are responded, and returned.
+	failure := errors.New("receiver failed")
+	req = newRequest(context.Background(), &EchoMsg{}, &Provenance{})
+	if err := relay(req, nil, failure); err != failure {
+		t.Fatalf("expected receiver's error, got: %v", err)
+	}
+	if err := <-req.failure; err != failure {
+		t.Fatalf("expected receiver's error responded, got: %v", err)
+	}
+}
+
+func TestRelayDefaultDeadline(t *testing.T) {
+	s := &Server{mailboxes: newMailboxMap(), maint: newMaintenanceState()}
+	boxC := make(chan Request, 1)
+	box := &Mailbox{name: "silent", nsName: "testing.mailbox.silent", C: boxC, c: boxC}
+	s.mailboxes.reserve(box.nsName)
+	s.mailboxes.set(box.nsName, box)
+
+	// The receiver never responds, so the relay
+	// ends only once the default deadline passes.
+	c := &Client{cfg: ClientCfg{Namespace: "testing", Server: s, DefaultRequestTimeout: 50 * time.Millisecond}}
+	req := newRequest(context.Background(), &EchoMsg{}, &Provenance{})
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Relay(context.Background(), req, "silent", &EchoMsg{})
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected relay to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected relay bounded by the default request timeout")
+	}
+}
@@ -0,0 +1,117 @@
+package grid
+
+import (
+	"context"
+	"sync"
+)
+
+// replayBuffer of the last messages put into mailboxes of a name.
+// Messages are kept encoded, since their receivers may change them.
+type replayBuffer struct {
+	mu   sync.Mutex
+	size int
+	msgs []RawMessage
+}
+
+// add the message, dropping the oldest if the buffer is full.
+func (rb *replayBuffer) add(msg RawMessage) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.msgs = append(rb.msgs, msg)
+	if over := len(rb.msgs) - rb.size; over > 0 {
+		rb.msgs = append(rb.msgs[:0:0], rb.msgs[over:]...)
+	}
+}
+
+// resize the buffer, dropping the oldest messages that
+// no longer fit, and return the messages retained.
+func (rb *replayBuffer) resize(size int) []RawMessage {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.size = size
+	if over := len(rb.msgs) - rb.size; over > 0 {
+		rb.msgs = append(rb.msgs[:0:0], rb.msgs[over:]...)
+	}
+	return append([]RawMessage(nil), rb.msgs...)
+}
+
+// NewRetainingMailbox is NewMailbox, for a mailbox that retains the
+// last n messages put into it, and into which those retained from
+// previous mailboxes of the same name on this server are replayed,
+// before it can receive any new ones. An actor that restarts, and
+// creates its mailbox again, then receives the recent messages it
+// would have missed first. Replayed messages are put into the mailbox
+// as requests whose responses go nowhere, see IsReplay, and those
+// that do not fit into the mailbox are dropped. Messages are retained
+// encoded, in the memory of the server, until it stops.
+//
+// Example usage:
+//
+//     mailbox, err := grid.NewRetainingMailbox(server, "monitor", 100, 50)
+//     ...
+//     defer mailbox.Close()
+//
+func NewRetainingMailbox(s *Server, name string, size, n int, options ...MailboxOption) (*Mailbox, error) {
+	return openMailbox(s, name, size, nil, n, options)
+}
+
+// retainMessages of the mailbox, the last n put into it, and replay
+// into it those retained from previous mailboxes of the same name.
+func (box *Mailbox) retainMessages(n int) {
+	if box.server == nil || n < 1 {
+		return
+	}
+	s := box.server
+	s.mu.Lock()
+	rb, ok := s.replays[box.nsName]
+	if !ok {
+		rb = &replayBuffer{}
+		s.replays[box.nsName] = rb
+	}
+	s.mu.Unlock()
+	msgs := rb.resize(n)
+
+	box.mu.Lock()
+	box.replay = rb
+	box.mu.Unlock()
+
+	for i := range msgs {
+		msg, err := decode(msgs[i].Codec, msgs[i].TypeName, msgs[i].Data)
+		if err != nil {
+			s.logf("%v: mailbox: %v, dropped replayed message: %v", s.cfg.Namespace, box.name, err)
+			continue
+		}
+		req := newRequest(context.Background(), msg, &Provenance{})
+		req.replayed = true
+		err = box.put(req)
+		if err != nil {
+			s.logf("%v: mailbox: %v, dropped: %v replayed messages: %v", s.cfg.Namespace, box.name, len(msgs)-i, err)
+			return
+		}
+	}
+}
+
+// IsReplay if the request is a replay of a message retained from
+// a previous mailbox, see NewRetainingMailbox.
+func IsReplay(req Request) bool {
+	r, ok := req.(*request)
+	return ok && r.replayed
+}
+
+// encodeRetained message of the request, if the mailbox retains
+// messages, and the request is not itself a replay, otherwise nil.
+// It is encoded before the request is queued, since the receiver
+// may change the message once it has it.
+func (box *Mailbox) encodeRetained(req *request) *RawMessage {
+	if box.replay == nil || req.replayed {
+		return nil
+	}
+	raw, err := encode(req.codec, req.msg)
+	if err != nil {
+		if box.server != nil {
+			box.server.logf("%v: mailbox: %v, failed retaining message: %v", box.server.cfg.Namespace, box.name, err)
+		}
+		return nil
+	}
+	return &raw
+}
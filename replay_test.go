@@ -0,0 +1,58 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestReplayBuffer(t *testing.T) {
+	rb := &replayBuffer{size: 3}
+	for i := 0; i < 5; i++ {
+		rb.add(RawMessage{TypeName: fmt.Sprint(i)})
+	}
+	msgs := rb.resize(2)
+	if len(msgs) != 2 || msgs[0].TypeName != "3" || msgs[1].TypeName != "4" {
+		t.Fatalf("expected the last messages, got: %v", msgs)
+	}
+}
+
+func TestRetainMessages(t *testing.T) {
+	s := &Server{replays: map[string]*replayBuffer{}}
+	box, _ := newTestMailbox(10)
+	box.server = s
+	box.nsName = "testing.mailbox.monitor"
+	box.retainMessages(2)
+	for _, msg := range []string{"a", "b", "c"} {
+		err := box.put(newRequest(context.Background(), &EchoMsg{Msg: msg}, &Provenance{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Messages are retained as they were put, even
+	// if the receiver changes them afterwards.
+	for len(box.C) > 0 {
+		req := <-box.C
+		req.Msg().(*EchoMsg).Msg = "changed"
+	}
+
+	// The mailbox of the restarted
+	// actor gets the last two.
+	next, _ := newTestMailbox(10)
+	next.server = s
+	next.nsName = box.nsName
+	next.retainMessages(2)
+	if len(next.C) != 2 {
+		t.Fatalf("expected two replayed messages, got: %v", len(next.C))
+	}
+	for _, expected := range []string{"b", "c"} {
+		req := <-next.C
+		if !IsReplay(req) || req.Msg().(*EchoMsg).Msg != expected {
+			t.Fatalf("expected replay of: %v, got: %v", expected, req.Msg())
+		}
+	}
+	if msgs := s.replays[box.nsName].resize(2); len(msgs) != 2 {
+		t.Fatalf("expected replays not to be retained again, got: %v", msgs)
+	}
+}
@@ -0,0 +1,48 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+)
+
+// RequestReplicated sends the request to both the primary actor's
+// mailbox and to its standby's mailbox, see Standby, and returns the
+// primary's response only once both have acknowledged, so that the
+// state change the request carries survives the loss of either of
+// their peers. If either fails, the request fails, and since the
+// other may have applied it, requests sent this way must be safe to
+// apply again when retried. Once the standby has been promoted, and
+// its own mailbox is closed, requests sent this way fail until a new
+// standby is running.
+//
+// Example usage:
+//
+//     res, err := client.RequestReplicated(ctx, "counter", "counter-standby", &Incr{By: 1})
+//
+func (c *Client) RequestReplicated(ctx context.Context, primary, standby string, msg interface{}) (interface{}, error) {
+	return requestReplicated(ctx, c.RequestC, primary, standby, msg)
+}
+
+// requestReplicated by sending the request to the primary and to
+// the standby at the same time, and waiting for both.
+func requestReplicated(ctx context.Context, request func(context.Context, string, interface{}) (interface{}, error), primary, standby string, msg interface{}) (interface{}, error) {
+	type result struct {
+		res interface{}
+		err error
+	}
+	standbyC := make(chan result, 1)
+	go func() {
+		res, err := request(ctx, standby, msg)
+		standbyC <- result{res, err}
+	}()
+
+	res, err := request(ctx, primary, msg)
+	replica := <-standbyC
+	if err != nil {
+		return nil, err
+	}
+	if replica.err != nil {
+		return nil, fmt.Errorf("%v: standby: %v, error: %v", ErrReplicationIncomplete, standby, replica.err)
+	}
+	return res, nil
+}
@@ -0,0 +1,49 @@
+package grid
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRequestReplicated(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	received := map[string]bool{}
+	failing := ""
+	request := func(ctx context.Context, receiver string, msg interface{}) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if receiver == failing {
+			return nil, errors.New("unreachable")
+		}
+		received[receiver] = true
+		return &EchoMsg{Msg: receiver}, nil
+	}
+
+	res, err := requestReplicated(context.Background(), request, "counter", "counter-standby", &EchoMsg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if echo := res.(*EchoMsg); echo.Msg != "counter" {
+		t.Fatalf("expected response of primary, got: %v", echo.Msg)
+	}
+	if !received["counter"] || !received["counter-standby"] {
+		t.Fatalf("expected request received by both, got: %v", received)
+	}
+
+	failing = "counter-standby"
+	_, err = requestReplicated(context.Background(), request, "counter", "counter-standby", &EchoMsg{})
+	if err == nil || !strings.Contains(err.Error(), ErrReplicationIncomplete.Error()) {
+		t.Fatalf("expected incomplete replication, got: %v", err)
+	}
+
+	failing = "counter"
+	_, err = requestReplicated(context.Background(), request, "counter", "counter-standby", &EchoMsg{})
+	if err == nil || strings.Contains(err.Error(), ErrReplicationIncomplete.Error()) {
+		t.Fatalf("expected failure of primary, got: %v", err)
+	}
+}
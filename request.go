@@ -3,7 +3,10 @@ package grid
 import (
 	"context"
 	"errors"
+	"fmt"
+	"runtime"
 	"sync"
+	"time"
 
 	"github.com/lytics/grid/codec"
 	netcontext "golang.org/x/net/context"
@@ -11,10 +14,32 @@ import (
 
 var (
 	// ErrAlreadyResponded when respond is called multiple
-	// times on a request.
+	// times on a request. The error returned is always an
+	// AlreadyRespondedError, which matches it with errors.Is.
 	ErrAlreadyResponded = errors.New("already responded")
 )
 
+// AlreadyRespondedError when respond is called multiple times on
+// a request, with the location of the first response, since the
+// second usually comes from another code path than the first.
+type AlreadyRespondedError struct {
+	// First is the file and line of the first
+	// response, empty if it is not known.
+	First string
+}
+
+func (e *AlreadyRespondedError) Error() string {
+	if e.First == "" {
+		return ErrAlreadyResponded.Error()
+	}
+	return fmt.Sprintf("%v, first at: %v", ErrAlreadyResponded, e.First)
+}
+
+// Is ErrAlreadyResponded.
+func (e *AlreadyRespondedError) Is(target error) bool {
+	return target == ErrAlreadyResponded
+}
+
 var (
 	constAck = &Ack{}
 )
@@ -25,14 +50,18 @@ type Request interface {
 	Msg() interface{}
 	Ack() error
 	Respond(msg interface{}) error
+	From() *Provenance
 }
 
 // newRequest state for use in the server. This actually converts
 // between the "context" and "golang.org/x/net/context" types of
-// Context so that method signatures are satisfied.
-func newRequest(ctx netcontext.Context, msg interface{}) *request {
+// Context so that method signatures are satisfied. The provenance
+// is also kept in the context, for the operations the request
+// asks of the server.
+func newRequest(ctx netcontext.Context, msg interface{}, from *Provenance) *request {
 	return &request{
-		ctx:      context.WithValue(ctx, "", ""),
+		ctx:      context.WithValue(ctx, provenanceKey, from),
+		from:     from,
 		msg:      msg,
 		failure:  make(chan error, 1),
 		response: make(chan *Delivery, 1),
@@ -43,9 +72,50 @@ type request struct {
 	mu       sync.Mutex
 	msg      interface{}
 	ctx      context.Context
+	from     *Provenance
 	failure  chan error
 	response chan *Delivery
 	finished bool
+	// responded is the caller of the first
+	// response, for AlreadyRespondedError.
+	responded uintptr
+	// progress sends updates to the sender,
+	// nil unless it asked for them.
+	progress func(update *Delivery) error
+	// seq of the request in its mailbox, and the
+	// time it was queued, for inspecting mailboxes.
+	seq    uint64
+	queued time.Time
+	// box tracking the request until it is responded
+	// to, by its fingerprint, if the box has a policy
+	// on poison messages.
+	box         *Mailbox
+	fingerprint string
+	// replayed if the request is a replay of
+	// a retained message, see NewRetainingMailbox.
+	replayed bool
+	// inbox storing the message of the request by
+	// its ID, until it is handled, if the mailbox is
+	// durable, and redelivered if the message is a
+	// redelivery, see NewDurableMailbox.
+	inbox       *inbox
+	stored      string
+	redelivered bool
+	// pace of the mailbox serving the request.
+	pace *mailboxPace
+	// release the request's hold on its tenant's
+	// quota, nil if it has none.
+	release func()
+	// codec the request was encoded with, which
+	// encodes its response, nil for protobuf.
+	codec codec.Codec
+}
+
+// unhold the request's tenant quota, if it holds any.
+func (req *request) unhold() {
+	if req.release != nil {
+		req.release()
+	}
 }
 
 // Context of request.
@@ -58,21 +128,49 @@ func (req *request) Msg() interface{} {
 	return req.msg
 }
 
+// From returns the provenance of the request, the peer and
+// actor that sent it, and the identity of its caller.
+func (req *request) From() *Provenance {
+	return req.from
+}
+
 // Ack request, same as responding with Respond
 // and "Ack" message.
 func (req *request) Ack() error {
-	return req.Respond(constAck)
+	return req.respond(constAck)
 }
 
-// Respond to request with a message.
+// Respond to request with a message. Responding more than once
+// returns an AlreadyRespondedError.
 func (req *request) Respond(msg interface{}) error {
+	return req.respond(msg)
+}
+
+func (req *request) respond(msg interface{}) error {
+	err := req.finish(msg)
+	req.handledStored()
+	return err
+}
+
+// finish the request with the message as its response.
+func (req *request) finish(msg interface{}) error {
 	req.mu.Lock()
 	defer req.mu.Unlock()
 
 	if req.finished {
-		return ErrAlreadyResponded
+		return req.alreadyResponded()
 	}
 	req.finished = true
+	var pc [1]uintptr
+	if runtime.Callers(4, pc[:]) == 1 {
+		req.responded = pc[0]
+	}
+	if req.box != nil {
+		req.box.handled(req)
+	}
+	if req.pace != nil {
+		req.pace.served(req.queued, time.Now())
+	}
 
 	fail, ok := msg.(error)
 	if ok {
@@ -86,14 +184,15 @@ func (req *request) Respond(msg interface{}) error {
 
 	// Encode the message here, in the thread of
 	// execution of the caller.
-	typeName, data, err := codec.Marshal(msg)
+	raw, err := encode(req.codec, msg)
 	if err != nil {
 		return err
 	}
 	res := &Delivery{
-		Ver:      Delivery_V1,
-		Data:     data,
-		TypeName: typeName,
+		Ver:      protocolVersion,
+		Data:     raw.Data,
+		TypeName: raw.TypeName,
+		Codec:    raw.Codec,
 	}
 
 	// Send the response bytes. Again, the bytes need
@@ -106,3 +205,12 @@ func (req *request) Respond(msg interface{}) error {
 		panic("grid: respond called multiple times")
 	}
 }
+
+// alreadyResponded error, with the location of the first response.
+func (req *request) alreadyResponded() error {
+	if req.responded == 0 {
+		return &AlreadyRespondedError{}
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{req.responded}).Next()
+	return &AlreadyRespondedError{First: fmt.Sprintf("%v:%v", frame.File, frame.Line)}
+}
@@ -1,6 +1,7 @@
 package grid
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
@@ -9,11 +10,53 @@ import (
 func TestRespondWithAlreadyResponded(t *testing.T) {
 	req := &request{finished: true}
 	err := req.Respond("some-msg")
-	if err != ErrAlreadyResponded {
+	if !errors.Is(err, ErrAlreadyResponded) {
 		t.Fatal("expected error")
 	}
 }
 
+func TestRespondTwiceReportsFirstResponse(t *testing.T) {
+	req := newRequest(context.Background(), &EchoMsg{}, &Provenance{})
+	if err := req.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	err := req.Respond(&EchoMsg{})
+	var already *AlreadyRespondedError
+	if !errors.As(err, &already) {
+		t.Fatalf("expected already responded error, got: %v", err)
+	}
+	if !strings.Contains(already.First, "request_test.go:") {
+		t.Fatalf("expected first response in the test, got: %v", already.First)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	var updates []*Delivery
+	req := newRequest(context.Background(), &EchoMsg{}, &Provenance{})
+	req.progress = func(update *Delivery) error {
+		updates = append(updates, update)
+		return nil
+	}
+	if err := Progress(req, &EchoMsg{Msg: "half"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Progress(req, &EchoMsg{Msg: "late"}); !errors.Is(err, ErrAlreadyResponded) {
+		t.Fatalf("expected already responded, got: %v", err)
+	}
+	if len(updates) != 1 || !updates[0].Progress {
+		t.Fatalf("expected one progress update, got: %v", updates)
+	}
+
+	// Senders that did not ask for updates get none.
+	req = newRequest(context.Background(), &EchoMsg{}, &Provenance{})
+	if err := Progress(req, &EchoMsg{}); err != ErrProgressUnsupported {
+		t.Fatalf("expected progress unsupported, got: %v", err)
+	}
+}
+
 func TestResponedWithError(t *testing.T) {
 	expected := errors.New("expected-error")
 
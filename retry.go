@@ -0,0 +1,119 @@
+package grid
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// retryAttempts of a request, including the first.
+	retryAttempts = 3
+	// retryBackoff between attempts of a request, when its
+	// deadline leaves enough time.
+	retryBackoff = 1 * time.Second
+	// retryMinBackoff below which there is too little time
+	// left before the deadline to make another attempt.
+	retryMinBackoff = 10 * time.Millisecond
+	// retryReserve of retries a budget holds when full, so
+	// that a client which has sent few requests may retry.
+	retryReserve = 10
+)
+
+// retryBudget of a client. Every request adds a fraction of a
+// retry to the budget, and every retry takes a whole one, so the
+// client retries at most that fraction of its requests, beyond a
+// small reserve. When peers fail, clients then stop retrying soon,
+// instead of multiplying the load on the peers that are left.
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+// newRetryBudget of the ratio, nil if the ratio is
+// zero, which is a budget without limit.
+func newRetryBudget(ratio float64) *retryBudget {
+	if ratio <= 0 {
+		return nil
+	}
+	return &retryBudget{ratio: ratio, tokens: retryReserve}
+}
+
+// deposit the fraction of a retry earned by a request.
+func (b *retryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.ratio
+	if b.tokens > retryReserve {
+		b.tokens = retryReserve
+	}
+}
+
+// withdraw a retry, false if the budget is spent.
+func (b *retryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// retry the attempt while it returns true, up to retryAttempts
+// times, as long as the client's retry budget allows. The backoff
+// between attempts is jittered, and shortened to leave time for
+// the next attempt before the context's deadline. If too little
+// time is left no more attempts are made.
+func (c *Client) retry(ctx context.Context, attempt func() bool) {
+	c.budget.deposit()
+	for i := 1; ; i++ {
+		if !attempt() || i == retryAttempts {
+			return
+		}
+		if !c.budget.withdraw() {
+			// Test hook.
+			c.cs.Inc(numRetryBudgetSpent)
+			return
+		}
+		wait, ok := backoff(ctx, retryBackoff, time.Now())
+		if !ok {
+			// Test hook.
+			c.cs.Inc(numRetryDeadline)
+			return
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff before the next attempt, at most max, and at most
+// half the time left before the context's deadline. It is
+// false if less than retryMinBackoff would be left.
+func backoff(ctx context.Context, max time.Duration, now time.Time) (time.Duration, bool) {
+	wait := max
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := deadline.Sub(now) / 2; left < wait {
+			wait = left
+		}
+	}
+	if wait < retryMinBackoff {
+		return 0, false
+	}
+	// Jitter, so that clients that failed together
+	// do not all try again at the same moment.
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)), true
+}
@@ -0,0 +1,77 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	b := newRetryBudget(0.5)
+
+	// The reserve is spent first, after which each
+	// two requests earn one retry.
+	for i := 0; i < retryReserve; i++ {
+		if !b.withdraw() {
+			t.Fatalf("expected reserve retry: %v", i)
+		}
+	}
+	if b.withdraw() {
+		t.Fatalf("expected budget to be spent")
+	}
+	b.deposit()
+	if b.withdraw() {
+		t.Fatalf("expected half a retry to not be enough")
+	}
+	b.deposit()
+	b.deposit()
+	if !b.withdraw() {
+		t.Fatalf("expected earned retry")
+	}
+
+	// No budget is no limit.
+	var none *retryBudget
+	none.deposit()
+	if !none.withdraw() {
+		t.Fatalf("expected no limit")
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	now := time.Now()
+
+	wait, ok := backoff(context.Background(), time.Second, now)
+	if !ok || wait < time.Second/2 || wait > time.Second {
+		t.Fatalf("expected jittered backoff, got: %v", wait)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(200*time.Millisecond))
+	defer cancel()
+	wait, ok = backoff(ctx, time.Second, now)
+	if !ok || wait > 100*time.Millisecond {
+		t.Fatalf("expected backoff within half the deadline, got: %v", wait)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), now.Add(5*time.Millisecond))
+	defer cancel()
+	if _, ok := backoff(ctx, time.Second, now); ok {
+		t.Fatalf("expected no time left to retry")
+	}
+
+	// Attempts stop once the deadline is near,
+	// instead of sleeping past it.
+	c := &Client{cs: newClientStats()}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	attempts := 0
+	c.retry(ctx, func() bool {
+		attempts++
+		return true
+	})
+	if attempts < 2 || attempts > retryAttempts {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+	if _, ok := ctx.Deadline(); !ok || ctx.Err() != nil {
+		t.Fatalf("expected retries to end before the deadline")
+	}
+}
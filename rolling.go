@@ -0,0 +1,248 @@
+package grid
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Control commands of rolling restarts, sent to one peer at a time.
+const (
+	controlCordon     = "grid-cordon"
+	controlUncordon   = "grid-uncordon"
+	controlMoveActors = "grid-move-actors"
+)
+
+// RollingStep of a rolling restart of a peer.
+type RollingStep string
+
+const (
+	// StepCordon of the peer, which announces it is a lame duck,
+	// so that no actors are started on it.
+	StepCordon RollingStep = "cordon"
+	// StepDrain of the durable actors of the peer, which are
+	// stopped, to be started again on other peers.
+	StepDrain RollingStep = "drain"
+	// StepVerify that the durable actors of the peer are running
+	// again, on other peers.
+	StepVerify RollingStep = "verify"
+	// StepUncordon of the peer, which takes actors again.
+	StepUncordon RollingStep = "uncordon"
+)
+
+// RollingProgress of a rolling restart.
+type RollingProgress struct {
+	// Peer being restarted.
+	Peer string
+	// Step the peer's restart has begun.
+	Step RollingStep
+	// Actors, the durable actors of the peer being moved.
+	Actors []string
+	// Restarted peers, and the Total number of them.
+	Restarted int
+	Total     int
+}
+
+// RollingRestart the durable actors of the namespace, one peer at a
+// time. Each peer is cordoned, so that actors are not started on it,
+// its durable actors are stopped, with the reason DoneMoved, and once
+// all of them are running again on other peers, the peer is uncordoned.
+// The progress function, if not nil, is called as each step begins.
+// Cancelling the context aborts the restart, uncordoning the peer
+// being restarted. Peers must have durable actors reconciled, see
+// PutDurableActor, and the namespace needs at least two peers.
+//
+// Example usage:
+//
+//     err := client.RollingRestart(ctx, func(p grid.RollingProgress) {
+//         log.Printf("peer %v: %v (%v/%v)", p.Peer, p.Step, p.Restarted, p.Total)
+//     })
+//
+func (c *Client) RollingRestart(ctx context.Context, progress func(RollingProgress)) error {
+	peers, err := c.QueryC(ctx, Peers)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		names = append(names, peer.Name())
+	}
+	sort.Strings(names)
+
+	for i, peer := range names {
+		report := func(step RollingStep, actors []string) {
+			if progress != nil {
+				progress(RollingProgress{
+					Peer:      peer,
+					Step:      step,
+					Actors:    actors,
+					Restarted: i,
+					Total:     len(names),
+				})
+			}
+		}
+		err := c.restartPeer(ctx, peer, report)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restartPeer by moving its durable actors to other peers.
+func (c *Client) restartPeer(ctx context.Context, peer string, report func(RollingStep, []string)) error {
+	report(StepCordon, nil)
+	_, err := c.RequestC(ctx, peer, &Control{Command: controlCordon})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Uncordon even if the restart was aborted,
+		// which is why the context is not the caller's.
+		report(StepUncordon, nil)
+		timeout, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+		defer cancel()
+		_, err := c.RequestC(timeout, peer, &Control{Command: controlUncordon})
+		if err != nil {
+			c.logf("failed uncordoning peer: %v, error: %v", peer, err)
+		}
+	}()
+
+	actors, err := c.durableActorsOn(ctx, peer)
+	if err != nil {
+		return err
+	}
+	if len(actors) == 0 {
+		return nil
+	}
+	report(StepDrain, actors)
+	data, err := json.Marshal(actors)
+	if err != nil {
+		return err
+	}
+	_, err = c.RequestC(ctx, peer, &Control{Command: controlMoveActors, Data: data})
+	if err != nil {
+		return err
+	}
+
+	report(StepVerify, actors)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		running, err := c.QueryC(ctx, Actors)
+		if err != nil {
+			return err
+		}
+		if movedOff(actors, peer, running) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ErrContextFinished
+		case <-ticker.C:
+		}
+	}
+}
+
+// durableActorsOn the peer, by name.
+func (c *Client) durableActorsOn(ctx context.Context, peer string) ([]string, error) {
+	durable, err := c.DurableActors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isDurable := make(map[string]bool, len(durable))
+	for _, start := range durable {
+		isDurable[start.Name] = true
+	}
+	running, err := c.QueryC(ctx, Actors, OnPeer(peer))
+	if err != nil {
+		return nil, err
+	}
+	var actors []string
+	for _, e := range running {
+		if isDurable[e.Name()] {
+			actors = append(actors, e.Name())
+		}
+	}
+	return actors, nil
+}
+
+// movedOff if all of the actors are running on other peers.
+func movedOff(actors []string, peer string, running []*QueryEvent) bool {
+	moved := make(map[string]bool, len(running))
+	for _, e := range running {
+		if e.Peer() != peer {
+			moved[e.Name()] = true
+		}
+	}
+	for _, name := range actors {
+		if !moved[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// Uncordon the server, withdrawing its LameDuck announcement,
+// so that clients route new work to it again.
+func (s *Server) Uncordon(ctx context.Context) error {
+	if s.registry == nil {
+		return ErrServerNotRunning
+	}
+	key, err := namespaceName(lameDucks, s.cfg.Namespace, s.registry.Registry())
+	if err != nil {
+		return err
+	}
+	return s.registry.Deregister(ctx, key)
+}
+
+// handleRollingControls of rolling restarts, and of the
+// stopping of actors, see Client.StopActor.
+func (s *Server) handleRollingControls() {
+	s.HandleControl(controlCordon, func(ctx context.Context, _ []byte) error {
+		return s.LameDuck(ctx)
+	})
+	s.HandleControl(controlUncordon, func(ctx context.Context, _ []byte) error {
+		return s.Uncordon(ctx)
+	})
+	s.HandleControl(controlMoveActors, func(ctx context.Context, data []byte) error {
+		var actors []string
+		err := json.Unmarshal(data, &actors)
+		if err != nil {
+			return err
+		}
+		s.moveActors(actors)
+		return nil
+	})
+	s.HandleControl(controlStopActors, func(ctx context.Context, data []byte) error {
+		var actors []string
+		err := json.Unmarshal(data, &actors)
+		if err != nil {
+			return err
+		}
+		s.stopActors(actors, func(cv *contextVal) { cv.stopped = true })
+		return nil
+	})
+}
+
+// moveActors off this server, by stopping them, so that they
+// are started again on other peers.
+func (s *Server) moveActors(actors []string) {
+	s.stopActors(actors, func(cv *contextVal) { cv.moved = true })
+}
+
+// stopActors of this server, marking the context of each
+// with why it is stopped.
+func (s *Server) stopActors(actors []string, mark func(cv *contextVal)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range actors {
+		cv, ok := s.contexts[name]
+		if !ok {
+			continue
+		}
+		mark(cv)
+		cv.stop()
+	}
+}
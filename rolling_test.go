@@ -0,0 +1,39 @@
+package grid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMovedOff(t *testing.T) {
+	running := []*QueryEvent{
+		{name: "a", peer: "peer-2"},
+		{name: "b", peer: "peer-1"},
+	}
+	if movedOff([]string{"a", "b"}, "peer-1", running) {
+		t.Fatalf("expected b not to have moved off peer-1")
+	}
+	if !movedOff([]string{"a"}, "peer-1", running) {
+		t.Fatalf("expected a to have moved off peer-1")
+	}
+	if movedOff([]string{"c"}, "peer-1", running) {
+		t.Fatalf("expected c not running to not have moved")
+	}
+}
+
+func TestMoveActors(t *testing.T) {
+	s := &Server{contexts: map[string]*contextVal{}}
+	ctx, stop := context.WithCancel(context.Background())
+	cv := &contextVal{server: s, actorName: "a", stop: stop}
+	s.contexts["a"] = cv
+	actorCtx := context.WithValue(ctx, contextKey, cv)
+
+	s.moveActors([]string{"a", "unknown"})
+	if actorCtx.Err() == nil {
+		t.Fatalf("expected the actor to be stopped")
+	}
+	s.ctx = context.Background()
+	if reason, _ := ContextDoneReason(actorCtx); reason != DoneMoved {
+		t.Fatalf("expected moved, got: %v", reason)
+	}
+}
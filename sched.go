@@ -0,0 +1,127 @@
+package grid
+
+import (
+	"context"
+	"sync"
+)
+
+// fairScheduler of the delivery work of a server, ie: decoding
+// requests and putting them into mailboxes, which is limited to a
+// number of slots. While all slots are taken, freed slots are given
+// to the waiting deliveries of each mailbox in turn, so that a
+// flooded mailbox cannot crowd out the deliveries to others, and
+// every actor makes progress under load.
+type fairScheduler struct {
+	mu      sync.Mutex
+	free    int
+	waiting map[string][]*slotWaiter
+	// turns of the mailboxes with deliveries
+	// waiting, in the order they are served.
+	turns []string
+}
+
+// slotWaiter of a delivery waiting for a slot.
+type slotWaiter struct {
+	c       chan struct{}
+	granted bool
+}
+
+// newFairScheduler of the number of slots, nil if it is
+// zero, which does not limit deliveries.
+func newFairScheduler(slots int) *fairScheduler {
+	if slots <= 0 {
+		return nil
+	}
+	return &fairScheduler{
+		free:    slots,
+		waiting: map[string][]*slotWaiter{},
+	}
+}
+
+// acquire a slot for a delivery to the mailbox, waiting for
+// its turn if all slots are taken, or for the context.
+func (fs *fairScheduler) acquire(ctx context.Context, mailbox string) error {
+	if fs == nil {
+		return nil
+	}
+	fs.mu.Lock()
+	if fs.free > 0 && len(fs.turns) == 0 {
+		fs.free--
+		fs.mu.Unlock()
+		return nil
+	}
+	w := &slotWaiter{c: make(chan struct{})}
+	if len(fs.waiting[mailbox]) == 0 {
+		fs.turns = append(fs.turns, mailbox)
+	}
+	fs.waiting[mailbox] = append(fs.waiting[mailbox], w)
+	fs.mu.Unlock()
+
+	select {
+	case <-w.c:
+		return nil
+	case <-ctx.Done():
+	}
+
+	fs.mu.Lock()
+	if w.granted {
+		// The slot was given just as the
+		// context finished, pass it on.
+		fs.mu.Unlock()
+		fs.release()
+		return ErrContextFinished
+	}
+	fs.remove(mailbox, w)
+	fs.mu.Unlock()
+	return ErrContextFinished
+}
+
+// release the slot, giving it to the next mailbox
+// in turn that has a delivery waiting.
+func (fs *fairScheduler) release() {
+	if fs == nil {
+		return
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if len(fs.turns) == 0 {
+		fs.free++
+		return
+	}
+	mailbox := fs.turns[0]
+	fs.turns = fs.turns[1:]
+	queue := fs.waiting[mailbox]
+	w := queue[0]
+	fs.waiting[mailbox] = queue[1:]
+	if len(queue) > 1 {
+		// Back of the line, until the
+		// others have had their turn.
+		fs.turns = append(fs.turns, mailbox)
+	} else {
+		delete(fs.waiting, mailbox)
+	}
+	w.granted = true
+	close(w.c)
+}
+
+// remove the waiter, which gave up.
+func (fs *fairScheduler) remove(mailbox string, w *slotWaiter) {
+	queue := fs.waiting[mailbox]
+	for i, other := range queue {
+		if other == w {
+			queue = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) > 0 {
+		fs.waiting[mailbox] = queue
+		return
+	}
+	delete(fs.waiting, mailbox)
+	for i, m := range fs.turns {
+		if m == mailbox {
+			fs.turns = append(fs.turns[:i:i], fs.turns[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package grid
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFairScheduler(t *testing.T) {
+	fs := newFairScheduler(1)
+	ctx := context.Background()
+	if err := fs.acquire(ctx, "flooded"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deliveries to the flooded mailbox queue up
+	// ahead of the one delivery to the other.
+	granted := make(chan string, 4)
+	queued := 0
+	wait := func(mailbox string) {
+		go func() {
+			if err := fs.acquire(ctx, mailbox); err == nil {
+				granted <- mailbox
+			}
+		}()
+		// Queue in a known order.
+		queued++
+		for {
+			fs.mu.Lock()
+			n := 0
+			for _, q := range fs.waiting {
+				n += len(q)
+			}
+			fs.mu.Unlock()
+			if n == queued {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	wait("flooded")
+	wait("flooded")
+	wait("flooded")
+	wait("other")
+
+	var order []string
+	for i := 0; i < 4; i++ {
+		fs.release()
+		order = append(order, <-granted)
+	}
+	expected := []string{"flooded", "other", "flooded", "flooded"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, order)
+	}
+	fs.release()
+	if fs.free != 1 {
+		t.Fatalf("expected the slot to be free, got: %v", fs.free)
+	}
+}
+
+func TestFairSchedulerContextFinished(t *testing.T) {
+	fs := newFairScheduler(1)
+	if err := fs.acquire(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := fs.acquire(ctx, "b"); err != ErrContextFinished {
+		t.Fatalf("expected context finished, got: %v", err)
+	}
+	if len(fs.turns) != 0 || len(fs.waiting) != 0 {
+		t.Fatalf("expected no waiters, got: %v", fs.waiting)
+	}
+	fs.release()
+	if fs.free != 1 {
+		t.Fatalf("expected the slot to be free, got: %v", fs.free)
+	}
+
+	// No slots is no limit.
+	if fs := newFairScheduler(0); fs.acquire(ctx, "a") != nil {
+		t.Fatal("expected no limit")
+	}
+}
@@ -0,0 +1,454 @@
+package grid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lytics/grid/codec"
+)
+
+// RegisterSchema of the start data of an actor type. The schema is a
+// JSON Schema, and starts of the type whose data does not validate
+// against it are rejected by the server with ErrInvalidStartData,
+// describing each problem by its JSON pointer, before the actor is
+// made. Data set with SetData, which is protobuf, is validated as
+// the JSON encoding of its message, other data must be JSON itself.
+//
+// Example usage:
+//
+//     err := server.RegisterSchema("worker", []byte(`{
+//         "type": "object",
+//         "properties": {
+//             "partition": {"type": "integer", "minimum": 0},
+//             "topic": {"type": "string", "minLength": 1}
+//         },
+//         "required": ["partition", "topic"],
+//         "additionalProperties": false
+//     }`))
+//
+// The keywords supported are: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum, maximum, exclusiveMinimum, and exclusiveMaximum,
+// besides annotations such as title and description. Schemas using
+// any other keyword are rejected with ErrInvalidSchema.
+func (s *Server) RegisterSchema(actorType string, schema []byte) error {
+	sch, err := parseSchema(schema)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schemas[actorType] = sch
+	return nil
+}
+
+// validateStart data against the schema of the start's type, if any.
+func (s *Server) validateStart(start *ActorStart) error {
+	s.mu.Lock()
+	sch := s.schemas[start.Type]
+	s.mu.Unlock()
+	if sch == nil {
+		return nil
+	}
+	doc, err := startDocument(start)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidStartData, err)
+	}
+	var problems []string
+	sch.validate("", doc, &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %v", ErrInvalidStartData, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// startDocument of the start's data, as decoded JSON. Empty
+// data is the JSON null. Data of a type is protobuf, see
+// ActorStart.SetData.
+func startDocument(start *ActorStart) (interface{}, error) {
+	data := start.Data
+	if start.DataType != "" {
+		msg, err := codec.Unmarshal(start.Data, start.DataType)
+		if err != nil {
+			return nil, err
+		}
+		data, err = json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("data is not JSON: %v", err)
+	}
+	return doc, nil
+}
+
+// schema parsed from the supported keywords of JSON Schema.
+type schema struct {
+	types            []string
+	enum             []interface{}
+	properties       map[string]*schema
+	required         []string
+	additional       *schema
+	noAdditional     bool
+	items            *schema
+	minItems         *int
+	maxItems         *int
+	minLength        *int
+	maxLength        *int
+	pattern          *regexp.Regexp
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+}
+
+// schemaAnnotations are keywords allowed, but not validated.
+var schemaAnnotations = map[string]bool{
+	"$schema":     true,
+	"$id":         true,
+	"$comment":    true,
+	"title":       true,
+	"description": true,
+	"default":     true,
+	"examples":    true,
+}
+
+var schemaTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+	"number":  true,
+	"integer": true,
+	"string":  true,
+}
+
+func parseSchema(buf []byte) (*schema, error) {
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	sch, err := newSchema("", doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return sch, nil
+}
+
+// newSchema from the decoded schema at path.
+func newSchema(path string, doc interface{}) (*schema, error) {
+	if b, ok := doc.(bool); ok {
+		// The schema true allows anything, and
+		// false allows nothing, ie: no type.
+		if b {
+			return &schema{}, nil
+		}
+		return &schema{types: []string{}}, nil
+	}
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%v: schema must be an object", pointer(path))
+	}
+	sch := &schema{}
+	for keyword, v := range m {
+		at := path + "/" + escapePointer(keyword)
+		var err error
+		switch keyword {
+		case "type":
+			sch.types, err = schemaTypeList(at, v)
+		case "enum":
+			list, ok := v.([]interface{})
+			if !ok {
+				err = fmt.Errorf("%v: must be an array", pointer(at))
+			}
+			sch.enum = list
+		case "const":
+			sch.enum = []interface{}{v}
+		case "properties":
+			props, ok := v.(map[string]interface{})
+			if !ok {
+				err = fmt.Errorf("%v: must be an object", pointer(at))
+				break
+			}
+			sch.properties = map[string]*schema{}
+			for name, p := range props {
+				sch.properties[name], err = newSchema(at+"/"+escapePointer(name), p)
+				if err != nil {
+					break
+				}
+			}
+		case "required":
+			sch.required, err = schemaStringList(at, v)
+		case "additionalProperties":
+			if b, ok := v.(bool); ok {
+				sch.noAdditional = !b
+				break
+			}
+			sch.additional, err = newSchema(at, v)
+		case "items":
+			sch.items, err = newSchema(at, v)
+		case "minItems":
+			sch.minItems, err = schemaCount(at, v)
+		case "maxItems":
+			sch.maxItems, err = schemaCount(at, v)
+		case "minLength":
+			sch.minLength, err = schemaCount(at, v)
+		case "maxLength":
+			sch.maxLength, err = schemaCount(at, v)
+		case "pattern":
+			p, ok := v.(string)
+			if !ok {
+				err = fmt.Errorf("%v: must be a string", pointer(at))
+				break
+			}
+			sch.pattern, err = regexp.Compile(p)
+		case "minimum":
+			sch.minimum, err = schemaNumber(at, v)
+		case "maximum":
+			sch.maximum, err = schemaNumber(at, v)
+		case "exclusiveMinimum":
+			sch.exclusiveMinimum, err = schemaNumber(at, v)
+		case "exclusiveMaximum":
+			sch.exclusiveMaximum, err = schemaNumber(at, v)
+		default:
+			if !schemaAnnotations[keyword] {
+				err = fmt.Errorf("%v: unsupported keyword", pointer(at))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sch, nil
+}
+
+func schemaTypeList(at string, v interface{}) ([]string, error) {
+	var types []string
+	if t, ok := v.(string); ok {
+		types = []string{t}
+	} else {
+		var err error
+		types, err = schemaStringList(at, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range types {
+		if !schemaTypes[t] {
+			return nil, fmt.Errorf("%v: unknown type: %v", pointer(at), t)
+		}
+	}
+	return types, nil
+}
+
+func schemaStringList(at string, v interface{}) ([]string, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%v: must be an array of strings", pointer(at))
+	}
+	strs := make([]string, 0, len(list))
+	for _, e := range list {
+		s, ok := e.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v: must be an array of strings", pointer(at))
+		}
+		strs = append(strs, s)
+	}
+	return strs, nil
+}
+
+func schemaNumber(at string, v interface{}) (*float64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("%v: must be a number", pointer(at))
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", pointer(at), err)
+	}
+	return &f, nil
+}
+
+func schemaCount(at string, v interface{}) (*int, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("%v: must be a non-negative integer", pointer(at))
+	}
+	i, err := strconv.Atoi(n.String())
+	if err != nil || i < 0 {
+		return nil, fmt.Errorf("%v: must be a non-negative integer", pointer(at))
+	}
+	return &i, nil
+}
+
+// validate the decoded JSON value at path, appending
+// a description of each problem found.
+func (sch *schema) validate(path string, v interface{}, problems *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*problems = append(*problems, pointer(path)+": "+fmt.Sprintf(format, args...))
+	}
+
+	if sch.types != nil && !sch.hasType(v) {
+		fail("expected %v, got %v", strings.Join(sch.types, " or "), jsonType(v))
+		return
+	}
+	if sch.enum != nil && !inEnum(sch.enum, v) {
+		fail("value is not one of the allowed values")
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, name := range sch.required {
+			if _, ok := v[name]; !ok {
+				fail("missing required property: %v", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			at := path + "/" + escapePointer(name)
+			if p, ok := sch.properties[name]; ok {
+				p.validate(at, v[name], problems)
+				continue
+			}
+			if sch.noAdditional {
+				*problems = append(*problems, pointer(at)+": unexpected property")
+				continue
+			}
+			if sch.additional != nil {
+				sch.additional.validate(at, v[name], problems)
+			}
+		}
+	case []interface{}:
+		if sch.minItems != nil && len(v) < *sch.minItems {
+			fail("expected at least %v items, got %v", *sch.minItems, len(v))
+		}
+		if sch.maxItems != nil && len(v) > *sch.maxItems {
+			fail("expected at most %v items, got %v", *sch.maxItems, len(v))
+		}
+		if sch.items != nil {
+			for i, e := range v {
+				sch.items.validate(path+"/"+strconv.Itoa(i), e, problems)
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if sch.minLength != nil && n < *sch.minLength {
+			fail("expected at least %v characters, got %v", *sch.minLength, n)
+		}
+		if sch.maxLength != nil && n > *sch.maxLength {
+			fail("expected at most %v characters, got %v", *sch.maxLength, n)
+		}
+		if sch.pattern != nil && !sch.pattern.MatchString(v) {
+			fail("does not match pattern: %v", sch.pattern)
+		}
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			fail("invalid number: %v", v)
+			return
+		}
+		if sch.minimum != nil && f < *sch.minimum {
+			fail("expected at least %v, got %v", *sch.minimum, v)
+		}
+		if sch.maximum != nil && f > *sch.maximum {
+			fail("expected at most %v, got %v", *sch.maximum, v)
+		}
+		if sch.exclusiveMinimum != nil && f <= *sch.exclusiveMinimum {
+			fail("expected more than %v, got %v", *sch.exclusiveMinimum, v)
+		}
+		if sch.exclusiveMaximum != nil && f >= *sch.exclusiveMaximum {
+			fail("expected less than %v, got %v", *sch.exclusiveMaximum, v)
+		}
+	}
+}
+
+func (sch *schema) hasType(v interface{}) bool {
+	actual := jsonType(v)
+	for _, t := range sch.types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType of the decoded JSON value, numbers without
+// a fractional part are integers.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		f, err := v.Float64()
+		if err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if jsonEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual compares numbers by value, so
+// that 1 and 1.0 are equal.
+func jsonEqual(a, b interface{}) bool {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, aerr := an.Float64()
+		bf, berr := bn.Float64()
+		return aerr == nil && berr == nil && af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// pointer for display, the root of the document is "/".
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func escapePointer(token string) string {
+	token = strings.Replace(token, "~", "~0", -1)
+	return strings.Replace(token, "/", "~1", -1)
+}
@@ -0,0 +1,89 @@
+package grid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateStart(t *testing.T) {
+	s := &Server{schemas: map[string]*schema{}}
+	err := s.RegisterSchema("worker", []byte(`{
+		"title": "worker",
+		"type": "object",
+		"properties": {
+			"partition": {"type": "integer", "minimum": 0},
+			"topic": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+			"mode": {"enum": ["fast", "safe"]},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2}
+		},
+		"required": ["partition", "topic"],
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := &ActorStart{Type: "worker", Data: []byte(`{"partition": 3, "topic": "clicks", "tags": ["a"]}`)}
+	if err := s.validateStart(valid); err != nil {
+		t.Fatalf("expected valid start, got: %v", err)
+	}
+
+	// Actor types without a schema are not validated.
+	if err := s.validateStart(&ActorStart{Type: "other", Data: []byte("not json")}); err != nil {
+		t.Fatalf("expected no validation, got: %v", err)
+	}
+
+	invalid := &ActorStart{Type: "worker", Data: []byte(`{"partition": 1.5, "topic": "", "mode": "slow", "tags": ["a", 2, "c"], "extra": true}`)}
+	err = s.validateStart(invalid)
+	if !errors.Is(err, ErrInvalidStartData) {
+		t.Fatalf("expected invalid start data, got: %v", err)
+	}
+	for _, problem := range []string{
+		"/extra: unexpected property",
+		"/mode: value is not one of the allowed values",
+		"/partition: expected integer, got number",
+		"/tags: expected at most 2 items, got 3",
+		"/tags/1: expected string, got integer",
+		"/topic: expected at least 1 characters, got 0",
+		"/topic: does not match pattern",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Fatalf("expected problem: %v, in: %v", problem, err)
+		}
+	}
+
+	err = s.validateStart(&ActorStart{Type: "worker"})
+	if err == nil || !strings.Contains(err.Error(), "/: expected object, got null") {
+		t.Fatalf("expected missing data to fail, got: %v", err)
+	}
+
+	// Data set from a message is validated
+	// as the message's JSON encoding.
+	err = s.RegisterSchema("control", []byte(`{"required": ["command"], "properties": {"command": {"const": "flush"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := &ActorStart{Type: "control"}
+	if err := start.SetData(&Control{Command: "flush"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.validateStart(start); err != nil {
+		t.Fatalf("expected valid start, got: %v", err)
+	}
+}
+
+func TestRegisterSchemaInvalid(t *testing.T) {
+	s := &Server{schemas: map[string]*schema{}}
+	for _, sch := range []string{
+		`not json`,
+		`{"type": "integers"}`,
+		`{"oneOf": [{"type": "string"}]}`,
+		`{"properties": {"a": {"minLength": -1}}}`,
+		`{"pattern": "("}`,
+	} {
+		if err := s.RegisterSchema("worker", []byte(sch)); !errors.Is(err, ErrInvalidSchema) {
+			t.Fatalf("expected invalid schema for: %v, got: %v", sch, err)
+		}
+	}
+}
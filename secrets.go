@@ -0,0 +1,88 @@
+package grid
+
+import (
+	"context"
+	"os"
+)
+
+// SecretProvider resolves secret references to their values,
+// for example by reading them from a vault or a cloud secret
+// manager. Only the references are ever written to etcd.
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref string) ([]byte, error)
+}
+
+// SecretFunc is a SecretProvider for ordinary functions.
+type SecretFunc func(ctx context.Context, ref string) ([]byte, error)
+
+// Resolve the reference by calling the function.
+func (f SecretFunc) Resolve(ctx context.Context, ref string) ([]byte, error) {
+	return f(ctx, ref)
+}
+
+// EnvSecrets is a SecretProvider that resolves references as
+// the names of environment variables of the server's process.
+var EnvSecrets SecretProvider = SecretFunc(func(ctx context.Context, ref string) ([]byte, error) {
+	v, ok := os.LookupEnv(ref)
+	if !ok {
+		return nil, ErrUnresolvedSecret
+	}
+	return []byte(v), nil
+})
+
+// MakeSecretActor using the given data and the resolved
+// secrets of the ActorStart to parameterize the making
+// of the actor; both are optional.
+type MakeSecretActor func(data []byte, secrets map[string][]byte) (Actor, error)
+
+// RegisterSecretDef of an actor whose constructor is given the
+// secrets of its ActorStart. The ActorStart names each secret and
+// gives its reference, the server resolves the references with
+// its SecretProvider just before making the actor, so credentials
+// never appear in the start message or in the registry.
+//
+// Example usage:
+//
+//     server.RegisterSecretDef("reader", func(data []byte, secrets map[string][]byte) (grid.Actor, error) {
+//         return &Reader{password: string(secrets["password"])}, nil
+//     })
+//
+//     start := grid.NewActorStart("reader-%d", i)
+//     start.Type = "reader"
+//     start.Secrets = map[string]string{"password": "prod/db/reader"}
+//
+func (s *Server) RegisterSecretDef(actorType string, f MakeSecretActor, options ...DefOption) {
+	s.registerDef(actorType, &actorDef{makeSecretActor: f}, options)
+}
+
+// make an actor of the definition, resolving the secrets of the start
+// with the provider. Secrets are resolved even for definitions
+// that do not use them, so a bad reference is never ignored.
+func (def *actorDef) make(ctx context.Context, provider SecretProvider, start *ActorStart) (Actor, error) {
+	secrets, err := resolveSecrets(ctx, provider, start.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	if def.makeSecretActor != nil {
+		return def.makeSecretActor(start.Data, secrets)
+	}
+	return def.makeActor(start.Data)
+}
+
+func resolveSecrets(ctx context.Context, provider SecretProvider, refs map[string]string) (map[string][]byte, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	if provider == nil {
+		return nil, ErrNilSecretProvider
+	}
+	secrets := make(map[string][]byte, len(refs))
+	for name, ref := range refs {
+		v, err := provider.Resolve(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		secrets[name] = v
+	}
+	return secrets, nil
+}
@@ -0,0 +1,54 @@
+package grid
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type secretActor struct {
+	password string
+}
+
+func (a *secretActor) Act(c context.Context) {}
+
+func TestActorDefSecrets(t *testing.T) {
+	provider := SecretFunc(func(ctx context.Context, ref string) ([]byte, error) {
+		if ref != "prod/db/reader" {
+			return nil, ErrUnresolvedSecret
+		}
+		return []byte("hunter2"), nil
+	})
+	def := &actorDef{makeSecretActor: func(data []byte, secrets map[string][]byte) (Actor, error) {
+		return &secretActor{password: string(secrets["password"])}, nil
+	}}
+
+	start := NewActorStart("reader")
+	start.Secrets = map[string]string{"password": "prod/db/reader"}
+
+	actor, err := def.make(context.Background(), provider, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actor.(*secretActor).password != "hunter2" {
+		t.Fatal("expected resolved secret")
+	}
+
+	// Only the reference is in the encoded start.
+	buf, err := json.Marshal(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), "hunter2") {
+		t.Fatal("expected secret value not to be encoded")
+	}
+
+	start.Secrets["password"] = "prod/db/writer"
+	if _, err := def.make(context.Background(), provider, start); err != ErrUnresolvedSecret {
+		t.Fatalf("expected unresolved secret, got: %v", err)
+	}
+	if _, err := def.make(context.Background(), nil, start); err != ErrNilSecretProvider {
+		t.Fatalf("expected nil secret provider, got: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package grid
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Selector of the entities returned by a query. Selectors are
+// applied by the client as it reads registrations from etcd, which
+// cannot filter by them, so that callers such as schedulers need
+// not filter the results themselves.
+//
+// Example usage:
+//
+//     workers, err := client.QueryC(ctx, grid.Actors,
+//         grid.NameMatches(regexp.MustCompile("^worker-")),
+//         grid.Healthy(),
+//     )
+//
+type Selector func(e *QueryEvent) bool
+
+// NameMatches selects entities whose names match the expression.
+func NameMatches(re *regexp.Regexp) Selector {
+	return func(e *QueryEvent) bool { return re.MatchString(e.name) }
+}
+
+// NamePrefix selects entities whose names begin with the prefix.
+func NamePrefix(prefix string) Selector {
+	return func(e *QueryEvent) bool { return strings.HasPrefix(e.name, prefix) }
+}
+
+// OnPeer selects entities on the peer.
+func OnPeer(peer string) Selector {
+	return func(e *QueryEvent) bool { return e.peer == peer }
+}
+
+// Healthy selects entities on peers that are neither suspect,
+// see QueryEvent.Suspect, nor lame ducks, nor in quarantine.
+func Healthy() Selector {
+	return func(e *QueryEvent) bool { return !e.suspect && !e.lame && !e.quarantined }
+}
+
+// selected if the event is selected by all of the selectors.
+func selected(e *QueryEvent, selectors []Selector) bool {
+	for _, s := range selectors {
+		if !s(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectEvents selected by all of the selectors.
+func selectEvents(events []*QueryEvent, selectors []Selector) []*QueryEvent {
+	if len(selectors) == 0 {
+		return events
+	}
+	var result []*QueryEvent
+	for _, e := range events {
+		if selected(e, selectors) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,32 @@
+package grid
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSelectEvents(t *testing.T) {
+	events := []*QueryEvent{
+		{name: "worker-1", peer: "peer-a"},
+		{name: "worker-2", peer: "peer-b", suspect: true},
+		{name: "reader-1", peer: "peer-a"},
+		{name: "worker-3", peer: "peer-a", lame: true},
+	}
+	if got := selectEvents(events, nil); len(got) != len(events) {
+		t.Fatalf("expected all events without selectors, got: %v", got)
+	}
+
+	workers := NameMatches(regexp.MustCompile("^worker-"))
+	got := selectEvents(events, []Selector{workers, OnPeer("peer-a")})
+	if len(got) != 2 || got[0].name != "worker-1" || got[1].name != "worker-3" {
+		t.Fatalf("expected workers on peer-a, got: %v", got)
+	}
+	got = selectEvents(events, []Selector{workers, Healthy()})
+	if len(got) != 1 || got[0].name != "worker-1" {
+		t.Fatalf("expected healthy workers, got: %v", got)
+	}
+	got = selectEvents(events, []Selector{NamePrefix("reader-")})
+	if len(got) != 1 || got[0].name != "reader-1" {
+		t.Fatalf("expected readers, got: %v", got)
+	}
+}
@@ -3,10 +3,13 @@ package grid
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"runtime/debug"
+	"runtime/pprof"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	etcdv3 "github.com/coreos/etcd/clientv3"
@@ -17,13 +20,30 @@ import (
 )
 
 const (
-	contextKey = "grid-context-key-xboKEsHA26"
+	contextKey    = "grid-context-key-xboKEsHA26"
+	provenanceKey = "grid-provenance-key-Qm3TnV8cZp"
+	lineageKey    = "grid-lineage-key-h7WcR2pLxe"
+	tenantKey     = "grid-tenant-key-Vd4sNq9KwB"
+	handleKey     = "grid-handle-key-Jt6YpW3sMf"
 )
 
 type contextVal struct {
 	server    *Server
 	actorID   string
 	actorName string
+	workers   *workerPool
+	usage     *actorUsage
+	logger    *ActorLogger
+	metrics   *ActorMetrics
+	// promoted names of primaries, registered by
+	// the actor as their standby, see Standby.
+	promoted []string
+	// stop the actor, which is moved if it was
+	// stopped to restart on another peer, and
+	// stopped if it alone was stopped.
+	stop    func()
+	moved   bool
+	stopped bool
 }
 
 // Server of a grid.
@@ -37,14 +57,58 @@ type Server struct {
 	stop      sync.Once
 	fatalErr  chan error
 	finalErr  error
-	actors    map[string]MakeActor
+	reason    DoneReason
+	actors    map[string]*actorDef
+	ordering  map[string][]string
+	schemas   map[string]*schema
+	controls  map[string]ControlFunc
+	config    *Config
+	workers   *workerPool
+	usage     map[string]*actorUsage
+	contexts  map[string]*contextVal
+	replays   map[string]*replayBuffer
+	limiter   *rateLimiter
+	tenants   *tenantQuotas
+	sched     *fairScheduler
+	faults    *faultTable
+	skews     *skewTable
+	starts    *startQueue
+	drain     *drainSignal
+	leader    *leaderTerm
+	gossip    *gossipTable
+	maint     *maintenanceState
+	poison    *poisonCounts
+	logs      *logForwarder
+	delivered int64
+	inflight  int64
+	running   sync.WaitGroup
 	registry  *registry.Registry
-	mailboxes map[string]*Mailbox
+	client    *Client
+	mailboxes *mailboxMap
+	// peerMailbox is the full name of the
+	// peer's own mailbox, through which
+	// actors are managed.
+	peerMailbox string
+	// seedMailbox is the name by which
+	// processes that do not know the peer
+	// reach its mailbox, see DiscoverEtcdSeed.
+	seedMailbox string
 }
 
 // NewServer for the grid. The namespace must contain only characters
-// in the set: [a-zA-Z0-9-_] and no other.
-func NewServer(etcd *etcdv3.Client, cfg ServerCfg) (*Server, error) {
+// in the set: [a-zA-Z0-9-_] and no other. The server is configured
+// either by a ServerCfg, or by options, which are applied in order:
+//
+//     server, err := grid.NewServer(etcd, grid.ServerCfg{Namespace: "x"})
+//
+//     server, err := grid.NewServer(etcd,
+//         grid.WithNamespace("x"),
+//         grid.WithLogger(logger),
+//         grid.WithTLS(config),
+//     )
+//
+func NewServer(etcd *etcdv3.Client, options ...ServerOption) (*Server, error) {
+	cfg := newServerCfg(options)
 	setServerCfgDefaults(&cfg)
 
 	if !isNameValid(cfg.Namespace) {
@@ -53,25 +117,60 @@ func NewServer(etcd *etcdv3.Client, cfg ServerCfg) (*Server, error) {
 	if etcd == nil {
 		return nil, ErrNilEtcd
 	}
-	return &Server{
+	var limiter *rateLimiter
+	if cfg.RateLimit > 0 {
+		limiter = newRateLimiter(cfg.RateLimit, cfg.RateBurst)
+	}
+	s := &Server{
 		cfg:      cfg,
+		limiter:  limiter,
+		tenants:  newTenantQuotas(cfg.TenantQuota, cfg.TenantQuotas),
+		sched:    newFairScheduler(cfg.DeliverySlots),
+		drain:    newDrainSignal(),
 		etcd:     etcd,
-		grpc:     grpc.NewServer(),
-		actors:   map[string]MakeActor{},
+		grpc:     grpc.NewServer(serverOptions(cfg)...),
+		actors:   map[string]*actorDef{},
+		ordering: map[string][]string{},
+		schemas:  map[string]*schema{},
+		controls: map[string]ControlFunc{},
+		config:   newConfig(),
+		usage:    map[string]*actorUsage{},
+		contexts: map[string]*contextVal{},
+		replays:  map[string]*replayBuffer{},
+		faults:   newFaultTable(),
+		skews:    newSkewTable(),
+		starts:   newStartQueue(),
+		gossip:   newGossipTable(cfg.FailureDetector),
+		maint:    newMaintenanceState(),
+		logs:     newLogForwarder(logBuffer),
 		fatalErr: make(chan error, 1),
-	}, nil
+	}
+	s.poison = newPoisonCounts(s.quarantine)
+	s.handleRollingControls()
+	s.handleFaultControls()
+	return s, nil
 }
 
 // RegisterDef of an actor. When a ActorStart message is sent to
 // a peer it will use the registered definitions to make and run
 // the actor. If an actor with actorType "leader" is registered
 // it will be started automatically when the Serve method is
-// called.
-func (s *Server) RegisterDef(actorType string, f MakeActor) {
+// called. Options such as OpExecCPU change how the actor is run.
+func (s *Server) RegisterDef(actorType string, f MakeActor, options ...DefOption) {
+	s.registerDef(actorType, &actorDef{makeActor: f}, options)
+}
+
+func (s *Server) registerDef(actorType string, def *actorDef, options []DefOption) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.actors[actorType] = f
+	for _, opt := range options {
+		switch opt {
+		case OpExecCPU:
+			def.cpu = true
+		}
+	}
+	s.actors[actorType] = def
 }
 
 // Context of the server, when it reports done the
@@ -84,18 +183,21 @@ func (s *Server) Context() context.Context {
 }
 
 // Serve the grid on the listener. The listener address type must be
-// net.TCPAddr, otherwise an error will be returned.
+// net.TCPAddr, otherwise an error will be returned. Failures during
+// startup are returned as a StartupError, with the failed phase.
 func (s *Server) Serve(lis net.Listener) error {
 	// Create a registry client, through which other
 	// entities like peers, actors, and mailboxes
 	// will be discovered.
 	r, err := registry.New(s.etcd)
 	if err != nil {
-		return err
+		return startupError(PhaseRegistry, err)
 	}
 	s.registry = r
 	s.registry.Timeout = s.cfg.Timeout
 	s.registry.LeaseDuration = s.cfg.LeaseDuration
+	s.registry.Codec = s.cfg.ValueCodec
+	s.registry.Labels = versionLabels(s.cfg.Labels, s.cfg.Build)
 
 	// Set registry logger.
 	if s.cfg.Logger != nil {
@@ -113,20 +215,45 @@ func (s *Server) Serve(lis net.Listener) error {
 	s.ctx = ctx
 	s.cancel = cancel
 
+	// Start the workers of CPU-bound actors.
+	s.workers = newWorkerPool(s.cfg.CPUWorkers)
+
 	// Start the registry and monitor that it is
 	// running correctly.
 	err = s.monitorRegistry(lis.Addr())
 	if err != nil {
-		return err
+		return startupError(PhaseRegistry, err)
 	}
 
 	// Peer's name is the registry's name.
 	name := s.registry.Registry()
 
+	// Create a client, through which the server
+	// sends requests to other peers.
+	client, err := NewClient(s.etcd, ClientCfg{
+		Namespace:  s.cfg.Namespace,
+		Timeout:    s.cfg.Timeout,
+		Signer:     s.cfg.Signer,
+		KMS:        s.cfg.KMS,
+		ValueCodec: s.cfg.ValueCodec,
+		Codec:      s.cfg.Codec,
+		Labels:     s.cfg.Labels,
+		Locality:   s.cfg.Locality,
+		Token:      s.cfg.Token,
+		TLS:        s.cfg.TLS,
+		Server:     s,
+		Logger:     s.cfg.Logger,
+	})
+	if err != nil {
+		return startupError(PhaseClient, err)
+	}
+	client.peer = name
+	s.client = client
+
 	// Namespaced name, which just includes the namespace.
 	nsName, err := namespaceName(Peers, s.cfg.Namespace, name)
 	if err != nil {
-		return err
+		return startupError(PhasePeer, err)
 	}
 
 	// Register the namespace name, other peers can search
@@ -135,27 +262,51 @@ func (s *Server) Serve(lis net.Listener) error {
 	err = s.registry.Register(timeoutC, nsName)
 	cancel()
 	if err != nil {
-		return err
+		return startupError(PhasePeer, err)
 	}
 
 	// Create the mailboxes map.
 	s.mu.Lock()
-	s.mailboxes = make(map[string]*Mailbox)
+	s.mailboxes = newMailboxMap()
 	s.mu.Unlock()
 
 	// Start a mailbox, this is critical because starting
 	// actors in a grid is just done via a normal request
 	// sending the message ActorDef to a listening peer's
 	// mailbox.
-	mailbox, err := NewMailbox(s, name, 100)
+	mailbox, err := NewMailbox(s, name, 100, OpCritical)
 	if err != nil {
-		return err
+		return startupError(PhaseMailbox, err)
+	}
+	s.peerMailbox = mailbox.String()
+	s.seedMailbox, err = seedReceiver(s.cfg.Namespace)
+	if err != nil {
+		return startupError(PhaseMailbox, err)
 	}
 	go s.runMailbox(mailbox)
 
 	// Start the leader actor, and monitor, ie: make sure
 	// that it's running.
 	s.monitorLeader()
+	s.monitorLeaderConflicts()
+
+	// Warn of peers speaking other versions
+	// of the wire protocol.
+	s.monitorVersionSkew()
+
+	// Keep durable actors running, on some peer.
+	s.monitorDurableActors()
+
+	// Follow the configuration and maintenance
+	// mode of the namespace.
+	s.watchConfig()
+	s.watchMaintenance()
+
+	// Gossip heartbeats with other peers.
+	s.monitorGossip()
+
+	// Forward the logs of actors to the collector.
+	s.forwardLogs()
 
 	// Monitor for fatal errors.
 	s.monitorFatalErrors()
@@ -179,65 +330,347 @@ func (s *Server) Serve(lis net.Listener) error {
 // Stop the server, blocking until all mailboxes registered with
 // this server have called their close method.
 func (s *Server) Stop() {
+	s.stop.Do(func() { s.shutdown(false) })
+}
+
+// shutdown the server, announcing it is a lame duck and draining
+// its actors first, unless it has been drained already, see Drain.
+func (s *Server) shutdown(drained bool) {
+	// The mailboxes map is nil if serving failed
+	// before it was created.
 	logMailboxes := func() {
 		s.mu.Lock()
 		defer s.mu.Unlock()
-		for _, mailbox := range s.mailboxes {
-			s.logf("%v: waiting for mailbox to close: %v", s.cfg.Namespace, mailbox)
+		if s.mailboxes == nil {
+			return
 		}
+		s.mailboxes.each(func(mailbox *Mailbox) {
+			s.logf("%v: waiting for mailbox to close: %v", s.cfg.Namespace, mailbox)
+		})
 	}
 
 	zeroMailboxes := func() bool {
 		s.mu.Lock()
 		defer s.mu.Unlock()
-		return len(s.mailboxes) == 0
+		return s.mailboxes == nil || s.mailboxes.len() == 0
 	}
 
-	s.stop.Do(func() {
-		if s.cancel == nil {
-			return
+	if s.cancel == nil {
+		return
+	}
+	if drained {
+		s.setDoneReason(DoneDrained)
+	} else {
+		s.announceLameDuck()
+		if s.cfg.DrainTimeout > 0 {
+			s.drainActors(s.cfg.DrainTimeout)
+			s.setDoneReason(DoneDrained)
 		}
-		s.cancel()
+	}
+	s.setDoneReason(DoneStopped)
+	s.cancel()
 
-		t0 := time.Now()
-		for {
-			time.Sleep(200 * time.Millisecond)
-			if zeroMailboxes() {
-				break
-			}
-			if time.Now().Sub(t0) > 20*time.Second {
-				t0 = time.Now()
-				logMailboxes()
-			}
+	t0 := time.Now()
+	for {
+		time.Sleep(200 * time.Millisecond)
+		if zeroMailboxes() {
+			break
 		}
+		if time.Now().Sub(t0) > 20*time.Second {
+			t0 = time.Now()
+			logMailboxes()
+		}
+	}
 
-		s.registry.Stop()
-		s.grpc.Stop()
-	})
+	if s.client != nil {
+		s.client.Close()
+	}
+	if s.workers != nil {
+		s.workers.close()
+	}
+	s.registry.Stop()
+	s.grpc.Stop()
 }
 
 // Process a request and return a response. Implements the interface for
 // gRPC definition of the wire service. Consider this a private method.
 func (s *Server) Process(c netcontext.Context, d *Delivery) (*Delivery, error) {
-	getMailbox := func() (*Mailbox, bool) {
-		s.mu.Lock()
-		defer s.mu.Unlock()
-		m, ok := s.mailboxes[d.Receiver]
-		return m, ok
+	err := s.authenticate(c)
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.deliver(c, d)
+	if err == errDropped {
+		<-c.Done()
+		return nil, ErrContextFinished
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.await(c, req)
+}
+
+// Stream requests and responses over one bidirectional gRPC stream,
+// where each response carries the ID of the request it answers. This
+// avoids setting up an RPC per message. Implements the interface for
+// gRPC definition of the wire service. Consider this a private method.
+func (s *Server) Stream(stream Wire_StreamServer) error {
+	err := s.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	// Advertise the features of this peer right away,
+	// the client does not use them until it knows.
+	err = stream.SendHeader(featuresMetadata())
+	if err != nil {
+		return err
+	}
+
+	var sendMu sync.Mutex
+	send := func(res *Delivery) {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		err := stream.Send(res)
+		if err != nil {
+			s.logf("%v: failed sending response on stream: %v", s.cfg.Namespace, err)
+		}
+	}
+	fail := func(id uint64, receiver string, err error) {
+		send(&Delivery{
+			Ver:     protocolVersion,
+			Id:      id,
+			Failure: err.Error(),
+			Credit:  s.credit(receiver),
+			Depth:   s.depth(receiver),
+			Flow:    true,
+		})
+	}
+
+	// handle one request of the stream, calling done
+	// once the delivery is no longer needed.
+	handle := func(d *Delivery, done func()) {
+		// Each request on the stream gets its own context,
+		// bounded by the deadline the sender requested.
+		c, cancel := requestContext(stream.Context(), d)
+
+		// Deliver in the order received, so that requests
+		// from one stream enter the mailbox in order, but
+		// wait for responses concurrently.
+		req, err := s.deliver(c, d)
+		id, receiver := d.Id, d.Receiver
+		done()
+		if err == errDropped {
+			// Nothing answers a dropped request,
+			// so nothing waits for it either.
+			cancel()
+			return
+		}
+		if err != nil {
+			cancel()
+			fail(id, receiver, err)
+			return
+		}
+		go func(id uint64, receiver string) {
+			defer cancel()
+			res, err := s.await(c, req)
+			if err != nil {
+				fail(id, receiver, err)
+				return
+			}
+			// Advertise the mailbox's headroom, so
+			// the sender knows how much more it
+			// may send.
+			res.Id = id
+			res.Credit = s.credit(receiver)
+			res.Depth = s.depth(receiver)
+			res.Flow = true
+			send(res)
+		}(id, receiver)
+	}
+
+	// Deliveries wait in a lane per receiver, so that a
+	// receiver that is slow to admit them, for example
+	// one that is durable, does not hold up the stream's
+	// deliveries to other receivers, nor its receiving,
+	// while those to one receiver stay in order.
+	lanes := newLanes()
+	for {
+		// Envelopes are pooled, since the message they
+		// carry is decoded, ie: copied, on delivery.
+		d := getDelivery()
+		err := stream.RecvMsg(d)
+		if err == io.EOF {
+			putDelivery(d)
+			return nil
+		}
+		if err != nil {
+			putDelivery(d)
+			return err
+		}
+
+		// The envelope goes back to the pool once each
+		// of its requests has been delivered.
+		remaining := int32(len(d.Batch))
+		if remaining == 0 {
+			remaining = 1
+		}
+		done := func() {
+			if atomic.AddInt32(&remaining, -1) == 0 {
+				putDelivery(d)
+			}
+		}
+
+		// A batch carries many small requests coalesced
+		// by the sender into one frame.
+		if len(d.Batch) > 0 {
+			for _, b := range d.Batch {
+				b := b
+				lanes.run(b.Receiver, func() { handle(b, done) })
+			}
+		} else {
+			lanes.run(d.Receiver, func() { handle(d, done) })
+		}
 	}
+}
 
-	mailbox, ok := getMailbox()
+// credit of the named mailbox, ie: how many more requests
+// its buffer has room for, zero if it is unknown.
+func (s *Server) credit(receiver string) int32 {
+	mailbox, ok := s.mailboxes.get(receiver)
 	if !ok {
-		return nil, ErrUnknownMailbox
+		return 0
+	}
+	return int32(mailbox.credit())
+}
+
+// depth of the named mailbox, ie: how many requests are
+// queued in it, zero if it is unknown.
+func (s *Server) depth(receiver string) int32 {
+	mailbox, ok := s.mailboxes.get(receiver)
+	if !ok {
+		return 0
+	}
+	return int32(mailbox.depth())
+}
+
+// deliver the request into the mailbox of its receiver.
+func (s *Server) deliver(c netcontext.Context, d *Delivery) (*request, error) {
+	caller, mailbox, err := s.admit(c, d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Delay or drop the request as faults injected
+	// into the peer say, before taking a turn, so
+	// that the delay does not hold up deliveries to
+	// other mailboxes. A dropped request is never
+	// put into the mailbox, so its sender times out.
+	drop, err := s.injectDelivery(c, mailbox)
+	if err != nil {
+		return nil, err
+	}
+	if drop {
+		return nil, errDropped
 	}
 
+	// Take turns with the deliveries to other
+	// mailboxes when the peer is loaded.
+	err = s.sched.acquire(c, mailbox.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer s.sched.release()
+
 	// Decode the request into an actual msg.
-	msg, err := codec.Unmarshal(d.Data, d.TypeName)
+	msg, err := decode(d.Codec, d.TypeName, d.Data)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueue(c, caller, mailbox, d, msg, nil)
+}
+
+// admit the delivery, checking its version and signature, and
+// the caller's rate, and find the mailbox of its receiver.
+func (s *Server) admit(c netcontext.Context, d *Delivery) (string, *Mailbox, error) {
+	err := checkVersion(d)
+	if err != nil {
+		return "", nil, err
+	}
+	err = verify(s.cfg.Signer, d)
+	if err != nil {
+		return "", nil, err
+	}
+	caller, err := s.callerIdentity(c)
+	if err != nil {
+		return "", nil, ErrUnauthorized
+	}
+	err = s.throttle(c, caller)
+	if err != nil {
+		return "", nil, err
+	}
+	err = s.tenants.throttle(d.Tenant, time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Processes discovering etcd through this peer
+	// as a seed do not know its name, and use the
+	// seed name for its mailbox.
+	if d.Receiver == s.seedMailbox {
+		d.Receiver = s.peerMailbox
+	}
+
+	// The mailboxes map is created before the gRPC
+	// server starts, so it is read without taking
+	// the server lock, which would be a point of
+	// contention for every request.
+	mailbox, ok := s.mailboxes.get(d.Receiver)
+	if !ok {
+		return "", nil, ErrUnknownMailbox
+	}
+	return caller, mailbox, nil
+}
+
+// enqueue the msg of the admitted delivery in the mailbox.
+func (s *Server) enqueue(c netcontext.Context, caller string, mailbox *Mailbox, d *Delivery, msg interface{}, progress func(*Delivery) error) (*request, error) {
+	// Check the caller may do what the
+	// request asks.
+	err := s.authorize(c, caller, d, msg)
 	if err != nil {
 		return nil, err
 	}
 
-	req := newRequest(c, msg)
+	// Reject the request while the namespace is
+	// in maintenance with delivery paused.
+	err = s.paused(mailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	req := newRequest(c, msg, &Provenance{
+		Peer:     d.FromPeer,
+		Actor:    d.FromActor,
+		Identity: caller,
+		Lineage:  d.Lineage,
+		Tenant:   d.Tenant,
+	})
+	req.progress = progress
+
+	// Respond with the codec the sender
+	// encoded the request with.
+	req.codec, err = codec.FindCodec(d.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hold one of the requests the tenant may have
+	// queued, until the request is responded to or
+	// its sender gives up.
+	req.release, err = s.tenants.hold(d.Tenant)
+	if err != nil {
+		return nil, err
+	}
 
 	// Send the filled envelope to the actual
 	// receiver. Also note that the receiver
@@ -246,8 +679,17 @@ func (s *Server) Process(c netcontext.Context, d *Delivery) (*Delivery, error) {
 	// it is busy.
 	err = mailbox.put(req)
 	if err != nil {
+		req.unhold()
 		return nil, err
 	}
+	return req, nil
+}
+
+// await the response to a delivered request.
+func (s *Server) await(c netcontext.Context, req *request) (*Delivery, error) {
+	defer req.unhold()
+	atomic.AddInt64(&s.inflight, 1)
+	defer atomic.AddInt64(&s.inflight, -1)
 
 	// Wait for the receiver to send back a
 	// reply, or the context to finish.
@@ -283,6 +725,26 @@ func (s *Server) runMailbox(mailbox *Mailbox) {
 						s.logf("%v: failed sending ack: %v", s.cfg.Namespace, err)
 					}
 				}
+			case *Control:
+				// Handled concurrently, so that slow commands
+				// do not hold up the starting of actors.
+				go s.control(req, msg)
+			case *Heartbeat:
+				s.heartbeat(req, msg)
+			case *MailboxPeek:
+				s.peekMailbox(req, msg)
+			case *MailboxRequeue:
+				s.requeueMailbox(req, msg)
+			case *MailboxTransfer:
+				s.transferMailbox(req, msg)
+			case *ActorDiagnose:
+				s.diagnoseActor(req, msg)
+			case *PendingStartsQuery:
+				s.pendingStarts(req)
+			case *PeerStatsQuery:
+				s.peerStats(req)
+			case *EtcdEndpointsQuery:
+				s.etcdEndpoints(req)
 			}
 		}
 	}
@@ -298,6 +760,7 @@ func (s *Server) monitorFatalErrors() {
 			case err := <-s.fatalErr:
 				if err != nil {
 					s.putFinalErr(err)
+					s.setDoneReason(DoneFailed)
 					s.Stop()
 				}
 			}
@@ -335,7 +798,7 @@ func (s *Server) monitorLeader() {
 			default:
 			}
 			time.Sleep(1 * time.Second)
-			err = s.startActor(s.cfg.Timeout, &ActorStart{Name: "leader", Type: "leader"})
+			err = s.startActor(s.cfg.Timeout, &ActorStart{Name: leaderName, Type: leaderName})
 			if err != nil && strings.Contains(err.Error(), registry.ErrAlreadyRegistered.Error()) {
 				return nil
 			}
@@ -360,8 +823,11 @@ func (s *Server) monitorLeader() {
 					s.logf("skipping leader startup since make leader returned nil")
 					return
 				}
-				if err != nil {
-					s.reportFatalError(fmt.Errorf("leader start failed: %v", err))
+				if err != nil && s.cfg.PartialStart {
+					s.logf("%v: serving without leader, which failed to start: %v", s.cfg.Namespace, err)
+					timer.Reset(30 * time.Second)
+				} else if err != nil {
+					s.reportFatalError(startupError(PhaseLeader, err))
 				} else {
 					timer.Reset(30 * time.Second)
 				}
@@ -370,6 +836,23 @@ func (s *Server) monitorLeader() {
 	}()
 }
 
+// monitorDurableActors periodically, starting those that
+// are not running anywhere in the namespace.
+func (s *Server) monitorDurableActors() {
+	go func() {
+		ticker := time.NewTicker(s.cfg.ReconcileInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.reconcileDurableActors()
+			}
+		}
+	}()
+}
+
 // reportFatalError to the fatal error monitor. The
 // consequence of a fatal error is handled by the
 // monitor itself.
@@ -407,9 +890,12 @@ func (s *Server) startActor(timeout time.Duration, start *ActorStart) error {
 // system to choose where to run the actor. Calling this method will start the
 // actor on the current host in the current process.
 func (s *Server) startActorC(c context.Context, start *ActorStart) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
+	if s.maint.current() != nil {
+		return ErrMaintenance
+	}
+	if s.faults.etcdLost(time.Now()) {
+		return ErrFaultInjected
+	}
 	if !isNameValid(start.Type) {
 		return ErrInvalidActorType
 	}
@@ -422,11 +908,29 @@ func (s *Server) startActorC(c context.Context, start *ActorStart) error {
 		return err
 	}
 
-	makeActor := s.actors[start.Type]
-	if makeActor == nil {
+	init, err := stateInit(c, s.cfg.KMS, s.cfg.Namespace, start)
+	if err != nil {
+		return err
+	}
+
+	// Only the definitions are read under the lock, so
+	// that starting many actors at once is not serialized
+	// by their registrations.
+	s.mu.Lock()
+	def := s.actors[start.Type]
+	s.mu.Unlock()
+	if def == nil {
 		return ErrDefNotRegistered
 	}
-	actor, err := makeActor(start.Data)
+	err = s.validateStart(start)
+	if err != nil {
+		return err
+	}
+	err = s.checkDependencies(c, start)
+	if err != nil {
+		return err
+	}
+	actor, err := def.make(c, s.cfg.Secrets, start)
 	if err != nil {
 		return err
 	}
@@ -436,30 +940,93 @@ func (s *Server) startActorC(c context.Context, start *ActorStart) error {
 
 	// Register the actor. This acts as a distributed mutex to
 	// prevent an actor from starting twice on one system or
-	// many systems.
+	// many systems. If the actor has initial state it is
+	// written in the same transaction, so that the actor
+	// is never registered without its state.
 	timeout, cancel := context.WithTimeout(c, s.cfg.Timeout)
-	err = s.registry.Register(timeout, nsName)
+	if len(init) == 0 {
+		err = s.registry.Register(timeout, nsName)
+	} else {
+		err = s.registry.RegisterWithInit(timeout, nsName, init)
+	}
 	cancel()
 	if err != nil {
 		return err
 	}
+	s.auditOperation(c, ActionStart, start.Name)
 
 	// The actor's context contains its full id, it's name and the
 	// full registration, which contains the actor's namespace.
-	actorCtx := context.WithValue(s.ctx, contextKey, &contextVal{
+	// CPU-bound actors also get the worker pool.
+	cv := &contextVal{
 		server:    s,
 		actorID:   nsName,
 		actorName: start.Name,
-	})
+	}
+	if def.cpu {
+		cv.workers = s.workers
+	}
+	cv.usage = s.trackUsage(start)
+	cv.logger = s.actorLogger(start)
+	cv.metrics = s.actorMetrics(start)
+
+	// The leader runs in the context of its term, so
+	// that it can step down if another leader is found.
+	parent := s.ctx
+	var term *leaderTerm
+	if start.Name == leaderName {
+		term = s.beginLeaderTerm()
+		parent = term.ctx
+	}
+	actorCtx, stop := context.WithCancel(context.WithValue(parent, contextKey, cv))
+	cv.stop = stop
+	s.mu.Lock()
+	s.contexts[start.Name] = cv
+	s.mu.Unlock()
+
+	// Unregister the actor once it exits, or fails to
+	// be prepared to act.
+	exit := func() {
+		timeout, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+		s.registry.Deregister(timeout, nsName)
+		s.mu.Lock()
+		promoted := cv.promoted
+		if s.contexts[start.Name] == cv {
+			delete(s.contexts, start.Name)
+		}
+		s.mu.Unlock()
+		stop()
+		for _, primary := range promoted {
+			s.registry.Deregister(timeout, primary)
+		}
+		cancel()
+		s.auditOperation(actorCtx, ActionStop, start.Name)
+		if term != nil {
+			s.endLeaderTerm(term)
+		}
+		s.untrackUsage(cv.usage)
+	}
+
+	// Label the actor's goroutines, so that profiles
+	// attribute their work to the actor.
+	labels := pprof.Labels("grid.actor", start.Name, "grid.type", start.Type)
+
+	// The actor is restored and preloaded before it acts,
+	// and before the start is acked, so that it is not
+	// sent requests before its state is loaded, and the
+	// starter learns of a failure to load it.
+	err = s.prepareStarted(c, actorCtx, start, actor, labels)
+	if err != nil {
+		exit()
+		return err
+	}
 
 	// Start the actor, unregister the actor in case of failure
 	// and capture panics that the actor raises.
+	s.running.Add(1)
 	go func() {
-		defer func() {
-			timeout, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
-			s.registry.Deregister(timeout, nsName)
-			cancel()
-		}()
+		defer s.running.Done()
+		defer exit()
 		defer func() {
 			if err := recover(); err != nil {
 				stack := niceStack(debug.Stack())
@@ -467,12 +1034,40 @@ func (s *Server) startActorC(c context.Context, start *ActorStart) error {
 					s.cfg.Namespace, start.Name, err, stack)
 			}
 		}()
-		actor.Act(actorCtx)
+		pprof.Do(actorCtx, labels, func(ctx context.Context) {
+			err := s.runActor(ctx, start.Name, actor)
+			if err != nil {
+				s.logf("%v: actor: %v, %v", s.cfg.Namespace, start.Name, err)
+			}
+		})
 	}()
 
 	return nil
 }
 
+// prepareStarted actor to act, with its context bounded by
+// that of the start, failing the start if it panics.
+func (s *Server) prepareStarted(c, actorCtx context.Context, start *ActorStart, actor Actor, labels pprof.LabelSet) (err error) {
+	ctx := actorCtx
+	if deadline, ok := c.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(actorCtx, deadline)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			stack := niceStack(debug.Stack())
+			s.logf("panic in namespace: %v, actor: %v, recovered from: %v, stack trace: %v",
+				s.cfg.Namespace, start.Name, r, stack)
+			err = fmt.Errorf("panic while preparing actor: %v", r)
+		}
+	}()
+	pprof.Do(ctx, labels, func(ctx context.Context) {
+		err = s.prepareActor(ctx, start.Name, actor)
+	})
+	return err
+}
+
 func (s *Server) logf(format string, v ...interface{}) {
 	if s.cfg.Logger != nil {
 		s.cfg.Logger.Printf(format, v...)
@@ -160,6 +160,10 @@ func TestServerStartNoEtcdRunning(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error")
 	}
+
+	// Stopping the server that failed to start
+	// must not wait for mailboxes it never had.
+	server.Stop()
 }
 
 func TestServerStartThenEtcdStop(t *testing.T) {
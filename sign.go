@@ -0,0 +1,137 @@
+package grid
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Signer signs the envelopes of requests sent by a client, and
+// verifies them on the receiving server, so that a party with
+// a position on the network, even inside TLS termination points,
+// cannot inject forged requests, such as actor starts. A Signer
+// is configured per namespace, through ClientCfg and ServerCfg,
+// and when set on a server, unsigned requests are rejected.
+//
+// Signatures cover the receiver, type, and data of a request,
+// they do not protect against a request being replayed.
+type Signer interface {
+	// Sign the payload.
+	Sign(payload []byte) ([]byte, error)
+	// Verify the signature of the payload, returning
+	// ErrInvalidSignature if it is not valid.
+	Verify(payload, signature []byte) error
+}
+
+// NewHMACSigner signs with HMAC-SHA256 using the key, any of the
+// previous keys are also accepted when verifying, which allows
+// keys to be rotated without all peers changing at once.
+func NewHMACSigner(key []byte, previous ...[]byte) Signer {
+	return &hmacSigner{
+		key:  key,
+		keys: append([][]byte{key}, previous...),
+	}
+}
+
+type hmacSigner struct {
+	key  []byte
+	keys [][]byte
+}
+
+func (hs *hmacSigner) Sign(payload []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, hs.key)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+func (hs *hmacSigner) Verify(payload, signature []byte) error {
+	for _, key := range hs.keys {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(payload)
+		if hmac.Equal(mac.Sum(nil), signature) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// NewEd25519Signer signs with the private key, and accepts
+// signatures of any of the trusted public keys. The private
+// key may be nil for a peer that only receives requests.
+func NewEd25519Signer(key ed25519.PrivateKey, trusted ...ed25519.PublicKey) Signer {
+	return &ed25519Signer{
+		key:     key,
+		trusted: trusted,
+	}
+}
+
+type ed25519Signer struct {
+	key     ed25519.PrivateKey
+	trusted []ed25519.PublicKey
+}
+
+func (es *ed25519Signer) Sign(payload []byte) ([]byte, error) {
+	if es.key == nil {
+		return nil, ErrInvalidSignature
+	}
+	return ed25519.Sign(es.key, payload), nil
+}
+
+func (es *ed25519Signer) Verify(payload, signature []byte) error {
+	for _, pub := range es.trusted {
+		if ed25519.Verify(pub, payload, signature) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// signedPayload of the delivery, its fields are length prefixed
+// so that bytes cannot be moved from one field to another.
+func signedPayload(d *Delivery) []byte {
+	fields := [][]byte{[]byte(d.Receiver), []byte(d.TypeName), d.Data}
+	if d.FromPeer != "" || d.FromActor != "" || d.Tenant != "" || d.Codec != "" {
+		fields = append(fields, []byte(d.FromPeer), []byte(d.FromActor))
+	}
+	if d.Tenant != "" || d.Codec != "" {
+		fields = append(fields, []byte(d.Tenant))
+	}
+	if d.Codec != "" {
+		fields = append(fields, []byte(d.Codec))
+	}
+	size := 0
+	for _, field := range fields {
+		size += len(field) + binary.MaxVarintLen64
+	}
+	buf := make([]byte, 0, size)
+	for _, field := range fields {
+		buf = binary.AppendUvarint(buf, uint64(len(field)))
+		buf = append(buf, field...)
+	}
+	return buf
+}
+
+// sign the delivery, if there is a signer.
+func sign(signer Signer, d *Delivery) error {
+	if signer == nil {
+		return nil
+	}
+	sig, err := signer.Sign(signedPayload(d))
+	if err != nil {
+		return err
+	}
+	d.Signature = sig
+	return nil
+}
+
+// verify the delivery, if there is a signer.
+func verify(signer Signer, d *Delivery) error {
+	if signer == nil {
+		return nil
+	}
+	if len(d.Signature) == 0 {
+		return ErrInvalidSignature
+	}
+	return signer.Verify(signedPayload(d), d.Signature)
+}
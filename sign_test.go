@@ -0,0 +1,64 @@
+package grid
+
+import (
+	"crypto/ed25519"
+	"testing"
+)
+
+func TestHMACSigner(t *testing.T) {
+	old := NewHMACSigner([]byte("old-key"))
+	current := NewHMACSigner([]byte("new-key"), []byte("old-key"))
+
+	d := &Delivery{Receiver: "testing.mailbox.a", TypeName: "msg", Data: []byte("data")}
+	if err := sign(old, d); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(current, d); err != nil {
+		t.Fatalf("expected previous key to be accepted, got: %v", err)
+	}
+
+	// The codec is signed, so data cannot be
+	// decoded by another codec than it was
+	// encoded with.
+	d.Codec = "json"
+	if err := verify(current, d); err != ErrInvalidSignature {
+		t.Fatalf("expected invalid signature, got: %v", err)
+	}
+	d.Codec = ""
+
+	// Moving bytes between fields changes the signature.
+	d.Receiver = "testing.mailbox.amsg"
+	d.TypeName = ""
+	if err := verify(current, d); err != ErrInvalidSignature {
+		t.Fatalf("expected invalid signature, got: %v", err)
+	}
+
+	unsigned := &Delivery{Receiver: "testing.mailbox.a"}
+	if err := verify(current, unsigned); err != ErrInvalidSignature {
+		t.Fatalf("expected invalid signature, got: %v", err)
+	}
+	if err := verify(nil, unsigned); err != nil {
+		t.Fatalf("expected no verification without signer, got: %v", err)
+	}
+}
+
+func TestEd25519Signer(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := NewEd25519Signer(priv)
+	receiver := NewEd25519Signer(nil, pub)
+
+	d := &Delivery{Receiver: "testing.mailbox.a", TypeName: "msg", Data: []byte("data")}
+	if err := sign(sender, d); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(receiver, d); err != nil {
+		t.Fatal(err)
+	}
+	d.Data = []byte("forged")
+	if err := verify(receiver, d); err != ErrInvalidSignature {
+		t.Fatalf("expected invalid signature, got: %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package grid
+
+import (
+	"context"
+	"sync"
+)
+
+// Sink pipelines messages to one receiver. Up to window messages
+// are in flight at once, rather than waiting one round-trip per
+// message, and acknowledgments are tracked cumulatively. Messages
+// are put into the receiver's mailbox in the order they were sent.
+//
+// Small messages are coalesced into fewer frames when the
+// client is configured with a CoalesceDelay.
+//
+// Once any message fails, for example because the receiver was
+// busy, the sink is failed. Messages sent after the failed one
+// may or may not have been received, so the caller should resume
+// from Acked, which counts the messages that are known to have
+// been received, in order, without any gap.
+//
+// Example usage:
+//
+//     sink, err := client.NewSink("counter", 100)
+//     ...
+//     for _, msg := range msgs {
+//         err := sink.Send(ctx, msg)
+//         if err != nil {
+//             // Resume after sink.Acked() messages.
+//         }
+//     }
+//     err = sink.Close(ctx)
+//
+type Sink struct {
+	c          *Client
+	nsReceiver string
+	client     *clientAndConn
+	window     chan struct{}
+	sendMu     sync.Mutex
+	mu         sync.Mutex
+	sent       uint64
+	acked      uint64
+	done       map[uint64]bool
+	err        error
+	closed     bool
+}
+
+// NewSink to the named receiver, with at most window messages
+// in flight at once.
+func (c *Client) NewSink(receiver string, window int) (*Sink, error) {
+	nsReceiver, err := namespaceName(Mailboxes, c.cfg.Namespace, receiver)
+	if err != nil {
+		return nil, err
+	}
+	if window < 1 {
+		window = 1
+	}
+	return &Sink{
+		c:          c,
+		nsReceiver: nsReceiver,
+		window:     make(chan struct{}, window),
+		done:       make(map[uint64]bool),
+	}, nil
+}
+
+// Send the message without waiting for the receiver to respond,
+// unless the window is full, in which case Send blocks until a
+// slot is free or the context finishes. The context must stay
+// alive until the message is acknowledged. An error is returned
+// if this, or any earlier message, failed.
+func (s *Sink) Send(ctx context.Context, msg interface{}) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	if err := s.failure(); err != nil {
+		return err
+	}
+	if s.isClosed() {
+		return ErrSinkClosed
+	}
+
+	select {
+	case s.window <- struct{}{}:
+	case <-ctx.Done():
+		return ErrContextFinished
+	}
+
+	raw, err := encode(s.c.cfg.Codec, msg)
+	if err != nil {
+		<-s.window
+		return err
+	}
+	req := &Delivery{
+		Ver:      protocolVersion,
+		Data:     raw.Data,
+		TypeName: raw.TypeName,
+		Codec:    raw.Codec,
+		Receiver: s.nsReceiver,
+	}
+	s.c.stamp(ctx, req)
+	err = sign(s.c.cfg.Signer, req)
+	if err != nil {
+		<-s.window
+		return err
+	}
+
+	s.mu.Lock()
+	s.sent++
+	seq := s.sent
+	s.mu.Unlock()
+
+	// The connection is looked up once, since the
+	// client rotates through connections to a peer,
+	// and order is only kept on one stream.
+	if s.client == nil {
+		s.client, _, err = s.c.getWireClient(ctx, s.nsReceiver)
+		if err != nil {
+			s.ack(seq, err)
+			return err
+		}
+	}
+	client := s.client
+	ms, err := client.openStream()
+	if err != nil {
+		s.ack(seq, err)
+		return err
+	}
+	if ms == nil {
+		// The receiver's peer does not serve streams,
+		// so there is nothing to pipeline over, each
+		// message waits for its response.
+		_, err := client.client.Process(ctx, req)
+		s.ack(seq, err)
+		return err
+	}
+
+	id, resC, err := ms.sendCoalesced(ctx, req)
+	if err != nil {
+		s.ack(seq, err)
+		return err
+	}
+	go func() {
+		_, err := ms.wait(ctx, id, resC)
+		s.ack(seq, err)
+	}()
+	return nil
+}
+
+// Acked is the number of messages known to have been received,
+// every message up to and including this count has been
+// acknowledged by the receiver.
+func (s *Sink) Acked() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.acked
+}
+
+// Flush blocks until every sent message is acknowledged or
+// failed, or the context finishes.
+func (s *Sink) Flush(ctx context.Context) error {
+	n := 0
+	defer func() {
+		for ; n > 0; n-- {
+			<-s.window
+		}
+	}()
+	for n < cap(s.window) {
+		select {
+		case s.window <- struct{}{}:
+			n++
+		case <-ctx.Done():
+			return ErrContextFinished
+		}
+	}
+	return s.failure()
+}
+
+// Close the sink, flushing any messages in flight.
+func (s *Sink) Close(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	return s.Flush(ctx)
+}
+
+// ack the message with the given sequence number, and free
+// its slot in the window.
+func (s *Sink) ack(seq uint64, err error) {
+	defer func() { <-s.window }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	if err != nil {
+		return
+	}
+	s.done[seq] = true
+	for s.done[s.acked+1] {
+		delete(s.done, s.acked+1)
+		s.acked++
+	}
+}
+
+func (s *Sink) failure() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Sink) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
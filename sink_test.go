@@ -0,0 +1,58 @@
+package grid
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSinkCumulativeAck(t *testing.T) {
+	s := &Sink{
+		window: make(chan struct{}, 3),
+		done:   make(map[uint64]bool),
+	}
+	for i := 0; i < 3; i++ {
+		s.window <- struct{}{}
+	}
+
+	// Out of order acknowledgments only count
+	// once there is no gap.
+	s.ack(2, nil)
+	if s.Acked() != 0 {
+		t.Fatalf("expected 0 acked, got: %v", s.Acked())
+	}
+	s.ack(1, nil)
+	if s.Acked() != 2 {
+		t.Fatalf("expected 2 acked, got: %v", s.Acked())
+	}
+
+	failure := errors.New("failure")
+	s.ack(3, failure)
+	if s.Acked() != 2 {
+		t.Fatalf("expected 2 acked, got: %v", s.Acked())
+	}
+	if err := s.Flush(context.Background()); err != failure {
+		t.Fatalf("expected failure, got: %v", err)
+	}
+	if err := s.Send(context.Background(), &EchoMsg{}); err != failure {
+		t.Fatalf("expected failure, got: %v", err)
+	}
+}
+
+func TestSinkClose(t *testing.T) {
+	s := &Sink{
+		window: make(chan struct{}, 2),
+		done:   make(map[uint64]bool),
+	}
+	s.window <- struct{}{}
+	s.ack(1, nil)
+
+	// Closing after every message was acknowledged
+	// succeeds, but sending afterwards does not.
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("expected close to succeed, got: %v", err)
+	}
+	if err := s.Send(context.Background(), &EchoMsg{}); err != ErrSinkClosed {
+		t.Fatalf("expected sink closed, got: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package grid
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// requestContext of the delivery, bounded by the timeout the sender
+// requested, measured from its receipt on this peer's monotonic clock,
+// so that the clocks of peers need not agree. Deliveries of senders
+// that send no timeout are bounded by their deadline instead, which
+// is only as right as the two peers' clocks.
+func requestContext(parent context.Context, d *Delivery) (context.Context, context.CancelFunc) {
+	switch {
+	case d.Timeout > 0:
+		return context.WithTimeout(parent, time.Duration(d.Timeout))
+	case d.Deadline > 0:
+		return context.WithDeadline(parent, time.Unix(0, d.Deadline))
+	}
+	return context.WithCancel(parent)
+}
+
+// setDeadline of the delivery from the context, both as the time,
+// for peers of older versions, and as the time remaining.
+func setDeadline(ctx context.Context, d *Delivery) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	d.Deadline = deadline.UnixNano()
+	if remaining := time.Until(deadline); remaining > 0 {
+		d.Timeout = int64(remaining)
+	}
+}
+
+// skewTable of the clock skew of peers, estimated from the times
+// their heartbeats are sent, so within the latency of the network.
+type skewTable struct {
+	mu     sync.Mutex
+	skews  map[string]time.Duration
+	warned map[string]bool
+}
+
+func newSkewTable() *skewTable {
+	return &skewTable{
+		skews:  map[string]time.Duration{},
+		warned: map[string]bool{},
+	}
+}
+
+// observe the skew of the peer's clock, which sent a heartbeat at
+// sent that was received at now. It returns the skew, and true if
+// it is newly beyond max, so that it is warned of once, rather than
+// on every heartbeat.
+func (st *skewTable) observe(peer string, sent, now time.Time, max time.Duration) (time.Duration, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	skew := sent.Sub(now)
+	st.skews[peer] = skew
+	if max <= 0 || absDuration(skew) <= max {
+		delete(st.warned, peer)
+		return skew, false
+	}
+	if st.warned[peer] {
+		return skew, false
+	}
+	st.warned[peer] = true
+	return skew, true
+}
+
+// track the registered peers, forgetting any others.
+func (st *skewTable) track(registered []string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	keep := make(map[string]bool, len(registered))
+	for _, peer := range registered {
+		keep[peer] = true
+	}
+	for peer := range st.skews {
+		if !keep[peer] {
+			delete(st.skews, peer)
+			delete(st.warned, peer)
+		}
+	}
+}
+
+// ClockSkews of the peers heard from by gossip, by name, positive for
+// peers whose clocks are ahead of this peer's. Skews are estimated
+// from heartbeats, so they are only known with gossip enabled, and
+// only to within the latency of the network. Skews beyond the
+// MaxClockSkew are logged as they happen.
+func (s *Server) ClockSkews() map[string]time.Duration {
+	s.skews.mu.Lock()
+	defer s.skews.mu.Unlock()
+	skews := make(map[string]time.Duration, len(s.skews.skews))
+	for peer, skew := range s.skews.skews {
+		skews[peer] = skew
+	}
+	return skews
+}
+
+// checkSkew of the peer that sent the heartbeat, warning
+// if it is beyond the MaxClockSkew.
+func (s *Server) checkSkew(msg *Heartbeat, now time.Time) {
+	if msg.Sent == 0 || msg.Peer == "" {
+		return
+	}
+	skew, warn := s.skews.observe(msg.Peer, time.Unix(0, msg.Sent), now, s.cfg.MaxClockSkew)
+	if warn {
+		s.logf("%v: clock of peer: %v is skewed by: %v, beyond max clock skew: %v, leases and deadlines may misbehave",
+			s.cfg.Namespace, msg.Peer, skew, s.cfg.MaxClockSkew)
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
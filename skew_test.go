@@ -0,0 +1,79 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRequestContext(t *testing.T) {
+	// A sender whose clock is an hour behind sends a deadline
+	// already passed, but the timeout is still honored.
+	d := &Delivery{
+		Deadline: time.Now().Add(-time.Hour + time.Minute).UnixNano(),
+		Timeout:  int64(time.Minute),
+	}
+	c, cancel := requestContext(context.Background(), d)
+	defer cancel()
+	deadline, ok := c.Deadline()
+	if !ok || time.Until(deadline) < 59*time.Second {
+		t.Fatalf("expected deadline about a minute away, got: %v", deadline)
+	}
+
+	// Senders of older versions only send the deadline.
+	d = &Delivery{Deadline: time.Now().Add(time.Minute).UnixNano()}
+	c, cancel = requestContext(context.Background(), d)
+	defer cancel()
+	if deadline, ok := c.Deadline(); !ok || deadline.UnixNano() != d.Deadline {
+		t.Fatalf("expected deadline of the delivery, got: %v", deadline)
+	}
+
+	c, cancel = requestContext(context.Background(), &Delivery{})
+	defer cancel()
+	if _, ok := c.Deadline(); ok {
+		t.Fatal("expected no deadline")
+	}
+}
+
+func TestSetDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	d := &Delivery{}
+	setDeadline(ctx, d)
+	if d.Deadline == 0 || d.Timeout <= 0 || time.Duration(d.Timeout) > time.Minute {
+		t.Fatalf("expected deadline and timeout, got: %v, %v", d.Deadline, d.Timeout)
+	}
+
+	d = &Delivery{}
+	setDeadline(context.Background(), d)
+	if d.Deadline != 0 || d.Timeout != 0 {
+		t.Fatalf("expected no deadline, got: %v, %v", d.Deadline, d.Timeout)
+	}
+}
+
+func TestSkewTable(t *testing.T) {
+	st := newSkewTable()
+	now := time.Now()
+	max := time.Second
+
+	skew, warn := st.observe("peer-a", now.Add(2*time.Second), now, max)
+	if skew != 2*time.Second || !warn {
+		t.Fatalf("expected warning of skew, got: %v, %v", skew, warn)
+	}
+	// Warned of once while it stays skewed.
+	if _, warn := st.observe("peer-a", now.Add(-3*time.Second), now, max); warn {
+		t.Fatal("expected no second warning")
+	}
+	if _, warn := st.observe("peer-a", now, now, max); warn {
+		t.Fatal("expected no warning without skew")
+	}
+	if _, warn := st.observe("peer-a", now.Add(-2*time.Second), now, max); !warn {
+		t.Fatal("expected warning of skew after recovering")
+	}
+
+	st.observe("peer-b", now, now, max)
+	st.track([]string{"peer-b"})
+	if _, ok := st.skews["peer-a"]; ok {
+		t.Fatal("expected peer-a forgotten")
+	}
+}
@@ -0,0 +1,165 @@
+package grid
+
+import (
+	"context"
+
+	"github.com/lytics/grid/registry"
+)
+
+// Standby blocks the calling actor, a warm standby of the primary
+// actor, until the primary is no longer registered, and then promotes
+// the calling actor by registering it under the primary's name. The
+// primary is unregistered when it exits, or when the lease of its
+// peer expires after the peer dies, so the failover takes at most
+// the server's LeaseDuration. The standby runs on another peer, see
+// Client.StartStandby, and keeps warm the state it needs from
+// replication messages that the primary sends to the standby's
+// mailbox. A standby on the primary's peer would die with it, so
+// Standby returns ErrStandbyOnPrimaryPeer when the primary is found
+// registered by the standby's own peer. Once promoted, the
+// standby creates the primary's mailbox, through which the primary's
+// senders reach it. The primary's name is unregistered when the
+// promoted actor exits.
+//
+// If another standby, or a cold start of the primary, registers the
+// primary first, the standby keeps waiting. The actor's own name,
+// see ContextActorName, does not change when it is promoted.
+//
+// Example usage:
+//
+//     func (a *StandbyActor) Act(ctx context.Context) {
+//         replicas, err := grid.NewMailbox(server, "counter-standby", 100)
+//         ...
+//         promoted := make(chan error, 1)
+//         go func() { promoted <- grid.Standby(ctx, "counter") }()
+//         for {
+//             select {
+//             case req := <-replicas.C:
+//                 a.apply(req.Msg())
+//                 req.Ack()
+//             case err := <-promoted:
+//                 ...
+//                 replicas.Close()
+//                 a.serve(ctx, "counter")
+//                 return
+//             }
+//         }
+//     }
+//
+func Standby(ctx context.Context, primary string) error {
+	v := ctx.Value(contextKey)
+	if v == nil {
+		return ErrInvalidContext
+	}
+	cv, ok := v.(*contextVal)
+	if !ok || cv.actorID == "" {
+		return ErrInvalidContext
+	}
+	if !isNameValid(primary) {
+		return ErrInvalidActorName
+	}
+	s := cv.server
+	nsName, err := namespaceName(Actors, s.cfg.Namespace, primary)
+	if err != nil {
+		return err
+	}
+	for {
+		err := s.waitUnregistered(ctx, nsName)
+		if err != nil {
+			return err
+		}
+		timeout, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+		err = s.registry.Register(timeout, nsName)
+		cancel()
+		if err == registry.ErrAlreadyRegistered {
+			// Another standby, or the primary
+			// itself, won the registration.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		cv.promoted = append(cv.promoted, nsName)
+		s.mu.Unlock()
+		s.logf("%v: actor: %v, promoted to: %v", s.cfg.Namespace, cv.actorName, primary)
+		return nil
+	}
+}
+
+// StartStandby of the primary actor on a peer other than the
+// primary's, chosen by the placement from the other peers, and
+// returns the peer. The primary must be registered, so that its
+// peer is known.
+//
+// Example usage:
+//
+//     start := grid.NewActorStart("counter-standby")
+//     start.Type = "counter-standby"
+//     peer, err := client.StartStandby(ctx, start, "counter", grid.LeastActors())
+//
+func (c *Client) StartStandby(ctx context.Context, start *ActorStart, primary string, placement Placement) (string, error) {
+	primaryPeer, err := c.actorPeer(ctx, primary)
+	if err != nil {
+		return "", err
+	}
+	peers, err := c.peerLoads(ctx)
+	if err != nil {
+		return "", err
+	}
+	peers = otherPeers(peers, primaryPeer)
+	if len(peers) == 0 {
+		return "", ErrNoPeers
+	}
+	return c.startOnPeer(ctx, start, placement(start, peers))
+}
+
+// otherPeers than the given one.
+func otherPeers(peers []PeerLoad, peer string) []PeerLoad {
+	others := make([]PeerLoad, 0, len(peers))
+	for _, p := range peers {
+		if p.Peer != peer {
+			others = append(others, p)
+		}
+	}
+	return others
+}
+
+// waitUnregistered until the key is not registered, by another peer.
+func (s *Server) waitUnregistered(ctx context.Context, key string) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	regs, events, err := s.registry.Watch(watchCtx, key)
+	if err != nil {
+		return err
+	}
+	// The watch is of the key as a prefix,
+	// so the keys of others may be found.
+	registered := false
+	for _, reg := range regs {
+		if reg.Key != key {
+			continue
+		}
+		if reg.Registry == s.registry.Registry() {
+			return ErrStandbyOnPrimaryPeer
+		}
+		registered = true
+	}
+	for registered {
+		select {
+		case <-ctx.Done():
+			return ErrContextFinished
+		case e, open := <-events:
+			if !open {
+				return ErrContextFinished
+			}
+			if e.Error != nil {
+				return e.Error
+			}
+			if e.Key == key && e.Type == registry.Delete {
+				registered = false
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStandbyInvalid(t *testing.T) {
+	err := Standby(context.Background(), "primary")
+	if err != ErrInvalidContext {
+		t.Fatalf("expected invalid context, got: %v", err)
+	}
+
+	// The server's own context is not of an actor.
+	s := &Server{cfg: ServerCfg{Namespace: "testing"}}
+	ctx := context.WithValue(context.Background(), contextKey, &contextVal{server: s})
+	if err := Standby(ctx, "primary"); err != ErrInvalidContext {
+		t.Fatalf("expected invalid context, got: %v", err)
+	}
+
+	ctx = context.WithValue(context.Background(), contextKey, &contextVal{
+		server:    s,
+		actorID:   "testing.actor.standby",
+		actorName: "standby",
+	})
+	if err := Standby(ctx, "primary.x"); err != ErrInvalidActorName {
+		t.Fatalf("expected invalid actor name, got: %v", err)
+	}
+}
+
+func TestOtherPeers(t *testing.T) {
+	peers := []PeerLoad{
+		{Peer: "peer-a", Actors: 1},
+		{Peer: "peer-b", Actors: 3},
+		{Peer: "peer-c", Actors: 2},
+	}
+
+	// The standby is never placed on its primary's
+	// peer, even by a placement that would choose it.
+	others := otherPeers(peers, "peer-a")
+	if got := LeastActors()(NewActorStart("standby"), others); got != "peer-c" {
+		t.Fatalf("expected standby on peer-c, got: %v", got)
+	}
+	for _, p := range others {
+		if p.Peer == "peer-a" {
+			t.Fatal("expected primary's peer excluded")
+		}
+	}
+	if len(otherPeers(peers[:1], "peer-a")) != 0 {
+		t.Fatal("expected no other peers")
+	}
+}
+
+// standbyActor waits to be promoted, reporting the result.
+type standbyActor struct {
+	primary  string
+	promoted chan error
+}
+
+func (a *standbyActor) Act(c context.Context) {
+	a.promoted <- Standby(c, a.primary)
+}
+
+func TestStandbyOnPrimaryPeer(t *testing.T) {
+	etcd, server, client := bootstrapClientTest(t)
+	defer etcd.Close()
+	defer server.Stop()
+	defer client.Close()
+
+	primaryActor := &startStopActor{started: make(chan bool, 1), stopped: make(chan bool, 1)}
+	server.RegisterDef("primary", func([]byte) (Actor, error) { return primaryActor, nil })
+	standby := &standbyActor{primary: "counter", promoted: make(chan error, 1)}
+	server.RegisterDef("standby", func([]byte) (Actor, error) { return standby, nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	primary := NewActorStart("counter")
+	primary.Type = "primary"
+	if err := server.startActorC(ctx, primary); err != nil {
+		t.Fatal(err)
+	}
+	<-primaryActor.started
+
+	// The only peer is the primary's, so
+	// there is none for its standby.
+	start := NewActorStart("counter-standby")
+	start.Type = "standby"
+	if _, err := client.StartStandby(ctx, start, "counter", LeastActors()); err != ErrNoPeers {
+		t.Fatalf("expected no peers, got: %v", err)
+	}
+
+	// A standby started on the primary's
+	// peer anyway is refused.
+	if err := server.startActorC(ctx, start); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-standby.promoted; err != ErrStandbyOnPrimaryPeer {
+		t.Fatalf("expected standby on primary's peer, got: %v", err)
+	}
+}
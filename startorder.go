@@ -0,0 +1,61 @@
+package grid
+
+import "context"
+
+// StartAfter declares that actors of the type are started only once
+// at least one actor of each of the dependency types is running in
+// the namespace, as reported by the peers. Starting the actor fails
+// with ErrDependencyNotRunning until then, and durable actors of the
+// type are left for a later round of reconciliation, instead of user
+// code sleeping between starts. Every peer should declare the same
+// dependencies, and they must not form a cycle.
+//
+// Example usage:
+//
+//     server.RegisterDef("store", makeStore)
+//     server.RegisterDef("indexer", makeIndexer)
+//     server.StartAfter("indexer", "store")
+//
+func (s *Server) StartAfter(actorType string, dependencies ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ordering[actorType] = append(s.ordering[actorType], dependencies...)
+}
+
+// dependencies of the actor type.
+func (s *Server) dependencies(actorType string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ordering[actorType]
+}
+
+// checkDependencies of the actor, asking the peers for
+// the types of their running actors.
+func (s *Server) checkDependencies(c context.Context, start *ActorStart) error {
+	deps := s.dependencies(start.Type)
+	if len(deps) == 0 {
+		return nil
+	}
+	if s.client == nil {
+		return ErrDependencyNotRunning
+	}
+	st, err := s.client.Stats(c)
+	if err != nil {
+		return err
+	}
+	if unmetDependency(deps, st.ActorTypes) != "" {
+		return ErrDependencyNotRunning
+	}
+	return nil
+}
+
+// unmetDependency of those given, that has no running actors,
+// or the empty string if all of them are met.
+func unmetDependency(deps []string, running map[string]int) string {
+	for _, dep := range deps {
+		if running[dep] == 0 {
+			return dep
+		}
+	}
+	return ""
+}
@@ -0,0 +1,33 @@
+package grid
+
+import "testing"
+
+func TestUnmetDependency(t *testing.T) {
+	running := map[string]int{"store": 2}
+	if dep := unmetDependency([]string{"store"}, running); dep != "" {
+		t.Fatalf("expected no unmet dependency, got: %v", dep)
+	}
+	if dep := unmetDependency([]string{"store", "cache"}, running); dep != "cache" {
+		t.Fatalf("expected cache, got: %v", dep)
+	}
+	if dep := unmetDependency(nil, nil); dep != "" {
+		t.Fatalf("expected no unmet dependency, got: %v", dep)
+	}
+}
+
+func TestStartAfter(t *testing.T) {
+	s := &Server{ordering: map[string][]string{}}
+	s.StartAfter("indexer", "store")
+	s.StartAfter("indexer", "cache")
+	deps := s.dependencies("indexer")
+	if len(deps) != 2 || deps[0] != "store" || deps[1] != "cache" {
+		t.Fatalf("expected store and cache, got: %v", deps)
+	}
+	err := s.checkDependencies(nil, &ActorStart{Type: "indexer"})
+	if err != ErrDependencyNotRunning {
+		t.Fatalf("expected dependency not running, got: %v", err)
+	}
+	if err := s.checkDependencies(nil, &ActorStart{Type: "store"}); err != nil {
+		t.Fatal(err)
+	}
+}
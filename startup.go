@@ -0,0 +1,55 @@
+package grid
+
+import "fmt"
+
+// StartupPhase of Serve, in which a startup error happened.
+type StartupPhase string
+
+const (
+	// PhaseRegistry of starting the registry, and registering
+	// the peer's address in etcd.
+	PhaseRegistry StartupPhase = "registry"
+	// PhaseClient of creating the client through which the
+	// server sends requests to other peers.
+	PhaseClient StartupPhase = "client"
+	// PhasePeer of registering the peer in the namespace.
+	PhasePeer StartupPhase = "peer"
+	// PhaseMailbox of creating the peer's own mailbox.
+	PhaseMailbox StartupPhase = "mailbox"
+	// PhaseLeader of starting the leader actor.
+	PhaseLeader StartupPhase = "leader"
+)
+
+// StartupError returned by Serve, with the phase of startup
+// that failed, so that callers can tell a registry they cannot
+// reach from a leader that failed to start.
+//
+// Example usage:
+//
+//     err := server.Serve(lis)
+//     var startup *grid.StartupError
+//     if errors.As(err, &startup) && startup.Phase == grid.PhaseLeader {
+//         ...
+//     }
+//
+type StartupError struct {
+	Phase StartupPhase
+	Err   error
+}
+
+func (e *StartupError) Error() string {
+	return fmt.Sprintf("grid: startup failed in phase: %v, error: %v", e.Phase, e.Err)
+}
+
+// Unwrap the error of the phase.
+func (e *StartupError) Unwrap() error {
+	return e.Err
+}
+
+// startupError of the phase, nil if the error is nil.
+func startupError(phase StartupPhase, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &StartupError{Phase: phase, Err: err}
+}
@@ -0,0 +1,20 @@
+package grid
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStartupError(t *testing.T) {
+	if err := startupError(PhaseMailbox, nil); err != nil {
+		t.Fatalf("expected nil, got: %v", err)
+	}
+	err := startupError(PhaseMailbox, ErrInvalidMailboxName)
+	var startup *StartupError
+	if !errors.As(err, &startup) || startup.Phase != PhaseMailbox {
+		t.Fatalf("expected startup error of mailbox phase, got: %v", err)
+	}
+	if !errors.Is(err, ErrInvalidMailboxName) {
+		t.Fatalf("expected wrapped error, got: %v", err)
+	}
+}
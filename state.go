@@ -0,0 +1,108 @@
+package grid
+
+import (
+	"context"
+	"strings"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+)
+
+// states is the key space of actor state.
+const states EntityType = "state"
+
+// ActorState returns the state keys and values of the named actor.
+// An actor's initial state can be given in its ActorStart, in which
+// case it is written in the same etcd transaction that registers
+// the actor, so an actor is never registered without its state.
+//
+// Example usage:
+//
+//     start := grid.NewActorStart("worker-%d", i)
+//     start.Type = "worker"
+//     start.State = map[string][]byte{"offset": []byte("0")}
+//
+//     ... and inside the actor ...
+//
+//     state, err := client.ActorState(ctx, name)
+//     offset := state["offset"]
+//
+func (c *Client) ActorState(ctx context.Context, actor string) (map[string][]byte, error) {
+	prefix, err := statePrefix(c.cfg.Namespace, actor)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.etcd.Get(ctx, prefix, etcdv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	state := make(map[string][]byte, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		value, err := open(ctx, c.cfg.KMS, kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		state[strings.TrimPrefix(string(kv.Key), prefix)] = value
+	}
+	return state, nil
+}
+
+// PutActorState writes one key of the named actor's state.
+func (c *Client) PutActorState(ctx context.Context, actor, key string, value []byte) error {
+	k, err := stateKey(c.cfg.Namespace, actor, key)
+	if err != nil {
+		return err
+	}
+	value, err = seal(ctx, c.cfg.KMS, value)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Put(ctx, k, string(value))
+	return err
+}
+
+// DeleteActorState removes all state of the named actor.
+func (c *Client) DeleteActorState(ctx context.Context, actor string) error {
+	prefix, err := statePrefix(c.cfg.Namespace, actor)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Delete(ctx, prefix, etcdv3.WithPrefix())
+	return err
+}
+
+// stateInit converts the state of an actor start into the
+// etcd keys and values to write when registering the actor.
+func stateInit(ctx context.Context, kms KMS, namespace string, start *ActorStart) (map[string]string, error) {
+	init := make(map[string]string, len(start.State))
+	for key, value := range start.State {
+		k, err := stateKey(namespace, start.Name, key)
+		if err != nil {
+			return nil, err
+		}
+		value, err = seal(ctx, kms, value)
+		if err != nil {
+			return nil, err
+		}
+		init[k] = string(value)
+	}
+	return init, nil
+}
+
+func stateKey(namespace, actor, key string) (string, error) {
+	prefix, err := statePrefix(namespace, actor)
+	if err != nil {
+		return "", err
+	}
+	if !isNameValid(key) {
+		return "", ErrInvalidStateKey
+	}
+	return prefix + key, nil
+}
+
+func statePrefix(namespace, actor string) (string, error) {
+	nsName, err := namespaceName(states, namespace, actor)
+	if err != nil {
+		return "", err
+	}
+	return nsName + ".", nil
+}
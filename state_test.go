@@ -0,0 +1,29 @@
+package grid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStateInit(t *testing.T) {
+	start := NewActorStart("worker-%d", 1)
+	start.State = map[string][]byte{"offset": []byte("42")}
+
+	init, err := stateInit(context.Background(), nil, "testing", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := init["testing.state.worker-1.offset"]; v != "42" {
+		t.Fatalf("expected initial offset, got: %v", init)
+	}
+}
+
+func TestStateInitInvalidKey(t *testing.T) {
+	start := NewActorStart("worker-%d", 1)
+	start.State = map[string][]byte{"bad.key": nil}
+
+	_, err := stateInit(context.Background(), nil, "testing", start)
+	if err != ErrInvalidStateKey {
+		t.Fatal("expected invalid state key error")
+	}
+}
@@ -0,0 +1,134 @@
+package grid
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+)
+
+// NamespaceStats of the usage of a namespace, for capacity
+// dashboards and for charging tenants of a shared grid.
+type NamespaceStats struct {
+	// At is the time the stats were collected.
+	At time.Time
+	// Peers registered in the namespace.
+	Peers int
+	// Actors registered in the namespace.
+	Actors int
+	// ActorTypes is the number of running actors by type,
+	// as reported by the peers that answered.
+	ActorTypes map[string]int
+	// Mailboxes registered in the namespace.
+	Mailboxes int
+	// Delivered is the number of messages delivered to the
+	// mailboxes of the peers that answered, since each of
+	// them started.
+	Delivered int64
+	// Keys used in etcd by the namespace.
+	Keys int64
+	// Unreachable peers, which did not answer, and whose
+	// actors and messages are not counted.
+	Unreachable []string
+}
+
+// MessageRate in messages per second, between the previous stats
+// and these. Peers that restarted in between, or that answered only
+// one of the two, make the rate inaccurate, it is zero if negative.
+//
+// Example usage:
+//
+//     prev, err := client.Stats(ctx)
+//     ...
+//     time.Sleep(time.Minute)
+//     stats, err := client.Stats(ctx)
+//     ...
+//     rate := stats.MessageRate(prev)
+//
+func (st *NamespaceStats) MessageRate(prev *NamespaceStats) float64 {
+	elapsed := st.At.Sub(prev.At).Seconds()
+	if elapsed <= 0 || st.Delivered < prev.Delivered {
+		return 0
+	}
+	return float64(st.Delivered-prev.Delivered) / elapsed
+}
+
+// Stats of the usage of this client's namespace. The registered
+// peers, actors and mailboxes, and the keys used in etcd, are
+// counted from etcd, while each peer is asked for the types of
+// its actors and the number of messages delivered to it.
+func (c *Client) Stats(ctx context.Context) (*NamespaceStats, error) {
+	peers, err := c.QueryC(ctx, Peers)
+	if err != nil {
+		return nil, err
+	}
+	actors, err := c.QueryC(ctx, Actors)
+	if err != nil {
+		return nil, err
+	}
+	mailboxes, err := c.QueryC(ctx, Mailboxes)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.etcd.Get(ctx, c.cfg.Namespace+".", etcdv3.WithPrefix(), etcdv3.WithCountOnly())
+	if err != nil {
+		return nil, err
+	}
+
+	st := &NamespaceStats{
+		Peers:      len(peers),
+		Actors:     len(actors),
+		ActorTypes: map[string]int{},
+		Mailboxes:  len(mailboxes),
+		Keys:       res.Count,
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+			ps, err := RequestT[*PeerStats](ctx, c, peer, &PeerStatsQuery{})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				st.Unreachable = append(st.Unreachable, peer)
+				return
+			}
+			st.add(ps)
+		}(peer.Name())
+	}
+	wg.Wait()
+	sort.Strings(st.Unreachable)
+	st.At = time.Now()
+	return st, nil
+}
+
+// add the stats of one peer.
+func (st *NamespaceStats) add(ps *PeerStats) {
+	for actorType, n := range ps.Actors {
+		st.ActorTypes[actorType] += int(n)
+	}
+	st.Delivered += ps.Delivered
+}
+
+// peerStats of the server, answering a stats query.
+func (s *Server) peerStats(req Request) {
+	ps := &PeerStats{
+		Peer:      s.registry.Registry(),
+		Actors:    map[string]int64{},
+		Delivered: atomic.LoadInt64(&s.delivered),
+	}
+	s.mu.Lock()
+	for _, u := range s.usage {
+		ps.Actors[u.actor]++
+	}
+	s.mu.Unlock()
+	err := req.Respond(ps)
+	if err != nil {
+		s.logf("%v: failed sending response for stats: %v", s.cfg.Namespace, err)
+	}
+}
@@ -0,0 +1,47 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNamespaceStats(t *testing.T) {
+	st := &NamespaceStats{ActorTypes: map[string]int{}}
+	st.add(&PeerStats{Actors: map[string]int64{"worker": 2, "leader": 1}, Delivered: 100})
+	st.add(&PeerStats{Actors: map[string]int64{"worker": 3}, Delivered: 50})
+	if st.ActorTypes["worker"] != 5 || st.ActorTypes["leader"] != 1 {
+		t.Fatalf("unexpected actor types: %v", st.ActorTypes)
+	}
+	if st.Delivered != 150 {
+		t.Fatalf("expected 150 delivered, got: %v", st.Delivered)
+	}
+
+	now := time.Now()
+	prev := &NamespaceStats{At: now, Delivered: 100}
+	st.At = now.Add(10 * time.Second)
+	if rate := st.MessageRate(prev); rate != 5 {
+		t.Fatalf("expected rate of 5, got: %v", rate)
+	}
+
+	// A peer restarted, so fewer messages are counted.
+	prev.Delivered = 200
+	if rate := st.MessageRate(prev); rate != 0 {
+		t.Fatalf("expected rate of 0, got: %v", rate)
+	}
+}
+
+func TestMailboxCountsDelivered(t *testing.T) {
+	s := &Server{}
+	boxC := make(chan Request, 1)
+	box := &Mailbox{C: boxC, c: boxC, server: s}
+	if err := box.put(newRequest(context.Background(), &EchoMsg{}, &Provenance{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := box.put(newRequest(context.Background(), &EchoMsg{}, &Provenance{})); err != ErrReceiverBusy {
+		t.Fatalf("expected receiver busy, got: %v", err)
+	}
+	if s.delivered != 1 {
+		t.Fatalf("expected 1 delivered, got: %v", s.delivered)
+	}
+}
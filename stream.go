@@ -0,0 +1,88 @@
+package grid
+
+import "context"
+
+// StreamSender is the sending half of a stream, see Client.Stream.
+type StreamSender struct {
+	conn *Conn
+}
+
+// Send the message on the stream. Messages are received in
+// the order they are sent.
+func (s *StreamSender) Send(msg interface{}) error {
+	return s.conn.Send(msg)
+}
+
+// Close the sending half of the stream, the receiver then gets
+// ErrConnClosed once it has received the messages already sent.
+// The receiving half stays open, until the receiver closes its
+// side of the stream.
+func (s *StreamSender) Close() error {
+	return s.conn.closeSend()
+}
+
+// StreamReceiver is the receiving half of a stream, see Client.Stream.
+type StreamReceiver struct {
+	conn *Conn
+}
+
+// Recv the next message of the stream, blocking until one is
+// available, the context finishes, or the stream ends, when
+// ErrConnClosed is returned.
+func (r *StreamReceiver) Recv(ctx context.Context) (interface{}, error) {
+	return r.conn.Recv(ctx)
+}
+
+// Close the stream, both halves of it, waiting for the receiver
+// to close its side too, or for the context to finish.
+func (r *StreamReceiver) Close(ctx context.Context) error {
+	return r.conn.Close(ctx)
+}
+
+// Stream to the receiver's mailbox, from the actor's context.
+// See Client.Stream.
+func Stream(ctx context.Context, receiver string) (*StreamSender, *StreamReceiver, error) {
+	client, err := ContextClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client.Stream(ctx, receiver)
+}
+
+// Stream of messages to and from the receiver's mailbox, as a pair
+// of its sending and receiving halves, so that one goroutine can
+// send while another receives. Messages are sent in order, on the
+// peer's existing gRPC transport, without the lookup and overhead
+// of a request per message, and encoded with the client's codec.
+// The receiver gets the stream as a connection, see Client.Connect,
+// which it accepts by acking. The stream lives as long as the
+// context, or until the receiving half is closed.
+//
+// Example usage:
+//
+//     send, recv, err := client.Stream(ctx, "aggregator")
+//     ...
+//     defer recv.Close(ctx)
+//
+//     go func() {
+//         for _, msg := range msgs {
+//             send.Send(msg)
+//         }
+//         send.Close()
+//     }()
+//     for {
+//         msg, err := recv.Recv(ctx)
+//         if err == grid.ErrConnClosed {
+//             break
+//         }
+//         ...
+//     }
+//
+func (c *Client) Stream(ctx context.Context, receiver string) (*StreamSender, *StreamReceiver, error) {
+	conn, err := c.Connect(ctx, receiver)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn.codec = c.cfg.Codec
+	return &StreamSender{conn: conn}, &StreamReceiver{conn: conn}, nil
+}
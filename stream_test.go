@@ -0,0 +1,68 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lytics/grid/codec"
+)
+
+func TestStream(t *testing.T) {
+	Register(EchoMsg{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	a, b := newPipe()
+	conn := newConn(a, a.CloseSend, func() {})
+	conn.codec = codec.JSON
+	send, recv := &StreamSender{conn: conn}, &StreamReceiver{conn: conn}
+	receiver := newConn(b, b.CloseSend, func() {})
+
+	for _, msg := range []string{"a", "b"} {
+		if err := send.Send(&EchoMsg{Msg: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := send.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := send.Send(&EchoMsg{Msg: "c"}); err != ErrConnClosed {
+		t.Fatalf("expected error: %v, got: %v", ErrConnClosed, err)
+	}
+	for _, expected := range []string{"a", "b"} {
+		msg, err := receiver.Recv(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if echo, ok := msg.(*EchoMsg); !ok || echo.Msg != expected {
+			t.Fatalf("expected message: %v, got: %v", expected, msg)
+		}
+	}
+	if _, err := receiver.Recv(ctx); err != ErrConnClosed {
+		t.Fatalf("expected error: %v, got: %v", ErrConnClosed, err)
+	}
+
+	// The receiving half stays open after
+	// the sending half is closed.
+	if err := receiver.Send(&EchoMsg{Msg: "done"}); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := recv.Recv(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if echo, ok := msg.(*EchoMsg); !ok || echo.Msg != "done" {
+		t.Fatalf("expected reply, got: %v", msg)
+	}
+	if err := receiver.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recv.Recv(ctx); err != ErrConnClosed {
+		t.Fatalf("expected error: %v, got: %v", ErrConnClosed, err)
+	}
+	if err := recv.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,273 @@
+package grid
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// controlStopActors stops the named actors of a peer.
+const controlStopActors = "grid-stop-actors"
+
+// Strategy of a supervisor for restarting its children.
+type Strategy int
+
+const (
+	// OneForOne restarts only the child that exited.
+	OneForOne Strategy = iota
+	// OneForAll stops the other children when one exits,
+	// and restarts all of them.
+	OneForAll
+)
+
+// Supervisor of child actors, which it starts, and restarts when
+// they exit, panic, or are lost with their peer, so that actors
+// such as the leader do not need loops of their own to reschedule
+// their workers. Children are started on peers chosen by the
+// Placement, other than lame ducks and suspects. The supervisor
+// is configured by its fields before Supervise is called.
+//
+// Example usage:
+//
+//     func (a *LeaderActor) Act(ctx context.Context) {
+//         sup := grid.NewSupervisor(a.client, grid.OneForOne)
+//         sup.MaxRestarts, sup.Period = 5, time.Minute
+//         for i := 0; i < 10; i++ {
+//             start := grid.NewActorStart("worker-%d", i)
+//             start.Type = "worker"
+//             sup.Add(start)
+//         }
+//         err := sup.Supervise(ctx)
+//         ...
+//     }
+//
+type Supervisor struct {
+	// Strategy of restarts, default is OneForOne.
+	Strategy Strategy
+	// MaxRestarts of children within the Period, after which
+	// Supervise gives up with ErrTooManyRestarts. Zero is no
+	// limit. The Period is also how long a child must run for
+	// its backoff to start over. Default period is a minute.
+	MaxRestarts int
+	Period      time.Duration
+	// MinBackoff before a child is restarted, doubling with
+	// each of its consecutive restarts, up to MaxBackoff.
+	// Defaults are a second and a minute.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Placement of children on peers, default is RandomPeer.
+	Placement Placement
+
+	client   *Client
+	children []*ActorStart
+}
+
+// NewSupervisor of children started through the client.
+func NewSupervisor(client *Client, strategy Strategy) *Supervisor {
+	return &Supervisor{
+		Strategy:   strategy,
+		Period:     time.Minute,
+		MinBackoff: time.Second,
+		MaxBackoff: time.Minute,
+		Placement:  RandomPeer(),
+		client:     client,
+	}
+}
+
+// Add a child to supervise.
+func (sup *Supervisor) Add(start *ActorStart) {
+	sup.children = append(sup.children, start)
+}
+
+// Supervise the children until the context finishes, or until
+// the children restart more than MaxRestarts within the Period.
+// Children already running, for example started by a previous
+// supervisor, are adopted, and children are left running when
+// the context finishes, so that a supervisor that is itself
+// restarted does not restart them.
+func (sup *Supervisor) Supervise(ctx context.Context) error {
+	// Scheduled restarts, and the watch, end
+	// with the supervision.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	children := make(map[string]*ActorStart, len(sup.children))
+	for _, start := range sup.children {
+		children[start.Name] = start
+	}
+
+	current, events, err := sup.client.QueryWatch(ctx, Actors)
+	if err != nil {
+		return err
+	}
+	st := &supervision{
+		sup:      sup,
+		running:  map[string]time.Time{},
+		stopping: map[string]bool{},
+		backoffs: map[string]int{},
+		due:      make(chan string),
+		window:   restartWindow{max: sup.MaxRestarts, period: sup.Period},
+	}
+	for _, e := range current {
+		if _, ok := children[e.Name()]; ok {
+			st.running[e.Name()] = time.Now()
+		}
+	}
+	for name := range children {
+		if _, ok := st.running[name]; !ok {
+			st.start(ctx, children[name])
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case name := <-st.due:
+			if _, ok := st.running[name]; !ok {
+				st.start(ctx, children[name])
+			}
+		case e, open := <-events:
+			if !open {
+				return ErrWatchClosedUnexpectedly
+			}
+			if e.Type == WatchError {
+				return e.Err()
+			}
+			if _, ok := children[e.Name()]; !ok {
+				continue
+			}
+			switch e.Type {
+			case EntityFound:
+				st.running[e.Name()] = time.Now()
+			case EntityLost:
+				err := st.exited(ctx, e.Name())
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// supervision state of a supervisor's children.
+type supervision struct {
+	sup *Supervisor
+	// running children, by when they were found.
+	running map[string]time.Time
+	// stopping children, stopped by the
+	// supervisor because a sibling exited.
+	stopping map[string]bool
+	// backoffs, ie: consecutive restarts,
+	// of the children.
+	backoffs map[string]int
+	due      chan string
+	window   restartWindow
+}
+
+// exited child, restarted as the strategy says.
+func (st *supervision) exited(ctx context.Context, name string) error {
+	now := time.Now()
+	found := st.running[name]
+	delete(st.running, name)
+	if st.stopping[name] {
+		delete(st.stopping, name)
+		st.schedule(ctx, name)
+		return nil
+	}
+	if now.Sub(found) >= st.sup.Period {
+		st.backoffs[name] = 0
+	}
+	if !st.window.add(now) {
+		return ErrTooManyRestarts
+	}
+	st.sup.client.logf("%v: supervised actor exited: %v", st.sup.client.cfg.Namespace, name)
+	st.schedule(ctx, name)
+	if st.sup.Strategy == OneForAll {
+		for sibling := range st.running {
+			if st.stopping[sibling] {
+				continue
+			}
+			err := st.sup.client.StopActor(ctx, sibling)
+			if err != nil {
+				st.sup.client.logf("%v: failed stopping supervised actor: %v, error: %v", st.sup.client.cfg.Namespace, sibling, err)
+				continue
+			}
+			st.stopping[sibling] = true
+		}
+	}
+	return nil
+}
+
+// schedule a restart of the child after its backoff.
+func (st *supervision) schedule(ctx context.Context, name string) {
+	delay := restartBackoff(st.sup.MinBackoff, st.sup.MaxBackoff, st.backoffs[name])
+	st.backoffs[name]++
+	time.AfterFunc(delay, func() {
+		select {
+		case st.due <- name:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// start the child on a peer, scheduling another attempt if
+// it fails.
+func (st *supervision) start(ctx context.Context, start *ActorStart) {
+	c := st.sup.client
+	_, err := c.StartActorWithPlacement(ctx, start, st.sup.Placement)
+	if err != nil {
+		c.logf("%v: failed starting supervised actor: %v, error: %v", c.cfg.Namespace, start.Name, err)
+		st.schedule(ctx, start.Name)
+	}
+}
+
+// restartBackoff before the restart of a child that has been restarted
+// n times in a row, doubling from least up to most.
+func restartBackoff(least, most time.Duration, n int) time.Duration {
+	d := least
+	for i := 0; i < n && d < most; i++ {
+		d *= 2
+	}
+	if d > most {
+		d = most
+	}
+	return d
+}
+
+// restartWindow of the restarts within the last period.
+type restartWindow struct {
+	max    int
+	period time.Duration
+	times  []time.Time
+}
+
+// add a restart at now, false if it is one too many.
+func (w *restartWindow) add(now time.Time) bool {
+	if w.max <= 0 {
+		return true
+	}
+	kept := w.times[:0]
+	for _, t := range w.times {
+		if now.Sub(t) < w.period {
+			kept = append(kept, t)
+		}
+	}
+	w.times = append(kept, now)
+	return len(w.times) <= w.max
+}
+
+// StopActor by name, on whichever peer it is running. The actor's
+// context is cancelled, with the reason DoneActorStopped.
+func (c *Client) StopActor(ctx context.Context, name string) error {
+	peer, err := c.actorPeer(ctx, name)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal([]string{name})
+	if err != nil {
+		return err
+	}
+	_, err = c.RequestC(ctx, peer, &Control{Command: controlStopActors, Data: data})
+	return err
+}
@@ -0,0 +1,61 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		n        int
+		expected time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{10, time.Minute},
+	} {
+		if d := restartBackoff(time.Second, time.Minute, tc.n); d != tc.expected {
+			t.Fatalf("expected backoff: %v, after %v restarts, got: %v", tc.expected, tc.n, d)
+		}
+	}
+}
+
+func TestRestartWindow(t *testing.T) {
+	now := time.Now()
+	w := restartWindow{max: 2, period: time.Minute}
+	if !w.add(now) || !w.add(now.Add(time.Second)) {
+		t.Fatal("expected restarts within max")
+	}
+	if w.add(now.Add(2 * time.Second)) {
+		t.Fatal("expected too many restarts")
+	}
+	if !w.add(now.Add(2 * time.Minute)) {
+		t.Fatal("expected restarts older than the period to be forgotten")
+	}
+
+	unlimited := restartWindow{}
+	for i := 0; i < 10; i++ {
+		if !unlimited.add(now) {
+			t.Fatal("expected no limit")
+		}
+	}
+}
+
+func TestStopActors(t *testing.T) {
+	s := &Server{contexts: map[string]*contextVal{}}
+	ctx, stop := context.WithCancel(context.Background())
+	cv := &contextVal{server: s, actorName: "worker", stop: stop}
+	s.contexts["worker"] = cv
+	actorCtx := context.WithValue(ctx, contextKey, cv)
+
+	s.stopActors([]string{"worker"}, func(cv *contextVal) { cv.stopped = true })
+	if actorCtx.Err() == nil {
+		t.Fatal("expected the actor to be stopped")
+	}
+	s.ctx = context.Background()
+	if reason, _ := ContextDoneReason(actorCtx); reason != DoneActorStopped {
+		t.Fatalf("expected actor stopped, got: %v", reason)
+	}
+}
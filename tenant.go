@@ -0,0 +1,141 @@
+package grid
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TenantQuota of the requests of one tenant to a server, so that a
+// shared grid serving many customers can isolate noisy tenants.
+type TenantQuota struct {
+	// RateLimit of requests per second of the tenant, above
+	// which its requests are rejected with ErrThrottled. The
+	// default of zero is no limit.
+	RateLimit float64
+	// RateBurst of requests the tenant may send at once above
+	// the RateLimit. Default is 1.
+	RateBurst int
+	// MaxQueued requests of the tenant, queued in or being handled
+	// by the server's mailboxes at once, above which its requests
+	// are rejected with ErrReceiverBusy. The default of zero is no
+	// limit.
+	MaxQueued int
+}
+
+// WithTenant returns a copy of the context carrying the tenant on
+// whose behalf requests sent with the context are made. Receiving
+// servers enforce the quotas of the tenant, see ServerCfg.TenantQuota,
+// and receivers see it in Provenance.Tenant.
+//
+// Example usage:
+//
+//     ctx := grid.WithTenant(ctx, "acme")
+//     res, err := client.RequestC(ctx, "indexer", msg)
+//
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// ContextTenant of the context, either the one set by WithTenant,
+// or else that of the request whose context it is, or else empty.
+func ContextTenant(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantKey).(string); ok {
+		return tenant
+	}
+	if from, ok := ctx.Value(provenanceKey).(*Provenance); ok {
+		return from.Tenant
+	}
+	return ""
+}
+
+// tenantQuotas enforced by a server.
+type tenantQuotas struct {
+	mu     sync.Mutex
+	quota  TenantQuota
+	quotas map[string]TenantQuota
+	// limiter of the rate of tenants with the default
+	// quota, by tenant, and limiters of the tenants
+	// with their own quotas.
+	limiter  *rateLimiter
+	limiters map[string]*rateLimiter
+	queued   map[string]int
+}
+
+// newTenantQuotas of each tenant, and of particular tenants,
+// nil if no tenant has a quota.
+func newTenantQuotas(quota TenantQuota, quotas map[string]TenantQuota) *tenantQuotas {
+	if quota == (TenantQuota{}) && len(quotas) == 0 {
+		return nil
+	}
+	tq := &tenantQuotas{
+		quota:    quota,
+		quotas:   quotas,
+		limiters: map[string]*rateLimiter{},
+		queued:   map[string]int{},
+	}
+	if quota.RateLimit > 0 {
+		tq.limiter = newRateLimiter(quota.RateLimit, quota.RateBurst)
+	}
+	for tenant, q := range quotas {
+		if q.RateLimit > 0 {
+			tq.limiters[tenant] = newRateLimiter(q.RateLimit, q.RateBurst)
+		}
+	}
+	return tq
+}
+
+// quotaOf the tenant.
+func (tq *tenantQuotas) quotaOf(tenant string) TenantQuota {
+	if q, ok := tq.quotas[tenant]; ok {
+		return q
+	}
+	return tq.quota
+}
+
+// throttle the request of the tenant if it is over its rate.
+// Requests of no tenant are throttled as those of the empty
+// tenant, since senders claim their tenant, and could else
+// escape their quota by claiming none.
+func (tq *tenantQuotas) throttle(tenant string, now time.Time) error {
+	if tq == nil {
+		return nil
+	}
+	limiter := tq.limiter
+	if _, ok := tq.quotas[tenant]; ok {
+		limiter = tq.limiters[tenant]
+	}
+	if limiter == nil || limiter.allow(tenant, now) {
+		return nil
+	}
+	return ErrThrottled
+}
+
+// hold one of the requests the tenant may have queued, the
+// returned func releases it, and may be called many times.
+func (tq *tenantQuotas) hold(tenant string) (func(), error) {
+	if tq == nil {
+		return nil, nil
+	}
+	max := tq.quotaOf(tenant).MaxQueued
+	if max <= 0 {
+		return nil, nil
+	}
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	if tq.queued[tenant] >= max {
+		return nil, ErrReceiverBusy
+	}
+	tq.queued[tenant]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			tq.mu.Lock()
+			defer tq.mu.Unlock()
+			tq.queued[tenant]--
+			if tq.queued[tenant] == 0 {
+				delete(tq.queued, tenant)
+			}
+		})
+	}, nil
+}
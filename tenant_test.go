@@ -0,0 +1,113 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTenantStamp(t *testing.T) {
+	c := &Client{peer: "peer-1", cfg: ClientCfg{Tenant: "default"}}
+
+	d := &Delivery{}
+	c.stamp(context.Background(), d)
+	if d.Tenant != "default" {
+		t.Fatalf("expected default tenant, got: %q", d.Tenant)
+	}
+
+	d = &Delivery{}
+	c.stamp(WithTenant(context.Background(), "acme"), d)
+	if d.Tenant != "acme" {
+		t.Fatalf("expected tenant of context, got: %q", d.Tenant)
+	}
+
+	req := newRequest(context.Background(), &EchoMsg{}, &Provenance{Tenant: "acme"})
+	if tenant := ContextTenant(req.Context()); tenant != "acme" {
+		t.Fatalf("expected tenant of request context, got: %q", tenant)
+	}
+	if tenant := ContextTenant(Derive(context.Background(), req)); tenant != "acme" {
+		t.Fatalf("expected tenant of derived context, got: %q", tenant)
+	}
+}
+
+func TestTenantSigned(t *testing.T) {
+	signer := NewHMACSigner([]byte("secret"))
+
+	d := &Delivery{Receiver: "testing.mailbox.worker", Tenant: "acme"}
+	if err := sign(signer, d); err != nil {
+		t.Fatal(err)
+	}
+	d.Tenant = "globex"
+	if err := verify(signer, d); err != ErrInvalidSignature {
+		t.Fatalf("expected forged tenant to be rejected, got: %v", err)
+	}
+}
+
+func TestTenantQuotasThrottle(t *testing.T) {
+	tq := newTenantQuotas(TenantQuota{RateLimit: 1}, map[string]TenantQuota{
+		"big":       {RateLimit: 1, RateBurst: 3},
+		"unlimited": {},
+	})
+	now := time.Now()
+
+	if err := tq.throttle("acme", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := tq.throttle("acme", now); err != ErrThrottled {
+		t.Fatalf("expected tenant throttled, got: %v", err)
+	}
+	if err := tq.throttle("globex", now); err != nil {
+		t.Fatalf("expected other tenant not throttled, got: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := tq.throttle("big", now); err != nil {
+			t.Fatalf("expected burst of tenant's own quota, got: %v", err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if err := tq.throttle("unlimited", now); err != nil {
+			t.Fatalf("expected no limit, got: %v", err)
+		}
+	}
+	if err := tq.throttle("", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := tq.throttle("", now); err != ErrThrottled {
+		t.Fatalf("expected default quota without tenant, got: %v", err)
+	}
+	if newTenantQuotas(TenantQuota{}, nil) != nil {
+		t.Fatal("expected no quotas")
+	}
+}
+
+func TestTenantQuotasHold(t *testing.T) {
+	tq := newTenantQuotas(TenantQuota{MaxQueued: 2}, nil)
+
+	first, err := tq.hold("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tq.hold("acme"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tq.hold("acme"); err != ErrReceiverBusy {
+		t.Fatalf("expected tenant over quota, got: %v", err)
+	}
+	if _, err := tq.hold("globex"); err != nil {
+		t.Fatalf("expected other tenant under quota, got: %v", err)
+	}
+	tq.hold("")
+	tq.hold("")
+	if _, err := tq.hold(""); err != ErrReceiverBusy {
+		t.Fatalf("expected default quota without tenant, got: %v", err)
+	}
+
+	first()
+	first()
+	if _, err := tq.hold("acme"); err != nil {
+		t.Fatalf("expected released hold, got: %v", err)
+	}
+	if _, err := tq.hold("acme"); err != ErrReceiverBusy {
+		t.Fatalf("expected release to count once, got: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package grid
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader serves a certificate and key from disk, reloading
+// them when the files change, so that long running peers keep
+// serving through routine certificate rotation without a restart.
+// Connections already established keep their certificate, new
+// connections get the current one.
+//
+// Example usage:
+//
+//     reloader, err := grid.NewCertReloader("/etc/grid/tls.crt", "/etc/grid/tls.key")
+//     ...
+//     server, err := grid.NewServer(etcd, grid.ServerCfg{
+//         Namespace: "billing",
+//         TLS: &tls.Config{
+//             GetCertificate: reloader.GetCertificate,
+//             ClientCAs:      pool,
+//             ClientAuth:     tls.RequireAndVerifyClientCert,
+//         },
+//     })
+//
+// A callback that fetches certificates from elsewhere, such as
+// a secrets service, can be set directly on the tls.Config.
+type CertReloader struct {
+	mu       sync.Mutex
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modTime  time.Time
+}
+
+// NewCertReloader of the certificate and key files, which are
+// loaded immediately, so that errors are found early.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	cr := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	_, err := cr.current()
+	if err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// GetCertificate for use as tls.Config.GetCertificate.
+func (cr *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return cr.current()
+}
+
+// GetClientCertificate for use as tls.Config.GetClientCertificate.
+func (cr *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return cr.current()
+}
+
+// current certificate, reloaded if either file changed. If a
+// reload fails, for example because only one of the files has
+// been written so far, the previous certificate is kept.
+func (cr *CertReloader) current() (*tls.Certificate, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	modTime, err := latestModTime(cr.certFile, cr.keyFile)
+	if err != nil && cr.cert != nil {
+		return cr.cert, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cr.cert != nil && !modTime.After(cr.modTime) {
+		return cr.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil && cr.cert != nil {
+		return cr.cert, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cr.cert = &cert
+	cr.modTime = modTime
+	return cr.cert, nil
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,94 @@
+package grid
+
+import (
+	"context"
+)
+
+// TransferMailbox closes the mailbox, and forwards the messages
+// queued in it to the mailbox to, which must accept the same
+// messages, for example when migrating an actor or scaling down,
+// so that queued work is not dropped. The mailbox is closed and
+// emptied at once, so no message is both forwarded and received,
+// and the receiver of the mailbox sees it closed. The messages are
+// forwarded in order, one at a time, in the background, and the
+// response of the mailbox to is sent back to the sender of each.
+// The number of messages transferred is returned. Servers with a
+// Policy only allow it to callers allowed ActionInspect on the
+// mailbox.
+//
+// Example usage:
+//
+//     n, err := client.TransferMailbox(ctx, "worker-3", "worker-0")
+//
+func (c *Client) TransferMailbox(ctx context.Context, mailbox, to string) (int, error) {
+	if !isNameValid(to) || to == mailbox {
+		return 0, ErrInvalidMailboxName
+	}
+	peer, err := c.mailboxPeer(ctx, mailbox)
+	if err != nil {
+		return 0, err
+	}
+	res, err := RequestT[*MailboxTransferResult](ctx, c, peer, &MailboxTransfer{
+		Mailbox: mailbox,
+		To:      to,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.Transferred), nil
+}
+
+// transferMailbox of the request, closing the mailbox and
+// forwarding its queued messages.
+func (s *Server) transferMailbox(req Request, msg *MailboxTransfer) {
+	if !isNameValid(msg.To) || msg.To == msg.Mailbox {
+		s.respondInspect(req, ErrInvalidMailboxName)
+		return
+	}
+	mailbox, err := s.inspected(msg.Mailbox)
+	if err != nil {
+		s.respondInspect(req, err)
+		return
+	}
+	reqs, err := mailbox.transfer()
+	if err != nil {
+		s.respondInspect(req, err)
+		return
+	}
+	s.auditOperation(req.Context(), ActionTransfer, msg.Mailbox)
+	go s.forward(reqs, msg.To)
+	s.respondInspect(req, &MailboxTransferResult{Transferred: int32(len(reqs))})
+}
+
+// forward the requests to the mailbox, in order, responding
+// to each with the mailbox's response. The requests keep
+// their contexts, and so their deadlines.
+func (s *Server) forward(reqs []*request, to string) {
+	for _, r := range reqs {
+		res, err := s.client.RequestC(r.Context(), to, r.Msg())
+		if err != nil {
+			err = r.Respond(err)
+		} else {
+			err = r.Respond(res)
+		}
+		if err != nil {
+			s.logf("%v: failed sending response for transferred message: %v", s.cfg.Namespace, err)
+		}
+	}
+}
+
+// transfer the queued requests out of the mailbox, closing it.
+func (box *Mailbox) transfer() ([]*request, error) {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+
+	if box.closed {
+		return nil, ErrUnknownMailbox
+	}
+	var taken []*request
+	box.inspectLocked(func(reqs []*request) []*request {
+		taken = reqs
+		return nil
+	})
+	return taken, box.closeLocked()
+}
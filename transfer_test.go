@@ -0,0 +1,37 @@
+package grid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMailboxTransfer(t *testing.T) {
+	c := make(chan Request, 10)
+	box := &Mailbox{C: c, c: c, cleanup: func() error { return nil }}
+	for i := 0; i < 3; i++ {
+		c <- newRequest(context.Background(), &EchoMsg{Msg: string(rune('a' + i))}, &Provenance{})
+	}
+
+	reqs, err := box.transfer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 3 {
+		t.Fatalf("expected 3 requests, got: %v", len(reqs))
+	}
+	for i, r := range reqs {
+		if msg := r.Msg().(*EchoMsg).Msg; msg != string(rune('a'+i)) {
+			t.Fatalf("expected requests in order, got: %v at: %v", msg, i)
+		}
+	}
+	if _, open := <-box.C; open {
+		t.Fatal("expected mailbox closed and empty")
+	}
+
+	if _, err := box.transfer(); err != ErrUnknownMailbox {
+		t.Fatalf("expected closed mailbox, got: %v", err)
+	}
+	if err := box.Close(); err != nil {
+		t.Fatalf("expected close of closed mailbox to do nothing, got: %v", err)
+	}
+}
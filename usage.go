@@ -0,0 +1,94 @@
+package grid
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ActorUsage of the server's resources by one of its actors.
+type ActorUsage struct {
+	Name    string
+	Type    string
+	Started time.Time
+	// ExecTime spent by the actor in Exec, measured as the
+	// wall-clock time Exec takes to run the function. It is
+	// not CPU time, it includes time the function waits, and
+	// excludes time Exec waits for a worker of the pool.
+	ExecTime time.Duration
+	// Execs is the number of calls to Exec.
+	Execs int64
+}
+
+// actorUsage accumulated while an actor runs.
+type actorUsage struct {
+	name    string
+	actor   string
+	started time.Time
+	exec    int64
+	execs   int64
+}
+
+func (u *actorUsage) add(d time.Duration) {
+	atomic.AddInt64(&u.exec, int64(d))
+	atomic.AddInt64(&u.execs, 1)
+}
+
+func (u *actorUsage) snapshot() *ActorUsage {
+	return &ActorUsage{
+		Name:     u.name,
+		Type:     u.actor,
+		Started:  u.started,
+		ExecTime: time.Duration(atomic.LoadInt64(&u.exec)),
+		Execs:    atomic.LoadInt64(&u.execs),
+	}
+}
+
+// TopActors returns the usage of at most n of the server's running
+// actors, those with the most ExecTime first, so operators can find
+// which actors are burning the peer's resources. Only work done in
+// Exec is accounted for, and by the time it takes, not the CPU time
+// it uses. The goroutines of actors are also labeled with the actor's
+// name and type, as "grid.actor" and "grid.type", so CPU profiles of
+// the process attribute their actual CPU time to the actors.
+func (s *Server) TopActors(n int) []*ActorUsage {
+	s.mu.Lock()
+	top := make([]*ActorUsage, 0, len(s.usage))
+	for _, u := range s.usage {
+		top = append(top, u.snapshot())
+	}
+	s.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].ExecTime != top[j].ExecTime {
+			return top[i].ExecTime > top[j].ExecTime
+		}
+		return top[i].Name < top[j].Name
+	})
+	if n >= 0 && n < len(top) {
+		top = top[:n]
+	}
+	return top
+}
+
+// trackUsage of the named actor, until it stops.
+func (s *Server) trackUsage(start *ActorStart) *actorUsage {
+	u := &actorUsage{
+		name:    start.Name,
+		actor:   start.Type,
+		started: time.Now(),
+	}
+	s.mu.Lock()
+	s.usage[start.Name] = u
+	s.mu.Unlock()
+	return u
+}
+
+// untrackUsage of the stopped actor.
+func (s *Server) untrackUsage(u *actorUsage) {
+	s.mu.Lock()
+	if s.usage[u.name] == u {
+		delete(s.usage, u.name)
+	}
+	s.mu.Unlock()
+}
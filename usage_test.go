@@ -0,0 +1,36 @@
+package grid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTopActors(t *testing.T) {
+	s := &Server{usage: map[string]*actorUsage{}}
+	light := s.trackUsage(&ActorStart{Name: "light", Type: "worker"})
+	heavy := s.trackUsage(&ActorStart{Name: "heavy", Type: "worker"})
+
+	// Exec accounts the time of the function
+	// to the actor of the context.
+	ctx := context.WithValue(context.Background(), contextKey, &contextVal{usage: heavy})
+	err := Exec(ctx, func() { time.Sleep(10 * time.Millisecond) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	light.add(time.Millisecond)
+
+	top := s.TopActors(1)
+	if len(top) != 1 {
+		t.Fatalf("expected 1 actor, got: %v", len(top))
+	}
+	if top[0].Name != "heavy" || top[0].ExecTime < 10*time.Millisecond || top[0].Execs != 1 {
+		t.Fatalf("unexpected usage: %+v", top[0])
+	}
+
+	s.untrackUsage(heavy)
+	top = s.TopActors(-1)
+	if len(top) != 1 || top[0].Name != "light" {
+		t.Fatalf("expected only the light actor, got: %v", top)
+	}
+}
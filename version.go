@@ -0,0 +1,66 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// protocolVersion stamped into every envelope. Peers reject
+// envelopes of versions newer than their own, with an error
+// naming both versions, rather than misreading them.
+const protocolVersion = Delivery_V2
+
+// featuresMetadataKey of the gRPC metadata in which each side
+// of a stream advertises the features it supports.
+const featuresMetadataKey = "grid-features"
+
+// Features of the wire protocol, which are only used on a stream
+// when both of its sides support them. Peers of older versions
+// advertise no features, and are sent only plain envelopes.
+const (
+	// featureBatch is understanding a batch of coalesced
+	// deliveries in one envelope.
+	featureBatch uint32 = 1 << iota
+	// featureFlow is advertising the credit of mailboxes
+	// in responses.
+	featureFlow
+)
+
+// supportedFeatures of this version of grid.
+const supportedFeatures = featureBatch | featureFlow
+
+// checkVersion of the envelope.
+func checkVersion(d *Delivery) error {
+	if d.Ver > protocolVersion {
+		return fmt.Errorf("%v: %v, this peer supports up to %v", ErrUnsupportedVersion, d.Ver, protocolVersion)
+	}
+	return nil
+}
+
+// withFeatures advertises the supported features in the
+// outgoing metadata of the context.
+func withFeatures(ctx context.Context) context.Context {
+	return metadata.NewOutgoingContext(ctx, featuresMetadata())
+}
+
+// featuresMetadata advertising the supported features.
+func featuresMetadata() metadata.MD {
+	return metadata.Pairs(featuresMetadataKey, strconv.FormatUint(uint64(supportedFeatures), 10))
+}
+
+// negotiate the features of a stream, from the metadata of
+// the other side, which are those both sides support.
+func negotiate(md metadata.MD) uint32 {
+	values := md.Get(featuresMetadataKey)
+	if len(values) == 0 {
+		return 0
+	}
+	remote, err := strconv.ParseUint(values[0], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return supportedFeatures & uint32(remote)
+}
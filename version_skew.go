@@ -0,0 +1,205 @@
+package grid
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// modulePath of grid, to find its version in the build info.
+const modulePath = "github.com/lytics/grid"
+
+// Labels grid writes into the registrations of every peer, next to
+// those of WithLabels, so that peers running different versions of
+// grid, or of the application, can be told apart, see VersionSkew.
+const (
+	// LabelVersion of grid the peer runs, the version of its
+	// module in the binary's build info.
+	LabelVersion = "grid.version"
+	// LabelProtocol version of the wire protocol the peer speaks.
+	LabelProtocol = "grid.protocol"
+	// LabelBuild of the application the peer runs, see ServerCfg.Build.
+	LabelBuild = "grid.build"
+)
+
+// VersionSkew found by a peer, with another peer of its namespace
+// speaking a different version of the wire protocol. A peer rejects
+// envelopes of versions newer than its own, so requests between the
+// two fail until they run compatible versions of grid.
+type VersionSkew struct {
+	Time      time.Time
+	Namespace string
+	// Peer finding the skew, and its versions.
+	Peer     string
+	Protocol int32
+	Version  string
+	// Other peer, and its versions.
+	Other         string
+	OtherProtocol int32
+	OtherVersion  string
+	OtherBuild    string
+}
+
+// String of the version skew, for logging.
+func (vs *VersionSkew) String() string {
+	return fmt.Sprintf("%v: version skew, peer: %v, protocol: %v, version: %v, other: %v, other protocol: %v, other version: %v, other build: %v",
+		vs.Namespace, vs.Peer, vs.Protocol, vs.Version, vs.Other, vs.OtherProtocol, vs.OtherVersion, vs.OtherBuild)
+}
+
+// Version of grid the peer of the named entity runs, see LabelVersion.
+// It is empty for peers of versions that do not record it.
+func (e *QueryEvent) Version() string {
+	return e.labels[LabelVersion]
+}
+
+// Protocol version of the peer of the named entity, see LabelProtocol.
+// It is zero for peers of versions that do not record it.
+func (e *QueryEvent) Protocol() int32 {
+	ver, _ := strconv.ParseInt(e.labels[LabelProtocol], 10, 32)
+	return int32(ver)
+}
+
+// Build of the application the peer of the named entity runs, see
+// LabelBuild. It is empty for peers of versions that do not record it.
+func (e *QueryEvent) Build() string {
+	return e.labels[LabelBuild]
+}
+
+// gridVersion in the binary's build info, "(devel)" when grid
+// is the main module, and empty if the binary has none.
+func gridVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if bi.Main.Path == modulePath {
+		return bi.Main.Version
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// appBuild in the binary's build info, the version of the main
+// module, or else its VCS revision.
+func appBuild() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		return bi.Main.Version
+	}
+	for _, setting := range bi.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return bi.Main.Version
+}
+
+// versionLabels of the peer, its labels with those of its versions.
+func versionLabels(labels map[string]string, build string) map[string]string {
+	if build == "" {
+		build = appBuild()
+	}
+	versioned := make(map[string]string, len(labels)+3)
+	for k, v := range labels {
+		versioned[k] = v
+	}
+	versioned[LabelVersion] = gridVersion()
+	versioned[LabelProtocol] = strconv.Itoa(int(protocolVersion))
+	versioned[LabelBuild] = build
+	return versioned
+}
+
+// versionSkew of the peer with the other peer, nil if they speak
+// the same protocol, or the other peer does not record its own.
+func versionSkew(namespace, peer string, other *QueryEvent) *VersionSkew {
+	if other.Protocol() == 0 || other.Protocol() == int32(protocolVersion) {
+		return nil
+	}
+	return &VersionSkew{
+		Time:          time.Now(),
+		Namespace:     namespace,
+		Peer:          peer,
+		Protocol:      int32(protocolVersion),
+		Version:       gridVersion(),
+		Other:         other.Peer(),
+		OtherProtocol: other.Protocol(),
+		OtherVersion:  other.Version(),
+		OtherBuild:    other.Build(),
+	}
+}
+
+// monitorVersionSkew between this peer and the others of its
+// namespace, reporting each peer found with a different version
+// of the wire protocol once.
+func (s *Server) monitorVersionSkew() {
+	go func() {
+		reported := map[string]bool{}
+		for {
+			err := s.watchVersionSkew(reported)
+			select {
+			case <-s.ctx.Done():
+				return
+			default:
+			}
+			s.logf("%v: failed watching peer versions: %v", s.cfg.Namespace, err)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(1 * time.Second):
+			}
+		}
+	}()
+}
+
+// watchVersionSkew of the peers, until the watch fails.
+func (s *Server) watchVersionSkew(reported map[string]bool) error {
+	current, changes, err := s.client.QueryWatch(s.ctx, Peers)
+	if err != nil {
+		return err
+	}
+	check := func(e *QueryEvent) {
+		if reported[e.Peer()] {
+			return
+		}
+		if vs := versionSkew(s.cfg.Namespace, s.name(), e); vs != nil {
+			reported[e.Peer()] = true
+			s.reportVersionSkew(vs)
+		}
+	}
+	for _, e := range current {
+		check(e)
+	}
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case e := <-changes:
+			if e.Err() != nil {
+				return e.Err()
+			}
+			if e.Type == EntityFound {
+				check(e)
+			}
+		}
+	}
+}
+
+// reportVersionSkew to the server's handler, default is to log it.
+func (s *Server) reportVersionSkew(vs *VersionSkew) {
+	if s.cfg.OnVersionSkew != nil {
+		s.cfg.OnVersionSkew(vs)
+		return
+	}
+	s.logf("%v", vs)
+}
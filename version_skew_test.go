@@ -0,0 +1,49 @@
+package grid
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestVersionLabels(t *testing.T) {
+	labels := map[string]string{LabelZone: "us-east-1a"}
+	versioned := versionLabels(labels, "v1.2.3")
+	if versioned[LabelZone] != "us-east-1a" || versioned[LabelBuild] != "v1.2.3" {
+		t.Fatalf("expected labels of the peer and its build, got: %v", versioned)
+	}
+	if versioned[LabelProtocol] != strconv.Itoa(int(protocolVersion)) {
+		t.Fatalf("expected protocol label, got: %v", versioned[LabelProtocol])
+	}
+	if len(labels) != 1 {
+		t.Fatalf("expected the peer's labels untouched, got: %v", labels)
+	}
+
+	e := &QueryEvent{peer: "peer-2", labels: versioned}
+	if e.Protocol() != int32(protocolVersion) || e.Build() != "v1.2.3" {
+		t.Fatalf("expected versions of the query event, got: %v, %v", e.Protocol(), e.Build())
+	}
+}
+
+func TestVersionSkew(t *testing.T) {
+	same := &QueryEvent{peer: "peer-2", labels: versionLabels(nil, "v1")}
+	if vs := versionSkew("testing", "peer-1", same); vs != nil {
+		t.Fatalf("expected no skew with the same protocol, got: %v", vs)
+	}
+	unknown := &QueryEvent{peer: "peer-3"}
+	if vs := versionSkew("testing", "peer-1", unknown); vs != nil {
+		t.Fatalf("expected no skew with peers not recording versions, got: %v", vs)
+	}
+
+	newer := &QueryEvent{peer: "peer-4", labels: map[string]string{
+		LabelProtocol: strconv.Itoa(int(protocolVersion) + 1),
+		LabelVersion:  "v9.0.0",
+		LabelBuild:    "abc123",
+	}}
+	vs := versionSkew("testing", "peer-1", newer)
+	if vs == nil {
+		t.Fatal("expected skew with a newer protocol")
+	}
+	if vs.Other != "peer-4" || vs.OtherProtocol != int32(protocolVersion)+1 || vs.OtherVersion != "v9.0.0" || vs.OtherBuild != "abc123" {
+		t.Fatalf("expected versions of the other peer, got: %v", vs)
+	}
+}
@@ -0,0 +1,70 @@
+package grid
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// keepWarm the connections to every registered peer, checking
+// for new peers, and for broken streams, every refresh interval,
+// until the context finishes.
+func (c *Client) keepWarm(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.PeersRefreshInterval)
+	defer ticker.Stop()
+	for {
+		c.warm(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// warm the connections to the registered peers, opening the
+// stream of each connection, which dials the peer. At most
+// WarmConnections peers are dialed at once.
+func (c *Client) warm(ctx context.Context) {
+	timeout, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	peers, err := c.QueryC(timeout, Peers)
+	if err != nil {
+		c.logf("failed finding peers to warm connections to: %v", err)
+		return
+	}
+
+	sem := make(chan struct{}, c.cfg.WarmConnections)
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		if peer.Address() == "" {
+			continue
+		}
+		c.mu.Lock()
+		ccpool, err := c.dialPool(peer.Address())
+		c.mu.Unlock()
+		if err != nil {
+			c.logf("failed dialing peer: %v, error: %v", peer.Name(), err)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(peer string, ccpool *clientAndConnPool) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			for _, cc := range ccpool.clientConns {
+				_, err := cc.openStream()
+				if err != nil {
+					c.logf("failed warming connection to peer: %v, error: %v", peer, err)
+					return
+				}
+			}
+		}(peer.Name(), ccpool)
+	}
+	wg.Wait()
+}
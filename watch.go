@@ -0,0 +1,212 @@
+package grid
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// WatchEventType of a watch event, see Client.Watch.
+type WatchEventType int
+
+const (
+	// EntityJoined the namespace, it is registered for the first
+	// time during the watch, or was registered when it began.
+	EntityJoined WatchEventType = 1
+	// EntityLeft the namespace, it is no longer registered.
+	EntityLeft WatchEventType = 2
+	// EntityRestarted it is registered again, either after it left
+	// during the watch, or by taking over its own registration, on
+	// the same or another peer.
+	EntityRestarted WatchEventType = 3
+)
+
+// String of the event type, for logging.
+func (t WatchEventType) String() string {
+	switch t {
+	case EntityJoined:
+		return "joined"
+	case EntityLeft:
+		return "left"
+	case EntityRestarted:
+		return "restarted"
+	default:
+		return "error"
+	}
+}
+
+// WatchEvent of an entity of the namespace, see Client.Watch.
+type WatchEvent struct {
+	Type   WatchEventType
+	Entity EntityType
+	Name   string
+	// Peer and Address the entity is on, for entities that
+	// left those it was last on.
+	Peer    string
+	Address string
+	// Epoch of the entity's registration, see QueryEvent.Epoch.
+	Epoch int64
+	// Labels of the peer of the entity, see QueryEvent.Labels.
+	Labels map[string]string
+	// Existing if the entity was registered when the watch began.
+	Existing bool
+	// Err of the watch, which then ends for the entity type.
+	// An event with an error is of no entity.
+	Err error
+}
+
+// String of the event, for logging.
+func (e *WatchEvent) String() string {
+	if e.Err != nil {
+		return fmt.Sprintf("watch error: %v", e.Err)
+	}
+	return fmt.Sprintf("%v %v %v, peer: %v, epoch: %v", e.Entity, e.Name, e.Type, e.Peer, e.Epoch)
+}
+
+// Watch the entities of the entity types, of this client's namespace,
+// selected by all the selectors, as typed events. Those registered
+// when the watch begins are sent first, as joined and existing. The
+// channel is closed once the context finishes, or the watch of every
+// entity type has failed. Entities that leave and join again during
+// the watch are reported as restarted, for which the watch remembers
+// the names of the entities that left.
+//
+// Example usage:
+//
+//     events, err := client.Watch(ctx, []grid.EntityType{grid.Peers, grid.Actors},
+//         grid.NameMatches(regexp.MustCompile("^worker-")),
+//     )
+//     ...
+//     for e := range events {
+//         switch {
+//         case e.Err != nil:
+//             ...
+//         case e.Type == grid.EntityLeft:
+//             // Reschedule the work of the entity.
+//         }
+//     }
+//
+func (c *Client) Watch(ctx context.Context, entities []EntityType, selectors ...Selector) (<-chan *WatchEvent, error) {
+	type watch struct {
+		current []*QueryEvent
+		changes <-chan *QueryEvent
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var watches []watch
+	for _, entity := range entities {
+		current, changes, err := c.QueryWatch(ctx, entity, selectors...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		watches = append(watches, watch{current, changes})
+	}
+
+	events := make(chan *WatchEvent)
+	put := func(e *WatchEvent) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case events <- e:
+			return true
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, w := range watches {
+		wg.Add(1)
+		go func(entity EntityType, w watch) {
+			defer wg.Done()
+			tracker := newWatchTracker()
+			for _, qe := range w.current {
+				e := tracker.track(qe)
+				e.Existing = true
+				if !put(e) {
+					return
+				}
+			}
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case qe := <-w.changes:
+					if qe.Err() != nil {
+						put(&WatchEvent{Entity: entity, Err: qe.Err()})
+						return
+					}
+					e := tracker.track(qe)
+					if e != nil && !put(e) {
+						return
+					}
+				}
+			}
+		}(entities[i], w)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(events)
+	}()
+	return events, nil
+}
+
+// watchTracker of the entities of a type seen by a watch, which
+// turns the found and lost events of QueryWatch into typed events.
+type watchTracker struct {
+	registered map[string]*QueryEvent
+	left       map[string]bool
+}
+
+func newWatchTracker() *watchTracker {
+	return &watchTracker{
+		registered: map[string]*QueryEvent{},
+		left:       map[string]bool{},
+	}
+}
+
+// track the query event, returning the typed event of the
+// change it is, nil if it changes nothing of the entity.
+func (wt *watchTracker) track(qe *QueryEvent) *WatchEvent {
+	name := qe.Name()
+	switch qe.Type {
+	case EntityLost:
+		last, ok := wt.registered[name]
+		if !ok {
+			return nil
+		}
+		delete(wt.registered, name)
+		wt.left[name] = true
+		return &WatchEvent{
+			Type:    EntityLeft,
+			Entity:  last.Entity(),
+			Name:    name,
+			Peer:    last.Peer(),
+			Address: last.Address(),
+			Epoch:   last.Epoch(),
+			Labels:  last.Labels(),
+		}
+	case EntityFound:
+		t := EntityJoined
+		if last, ok := wt.registered[name]; ok {
+			if last.Epoch() == qe.Epoch() && last.Peer() == qe.Peer() && last.registered == qe.registered {
+				wt.registered[name] = qe
+				return nil
+			}
+			t = EntityRestarted
+		} else if wt.left[name] {
+			t = EntityRestarted
+			delete(wt.left, name)
+		}
+		wt.registered[name] = qe
+		return &WatchEvent{
+			Type:    t,
+			Entity:  qe.Entity(),
+			Name:    name,
+			Peer:    qe.Peer(),
+			Address: qe.Address(),
+			Epoch:   qe.Epoch(),
+			Labels:  qe.Labels(),
+		}
+	}
+	return nil
+}
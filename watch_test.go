@@ -0,0 +1,37 @@
+package grid
+
+import "testing"
+
+func TestWatchTracker(t *testing.T) {
+	found := func(name, peer string, epoch int64) *QueryEvent {
+		return &QueryEvent{name: name, peer: peer, epoch: epoch, entity: Actors, Type: EntityFound}
+	}
+	lost := &QueryEvent{name: "worker-1", entity: Actors, Type: EntityLost}
+
+	wt := newWatchTracker()
+	steps := []struct {
+		qe       *QueryEvent
+		expected WatchEventType
+		peer     string
+	}{
+		{found("worker-1", "peer-1", 1), EntityJoined, "peer-1"},
+		{found("worker-1", "peer-1", 1), 0, ""},
+		{found("worker-1", "peer-2", 2), EntityRestarted, "peer-2"},
+		{lost, EntityLeft, "peer-2"},
+		{lost, 0, ""},
+		{found("worker-1", "peer-3", 3), EntityRestarted, "peer-3"},
+		{found("worker-2", "peer-1", 4), EntityJoined, "peer-1"},
+	}
+	for i, step := range steps {
+		e := wt.track(step.qe)
+		if step.expected == 0 {
+			if e != nil {
+				t.Fatalf("step: %v, expected no event, got: %v", i, e)
+			}
+			continue
+		}
+		if e == nil || e.Type != step.expected || e.Peer != step.peer || e.Entity != Actors {
+			t.Fatalf("step: %v, expected: %v on: %v, got: %v", i, step.expected, step.peer, e)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package grid
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	etcdv3 "github.com/coreos/etcd/clientv3"
+)
+
+// weights is the key space of the routing weights of mailboxes.
+const weights EntityType = "weight"
+
+// defaultWeight of a mailbox that has no weight set.
+const defaultWeight = 1
+
+// weightCache of the weights of a namespace, read from etcd
+// at most once per refresh interval.
+type weightCache struct {
+	fetched time.Time
+	weights map[string]int
+}
+
+// SetWeight of the mailbox, the share of the requests to a group
+// routed to it relative to the other members' weights, for example
+// a member on a machine twice as big can be given twice the weight.
+// Members without a weight have a weight of 1, and members with a
+// weight of 0 are sent no requests. The weight can be changed at
+// any time, clients see it within their PeersRefreshInterval.
+func (c *Client) SetWeight(ctx context.Context, mailbox string, weight int) error {
+	if weight < 0 {
+		return ErrInvalidWeight
+	}
+	key, err := namespaceName(weights, c.cfg.Namespace, mailbox)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Put(ctx, key, strconv.Itoa(weight))
+	return err
+}
+
+// DeleteWeight of the mailbox, which then has a weight of 1.
+func (c *Client) DeleteWeight(ctx context.Context, mailbox string) error {
+	key, err := namespaceName(weights, c.cfg.Namespace, mailbox)
+	if err != nil {
+		return err
+	}
+	_, err = c.etcd.Delete(ctx, key)
+	return err
+}
+
+// Weights of the mailboxes of the namespace that have one set.
+func (c *Client) Weights(ctx context.Context) (map[string]int, error) {
+	prefix, err := namespacePrefix(weights, c.cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.etcd.Get(ctx, prefix, etcdv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	ws := make(map[string]int, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		weight, err := strconv.Atoi(string(kv.Value))
+		if err != nil {
+			return nil, err
+		}
+		ws[strings.TrimPrefix(string(kv.Key), prefix)] = weight
+	}
+	return ws, nil
+}
+
+// RequestGroup sends the request to one member of the group, chosen
+// in proportion to the members' weights, see SetWeight. If the chosen
+// member is busy or unregistered, another member is tried, until
+// the request is received or no member is left. Members on peers
+// that are shutting down, see Server.LameDuck, are only tried once
+// no other member is left, and members on peers in quarantine, see
+// QuarantinePeer, are never tried. Likewise, members whose mailboxes
+// have at least the client's SaturatedDepth of requests queued, as
+// last advertised by their peers, are only tried once no member below
+// it is left, so that load spreads to healthy replicas instead of
+// queuing behind a hot one. Among the members tried first, those on
+// peers nearest to the client, by its Locality, are tried before the
+// others.
+//
+// Example usage:
+//
+//     group := grid.NewListGroup("worker-0", "worker-1", "worker-2")
+//     res, err := client.RequestGroup(ctx, group, &Work{})
+//
+func (c *Client) RequestGroup(ctx context.Context, g *Group, msg interface{}) (interface{}, error) {
+	ws, err := c.cachedWeights(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	live, lame := c.avoidLameDucks(ctx, c.avoidQuarantined(ctx, g.Members()))
+	live, hot := c.avoidSaturated(live)
+	tiers := append(c.byLocality(ctx, live), hot, lame)
+	for _, members := range tiers {
+		if len(members) == 0 {
+			continue
+		}
+		res, err := c.requestMembers(ctx, members, ws, msg)
+		if err != ErrNoGroupMember {
+			return res, err
+		}
+	}
+	return nil, ErrNoGroupMember
+}
+
+// requestMembers one at a time, in proportion to their weights,
+// until the request is received or no member is left.
+func (c *Client) requestMembers(ctx context.Context, members []string, ws map[string]int, msg interface{}) (interface{}, error) {
+	for {
+		member := weightedMember(members, ws, rand.Intn)
+		if member == "" {
+			return nil, ErrNoGroupMember
+		}
+		res, err := c.RequestC(ctx, member, msg)
+		if err == nil {
+			return res, nil
+		}
+		if !strings.Contains(err.Error(), ErrReceiverBusy.Error()) &&
+			!strings.Contains(err.Error(), ErrUnregisteredMailbox.Error()) {
+			return nil, err
+		}
+		// The member definitely did not get the
+		// request, so try the others.
+		members = without(members, member)
+	}
+}
+
+// cachedWeights of the namespace, refreshed if older than
+// the PeersRefreshInterval.
+func (c *Client) cachedWeights(ctx context.Context) (map[string]int, error) {
+	c.mu.Lock()
+	cache := c.weights
+	c.mu.Unlock()
+	if cache != nil && time.Since(cache.fetched) < c.cfg.PeersRefreshInterval {
+		return cache.weights, nil
+	}
+
+	ws, err := c.Weights(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.weights = &weightCache{fetched: time.Now(), weights: ws}
+	c.mu.Unlock()
+	return ws, nil
+}
+
+// weightedMember of the members, chosen in proportion to their
+// weights by intn, or empty if no member has any weight.
+func weightedMember(members []string, ws map[string]int, intn func(n int) int) string {
+	weightOf := func(member string) int {
+		if w, ok := ws[member]; ok {
+			return w
+		}
+		return defaultWeight
+	}
+	total := 0
+	for _, m := range members {
+		total += weightOf(m)
+	}
+	if total == 0 {
+		return ""
+	}
+	x := intn(total)
+	for _, m := range members {
+		x -= weightOf(m)
+		if x < 0 {
+			return m
+		}
+	}
+	return ""
+}
+
+func without(members []string, member string) []string {
+	rest := make([]string, 0, len(members))
+	for _, m := range members {
+		if m != member {
+			rest = append(rest, m)
+		}
+	}
+	return rest
+}
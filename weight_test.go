@@ -0,0 +1,31 @@
+package grid
+
+import "testing"
+
+func TestWeightedMember(t *testing.T) {
+	members := []string{"a", "b", "c"}
+	ws := map[string]int{"a": 2, "c": 0}
+
+	// The member "a" takes the first two of the
+	// three slots, "b" has the default weight of
+	// one, and "c" takes none.
+	expected := []string{"a", "a", "b"}
+	for x, member := range expected {
+		got := weightedMember(members, ws, func(n int) int {
+			if n != 3 {
+				t.Fatalf("expected total weight: 3, got: %v", n)
+			}
+			return x
+		})
+		if got != member {
+			t.Fatalf("expected member: %v, got: %v", member, got)
+		}
+	}
+
+	if got := weightedMember([]string{"c"}, ws, func(n int) int { return 0 }); got != "" {
+		t.Fatalf("expected no member, got: %v", got)
+	}
+	if got := without(members, "b"); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Fatalf("unexpected members: %v", got)
+	}
+}
@@ -13,6 +13,18 @@ It has these top-level messages:
 	ActorStart
 	Ack
 	EchoMsg
+	Control
+	Heartbeat
+	MailboxPeek
+	QueuedMessage
+	MailboxPeekResult
+	MailboxRequeue
+	PeerStatsQuery
+	PeerStats
+	EtcdEndpointsQuery
+	EtcdEndpoints
+	ActorLog
+	ActorLogs
 */
 package grid
 
@@ -40,13 +52,16 @@ type Delivery_Ver int32
 
 const (
 	Delivery_V1 Delivery_Ver = 0
+	Delivery_V2 Delivery_Ver = 1
 )
 
 var Delivery_Ver_name = map[int32]string{
 	0: "V1",
+	1: "V2",
 }
 var Delivery_Ver_value = map[string]int32{
 	"V1": 0,
+	"V2": 1,
 }
 
 func (x Delivery_Ver) String() string {
@@ -55,10 +70,25 @@ func (x Delivery_Ver) String() string {
 func (Delivery_Ver) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 0} }
 
 type Delivery struct {
-	Ver      Delivery_Ver `protobuf:"varint,1,opt,name=ver,enum=grid.Delivery_Ver" json:"ver,omitempty"`
-	Data     []byte       `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
-	TypeName string       `protobuf:"bytes,3,opt,name=typeName" json:"typeName,omitempty"`
-	Receiver string       `protobuf:"bytes,4,opt,name=receiver" json:"receiver,omitempty"`
+	Ver       Delivery_Ver `protobuf:"varint,1,opt,name=ver,enum=grid.Delivery_Ver" json:"ver,omitempty"`
+	Data      []byte       `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	TypeName  string       `protobuf:"bytes,3,opt,name=typeName" json:"typeName,omitempty"`
+	Receiver  string       `protobuf:"bytes,4,opt,name=receiver" json:"receiver,omitempty"`
+	Id        uint64       `protobuf:"varint,5,opt,name=id" json:"id,omitempty"`
+	Failure   string       `protobuf:"bytes,6,opt,name=failure" json:"failure,omitempty"`
+	Deadline  int64        `protobuf:"varint,7,opt,name=deadline" json:"deadline,omitempty"`
+	Batch     []*Delivery  `protobuf:"bytes,8,rep,name=batch" json:"batch,omitempty"`
+	Credit    int32        `protobuf:"varint,9,opt,name=credit" json:"credit,omitempty"`
+	Flow      bool         `protobuf:"varint,10,opt,name=flow" json:"flow,omitempty"`
+	Signature []byte       `protobuf:"bytes,11,opt,name=signature,proto3" json:"signature,omitempty"`
+	FromPeer  string       `protobuf:"bytes,12,opt,name=fromPeer" json:"fromPeer,omitempty"`
+	FromActor string       `protobuf:"bytes,13,opt,name=fromActor" json:"fromActor,omitempty"`
+	Progress  bool         `protobuf:"varint,14,opt,name=progress" json:"progress,omitempty"`
+	Lineage   string       `protobuf:"bytes,15,opt,name=lineage" json:"lineage,omitempty"`
+	Depth     int32        `protobuf:"varint,16,opt,name=depth" json:"depth,omitempty"`
+	Tenant    string       `protobuf:"bytes,17,opt,name=tenant" json:"tenant,omitempty"`
+	Codec     string       `protobuf:"bytes,18,opt,name=codec" json:"codec,omitempty"`
+	Timeout   int64        `protobuf:"varint,19,opt,name=timeout" json:"timeout,omitempty"`
 }
 
 func (m *Delivery) Reset()                    { *m = Delivery{} }
@@ -94,10 +124,118 @@ func (m *Delivery) GetReceiver() string {
 	return ""
 }
 
+func (m *Delivery) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Delivery) GetFailure() string {
+	if m != nil {
+		return m.Failure
+	}
+	return ""
+}
+
+func (m *Delivery) GetDeadline() int64 {
+	if m != nil {
+		return m.Deadline
+	}
+	return 0
+}
+
+func (m *Delivery) GetBatch() []*Delivery {
+	if m != nil {
+		return m.Batch
+	}
+	return nil
+}
+
+func (m *Delivery) GetCredit() int32 {
+	if m != nil {
+		return m.Credit
+	}
+	return 0
+}
+
+func (m *Delivery) GetFlow() bool {
+	if m != nil {
+		return m.Flow
+	}
+	return false
+}
+
+func (m *Delivery) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *Delivery) GetFromPeer() string {
+	if m != nil {
+		return m.FromPeer
+	}
+	return ""
+}
+
+func (m *Delivery) GetFromActor() string {
+	if m != nil {
+		return m.FromActor
+	}
+	return ""
+}
+
+func (m *Delivery) GetProgress() bool {
+	if m != nil {
+		return m.Progress
+	}
+	return false
+}
+
+func (m *Delivery) GetLineage() string {
+	if m != nil {
+		return m.Lineage
+	}
+	return ""
+}
+
+func (m *Delivery) GetDepth() int32 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
+}
+
+func (m *Delivery) GetTenant() string {
+	if m != nil {
+		return m.Tenant
+	}
+	return ""
+}
+
+func (m *Delivery) GetCodec() string {
+	if m != nil {
+		return m.Codec
+	}
+	return ""
+}
+
+func (m *Delivery) GetTimeout() int64 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
 type ActorStart struct {
-	Type string `protobuf:"bytes,1,opt,name=type" json:"type,omitempty"`
-	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
-	Data []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Type     string            `protobuf:"bytes,1,opt,name=type" json:"type,omitempty"`
+	Name     string            `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Data     []byte            `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	State    map[string][]byte `protobuf:"bytes,4,rep,name=state" json:"state,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Secrets  map[string]string `protobuf:"bytes,5,rep,name=secrets" json:"secrets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	DataType string            `protobuf:"bytes,6,opt,name=dataType" json:"dataType,omitempty"`
 }
 
 func (m *ActorStart) Reset()                    { *m = ActorStart{} }
@@ -126,6 +264,27 @@ func (m *ActorStart) GetData() []byte {
 	return nil
 }
 
+func (m *ActorStart) GetState() map[string][]byte {
+	if m != nil {
+		return m.State
+	}
+	return nil
+}
+
+func (m *ActorStart) GetSecrets() map[string]string {
+	if m != nil {
+		return m.Secrets
+	}
+	return nil
+}
+
+func (m *ActorStart) GetDataType() string {
+	if m != nil {
+		return m.DataType
+	}
+	return ""
+}
+
 type Ack struct {
 }
 
@@ -150,11 +309,575 @@ func (m *EchoMsg) GetMsg() string {
 	return ""
 }
 
+type Control struct {
+	Command string `protobuf:"bytes,1,opt,name=command" json:"command,omitempty"`
+	Data    []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *Control) Reset()                    { *m = Control{} }
+func (m *Control) String() string            { return proto.CompactTextString(m) }
+func (*Control) ProtoMessage()               {}
+func (*Control) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *Control) GetCommand() string {
+	if m != nil {
+		return m.Command
+	}
+	return ""
+}
+
+func (m *Control) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type Heartbeat struct {
+	Peer string           `protobuf:"bytes,1,opt,name=peer" json:"peer,omitempty"`
+	Seen map[string]int64 `protobuf:"bytes,2,rep,name=seen" json:"seen,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Sent int64            `protobuf:"varint,3,opt,name=sent" json:"sent,omitempty"`
+}
+
+func (m *Heartbeat) Reset()                    { *m = Heartbeat{} }
+func (m *Heartbeat) String() string            { return proto.CompactTextString(m) }
+func (*Heartbeat) ProtoMessage()               {}
+func (*Heartbeat) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *Heartbeat) GetPeer() string {
+	if m != nil {
+		return m.Peer
+	}
+	return ""
+}
+
+func (m *Heartbeat) GetSeen() map[string]int64 {
+	if m != nil {
+		return m.Seen
+	}
+	return nil
+}
+
+func (m *Heartbeat) GetSent() int64 {
+	if m != nil {
+		return m.Sent
+	}
+	return 0
+}
+
+type MailboxPeek struct {
+	Mailbox string `protobuf:"bytes,1,opt,name=mailbox" json:"mailbox,omitempty"`
+	Limit   int32  `protobuf:"varint,2,opt,name=limit" json:"limit,omitempty"`
+	Payload bool   `protobuf:"varint,3,opt,name=payload" json:"payload,omitempty"`
+}
+
+func (m *MailboxPeek) Reset()                    { *m = MailboxPeek{} }
+func (m *MailboxPeek) String() string            { return proto.CompactTextString(m) }
+func (*MailboxPeek) ProtoMessage()               {}
+func (*MailboxPeek) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *MailboxPeek) GetMailbox() string {
+	if m != nil {
+		return m.Mailbox
+	}
+	return ""
+}
+
+func (m *MailboxPeek) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *MailboxPeek) GetPayload() bool {
+	if m != nil {
+		return m.Payload
+	}
+	return false
+}
+
+type QueuedMessage struct {
+	Seq       uint64 `protobuf:"varint,1,opt,name=seq" json:"seq,omitempty"`
+	TypeName  string `protobuf:"bytes,2,opt,name=typeName" json:"typeName,omitempty"`
+	FromPeer  string `protobuf:"bytes,3,opt,name=fromPeer" json:"fromPeer,omitempty"`
+	FromActor string `protobuf:"bytes,4,opt,name=fromActor" json:"fromActor,omitempty"`
+	Identity  string `protobuf:"bytes,5,opt,name=identity" json:"identity,omitempty"`
+	Queued    int64  `protobuf:"varint,6,opt,name=queued" json:"queued,omitempty"`
+	Deadline  int64  `protobuf:"varint,7,opt,name=deadline" json:"deadline,omitempty"`
+	Data      []byte `protobuf:"bytes,8,opt,name=data,proto3" json:"data,omitempty"`
+	Codec     string `protobuf:"bytes,9,opt,name=codec" json:"codec,omitempty"`
+}
+
+func (m *QueuedMessage) Reset()                    { *m = QueuedMessage{} }
+func (m *QueuedMessage) String() string            { return proto.CompactTextString(m) }
+func (*QueuedMessage) ProtoMessage()               {}
+func (*QueuedMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *QueuedMessage) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *QueuedMessage) GetTypeName() string {
+	if m != nil {
+		return m.TypeName
+	}
+	return ""
+}
+
+func (m *QueuedMessage) GetFromPeer() string {
+	if m != nil {
+		return m.FromPeer
+	}
+	return ""
+}
+
+func (m *QueuedMessage) GetFromActor() string {
+	if m != nil {
+		return m.FromActor
+	}
+	return ""
+}
+
+func (m *QueuedMessage) GetIdentity() string {
+	if m != nil {
+		return m.Identity
+	}
+	return ""
+}
+
+func (m *QueuedMessage) GetQueued() int64 {
+	if m != nil {
+		return m.Queued
+	}
+	return 0
+}
+
+func (m *QueuedMessage) GetDeadline() int64 {
+	if m != nil {
+		return m.Deadline
+	}
+	return 0
+}
+
+func (m *QueuedMessage) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *QueuedMessage) GetCodec() string {
+	if m != nil {
+		return m.Codec
+	}
+	return ""
+}
+
+type MailboxPeekResult struct {
+	Msgs []*QueuedMessage `protobuf:"bytes,1,rep,name=msgs" json:"msgs,omitempty"`
+}
+
+func (m *MailboxPeekResult) Reset()                    { *m = MailboxPeekResult{} }
+func (m *MailboxPeekResult) String() string            { return proto.CompactTextString(m) }
+func (*MailboxPeekResult) ProtoMessage()               {}
+func (*MailboxPeekResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *MailboxPeekResult) GetMsgs() []*QueuedMessage {
+	if m != nil {
+		return m.Msgs
+	}
+	return nil
+}
+
+type MailboxRequeue struct {
+	Mailbox string   `protobuf:"bytes,1,opt,name=mailbox" json:"mailbox,omitempty"`
+	Requeue []uint64 `protobuf:"varint,2,rep,packed,name=requeue" json:"requeue,omitempty"`
+	Discard []uint64 `protobuf:"varint,3,rep,packed,name=discard" json:"discard,omitempty"`
+}
+
+func (m *MailboxRequeue) Reset()                    { *m = MailboxRequeue{} }
+func (m *MailboxRequeue) String() string            { return proto.CompactTextString(m) }
+func (*MailboxRequeue) ProtoMessage()               {}
+func (*MailboxRequeue) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *MailboxRequeue) GetMailbox() string {
+	if m != nil {
+		return m.Mailbox
+	}
+	return ""
+}
+
+func (m *MailboxRequeue) GetRequeue() []uint64 {
+	if m != nil {
+		return m.Requeue
+	}
+	return nil
+}
+
+func (m *MailboxRequeue) GetDiscard() []uint64 {
+	if m != nil {
+		return m.Discard
+	}
+	return nil
+}
+
+type PeerStatsQuery struct {
+}
+
+func (m *PeerStatsQuery) Reset()                    { *m = PeerStatsQuery{} }
+func (m *PeerStatsQuery) String() string            { return proto.CompactTextString(m) }
+func (*PeerStatsQuery) ProtoMessage()               {}
+func (*PeerStatsQuery) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+type PeerStats struct {
+	Peer      string           `protobuf:"bytes,1,opt,name=peer" json:"peer,omitempty"`
+	Actors    map[string]int64 `protobuf:"bytes,2,rep,name=actors" json:"actors,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Delivered int64            `protobuf:"varint,3,opt,name=delivered" json:"delivered,omitempty"`
+}
+
+func (m *PeerStats) Reset()                    { *m = PeerStats{} }
+func (m *PeerStats) String() string            { return proto.CompactTextString(m) }
+func (*PeerStats) ProtoMessage()               {}
+func (*PeerStats) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *PeerStats) GetPeer() string {
+	if m != nil {
+		return m.Peer
+	}
+	return ""
+}
+
+func (m *PeerStats) GetActors() map[string]int64 {
+	if m != nil {
+		return m.Actors
+	}
+	return nil
+}
+
+func (m *PeerStats) GetDelivered() int64 {
+	if m != nil {
+		return m.Delivered
+	}
+	return 0
+}
+
+type EtcdEndpointsQuery struct {
+}
+
+func (m *EtcdEndpointsQuery) Reset()                    { *m = EtcdEndpointsQuery{} }
+func (m *EtcdEndpointsQuery) String() string            { return proto.CompactTextString(m) }
+func (*EtcdEndpointsQuery) ProtoMessage()               {}
+func (*EtcdEndpointsQuery) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+type EtcdEndpoints struct {
+	Endpoints []string `protobuf:"bytes,1,rep,name=endpoints" json:"endpoints,omitempty"`
+}
+
+func (m *EtcdEndpoints) Reset()                    { *m = EtcdEndpoints{} }
+func (m *EtcdEndpoints) String() string            { return proto.CompactTextString(m) }
+func (*EtcdEndpoints) ProtoMessage()               {}
+func (*EtcdEndpoints) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *EtcdEndpoints) GetEndpoints() []string {
+	if m != nil {
+		return m.Endpoints
+	}
+	return nil
+}
+
+type ActorLog struct {
+	Peer  string `protobuf:"bytes,1,opt,name=peer" json:"peer,omitempty"`
+	Actor string `protobuf:"bytes,2,opt,name=actor" json:"actor,omitempty"`
+	Type  string `protobuf:"bytes,3,opt,name=type" json:"type,omitempty"`
+	Time  int64  `protobuf:"varint,4,opt,name=time" json:"time,omitempty"`
+	Line  string `protobuf:"bytes,5,opt,name=line" json:"line,omitempty"`
+}
+
+func (m *ActorLog) Reset()                    { *m = ActorLog{} }
+func (m *ActorLog) String() string            { return proto.CompactTextString(m) }
+func (*ActorLog) ProtoMessage()               {}
+func (*ActorLog) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *ActorLog) GetPeer() string {
+	if m != nil {
+		return m.Peer
+	}
+	return ""
+}
+
+func (m *ActorLog) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+func (m *ActorLog) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *ActorLog) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *ActorLog) GetLine() string {
+	if m != nil {
+		return m.Line
+	}
+	return ""
+}
+
+type ActorLogs struct {
+	Logs    []*ActorLog `protobuf:"bytes,1,rep,name=logs" json:"logs,omitempty"`
+	Dropped int64       `protobuf:"varint,2,opt,name=dropped" json:"dropped,omitempty"`
+}
+
+func (m *ActorLogs) Reset()                    { *m = ActorLogs{} }
+func (m *ActorLogs) String() string            { return proto.CompactTextString(m) }
+func (*ActorLogs) ProtoMessage()               {}
+func (*ActorLogs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *ActorLogs) GetLogs() []*ActorLog {
+	if m != nil {
+		return m.Logs
+	}
+	return nil
+}
+
+func (m *ActorLogs) GetDropped() int64 {
+	if m != nil {
+		return m.Dropped
+	}
+	return 0
+}
+
+type MailboxTransfer struct {
+	Mailbox string `protobuf:"bytes,1,opt,name=mailbox" json:"mailbox,omitempty"`
+	To      string `protobuf:"bytes,2,opt,name=to" json:"to,omitempty"`
+}
+
+func (m *MailboxTransfer) Reset()                    { *m = MailboxTransfer{} }
+func (m *MailboxTransfer) String() string            { return proto.CompactTextString(m) }
+func (*MailboxTransfer) ProtoMessage()               {}
+func (*MailboxTransfer) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *MailboxTransfer) GetMailbox() string {
+	if m != nil {
+		return m.Mailbox
+	}
+	return ""
+}
+
+func (m *MailboxTransfer) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+type MailboxTransferResult struct {
+	Transferred int32 `protobuf:"varint,1,opt,name=transferred" json:"transferred,omitempty"`
+}
+
+func (m *MailboxTransferResult) Reset()                    { *m = MailboxTransferResult{} }
+func (m *MailboxTransferResult) String() string            { return proto.CompactTextString(m) }
+func (*MailboxTransferResult) ProtoMessage()               {}
+func (*MailboxTransferResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *MailboxTransferResult) GetTransferred() int32 {
+	if m != nil {
+		return m.Transferred
+	}
+	return 0
+}
+
+type ActorDiagnose struct {
+	Actor string `protobuf:"bytes,1,opt,name=actor" json:"actor,omitempty"`
+}
+
+func (m *ActorDiagnose) Reset()                    { *m = ActorDiagnose{} }
+func (m *ActorDiagnose) String() string            { return proto.CompactTextString(m) }
+func (*ActorDiagnose) ProtoMessage()               {}
+func (*ActorDiagnose) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *ActorDiagnose) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+type ActorGoroutines struct {
+	Count     int32  `protobuf:"varint,1,opt,name=count" json:"count,omitempty"`
+	BlockedOn string `protobuf:"bytes,2,opt,name=blockedOn" json:"blockedOn,omitempty"`
+	Stack     string `protobuf:"bytes,3,opt,name=stack" json:"stack,omitempty"`
+}
+
+func (m *ActorGoroutines) Reset()                    { *m = ActorGoroutines{} }
+func (m *ActorGoroutines) String() string            { return proto.CompactTextString(m) }
+func (*ActorGoroutines) ProtoMessage()               {}
+func (*ActorGoroutines) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *ActorGoroutines) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *ActorGoroutines) GetBlockedOn() string {
+	if m != nil {
+		return m.BlockedOn
+	}
+	return ""
+}
+
+func (m *ActorGoroutines) GetStack() string {
+	if m != nil {
+		return m.Stack
+	}
+	return ""
+}
+
+type ActorDiagnosis struct {
+	Actor      string             `protobuf:"bytes,1,opt,name=actor" json:"actor,omitempty"`
+	Peer       string             `protobuf:"bytes,2,opt,name=peer" json:"peer,omitempty"`
+	Goroutines []*ActorGoroutines `protobuf:"bytes,3,rep,name=goroutines" json:"goroutines,omitempty"`
+}
+
+func (m *ActorDiagnosis) Reset()                    { *m = ActorDiagnosis{} }
+func (m *ActorDiagnosis) String() string            { return proto.CompactTextString(m) }
+func (*ActorDiagnosis) ProtoMessage()               {}
+func (*ActorDiagnosis) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *ActorDiagnosis) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+func (m *ActorDiagnosis) GetPeer() string {
+	if m != nil {
+		return m.Peer
+	}
+	return ""
+}
+
+func (m *ActorDiagnosis) GetGoroutines() []*ActorGoroutines {
+	if m != nil {
+		return m.Goroutines
+	}
+	return nil
+}
+
+type PendingStartsQuery struct {
+}
+
+func (m *PendingStartsQuery) Reset()                    { *m = PendingStartsQuery{} }
+func (m *PendingStartsQuery) String() string            { return proto.CompactTextString(m) }
+func (*PendingStartsQuery) ProtoMessage()               {}
+func (*PendingStartsQuery) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+type PendingStart struct {
+	Name     string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Peer     string `protobuf:"bytes,2,opt,name=peer" json:"peer,omitempty"`
+	Error    string `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+	Attempts int32  `protobuf:"varint,4,opt,name=attempts" json:"attempts,omitempty"`
+	Next     int64  `protobuf:"varint,5,opt,name=next" json:"next,omitempty"`
+}
+
+func (m *PendingStart) Reset()                    { *m = PendingStart{} }
+func (m *PendingStart) String() string            { return proto.CompactTextString(m) }
+func (*PendingStart) ProtoMessage()               {}
+func (*PendingStart) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *PendingStart) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *PendingStart) GetPeer() string {
+	if m != nil {
+		return m.Peer
+	}
+	return ""
+}
+
+func (m *PendingStart) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *PendingStart) GetAttempts() int32 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
+func (m *PendingStart) GetNext() int64 {
+	if m != nil {
+		return m.Next
+	}
+	return 0
+}
+
+type PendingStarts struct {
+	Starts []*PendingStart `protobuf:"bytes,1,rep,name=starts" json:"starts,omitempty"`
+}
+
+func (m *PendingStarts) Reset()                    { *m = PendingStarts{} }
+func (m *PendingStarts) String() string            { return proto.CompactTextString(m) }
+func (*PendingStarts) ProtoMessage()               {}
+func (*PendingStarts) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *PendingStarts) GetStarts() []*PendingStart {
+	if m != nil {
+		return m.Starts
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Delivery)(nil), "grid.Delivery")
 	proto.RegisterType((*ActorStart)(nil), "grid.ActorStart")
 	proto.RegisterType((*Ack)(nil), "grid.Ack")
 	proto.RegisterType((*EchoMsg)(nil), "grid.EchoMsg")
+	proto.RegisterType((*Control)(nil), "grid.Control")
+	proto.RegisterType((*Heartbeat)(nil), "grid.Heartbeat")
+	proto.RegisterType((*MailboxPeek)(nil), "grid.MailboxPeek")
+	proto.RegisterType((*QueuedMessage)(nil), "grid.QueuedMessage")
+	proto.RegisterType((*MailboxPeekResult)(nil), "grid.MailboxPeekResult")
+	proto.RegisterType((*MailboxRequeue)(nil), "grid.MailboxRequeue")
+	proto.RegisterType((*PeerStatsQuery)(nil), "grid.PeerStatsQuery")
+	proto.RegisterType((*PeerStats)(nil), "grid.PeerStats")
+	proto.RegisterType((*EtcdEndpointsQuery)(nil), "grid.EtcdEndpointsQuery")
+	proto.RegisterType((*EtcdEndpoints)(nil), "grid.EtcdEndpoints")
+	proto.RegisterType((*ActorLog)(nil), "grid.ActorLog")
+	proto.RegisterType((*ActorLogs)(nil), "grid.ActorLogs")
+	proto.RegisterType((*MailboxTransfer)(nil), "grid.MailboxTransfer")
+	proto.RegisterType((*MailboxTransferResult)(nil), "grid.MailboxTransferResult")
+	proto.RegisterType((*ActorDiagnose)(nil), "grid.ActorDiagnose")
+	proto.RegisterType((*ActorGoroutines)(nil), "grid.ActorGoroutines")
+	proto.RegisterType((*ActorDiagnosis)(nil), "grid.ActorDiagnosis")
+	proto.RegisterType((*PendingStartsQuery)(nil), "grid.PendingStartsQuery")
+	proto.RegisterType((*PendingStart)(nil), "grid.PendingStart")
+	proto.RegisterType((*PendingStarts)(nil), "grid.PendingStarts")
 	proto.RegisterEnum("grid.Delivery_Ver", Delivery_Ver_name, Delivery_Ver_value)
 }
 
@@ -170,6 +893,8 @@ const _ = grpc.SupportPackageIsVersion4
 
 type WireClient interface {
 	Process(ctx context.Context, in *Delivery, opts ...grpc.CallOption) (*Delivery, error)
+	Stream(ctx context.Context, opts ...grpc.CallOption) (Wire_StreamClient, error)
+	Connect(ctx context.Context, opts ...grpc.CallOption) (Wire_ConnectClient, error)
 }
 
 type wireClient struct {
@@ -189,10 +914,74 @@ func (c *wireClient) Process(ctx context.Context, in *Delivery, opts ...grpc.Cal
 	return out, nil
 }
 
+func (c *wireClient) Stream(ctx context.Context, opts ...grpc.CallOption) (Wire_StreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Wire_serviceDesc.Streams[0], c.cc, "/grid.wire/Stream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &wireStreamClient{stream}
+	return x, nil
+}
+
+type Wire_StreamClient interface {
+	Send(*Delivery) error
+	Recv() (*Delivery, error)
+	grpc.ClientStream
+}
+
+type wireStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *wireStreamClient) Send(m *Delivery) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *wireStreamClient) Recv() (*Delivery, error) {
+	m := new(Delivery)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *wireClient) Connect(ctx context.Context, opts ...grpc.CallOption) (Wire_ConnectClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Wire_serviceDesc.Streams[1], c.cc, "/grid.wire/Connect", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &wireConnectClient{stream}
+	return x, nil
+}
+
+type Wire_ConnectClient interface {
+	Send(*Delivery) error
+	Recv() (*Delivery, error)
+	grpc.ClientStream
+}
+
+type wireConnectClient struct {
+	grpc.ClientStream
+}
+
+func (x *wireConnectClient) Send(m *Delivery) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *wireConnectClient) Recv() (*Delivery, error) {
+	m := new(Delivery)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for Wire service
 
 type WireServer interface {
 	Process(context.Context, *Delivery) (*Delivery, error)
+	Stream(Wire_StreamServer) error
+	Connect(Wire_ConnectServer) error
 }
 
 func RegisterWireServer(s *grpc.Server, srv WireServer) {
@@ -217,6 +1006,58 @@ func _Wire_Process_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Wire_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WireServer).Stream(&wireStreamServer{stream})
+}
+
+type Wire_StreamServer interface {
+	Send(*Delivery) error
+	Recv() (*Delivery, error)
+	grpc.ServerStream
+}
+
+type wireStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *wireStreamServer) Send(m *Delivery) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *wireStreamServer) Recv() (*Delivery, error) {
+	m := new(Delivery)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Wire_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WireServer).Connect(&wireConnectServer{stream})
+}
+
+type Wire_ConnectServer interface {
+	Send(*Delivery) error
+	Recv() (*Delivery, error)
+	grpc.ServerStream
+}
+
+type wireConnectServer struct {
+	grpc.ServerStream
+}
+
+func (x *wireConnectServer) Send(m *Delivery) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *wireConnectServer) Recv() (*Delivery, error) {
+	m := new(Delivery)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _Wire_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "grid.wire",
 	HandlerType: (*WireServer)(nil),
@@ -226,28 +1067,100 @@ var _Wire_serviceDesc = grpc.ServiceDesc{
 			Handler:    _Wire_Process_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _Wire_Stream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Connect",
+			Handler:       _Wire_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "wire.proto",
 }
 
 func init() { proto.RegisterFile("wire.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 243 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x5c, 0x90, 0x4f, 0x4b, 0x03, 0x31,
-	0x10, 0xc5, 0x9b, 0xcd, 0xda, 0x3f, 0x83, 0x96, 0x32, 0xa7, 0xa5, 0x5e, 0x96, 0xe0, 0x61, 0x41,
-	0x58, 0xb0, 0xfd, 0x04, 0x05, 0x05, 0x2f, 0x8a, 0xac, 0xb0, 0xf7, 0x98, 0x0e, 0xeb, 0xa2, 0x35,
-	0x65, 0x12, 0x2a, 0xfd, 0x0c, 0x7e, 0x69, 0x99, 0xd4, 0x56, 0xf4, 0xf6, 0xde, 0xfc, 0xc2, 0x9b,
-	0x97, 0x01, 0xf8, 0xec, 0x99, 0xea, 0x2d, 0xfb, 0xe8, 0x31, 0xef, 0xb8, 0x5f, 0x9b, 0x2f, 0x05,
-	0xe3, 0x5b, 0x7a, 0xef, 0x77, 0xc4, 0x7b, 0xbc, 0x02, 0xbd, 0x23, 0x2e, 0x54, 0xa9, 0xaa, 0xe9,
-	0x02, 0x6b, 0x79, 0x50, 0x1f, 0x61, 0xdd, 0x12, 0x37, 0x82, 0x11, 0x21, 0x5f, 0xdb, 0x68, 0x8b,
-	0xac, 0x54, 0xd5, 0x79, 0x93, 0x34, 0xce, 0x61, 0x1c, 0xf7, 0x5b, 0x7a, 0xb4, 0x1b, 0x2a, 0x74,
-	0xa9, 0xaa, 0x49, 0x73, 0xf2, 0xc2, 0x98, 0x1c, 0x49, 0x4a, 0x91, 0x1f, 0xd8, 0xd1, 0x9b, 0x0b,
-	0xd0, 0x2d, 0x31, 0x0e, 0x21, 0x6b, 0x6f, 0x66, 0x03, 0x73, 0x0f, 0xb0, 0x72, 0xd1, 0xf3, 0x73,
-	0xb4, 0x1c, 0x65, 0x91, 0x84, 0xa4, 0x3e, 0x93, 0x26, 0x69, 0x99, 0x7d, 0xc8, 0x92, 0xec, 0x30,
-	0x13, 0x7d, 0x2a, 0xa4, 0x7f, 0x0b, 0x99, 0x33, 0xd0, 0x2b, 0xf7, 0x66, 0x2e, 0x61, 0x74, 0xe7,
-	0x5e, 0xfd, 0x43, 0xe8, 0x70, 0x06, 0x7a, 0x13, 0xba, 0x9f, 0x30, 0x91, 0x8b, 0x25, 0xe4, 0x72,
-	0x0f, 0xbc, 0x86, 0xd1, 0x13, 0x7b, 0x47, 0x21, 0xe0, 0xf4, 0xef, 0xa7, 0xe7, 0xff, 0xbc, 0x19,
-	0xbc, 0x0c, 0xd3, 0xf5, 0x96, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x1c, 0x06, 0x11, 0x27, 0x4b,
-	0x01, 0x00, 0x00,
+	// 1186 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xdb, 0x6e, 0x1c, 0x45,
+	0x13, 0xce, 0x1c, 0xf6, 0x30, 0x65, 0x7b, 0xe3, 0x74, 0x9c, 0x5f, 0xfd, 0x3b, 0x20, 0xad, 0x46,
+	0x41, 0xac, 0x40, 0x59, 0x88, 0x23, 0x94, 0x13, 0x37, 0x11, 0xb1, 0x00, 0x29, 0x01, 0xa7, 0x1d,
+	0x85, 0x0b, 0x24, 0xa4, 0xf6, 0x4c, 0x79, 0x32, 0xf2, 0xee, 0xf4, 0xa6, 0xbb, 0x37, 0xc9, 0x4a,
+	0x3c, 0x06, 0x12, 0x17, 0x3c, 0x03, 0xaf, 0xc1, 0xfb, 0xf0, 0x06, 0xa8, 0x6b, 0x7a, 0x66, 0x67,
+	0x13, 0xcb, 0x86, 0xab, 0xad, 0xaf, 0xba, 0xaa, 0xbb, 0x0e, 0x5f, 0xd5, 0x0e, 0xc0, 0xdb, 0x52,
+	0xe3, 0x74, 0xa1, 0x95, 0x55, 0x2c, 0x2e, 0x74, 0x99, 0xa7, 0xbf, 0xc7, 0x30, 0x7c, 0x82, 0xb3,
+	0xf2, 0x0d, 0xea, 0x15, 0xbb, 0x05, 0xd1, 0x1b, 0xd4, 0x3c, 0x18, 0x07, 0x93, 0xd1, 0x01, 0x9b,
+	0x3a, 0x83, 0x69, 0x73, 0x38, 0x7d, 0x89, 0x5a, 0xb8, 0x63, 0xc6, 0x20, 0xce, 0xa5, 0x95, 0x3c,
+	0x1c, 0x07, 0x93, 0x6d, 0x41, 0x32, 0xdb, 0x87, 0xa1, 0x5d, 0x2d, 0xf0, 0x07, 0x39, 0x47, 0x1e,
+	0x8d, 0x83, 0x49, 0x22, 0x5a, 0xec, 0xce, 0x34, 0x66, 0xe8, 0x6e, 0xe1, 0x71, 0x7d, 0xd6, 0x60,
+	0x36, 0x82, 0xb0, 0xcc, 0x79, 0x6f, 0x1c, 0x4c, 0x62, 0x11, 0x96, 0x39, 0xe3, 0x30, 0x38, 0x95,
+	0xe5, 0x6c, 0xa9, 0x91, 0xf7, 0xc9, 0xb4, 0x81, 0xee, 0x96, 0x1c, 0x65, 0x3e, 0x2b, 0x2b, 0xe4,
+	0x83, 0x71, 0x30, 0x89, 0x44, 0x8b, 0xd9, 0x2d, 0xe8, 0x9d, 0x48, 0x9b, 0xbd, 0xe2, 0xc3, 0x71,
+	0x34, 0xd9, 0x3a, 0x18, 0x6d, 0x46, 0x2e, 0xea, 0x43, 0xf6, 0x3f, 0xe8, 0x67, 0x1a, 0xf3, 0xd2,
+	0xf2, 0x64, 0x1c, 0x4c, 0x7a, 0xc2, 0x23, 0x97, 0xcf, 0xe9, 0x4c, 0xbd, 0xe5, 0x30, 0x0e, 0x26,
+	0x43, 0x41, 0x32, 0xfb, 0x08, 0x12, 0x53, 0x16, 0x95, 0xb4, 0x2e, 0x92, 0x2d, 0x4a, 0x74, 0xad,
+	0x70, 0xb1, 0x9c, 0x6a, 0x35, 0x3f, 0x42, 0xd4, 0x7c, 0xbb, 0xce, 0xa8, 0xc1, 0xce, 0xd3, 0xc9,
+	0x8f, 0x33, 0xab, 0x34, 0xdf, 0xa1, 0xc3, 0xb5, 0xc2, 0x79, 0x2e, 0xb4, 0x2a, 0x34, 0x1a, 0xc3,
+	0x47, 0xf4, 0x5e, 0x8b, 0x5d, 0xee, 0x2e, 0x1b, 0x59, 0x20, 0xbf, 0x5a, 0xe7, 0xee, 0x21, 0xdb,
+	0x83, 0x5e, 0x8e, 0x0b, 0xfb, 0x8a, 0xef, 0x52, 0xe0, 0x35, 0x70, 0xf9, 0x58, 0xac, 0x64, 0x65,
+	0xf9, 0x35, 0x32, 0xf7, 0xc8, 0x59, 0x67, 0x2a, 0xc7, 0x8c, 0x33, 0x52, 0xd7, 0xc0, 0xdd, 0x6e,
+	0xcb, 0x39, 0xaa, 0xa5, 0xe5, 0xd7, 0xa9, 0x7c, 0x0d, 0x4c, 0x6f, 0x40, 0xf4, 0x12, 0x35, 0xeb,
+	0x43, 0xf8, 0xf2, 0xce, 0xee, 0x15, 0xfa, 0x3d, 0xd8, 0x0d, 0xd2, 0xbf, 0x42, 0x00, 0x0a, 0xfa,
+	0xd8, 0x4a, 0x4d, 0x55, 0x72, 0x1d, 0x25, 0x72, 0x24, 0x82, 0x64, 0xa7, 0xab, 0x5c, 0xc7, 0xc3,
+	0x5a, 0xe7, 0xe4, 0x96, 0x1d, 0x51, 0x87, 0x1d, 0x77, 0xa0, 0x67, 0xac, 0xb4, 0xc8, 0x63, 0xea,
+	0xcf, 0xcd, 0xba, 0x3f, 0xeb, 0xcb, 0xa7, 0xc7, 0xee, 0xf4, 0xb0, 0xb2, 0xae, 0x59, 0x64, 0xc9,
+	0xee, 0xc1, 0xc0, 0x60, 0xa6, 0xd1, 0x1a, 0xde, 0x23, 0xa7, 0x8f, 0x3f, 0x74, 0xaa, 0xcf, 0x6b,
+	0xb7, 0xc6, 0x9a, 0x78, 0x22, 0xad, 0x7c, 0xb1, 0x5a, 0x34, 0x14, 0x6a, 0xf1, 0xfe, 0x7d, 0x80,
+	0xf5, 0x4b, 0x6c, 0x17, 0xa2, 0x33, 0x5c, 0xf9, 0x84, 0x9c, 0xe8, 0x2a, 0xf7, 0x46, 0xce, 0x96,
+	0xe8, 0xa9, 0x5d, 0x83, 0x87, 0xe1, 0xfd, 0x60, 0xff, 0x21, 0x6c, 0x77, 0x9f, 0xbb, 0xcc, 0x37,
+	0xe9, 0xf8, 0xa6, 0x3d, 0x88, 0x1e, 0x67, 0x67, 0xe9, 0x4d, 0x18, 0x1c, 0x66, 0xaf, 0xd4, 0x33,
+	0x53, 0x38, 0xef, 0xb9, 0x29, 0x1a, 0xef, 0xb9, 0x29, 0xd2, 0x7b, 0x30, 0xf8, 0x46, 0x55, 0x56,
+	0xab, 0x99, 0x6b, 0x54, 0xa6, 0xe6, 0x73, 0x59, 0xe5, 0xde, 0xa0, 0x81, 0xe7, 0x0d, 0x5e, 0xfa,
+	0x47, 0x00, 0xc9, 0x77, 0x28, 0xb5, 0x3d, 0x41, 0x49, 0x4d, 0x5a, 0xa0, 0x9f, 0xe0, 0x44, 0x90,
+	0xcc, 0x6e, 0x43, 0x6c, 0x10, 0x2b, 0x1e, 0x52, 0x19, 0xff, 0x5f, 0x97, 0xb1, 0x75, 0x99, 0x1e,
+	0x23, 0x56, 0x75, 0x09, 0xc9, 0xcc, 0x5d, 0x61, 0xb0, 0xb2, 0xd4, 0xbf, 0x48, 0x90, 0xbc, 0x7f,
+	0x0f, 0x92, 0xd6, 0xec, 0xb2, 0xd4, 0xa3, 0x6e, 0xea, 0x3f, 0xc1, 0xd6, 0x33, 0x59, 0xce, 0x4e,
+	0xd4, 0xbb, 0x23, 0xc4, 0x33, 0x97, 0xda, 0xbc, 0x86, 0x4d, 0x6a, 0x1e, 0xba, 0x2b, 0x66, 0xe5,
+	0xbc, 0xb4, 0x74, 0x45, 0x4f, 0xd4, 0xc0, 0xd9, 0x2f, 0xe4, 0x6a, 0xa6, 0x64, 0x4e, 0xe1, 0x0c,
+	0x45, 0x03, 0xd3, 0xbf, 0x03, 0xd8, 0x79, 0xbe, 0xc4, 0x25, 0xe6, 0xcf, 0xd0, 0x18, 0x37, 0x23,
+	0xbb, 0x10, 0x19, 0x7c, 0x4d, 0xf7, 0xc6, 0xc2, 0x89, 0x1b, 0x3b, 0x29, 0xfc, 0x70, 0x27, 0xb5,
+	0x13, 0x1c, 0x5d, 0x34, 0xc1, 0xf1, 0x39, 0x13, 0x5c, 0xe6, 0x58, 0xd9, 0xd2, 0xae, 0x68, 0x6f,
+	0x25, 0xa2, 0xc5, 0x6e, 0x22, 0x5f, 0x53, 0x50, 0xc4, 0xbc, 0x48, 0x78, 0x74, 0xe1, 0xee, 0x6a,
+	0x9a, 0x3a, 0xec, 0xcc, 0x4b, 0x3b, 0xc1, 0x49, 0x67, 0x82, 0xd3, 0xaf, 0xe1, 0x5a, 0xa7, 0x98,
+	0x02, 0xcd, 0x72, 0x66, 0xd9, 0xa7, 0x10, 0xcf, 0x4d, 0x61, 0x78, 0x40, 0xdd, 0xbd, 0x5e, 0x77,
+	0x77, 0xa3, 0x32, 0x82, 0x0c, 0xd2, 0x5f, 0x60, 0xe4, 0xbd, 0x05, 0x52, 0x58, 0x17, 0x74, 0x83,
+	0xc3, 0x40, 0xd7, 0x46, 0xc4, 0x9a, 0x58, 0x34, 0xd0, 0x9d, 0xe4, 0xa5, 0xc9, 0xa4, 0x76, 0x1d,
+	0xa1, 0x13, 0x0f, 0xd3, 0x5d, 0x18, 0xb9, 0xea, 0xb9, 0xf9, 0x32, 0xcf, 0x97, 0xa8, 0x57, 0xe9,
+	0x9f, 0x01, 0x24, 0xad, 0xea, 0x5c, 0x6a, 0xde, 0x85, 0xbe, 0x74, 0x45, 0x35, 0x3c, 0xec, 0x2e,
+	0x86, 0xd6, 0xa9, 0x9e, 0x76, 0x3f, 0xe1, 0xde, 0xd4, 0xb5, 0x27, 0xaf, 0x37, 0x3b, 0xe6, 0x9e,
+	0xa5, 0x6b, 0xc5, 0xfe, 0x03, 0xd8, 0xea, 0x38, 0xfd, 0x27, 0xb2, 0xee, 0x01, 0x3b, 0xb4, 0x59,
+	0x7e, 0x58, 0xe5, 0x0b, 0x55, 0x56, 0x4d, 0x16, 0xb7, 0x61, 0x67, 0x43, 0xeb, 0xde, 0xc7, 0x06,
+	0x50, 0xd9, 0x13, 0xb1, 0x56, 0xa4, 0x0b, 0x18, 0xd2, 0xfb, 0x4f, 0x55, 0x71, 0x6e, 0xca, 0x7b,
+	0xd0, 0xa3, 0x3c, 0x9a, 0x35, 0x41, 0xa0, 0x5d, 0xae, 0xd1, 0xe6, 0x72, 0x75, 0x1b, 0x9a, 0x18,
+	0x18, 0x09, 0x92, 0x9d, 0x8e, 0x48, 0x54, 0x13, 0x8f, 0xe4, 0xf4, 0x7b, 0x48, 0x9a, 0x17, 0x0d,
+	0x4b, 0x21, 0x9e, 0xa9, 0x96, 0x0e, 0xa3, 0xce, 0xce, 0x7c, 0xaa, 0x0a, 0x41, 0x67, 0xd4, 0x43,
+	0xad, 0x16, 0x0b, 0xcc, 0x7d, 0x0d, 0x1a, 0x98, 0x3e, 0x82, 0xab, 0x9e, 0x23, 0x2f, 0xb4, 0xac,
+	0xcc, 0x29, 0xea, 0x0b, 0x48, 0x32, 0x82, 0xd0, 0x2a, 0x9f, 0x46, 0x68, 0x55, 0xfa, 0x00, 0x6e,
+	0xbc, 0xe7, 0xec, 0x29, 0x3a, 0x86, 0x2d, 0xeb, 0x35, 0xae, 0x65, 0x01, 0x4d, 0x78, 0x57, 0x95,
+	0x7e, 0x02, 0x3b, 0x14, 0xe3, 0x93, 0x52, 0x16, 0x95, 0x32, 0xb8, 0xae, 0x52, 0xd0, 0xa9, 0x52,
+	0xfa, 0x33, 0x5c, 0x25, 0xb3, 0x6f, 0x95, 0x56, 0x4b, 0x5b, 0x56, 0x68, 0xea, 0x49, 0x59, 0x56,
+	0xd6, 0xdf, 0x5a, 0x03, 0xd7, 0xa2, 0x93, 0x99, 0xca, 0xce, 0x30, 0xff, 0xb1, 0xf2, 0x11, 0xae,
+	0x15, 0xce, 0xc7, 0x58, 0x99, 0x9d, 0xf9, 0x6a, 0xd7, 0x20, 0x7d, 0x0d, 0xa3, 0x6e, 0x0c, 0xa5,
+	0x39, 0x3f, 0x88, 0xb6, 0xa9, 0x61, 0xa7, 0xa9, 0x5f, 0x01, 0x14, 0x6d, 0x4c, 0x34, 0x18, 0x5b,
+	0x07, 0x37, 0x3a, 0xb5, 0x5f, 0x07, 0x2c, 0x3a, 0x86, 0x8e, 0x70, 0x47, 0x58, 0xe5, 0x65, 0x55,
+	0xd0, 0x1f, 0x9a, 0x27, 0xdc, 0xaf, 0xb0, 0xdd, 0xd5, 0xb6, 0x7f, 0xb2, 0xc1, 0xe6, 0x9f, 0xec,
+	0x07, 0x41, 0xec, 0x41, 0x0f, 0xb5, 0x56, 0xcd, 0x3e, 0xab, 0x81, 0x5b, 0x3d, 0xd2, 0x5a, 0x9c,
+	0x2f, 0xac, 0x21, 0x26, 0xf5, 0x44, 0x8b, 0xe9, 0x66, 0x7c, 0x67, 0x89, 0x4d, 0x91, 0x20, 0x39,
+	0x7d, 0x04, 0x3b, 0x1b, 0x31, 0xb1, 0xcf, 0xa0, 0x6f, 0x48, 0xf2, 0x9c, 0x62, 0xcd, 0x8c, 0xae,
+	0x8d, 0x84, 0xb7, 0x38, 0xf8, 0x2d, 0x80, 0xd8, 0x7d, 0x61, 0xb2, 0xcf, 0x61, 0x70, 0xa4, 0x55,
+	0xe6, 0xbe, 0x6a, 0xde, 0xfb, 0x18, 0xdb, 0x7f, 0x0f, 0xa7, 0x57, 0xd8, 0x14, 0xfa, 0xc7, 0x56,
+	0xa3, 0x9c, 0x5f, 0x6e, 0x3b, 0x09, 0xbe, 0x0c, 0xd8, 0x17, 0xf4, 0x5f, 0x59, 0x61, 0x66, 0xff,
+	0x9d, 0xc3, 0x49, 0x9f, 0x3e, 0x78, 0xef, 0xfe, 0x33, 0x00, 0x27, 0x16, 0xbd, 0xd8, 0xfe, 0x0a,
+	0x00, 0x00,
 }
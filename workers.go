@@ -0,0 +1,130 @@
+package grid
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefOption for actor definitions.
+type DefOption int
+
+const (
+	// OpExecCPU marks the actor as CPU-bound. Work the actor
+	// passes to Exec runs on the server's bounded pool of CPU
+	// workers, so that heavy actors do not starve the goroutines
+	// serving messages of latency-sensitive actors.
+	OpExecCPU DefOption = 0
+)
+
+// actorDef registered with a server.
+type actorDef struct {
+	makeActor       MakeActor
+	makeSecretActor MakeSecretActor
+	cpu             bool
+}
+
+// Exec the function on the CPU worker pool of the server, if the
+// context is of an actor defined with OpExecCPU, blocking until
+// a worker is free and the function has returned. For all other
+// actors the function is run directly.
+//
+// Example usage:
+//
+//     func (a *IndexActor) Act(ctx context.Context) {
+//         ...
+//         case req := <-mailbox.C:
+//             var index *Index
+//             err := grid.Exec(ctx, func() {
+//                 index = buildIndex(req.Msg())
+//             })
+//             ...
+//     }
+//
+func Exec(c context.Context, f func()) error {
+	v := c.Value(contextKey)
+	if v == nil {
+		return ErrInvalidContext
+	}
+	cv, ok := v.(*contextVal)
+	if !ok {
+		return ErrInvalidContext
+	}
+	if cv.usage != nil {
+		g := f
+		f = func() {
+			start := time.Now()
+			g()
+			cv.usage.add(time.Since(start))
+		}
+	}
+	if cv.workers == nil {
+		f()
+		return nil
+	}
+	return cv.workers.exec(c, f)
+}
+
+// workerPool of a fixed number of goroutines.
+type workerPool struct {
+	jobs chan func()
+	done chan struct{}
+	stop sync.Once
+}
+
+func newWorkerPool(size int) *workerPool {
+	wp := &workerPool{
+		jobs: make(chan func()),
+		done: make(chan struct{}),
+	}
+	for i := 0; i < size; i++ {
+		go wp.work()
+	}
+	return wp
+}
+
+func (wp *workerPool) work() {
+	for {
+		select {
+		case <-wp.done:
+			return
+		case job := <-wp.jobs:
+			job()
+		}
+	}
+}
+
+// exec the function on a worker, and wait for it to return.
+// A panic of the function is raised again in the caller, as if
+// the function had run there, so that it fails the actor that
+// called Exec, rather than the worker and the whole server.
+func (wp *workerPool) exec(c context.Context, f func()) error {
+	finished := make(chan struct{})
+	var panicked interface{}
+	job := func() {
+		defer close(finished)
+		defer func() {
+			panicked = recover()
+		}()
+		f()
+	}
+	select {
+	case <-c.Done():
+		return ErrContextFinished
+	case <-wp.done:
+		return ErrServerNotRunning
+	case wp.jobs <- job:
+	}
+	<-finished
+	if panicked != nil {
+		panic(panicked)
+	}
+	return nil
+}
+
+// close the pool, running jobs are not interrupted.
+func (wp *workerPool) close() {
+	wp.stop.Do(func() {
+		close(wp.done)
+	})
+}
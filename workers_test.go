@@ -0,0 +1,83 @@
+package grid
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWorkerPoolBounded(t *testing.T) {
+	wp := newWorkerPool(2)
+	defer wp.close()
+
+	var running, most int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := wp.exec(context.Background(), func() {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&most)
+					if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+						break
+					}
+				}
+				atomic.AddInt32(&running, -1)
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if most > 2 {
+		t.Fatalf("expected at most 2 concurrent jobs, got: %v", most)
+	}
+}
+
+func TestWorkerPoolPanic(t *testing.T) {
+	wp := newWorkerPool(1)
+	defer wp.close()
+
+	// The panic is raised in the caller, and the
+	// worker lives on to run the next job.
+	func() {
+		defer func() {
+			if r := recover(); r != "bad input" {
+				t.Fatalf("expected panic in caller, got: %v", r)
+			}
+		}()
+		wp.exec(context.Background(), func() { panic("bad input") })
+	}()
+
+	ran := false
+	err := wp.exec(context.Background(), func() { ran = true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Fatal("expected worker to survive the panic")
+	}
+}
+
+func TestExecWithoutPool(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey, &contextVal{})
+
+	ran := false
+	err := Exec(ctx, func() { ran = true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Fatal("expected function to run")
+	}
+
+	err = Exec(context.Background(), func() {})
+	if err != ErrInvalidContext {
+		t.Fatalf("expected invalid context, got: %v", err)
+	}
+}